@@ -52,7 +52,8 @@ $Services = @(
     "restaurant",
     "college-admission",
     "electronics-store",
-    "electronics-store-tracing"
+    "electronics-store-tracing",
+    "graphql-gateway"
 )
 
 # Generate documentation if requested
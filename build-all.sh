@@ -3,7 +3,7 @@
 echo "=== Building All Cluster-Tester Applications ==="
 echo
 
-apps=("coffee-shop" "pet-store" "restaurant" "college-admission" "electronics-store" "electronics-store-tracing" "cluster-operator")
+apps=("coffee-shop" "pet-store" "restaurant" "college-admission" "electronics-store" "electronics-store-tracing" "graphql-gateway" "cluster-operator")
 
 for app in "${apps[@]}"; do
     echo "Building $app..."
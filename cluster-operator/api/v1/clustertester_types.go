@@ -39,6 +39,44 @@ type ServiceConfig struct {
 
 	// Resources specifies resource requirements
 	Resources *ResourceRequirements `json:"resources,omitempty"`
+
+	// Seed specifies the dataset the service is loaded with on startup
+	Seed *SeedConfig `json:"seed,omitempty"`
+
+	// RateLimit protects the service from clients that send too much
+	RateLimit *RateLimitConfig `json:"rateLimit,omitempty"`
+}
+
+// RateLimitConfig defines the request limits of a service
+type RateLimitConfig struct {
+	// RequestsPerSecond is the sustained rate allowed to each client IP and each API key or token subject; unset or 0 disables rate limiting
+	// +kubebuilder:validation:Minimum=0
+	RequestsPerSecond int32 `json:"requestsPerSecond,omitempty"`
+
+	// Burst is the number of requests a client may send at once (default requestsPerSecond)
+	// +kubebuilder:validation:Minimum=0
+	Burst int32 `json:"burst,omitempty"`
+
+	// MaxBodyBytes caps the size of request bodies; larger requests get 413 (default 1048576)
+	// +kubebuilder:validation:Minimum=0
+	MaxBodyBytes int64 `json:"maxBodyBytes,omitempty"`
+}
+
+// SeedConfig defines the fixture data a service starts with
+type SeedConfig struct {
+	// ConfigMap is the name of a ConfigMap in the target namespace holding the fixture file
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// Key is the ConfigMap key of the fixture file; a .json or .csv extension selects the format (default fixtures.json)
+	Key string `json:"key,omitempty"`
+
+	// Generate specifies the number of synthetic records to add after the fixture file is loaded
+	// +kubebuilder:validation:Minimum=0
+	Generate int32 `json:"generate,omitempty"`
+
+	// Mode is replace to drop the built-in records before loading, or append to keep them (default replace)
+	// +kubebuilder:validation:Enum=replace;append
+	Mode string `json:"mode,omitempty"`
 }
 
 // ResourceRequirements defines resource requirements for a service
@@ -71,6 +109,34 @@ type DatabaseConfig struct {
 	StorageClass string `json:"storageClass,omitempty"`
 }
 
+// MessagingConfig defines the message broker the services publish their events to
+type MessagingConfig struct {
+	// Enabled deploys a NATS server and points every service at it with NATS_URL
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Image specifies the broker container image (default nats)
+	Image string `json:"image,omitempty"`
+
+	// Tag specifies the broker image tag (default 2.10-alpine)
+	Tag string `json:"tag,omitempty"`
+}
+
+// CacheConfig defines the cache the electronics stores read their products through
+type CacheConfig struct {
+	// Enabled deploys a Redis server and points the electronics stores at it with REDIS_ADDR
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Image specifies the cache container image (default redis)
+	Image string `json:"image,omitempty"`
+
+	// Tag specifies the cache image tag (default 7-alpine)
+	Tag string `json:"tag,omitempty"`
+
+	// TTLSeconds is how long a product read is cached for (default 30)
+	// +kubebuilder:validation:Minimum=1
+	TTLSeconds int32 `json:"ttlSeconds,omitempty"`
+}
+
 // ClusterTesterSpec defines the desired state of ClusterTester
 type ClusterTesterSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -94,18 +160,38 @@ type ClusterTesterSpec struct {
 	// ElectronicsStoreTracing service configuration
 	ElectronicsStoreTracing ServiceConfig `json:"electronicsStoreTracing,omitempty"`
 
+	// GraphQLGateway service configuration. The gateway serves one GraphQL
+	// schema over the other enabled services.
+	GraphQLGateway ServiceConfig `json:"graphqlGateway,omitempty"`
+
 	// Database configuration for services that need it
 	Database DatabaseConfig `json:"database,omitempty"`
 
+	// Messaging configuration. With a broker the services publish their
+	// events to each other, such as coffee price changes to the restaurant.
+	Messaging MessagingConfig `json:"messaging,omitempty"`
+
+	// Cache configuration. With a cache the electronics stores serve
+	// repeated product reads without querying the database.
+	Cache CacheConfig `json:"cache,omitempty"`
+
 	// Global configuration
 	Global GlobalConfig `json:"global,omitempty"`
 }
 
 // GlobalConfig defines global configuration options
 type GlobalConfig struct {
-	// Namespace specifies the target namespace for deployments
+	// Namespace specifies the target namespace for deployments. Objects in another namespace than the ClusterTester are owned through labels and deleted with it by a finalizer
 	Namespace string `json:"namespace,omitempty"`
 
+	// NamePrefix starts the names of the objects created for the ClusterTester, as in <namePrefix>-coffee-shop (default the ClusterTester's name)
+	// +kubebuilder:validation:MaxLength=37
+	// +kubebuilder:validation:Pattern=`^[a-z]([-a-z0-9]*[a-z0-9])?$`
+	NamePrefix string `json:"namePrefix,omitempty"`
+
+	// CreateNamespace creates the target namespace when it does not exist; the operator deletes a namespace it created with the ClusterTester
+	CreateNamespace bool `json:"createNamespace,omitempty"`
+
 	// ImagePullPolicy specifies the image pull policy
 	ImagePullPolicy string `json:"imagePullPolicy,omitempty"`
 
@@ -117,6 +203,32 @@ type GlobalConfig struct {
 
 	// IngressHost specifies the base host for ingress
 	IngressHost string `json:"ingressHost,omitempty"`
+
+	// LogLevel sets the minimum level the services log at (default info)
+	// +kubebuilder:validation:Enum=debug;info;warn;error
+	LogLevel string `json:"logLevel,omitempty"`
+
+	// LogFormat sets the log output of the services, json for structured records or text (default json)
+	// +kubebuilder:validation:Enum=json;text
+	LogFormat string `json:"logFormat,omitempty"`
+
+	// Auth requires credentials on the data and admin endpoints of every service
+	Auth *AuthConfig `json:"auth,omitempty"`
+}
+
+// AuthConfig defines how the services authenticate their callers
+type AuthConfig struct {
+	// Enabled makes the operator generate a read and a write API key in the <name>-api-keys Secret and require credentials
+	Enabled bool `json:"enabled,omitempty"`
+
+	// JWKSConfigMap is the name of a ConfigMap in the target namespace whose jwks.json key holds a JSON Web Key Set; bearer tokens signed by its keys are accepted too
+	JWKSConfigMap string `json:"jwksConfigMap,omitempty"`
+
+	// Issuer is the iss claim bearer tokens must carry
+	Issuer string `json:"issuer,omitempty"`
+
+	// Audience is the aud claim bearer tokens must carry
+	Audience string `json:"audience,omitempty"`
 }
 
 // ServiceStatus defines the status of a deployed service
@@ -135,6 +247,9 @@ type ServiceStatus struct {
 
 	// Endpoint indicates the service endpoint
 	Endpoint string `json:"endpoint,omitempty"`
+
+	// GRPCEndpoint indicates the endpoint of the service's gRPC API
+	GRPCEndpoint string `json:"grpcEndpoint,omitempty"`
 }
 
 // ClusterTesterStatus defines the observed state of ClusterTester
@@ -18,11 +18,17 @@ package controller
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"maps"
+	"path"
+	"slices"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -33,6 +39,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	clusterv1 "github.com/cdcent/cluster-tester/cluster-operator/api/v1"
@@ -42,6 +49,11 @@ import (
 type ClusterTesterReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// WatchNamespaces are the namespaces the manager's cache is restricted
+	// to, or empty when it watches the whole cluster. ClusterTesters that
+	// deploy to another namespace fail.
+	WatchNamespaces []string
 }
 
 //+kubebuilder:rbac:groups=cluster.cdcent.io,resources=clustertesters,verbs=get;list;watch;create;update;patch;delete
@@ -49,8 +61,13 @@ type ClusterTesterReconciler struct {
 //+kubebuilder:rbac:groups=cluster.cdcent.io,resources=clustertesters/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;create;delete
 //+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -68,6 +85,27 @@ func (r *ClusterTesterReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, err
 	}
 
+	// Delete what garbage collection cannot: the objects in other namespaces
+	if !clusterTester.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(&clusterTester, cleanupFinalizer) {
+			if err := r.cleanup(ctx, &clusterTester); err != nil {
+				logger.Error(err, "Failed to delete the objects of ClusterTester")
+				return ctrl.Result{}, err
+			}
+			controllerutil.RemoveFinalizer(&clusterTester, cleanupFinalizer)
+			if err := r.Update(ctx, &clusterTester); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+	if crossNamespace(&clusterTester) && controllerutil.AddFinalizer(&clusterTester, cleanupFinalizer) {
+		if err := r.Update(ctx, &clusterTester); err != nil {
+			logger.Error(err, "Failed to add finalizer to ClusterTester")
+			return ctrl.Result{}, err
+		}
+	}
+
 	// Update status to indicate reconciliation is starting
 	if clusterTester.Status.Phase == "" {
 		clusterTester.Status.Phase = "Initializing"
@@ -77,6 +115,28 @@ func (r *ClusterTesterReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
+	// The target namespace must be in the manager's cache
+	if namespace := targetNamespace(&clusterTester); !r.watched(namespace) {
+		err := fmt.Errorf("target namespace %q is not among the watched namespaces %v", namespace, r.WatchNamespaces)
+		logger.Error(err, "Cannot deploy ClusterTester")
+		return r.updateStatusError(ctx, &clusterTester, "NamespaceNotWatched", err)
+	}
+
+	// Two ClusterTesters must not share the names of their objects, which
+	// must also be valid
+	if err := checkNames(&clusterTester, r.getServiceConfigs(&clusterTester)); err != nil {
+		logger.Error(err, "Cannot deploy ClusterTester")
+		return r.updateStatusError(ctx, &clusterTester, "InvalidName", err)
+	}
+
+	// Create the target namespace if asked to
+	if clusterTester.Spec.Global.CreateNamespace {
+		if err := r.reconcileNamespace(ctx, &clusterTester); err != nil {
+			logger.Error(err, "Failed to reconcile target namespace")
+			return r.updateStatusError(ctx, &clusterTester, "NamespaceFailed", err)
+		}
+	}
+
 	// Deploy database if enabled
 	if clusterTester.Spec.Database.Enabled {
 		if err := r.reconcileDatabase(ctx, &clusterTester); err != nil {
@@ -85,6 +145,30 @@ func (r *ClusterTesterReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
+	// Deploy the message broker if enabled
+	if clusterTester.Spec.Messaging.Enabled {
+		if err := r.reconcileMessaging(ctx, &clusterTester); err != nil {
+			logger.Error(err, "Failed to reconcile message broker")
+			return r.updateStatusError(ctx, &clusterTester, "MessagingFailed", err)
+		}
+	}
+
+	// Deploy the cache if enabled
+	if clusterTester.Spec.Cache.Enabled {
+		if err := r.reconcileCache(ctx, &clusterTester); err != nil {
+			logger.Error(err, "Failed to reconcile cache")
+			return r.updateStatusError(ctx, &clusterTester, "CacheFailed", err)
+		}
+	}
+
+	// Generate the API keys the services require
+	if authEnabled(clusterTester.Spec.Global) {
+		if err := r.reconcileAuthSecret(ctx, &clusterTester); err != nil {
+			logger.Error(err, "Failed to reconcile API key secret")
+			return r.updateStatusError(ctx, &clusterTester, "AuthFailed", err)
+		}
+	}
+
 	// Deploy services
 	services := r.getServiceConfigs(&clusterTester)
 	var serviceStatuses []clusterv1.ServiceStatus
@@ -100,6 +184,13 @@ func (r *ClusterTesterReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
+	// Objects from before resourceName are removed once their replacements
+	// exist.
+	if err := r.removeLegacyObjects(ctx, &clusterTester, slices.Sorted(maps.Keys(services))); err != nil {
+		logger.Error(err, "Failed to remove objects with unprefixed names")
+		return r.updateStatusError(ctx, &clusterTester, "LegacyCleanupFailed", err)
+	}
+
 	// Update status
 	clusterTester.Status.Services = serviceStatuses
 	clusterTester.Status.Phase = "Ready"
@@ -206,20 +297,35 @@ func (r *ClusterTesterReconciler) getServiceConfigs(clusterTester *clusterv1.Clu
 	}
 	services["electronics-store-tracing"] = electronicsStoreTracing
 
+	// GraphQL Gateway
+	graphqlGateway := clusterTester.Spec.GraphQLGateway
+	if graphqlGateway.Replicas == nil {
+		graphqlGateway.Replicas = &defaultReplicas
+	}
+	if graphqlGateway.Image == "" {
+		graphqlGateway.Image = "graphql-gateway"
+	}
+	if graphqlGateway.Tag == "" {
+		graphqlGateway.Tag = "latest"
+	}
+	services["graphql-gateway"] = graphqlGateway
+
 	return services
 }
 
 func (r *ClusterTesterReconciler) reconcileService(ctx context.Context, clusterTester *clusterv1.ClusterTester, serviceName string, config clusterv1.ServiceConfig) (clusterv1.ServiceStatus, error) {
 	logger := log.FromContext(ctx)
 
-	namespace := clusterTester.Namespace
-	if clusterTester.Spec.Global.Namespace != "" {
-		namespace = clusterTester.Spec.Global.Namespace
+	namespace := targetNamespace(clusterTester)
+
+	// The pods run as the service's own ServiceAccount
+	if err := r.reconcileServiceAccount(ctx, clusterTester, serviceName, namespace); err != nil {
+		return clusterv1.ServiceStatus{}, err
 	}
 
 	// Create deployment
 	deployment := r.createDeployment(clusterTester, serviceName, config, namespace)
-	if err := controllerutil.SetControllerReference(clusterTester, deployment, r.Scheme); err != nil {
+	if err := r.setOwner(clusterTester, deployment); err != nil {
 		return clusterv1.ServiceStatus{}, err
 	}
 
@@ -232,8 +338,12 @@ func (r *ClusterTesterReconciler) reconcileService(ctx context.Context, clusterT
 		}
 	} else if err != nil {
 		return clusterv1.ServiceStatus{}, err
+	} else if err = checkOwner(clusterTester, found); err != nil {
+		return clusterv1.ServiceStatus{}, err
 	} else {
-		// Update deployment if needed
+		// Update deployment if needed. The selector cannot change, so
+		// Deployments created before it had the owner's namespace keep theirs.
+		deployment.Spec.Selector = found.Spec.Selector
 		found.Spec = deployment.Spec
 		if err = r.Update(ctx, found); err != nil {
 			return clusterv1.ServiceStatus{}, err
@@ -242,7 +352,7 @@ func (r *ClusterTesterReconciler) reconcileService(ctx context.Context, clusterT
 
 	// Create service
 	service := r.createService(clusterTester, serviceName, namespace)
-	if err := controllerutil.SetControllerReference(clusterTester, service, r.Scheme); err != nil {
+	if err := r.setOwner(clusterTester, service); err != nil {
 		return clusterv1.ServiceStatus{}, err
 	}
 
@@ -255,6 +365,13 @@ func (r *ClusterTesterReconciler) reconcileService(ctx context.Context, clusterT
 		}
 	} else if err != nil {
 		return clusterv1.ServiceStatus{}, err
+	} else if err = checkOwner(clusterTester, foundService); err != nil {
+		return clusterv1.ServiceStatus{}, err
+	} else if addMissingPorts(foundService, service.Spec.Ports) {
+		logger.Info("Adding ports to service", "service", service.Name)
+		if err = r.Update(ctx, foundService); err != nil {
+			return clusterv1.ServiceStatus{}, err
+		}
 	}
 
 	// Get current deployment status
@@ -267,17 +384,126 @@ func (r *ClusterTesterReconciler) reconcileService(ctx context.Context, clusterT
 		Ready:         found.Status.ReadyReplicas == found.Status.Replicas && found.Status.Replicas > 0,
 		Replicas:      found.Status.Replicas,
 		ReadyReplicas: found.Status.ReadyReplicas,
-		Endpoint:      fmt.Sprintf("%s.%s.svc.cluster.local:8080", service.Name, namespace),
+		Endpoint:      serviceEndpoint(service.Name, namespace),
+	}
+	if !httpOnly[serviceName] {
+		status.GRPCEndpoint = serviceGRPCEndpoint(service.Name, namespace)
 	}
 
 	return status, nil
 }
 
+// reconcileServiceAccount creates the ServiceAccount a service runs as and,
+// for the leader-elected services, the Role and RoleBinding that let it
+// take the migrations Lease of the database. The other services get no API access, and no
+// token is mounted into their pods.
+func (r *ClusterTesterReconciler) reconcileServiceAccount(ctx context.Context, clusterTester *clusterv1.ClusterTester, serviceName, namespace string) error {
+	objects := []client.Object{r.createServiceAccount(clusterTester, serviceName, namespace)}
+	if leaderElected[serviceName] {
+		objects = append(objects,
+			r.createLeaderElectionRole(clusterTester, serviceName, namespace),
+			r.createLeaderElectionRoleBinding(clusterTester, serviceName, namespace))
+	}
+
+	for _, obj := range objects {
+		if err := r.setOwner(clusterTester, obj); err != nil {
+			return err
+		}
+		if err := r.createIfNotOwned(ctx, clusterTester, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createIfNotFound creates obj unless an object of its kind and name exists.
+func (r *ClusterTesterReconciler) createIfNotFound(ctx context.Context, obj client.Object) error {
+	found := obj.DeepCopyObject().(client.Object)
+	err := r.Get(ctx, client.ObjectKeyFromObject(obj), found)
+	if err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+	log.FromContext(ctx).Info("Creating object", "type", fmt.Sprintf("%T", obj), "name", obj.GetName())
+	return r.Create(ctx, obj)
+}
+
+// serviceAccountLabels returns the labels of the RBAC objects of a service.
+func serviceAccountLabels(clusterTester *clusterv1.ClusterTester, serviceName string) map[string]string {
+	return map[string]string{
+		"app":                          serviceName,
+		"app.kubernetes.io/name":       serviceName,
+		"app.kubernetes.io/instance":   clusterTester.Name,
+		"app.kubernetes.io/part-of":    "cluster-tester",
+		"app.kubernetes.io/managed-by": "cluster-tester-operator",
+	}
+}
+
+func (r *ClusterTesterReconciler) createServiceAccount(clusterTester *clusterv1.ClusterTester, serviceName, namespace string) *corev1.ServiceAccount {
+	automount := leaderElected[serviceName]
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName(clusterTester, serviceName),
+			Namespace: namespace,
+			Labels:    serviceAccountLabels(clusterTester, serviceName),
+		},
+		AutomountServiceAccountToken: &automount,
+	}
+}
+
+// createLeaderElectionRole returns a Role that allows creating Leases and
+// reading and renewing the migrations Lease of the database. Create cannot be
+// limited to a name.
+func (r *ClusterTesterReconciler) createLeaderElectionRole(clusterTester *clusterv1.ClusterTester, serviceName, namespace string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      leaderElectionRoleName(clusterTester, serviceName),
+			Namespace: namespace,
+			Labels:    serviceAccountLabels(clusterTester, serviceName),
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"coordination.k8s.io"},
+				Resources: []string{"leases"},
+				Verbs:     []string{"create"},
+			},
+			{
+				APIGroups:     []string{"coordination.k8s.io"},
+				Resources:     []string{"leases"},
+				ResourceNames: []string{migrationLeaseName(clusterTester)},
+				Verbs:         []string{"get", "update"},
+			},
+		},
+	}
+}
+
+func (r *ClusterTesterReconciler) createLeaderElectionRoleBinding(clusterTester *clusterv1.ClusterTester, serviceName, namespace string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      leaderElectionRoleName(clusterTester, serviceName),
+			Namespace: namespace,
+			Labels:    serviceAccountLabels(clusterTester, serviceName),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     leaderElectionRoleName(clusterTester, serviceName),
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      resourceName(clusterTester, serviceName),
+			Namespace: namespace,
+		}},
+	}
+}
+
 func (r *ClusterTesterReconciler) createDeployment(clusterTester *clusterv1.ClusterTester, serviceName string, config clusterv1.ServiceConfig, namespace string) *appsv1.Deployment {
+	// Same-named ClusterTesters from other namespaces may deploy to the same
+	// target namespace, so the selectors include the owner's namespace.
 	labels := map[string]string{
 		"app":                          serviceName,
 		"app.kubernetes.io/name":       serviceName,
 		"app.kubernetes.io/instance":   clusterTester.Name,
+		ownerNamespaceLabel:            clusterTester.Namespace,
 		"app.kubernetes.io/component":  "microservice",
 		"app.kubernetes.io/part-of":    "cluster-tester",
 		"app.kubernetes.io/managed-by": "cluster-tester-operator",
@@ -288,9 +514,13 @@ func (r *ClusterTesterReconciler) createDeployment(clusterTester *clusterv1.Clus
 		imagePullPolicy = corev1.PullPolicy(clusterTester.Spec.Global.ImagePullPolicy)
 	}
 
+	// The probes use the gRPC health service, which answers for the
+	// "liveness" service like /livez and for the server like /readyz.
+	livenessService := "liveness"
+
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      serviceName,
+			Name:      resourceName(clusterTester, serviceName),
 			Namespace: namespace,
 			Labels:    labels,
 		},
@@ -304,6 +534,7 @@ func (r *ClusterTesterReconciler) createDeployment(clusterTester *clusterv1.Clus
 					Labels: labels,
 				},
 				Spec: corev1.PodSpec{
+					ServiceAccountName: resourceName(clusterTester, serviceName),
 					Containers: []corev1.Container{
 						{
 							Name:            serviceName,
@@ -315,12 +546,17 @@ func (r *ClusterTesterReconciler) createDeployment(clusterTester *clusterv1.Clus
 									ContainerPort: 8080,
 									Protocol:      corev1.ProtocolTCP,
 								},
+								{
+									Name:          "grpc",
+									ContainerPort: 9090,
+									Protocol:      corev1.ProtocolTCP,
+								},
 							},
 							LivenessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
-									HTTPGet: &corev1.HTTPGetAction{
-										Path: "/health",
-										Port: intstr.FromInt(8080),
+									GRPC: &corev1.GRPCAction{
+										Port:    9090,
+										Service: &livenessService,
 									},
 								},
 								InitialDelaySeconds: 30,
@@ -328,9 +564,8 @@ func (r *ClusterTesterReconciler) createDeployment(clusterTester *clusterv1.Clus
 							},
 							ReadinessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
-									HTTPGet: &corev1.HTTPGetAction{
-										Path: "/health",
-										Port: intstr.FromInt(8080),
+									GRPC: &corev1.GRPCAction{
+										Port: 9090,
 									},
 								},
 								InitialDelaySeconds: 5,
@@ -343,6 +578,18 @@ func (r *ClusterTesterReconciler) createDeployment(clusterTester *clusterv1.Clus
 		},
 	}
 
+	// Services without a gRPC API are probed over HTTP instead
+	if httpOnly[serviceName] {
+		container := &deployment.Spec.Template.Spec.Containers[0]
+		container.Ports = container.Ports[:1]
+		container.LivenessProbe.ProbeHandler = corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/livez", Port: intstr.FromInt(8080)},
+		}
+		container.ReadinessProbe.ProbeHandler = corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/readyz", Port: intstr.FromInt(8080)},
+		}
+	}
+
 	// Add resource requirements if specified
 	if config.Resources != nil {
 		resources := corev1.ResourceRequirements{}
@@ -363,10 +610,10 @@ func (r *ClusterTesterReconciler) createDeployment(clusterTester *clusterv1.Clus
 
 	// Add database environment variables for services that need them
 	if serviceName == "electronics-store" || serviceName == "electronics-store-tracing" {
-		deployment.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{
+		dbEnv := []corev1.EnvVar{
 			{
 				Name:  "DB_HOST",
-				Value: "mysql",
+				Value: resourceName(clusterTester, "mysql"),
 			},
 			{
 				Name:  "DB_PORT",
@@ -385,16 +632,341 @@ func (r *ClusterTesterReconciler) createDeployment(clusterTester *clusterv1.Clus
 				Value: "password123",
 			},
 		}
+
+		// Schema migrations and seed data run as init containers so the
+		// application only starts once the database is up to date.
+		container := &deployment.Spec.Template.Spec.Containers[0]
+		container.Env = append(dbEnv, corev1.EnvVar{Name: "DB_AUTO_MIGRATE", Value: "false"})
+		deployment.Spec.Template.Spec.InitContainers = []corev1.Container{
+			migrationContainer("migrate", container.Image, imagePullPolicy, dbEnv, "up"),
+			migrationContainer("seed", container.Image, imagePullPolicy, dbEnv, "seed"),
+		}
+	}
+
+	app := &deployment.Spec.Template.Spec.Containers[0]
+	app.Env = append(app.Env, loggingEnv(clusterTester.Spec.Global)...)
+	if config.RateLimit != nil {
+		app.Env = append(app.Env, rateLimitEnv(*config.RateLimit)...)
+	}
+
+	if config.Seed != nil {
+		addSeed(&deployment.Spec.Template.Spec, *config.Seed)
+	}
+
+	if leaderElected[serviceName] {
+		addLeaderElection(&deployment.Spec.Template.Spec, migrationLeaseName(clusterTester))
+	}
+
+	if authEnabled(clusterTester.Spec.Global) {
+		addAuth(&deployment.Spec.Template.Spec, apiKeySecretName(clusterTester), *clusterTester.Spec.Global.Auth)
+	}
+
+	app.Env = append(app.Env, r.dependencyEnv(clusterTester, serviceName, namespace)...)
+
+	// The services publish their events to the broker; the gateway has
+	// none to publish
+	if clusterTester.Spec.Messaging.Enabled && !httpOnly[serviceName] {
+		app.Env = append(app.Env, corev1.EnvVar{Name: "NATS_URL", Value: natsURL(clusterTester, namespace)})
+	}
+
+	// The electronics stores read their products through the cache
+	if clusterTester.Spec.Cache.Enabled && cached[serviceName] {
+		app.Env = append(app.Env, corev1.EnvVar{Name: "REDIS_ADDR", Value: redisAddr(clusterTester, namespace)})
+		if ttl := clusterTester.Spec.Cache.TTLSeconds; ttl > 0 {
+			app.Env = append(app.Env, corev1.EnvVar{Name: "CACHE_TTL_SECONDS", Value: fmt.Sprint(ttl)})
+		}
 	}
 
 	return deployment
 }
 
+// addMissingPorts adds the ports that service has no port of the same name
+// for, such as the gRPC port of Services created before it was served, and
+// reports whether it added any. Existing ports are kept as they are, so that
+// their node ports do not change.
+func addMissingPorts(service *corev1.Service, ports []corev1.ServicePort) bool {
+	added := false
+	for _, port := range ports {
+		if !slices.ContainsFunc(service.Spec.Ports, func(p corev1.ServicePort) bool { return p.Name == port.Name }) {
+			service.Spec.Ports = append(service.Spec.Ports, port)
+			added = true
+		}
+	}
+	return added
+}
+
+// serviceEndpoint returns the in-cluster address of a service, as reported in
+// ServiceStatus.Endpoint.
+func serviceEndpoint(serviceName, namespace string) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local:8080", serviceName, namespace)
+}
+
+// serviceGRPCEndpoint returns the in-cluster address of the gRPC API of a
+// service, as reported in ServiceStatus.GRPCEndpoint.
+func serviceGRPCEndpoint(serviceName, namespace string) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local:9090", serviceName, namespace)
+}
+
+// serviceDependencies lists, for each service, the services it calls and the
+// prefix of the variables that tell it where to find them.
+var serviceDependencies = map[string][]struct{ service, envPrefix string }{
+	"restaurant": {{"coffee-shop", "COFFEE_SHOP"}},
+	"graphql-gateway": {
+		{"coffee-shop", "COFFEE_SHOP"},
+		{"pet-store", "PET_STORE"},
+		{"restaurant", "RESTAURANT"},
+		{"college-admission", "COLLEGE_ADMISSION"},
+		{"electronics-store", "ELECTRONICS_STORE"},
+	},
+}
+
+// httpOnly lists the services that serve no gRPC API: the GraphQL gateway.
+// Their pods are probed over HTTP and their Services have no gRPC port.
+var httpOnly = map[string]bool{
+	"graphql-gateway": true,
+}
+
+// forwardsCredentials lists the services that call their dependencies with
+// the credentials of their own callers: the GraphQL gateway, which does not
+// check them itself. They get no key, so that an anonymous caller cannot
+// read through them what the services would deny it.
+var forwardsCredentials = map[string]bool{
+	"graphql-gateway": true,
+}
+
+// dependencyEnv returns the variables that point a service at the enabled
+// services it calls: <PREFIX>_URL from the endpoint of the dependency and,
+// when auth is enabled, <PREFIX>_API_KEY with the generated read key unless
+// the service forwards its callers' credentials. Disabled dependencies are
+// left unset, which the services treat as unavailable.
+func (r *ClusterTesterReconciler) dependencyEnv(clusterTester *clusterv1.ClusterTester, serviceName, namespace string) []corev1.EnvVar {
+	services := r.getServiceConfigs(clusterTester)
+	var env []corev1.EnvVar
+	for _, dep := range serviceDependencies[serviceName] {
+		if !services[dep.service].Enabled {
+			continue
+		}
+		env = append(env, corev1.EnvVar{Name: dep.envPrefix + "_URL", Value: "http://" + serviceEndpoint(resourceName(clusterTester, dep.service), namespace)})
+		if authEnabled(clusterTester.Spec.Global) && !forwardsCredentials[serviceName] {
+			env = append(env, corev1.EnvVar{Name: dep.envPrefix + "_API_KEY", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: apiKeySecretName(clusterTester)},
+					Key:                  readKeyKey,
+				},
+			}})
+		}
+	}
+	return env
+}
+
+// loggingEnv returns the variables that select the log level and format of a
+// service. The pod name and namespace are tagged onto every log record so
+// that service logs can be matched with the operator's.
+func loggingEnv(global clusterv1.GlobalConfig) []corev1.EnvVar {
+	level := global.LogLevel
+	if level == "" {
+		level = "info"
+	}
+	format := global.LogFormat
+	if format == "" {
+		format = "json"
+	}
+	return append([]corev1.EnvVar{
+		{Name: "LOG_LEVEL", Value: level},
+		{Name: "LOG_FORMAT", Value: format},
+		// Release mode stops gin from printing its unstructured route table.
+		{Name: "GIN_MODE", Value: "release"},
+	}, podEnv()...)
+}
+
+// podEnv returns the variables that tell a container the name and namespace
+// of its pod.
+func podEnv() []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+		}},
+		{Name: "POD_NAMESPACE", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+		}},
+	}
+}
+
+// leaderElected lists the services whose replicas elect a leader to run
+// singleton jobs: the migrations and seeding of the database-backed services.
+// Pet-store is not one of them, as each of its pods sweeps the reservations
+// of its own in-memory pets.
+var leaderElected = map[string]bool{
+	"electronics-store":         true,
+	"electronics-store-tracing": true,
+}
+
+// migrationLeaseName returns the name of the Lease the leader-elected
+// services hold while they migrate and seed. The electronics stores share
+// the database, so they share one Lease, and a pod of one never migrates
+// while a pod of the other does.
+func migrationLeaseName(clusterTester *clusterv1.ClusterTester) string {
+	return resourceName(clusterTester, "mysql-migrations")
+}
+
+// leaderElectionRoleName returns the name of the Role, and of its
+// RoleBinding, that lets a service's ServiceAccount use its Lease.
+func leaderElectionRoleName(clusterTester *clusterv1.ClusterTester, serviceName string) string {
+	return resourceName(clusterTester, serviceName+"-leader-election")
+}
+
+// addLeaderElection points the containers of a leader-elected service,
+// including the init containers that migrate and seed, at the Lease named
+// leaseName.
+func addLeaderElection(podSpec *corev1.PodSpec, leaseName string) {
+	lease := corev1.EnvVar{Name: "LEADER_ELECTION_LEASE", Value: leaseName}
+	app := &podSpec.Containers[0]
+	app.Env = append(app.Env, lease)
+	for i := range podSpec.InitContainers {
+		init := &podSpec.InitContainers[i]
+		init.Env = append(append(init.Env, lease), podEnv()...)
+	}
+}
+
+// rateLimitEnv returns the variables that set the request limits of a
+// service. Unset fields keep the service defaults.
+func rateLimitEnv(limit clusterv1.RateLimitConfig) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	if limit.RequestsPerSecond > 0 {
+		env = append(env, corev1.EnvVar{Name: "RATE_LIMIT_RPS", Value: fmt.Sprintf("%d", limit.RequestsPerSecond)})
+	}
+	if limit.Burst > 0 {
+		env = append(env, corev1.EnvVar{Name: "RATE_LIMIT_BURST", Value: fmt.Sprintf("%d", limit.Burst)})
+	}
+	if limit.MaxBodyBytes > 0 {
+		env = append(env, corev1.EnvVar{Name: "MAX_BODY_BYTES", Value: fmt.Sprintf("%d", limit.MaxBodyBytes)})
+	}
+	return env
+}
+
+// seedMountPath is where the seed ConfigMap is mounted in the container that
+// loads it.
+const seedMountPath = "/etc/cluster-tester/seed"
+
+// addSeed mounts the seed ConfigMap and sets the SEED_* variables the services
+// read on startup. Database-backed services load fixtures in their "seed"
+// init container; the in-memory services load them in the app container.
+func addSeed(podSpec *corev1.PodSpec, seed clusterv1.SeedConfig) {
+	mode := seed.Mode
+	if mode == "" {
+		mode = "replace"
+	}
+	env := []corev1.EnvVar{{Name: "SEED_MODE", Value: mode}}
+	if seed.Generate > 0 {
+		env = append(env, corev1.EnvVar{Name: "SEED_GENERATE", Value: fmt.Sprintf("%d", seed.Generate)})
+	}
+
+	var mounts []corev1.VolumeMount
+	if seed.ConfigMap != "" {
+		key := seed.Key
+		if key == "" {
+			key = "fixtures.json"
+		}
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "seed",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: seed.ConfigMap},
+					Items:                []corev1.KeyToPath{{Key: key, Path: key}},
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: "seed", MountPath: seedMountPath, ReadOnly: true})
+		env = append(env, corev1.EnvVar{Name: "SEED_FILE", Value: path.Join(seedMountPath, key)})
+	}
+
+	target := &podSpec.Containers[0]
+	for i := range podSpec.InitContainers {
+		if podSpec.InitContainers[i].Name == "seed" {
+			target = &podSpec.InitContainers[i]
+		}
+	}
+	target.Env = append(target.Env, env...)
+	target.VolumeMounts = append(target.VolumeMounts, mounts...)
+}
+
+// authMountPath is where the API keys and the JWKS are mounted.
+const authMountPath = "/etc/cluster-tester/auth"
+
+// Keys of the generated API key Secret. The services read the key file;
+// the read and write keys are there for clients such as contract-test.sh.
+const (
+	apiKeysFileKey = "api-keys"
+	readKeyKey     = "read-key"
+	writeKeyKey    = "write-key"
+)
+
+func authEnabled(global clusterv1.GlobalConfig) bool {
+	return global.Auth != nil && global.Auth.Enabled
+}
+
+// apiKeySecretName returns the name of the Secret holding the API keys of a
+// ClusterTester.
+func apiKeySecretName(clusterTester *clusterv1.ClusterTester) string {
+	return resourceName(clusterTester, "api-keys")
+}
+
+// addAuth mounts the API key file, and the JWKS when one is configured, into
+// the app container and sets the AUTH_* variables that make the service
+// require credentials.
+func addAuth(podSpec *corev1.PodSpec, secretName string, auth clusterv1.AuthConfig) {
+	sources := []corev1.VolumeProjection{{
+		Secret: &corev1.SecretProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+			Items:                []corev1.KeyToPath{{Key: apiKeysFileKey, Path: apiKeysFileKey}},
+		},
+	}}
+	env := []corev1.EnvVar{{Name: "AUTH_API_KEYS_FILE", Value: path.Join(authMountPath, apiKeysFileKey)}}
+	if auth.JWKSConfigMap != "" {
+		sources = append(sources, corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: auth.JWKSConfigMap},
+				Items:                []corev1.KeyToPath{{Key: "jwks.json", Path: "jwks.json"}},
+			},
+		})
+		env = append(env, corev1.EnvVar{Name: "AUTH_JWKS_FILE", Value: path.Join(authMountPath, "jwks.json")})
+		if auth.Issuer != "" {
+			env = append(env, corev1.EnvVar{Name: "AUTH_JWT_ISSUER", Value: auth.Issuer})
+		}
+		if auth.Audience != "" {
+			env = append(env, corev1.EnvVar{Name: "AUTH_JWT_AUDIENCE", Value: auth.Audience})
+		}
+	}
+
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "auth",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{Sources: sources},
+		},
+	})
+	app := &podSpec.Containers[0]
+	app.Env = append(app.Env, env...)
+	app.VolumeMounts = append(app.VolumeMounts, corev1.VolumeMount{Name: "auth", MountPath: authMountPath, ReadOnly: true})
+}
+
+// migrationContainer returns an init container that runs the given
+// "migrate" subcommand of a database-backed service image.
+func migrationContainer(name, image string, pullPolicy corev1.PullPolicy, env []corev1.EnvVar, command string) corev1.Container {
+	return corev1.Container{
+		Name:            name,
+		Image:           image,
+		ImagePullPolicy: pullPolicy,
+		Command:         []string{"./electronics-store-be", "migrate", command},
+		Env:             append([]corev1.EnvVar(nil), env...),
+	}
+}
+
 func (r *ClusterTesterReconciler) createService(clusterTester *clusterv1.ClusterTester, serviceName string, namespace string) *corev1.Service {
 	labels := map[string]string{
 		"app":                          serviceName,
 		"app.kubernetes.io/name":       serviceName,
 		"app.kubernetes.io/instance":   clusterTester.Name,
+		ownerNamespaceLabel:            clusterTester.Namespace,
 		"app.kubernetes.io/component":  "microservice",
 		"app.kubernetes.io/part-of":    "cluster-tester",
 		"app.kubernetes.io/managed-by": "cluster-tester-operator",
@@ -405,9 +977,13 @@ func (r *ClusterTesterReconciler) createService(clusterTester *clusterv1.Cluster
 		serviceType = corev1.ServiceType(clusterTester.Spec.Global.ServiceType)
 	}
 
-	return &corev1.Service{
+	// The gRPC port is marked as cleartext HTTP/2, so that meshes and
+	// gateways balance its requests rather than its connections.
+	h2c := "kubernetes.io/h2c"
+
+	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      serviceName,
+			Name:      resourceName(clusterTester, serviceName),
 			Namespace: namespace,
 			Labels:    labels,
 		},
@@ -421,18 +997,26 @@ func (r *ClusterTesterReconciler) createService(clusterTester *clusterv1.Cluster
 					TargetPort: intstr.FromInt(8080),
 					Protocol:   corev1.ProtocolTCP,
 				},
+				{
+					Name:        "grpc",
+					Port:        9090,
+					TargetPort:  intstr.FromInt(9090),
+					Protocol:    corev1.ProtocolTCP,
+					AppProtocol: &h2c,
+				},
 			},
 		},
 	}
+	if httpOnly[serviceName] {
+		service.Spec.Ports = service.Spec.Ports[:1]
+	}
+	return service
 }
 
 func (r *ClusterTesterReconciler) reconcileDatabase(ctx context.Context, clusterTester *clusterv1.ClusterTester) error {
 	logger := log.FromContext(ctx)
 
-	namespace := clusterTester.Namespace
-	if clusterTester.Spec.Global.Namespace != "" {
-		namespace = clusterTester.Spec.Global.Namespace
-	}
+	namespace := targetNamespace(clusterTester)
 
 	dbConfig := clusterTester.Spec.Database
 	if dbConfig.Type == "" {
@@ -449,13 +1033,17 @@ func (r *ClusterTesterReconciler) reconcileDatabase(ctx context.Context, cluster
 	}
 
 	// Create PVC
-	pvc := r.createDatabasePVC(clusterTester, dbConfig, namespace)
-	if err := controllerutil.SetControllerReference(clusterTester, pvc, r.Scheme); err != nil {
+	claimName, err := r.databaseClaimName(ctx, clusterTester)
+	if err != nil {
+		return err
+	}
+	pvc := r.createDatabasePVC(clusterTester, dbConfig, namespace, claimName)
+	if err := r.setOwner(clusterTester, pvc); err != nil {
 		return err
 	}
 
 	foundPVC := &corev1.PersistentVolumeClaim{}
-	err := r.Get(ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, foundPVC)
+	err = r.Get(ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, foundPVC)
 	if err != nil && errors.IsNotFound(err) {
 		logger.Info("Creating PVC", "pvc", pvc.Name)
 		if err = r.Create(ctx, pvc); err != nil {
@@ -463,11 +1051,13 @@ func (r *ClusterTesterReconciler) reconcileDatabase(ctx context.Context, cluster
 		}
 	} else if err != nil {
 		return err
+	} else if err = checkOwner(clusterTester, foundPVC); err != nil {
+		return err
 	}
 
 	// Create deployment
-	deployment := r.createDatabaseDeployment(clusterTester, dbConfig, namespace)
-	if err := controllerutil.SetControllerReference(clusterTester, deployment, r.Scheme); err != nil {
+	deployment := r.createDatabaseDeployment(clusterTester, dbConfig, namespace, claimName)
+	if err := r.setOwner(clusterTester, deployment); err != nil {
 		return err
 	}
 
@@ -480,11 +1070,13 @@ func (r *ClusterTesterReconciler) reconcileDatabase(ctx context.Context, cluster
 		}
 	} else if err != nil {
 		return err
+	} else if err = checkOwner(clusterTester, found); err != nil {
+		return err
 	}
 
 	// Create service
 	service := r.createDatabaseService(clusterTester, namespace)
-	if err := controllerutil.SetControllerReference(clusterTester, service, r.Scheme); err != nil {
+	if err := r.setOwner(clusterTester, service); err != nil {
 		return err
 	}
 
@@ -497,12 +1089,81 @@ func (r *ClusterTesterReconciler) reconcileDatabase(ctx context.Context, cluster
 		}
 	} else if err != nil {
 		return err
+	} else if err = checkOwner(clusterTester, foundService); err != nil {
+		return err
 	}
 
 	return nil
 }
 
-func (r *ClusterTesterReconciler) createDatabasePVC(clusterTester *clusterv1.ClusterTester, dbConfig clusterv1.DatabaseConfig, namespace string) *corev1.PersistentVolumeClaim {
+// reconcileAuthSecret creates the Secret with a read and a write API key.
+// Existing keys are kept, so clients keep working across reconciles; delete
+// the Secret and restart the services to rotate them.
+func (r *ClusterTesterReconciler) reconcileAuthSecret(ctx context.Context, clusterTester *clusterv1.ClusterTester) error {
+	logger := log.FromContext(ctx)
+
+	namespace := targetNamespace(clusterTester)
+
+	name := apiKeySecretName(clusterTester)
+	found := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, found)
+	if err == nil {
+		return checkOwner(clusterTester, found)
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	secret, err := r.createAuthSecret(clusterTester, name, namespace)
+	if err != nil {
+		return err
+	}
+	if err := r.setOwner(clusterTester, secret); err != nil {
+		return err
+	}
+	logger.Info("Creating API key secret", "secret", secret.Name)
+	return r.Create(ctx, secret)
+}
+
+func (r *ClusterTesterReconciler) createAuthSecret(clusterTester *clusterv1.ClusterTester, name, namespace string) (*corev1.Secret, error) {
+	readKey, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	writeKey, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/instance":   clusterTester.Name,
+				"app.kubernetes.io/part-of":    "cluster-tester",
+				"app.kubernetes.io/managed-by": "cluster-tester-operator",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			readKeyKey:  []byte(readKey),
+			writeKeyKey: []byte(writeKey),
+			// One key per line followed by its scopes and a name for the logs.
+			apiKeysFileKey: []byte(fmt.Sprintf("%s read reader\n%s read write writer\n", readKey, writeKey)),
+		},
+	}, nil
+}
+
+// generateAPIKey returns a random 256-bit key, hex encoded.
+func generateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (r *ClusterTesterReconciler) createDatabasePVC(clusterTester *clusterv1.ClusterTester, dbConfig clusterv1.DatabaseConfig, namespace, claimName string) *corev1.PersistentVolumeClaim {
 	labels := map[string]string{
 		"app":                          "mysql",
 		"app.kubernetes.io/name":       "mysql",
@@ -514,7 +1175,7 @@ func (r *ClusterTesterReconciler) createDatabasePVC(clusterTester *clusterv1.Clu
 
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "mysql-pvc",
+			Name:      claimName,
 			Namespace: namespace,
 			Labels:    labels,
 		},
@@ -537,11 +1198,12 @@ func (r *ClusterTesterReconciler) createDatabasePVC(clusterTester *clusterv1.Clu
 	return pvc
 }
 
-func (r *ClusterTesterReconciler) createDatabaseDeployment(clusterTester *clusterv1.ClusterTester, dbConfig clusterv1.DatabaseConfig, namespace string) *appsv1.Deployment {
+func (r *ClusterTesterReconciler) createDatabaseDeployment(clusterTester *clusterv1.ClusterTester, dbConfig clusterv1.DatabaseConfig, namespace, claimName string) *appsv1.Deployment {
 	labels := map[string]string{
 		"app":                          "mysql",
 		"app.kubernetes.io/name":       "mysql",
 		"app.kubernetes.io/instance":   clusterTester.Name,
+		ownerNamespaceLabel:            clusterTester.Namespace,
 		"app.kubernetes.io/component":  "database",
 		"app.kubernetes.io/part-of":    "cluster-tester",
 		"app.kubernetes.io/managed-by": "cluster-tester-operator",
@@ -551,7 +1213,7 @@ func (r *ClusterTesterReconciler) createDatabaseDeployment(clusterTester *cluste
 
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "mysql",
+			Name:      resourceName(clusterTester, "mysql"),
 			Namespace: namespace,
 			Labels:    labels,
 		},
@@ -607,7 +1269,7 @@ func (r *ClusterTesterReconciler) createDatabaseDeployment(clusterTester *cluste
 							Name: "mysql-storage",
 							VolumeSource: corev1.VolumeSource{
 								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-									ClaimName: "mysql-pvc",
+									ClaimName: claimName,
 								},
 							},
 						},
@@ -623,6 +1285,7 @@ func (r *ClusterTesterReconciler) createDatabaseService(clusterTester *clusterv1
 		"app":                          "mysql",
 		"app.kubernetes.io/name":       "mysql",
 		"app.kubernetes.io/instance":   clusterTester.Name,
+		ownerNamespaceLabel:            clusterTester.Namespace,
 		"app.kubernetes.io/component":  "database",
 		"app.kubernetes.io/part-of":    "cluster-tester",
 		"app.kubernetes.io/managed-by": "cluster-tester-operator",
@@ -630,7 +1293,7 @@ func (r *ClusterTesterReconciler) createDatabaseService(clusterTester *clusterv1
 
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "mysql",
+			Name:      resourceName(clusterTester, "mysql"),
 			Namespace: namespace,
 			Labels:    labels,
 		},
@@ -649,8 +1312,332 @@ func (r *ClusterTesterReconciler) createDatabaseService(clusterTester *clusterv1
 	}
 }
 
+// natsURL returns the in-cluster address of the message broker, as set in
+// NATS_URL.
+func natsURL(clusterTester *clusterv1.ClusterTester, namespace string) string {
+	return fmt.Sprintf("nats://%s.%s.svc.cluster.local:4222", resourceName(clusterTester, "nats"), namespace)
+}
+
+func (r *ClusterTesterReconciler) reconcileMessaging(ctx context.Context, clusterTester *clusterv1.ClusterTester) error {
+	logger := log.FromContext(ctx)
+
+	namespace := targetNamespace(clusterTester)
+
+	messaging := clusterTester.Spec.Messaging
+	if messaging.Image == "" {
+		messaging.Image = "nats"
+	}
+	if messaging.Tag == "" {
+		messaging.Tag = "2.10-alpine"
+	}
+
+	// Create deployment
+	deployment := r.createMessagingDeployment(clusterTester, messaging, namespace)
+	if err := r.setOwner(clusterTester, deployment); err != nil {
+		return err
+	}
+
+	found := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		logger.Info("Creating message broker deployment", "deployment", deployment.Name)
+		if err = r.Create(ctx, deployment); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if err = checkOwner(clusterTester, found); err != nil {
+		return err
+	}
+
+	// Create service
+	service := r.createMessagingService(clusterTester, namespace)
+	if err := r.setOwner(clusterTester, service); err != nil {
+		return err
+	}
+
+	foundService := &corev1.Service{}
+	err = r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, foundService)
+	if err != nil && errors.IsNotFound(err) {
+		logger.Info("Creating message broker service", "service", service.Name)
+		if err = r.Create(ctx, service); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if err = checkOwner(clusterTester, foundService); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func messagingLabels(clusterTester *clusterv1.ClusterTester) map[string]string {
+	return map[string]string{
+		"app":                          "nats",
+		"app.kubernetes.io/name":       "nats",
+		"app.kubernetes.io/instance":   clusterTester.Name,
+		ownerNamespaceLabel:            clusterTester.Namespace,
+		"app.kubernetes.io/component":  "messaging",
+		"app.kubernetes.io/part-of":    "cluster-tester",
+		"app.kubernetes.io/managed-by": "cluster-tester-operator",
+	}
+}
+
+// createMessagingDeployment returns a single NATS server. Events are not
+// persisted: subscribers that are down miss them, which the services allow
+// for.
+func (r *ClusterTesterReconciler) createMessagingDeployment(clusterTester *clusterv1.ClusterTester, messaging clusterv1.MessagingConfig, namespace string) *appsv1.Deployment {
+	labels := messagingLabels(clusterTester)
+	replicas := int32(1)
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName(clusterTester, "nats"),
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "nats",
+							Image: fmt.Sprintf("%s:%s", messaging.Image, messaging.Tag),
+							// The monitoring port serves the health check.
+							Args: []string{"--http_port", "8222"},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "client",
+									ContainerPort: 4222,
+									Protocol:      corev1.ProtocolTCP,
+								},
+								{
+									Name:          "monitor",
+									ContainerPort: 8222,
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(8222)},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       10,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(8222)},
+								},
+								InitialDelaySeconds: 2,
+								PeriodSeconds:       5,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *ClusterTesterReconciler) createMessagingService(clusterTester *clusterv1.ClusterTester, namespace string) *corev1.Service {
+	labels := messagingLabels(clusterTester)
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName(clusterTester, "nats"),
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "client",
+					Port:       4222,
+					TargetPort: intstr.FromInt(4222),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+// cached are the services that read through the cache when spec.cache is
+// enabled.
+var cached = map[string]bool{
+	"electronics-store":         true,
+	"electronics-store-tracing": true,
+}
+
+// redisAddr returns the in-cluster address of the cache, as set in
+// REDIS_ADDR.
+func redisAddr(clusterTester *clusterv1.ClusterTester, namespace string) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local:6379", resourceName(clusterTester, "redis"), namespace)
+}
+
+func (r *ClusterTesterReconciler) reconcileCache(ctx context.Context, clusterTester *clusterv1.ClusterTester) error {
+	logger := log.FromContext(ctx)
+
+	namespace := targetNamespace(clusterTester)
+
+	cache := clusterTester.Spec.Cache
+	if cache.Image == "" {
+		cache.Image = "redis"
+	}
+	if cache.Tag == "" {
+		cache.Tag = "7-alpine"
+	}
+
+	// Create deployment
+	deployment := r.createCacheDeployment(clusterTester, cache, namespace)
+	if err := r.setOwner(clusterTester, deployment); err != nil {
+		return err
+	}
+
+	found := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		logger.Info("Creating cache deployment", "deployment", deployment.Name)
+		if err = r.Create(ctx, deployment); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if err = checkOwner(clusterTester, found); err != nil {
+		return err
+	}
+
+	// Create service
+	service := r.createCacheService(clusterTester, namespace)
+	if err := r.setOwner(clusterTester, service); err != nil {
+		return err
+	}
+
+	foundService := &corev1.Service{}
+	err = r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, foundService)
+	if err != nil && errors.IsNotFound(err) {
+		logger.Info("Creating cache service", "service", service.Name)
+		if err = r.Create(ctx, service); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if err = checkOwner(clusterTester, foundService); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func cacheLabels(clusterTester *clusterv1.ClusterTester) map[string]string {
+	return map[string]string{
+		"app":                          "redis",
+		"app.kubernetes.io/name":       "redis",
+		"app.kubernetes.io/instance":   clusterTester.Name,
+		ownerNamespaceLabel:            clusterTester.Namespace,
+		"app.kubernetes.io/component":  "cache",
+		"app.kubernetes.io/part-of":    "cluster-tester",
+		"app.kubernetes.io/managed-by": "cluster-tester-operator",
+	}
+}
+
+// createCacheDeployment returns a single Redis server that keeps nothing on
+// disk and evicts the least recently used reads when it is full. A restart
+// empties it, which only costs the services misses.
+func (r *ClusterTesterReconciler) createCacheDeployment(clusterTester *clusterv1.ClusterTester, cache clusterv1.CacheConfig, namespace string) *appsv1.Deployment {
+	labels := cacheLabels(clusterTester)
+	replicas := int32(1)
+	ping := &corev1.ExecAction{Command: []string{"redis-cli", "ping"}}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName(clusterTester, "redis"),
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "redis",
+							Image: fmt.Sprintf("%s:%s", cache.Image, cache.Tag),
+							Args: []string{
+								"--save", "",
+								"--appendonly", "no",
+								"--maxmemory", "256mb",
+								"--maxmemory-policy", "allkeys-lru",
+							},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "redis",
+									ContainerPort: 6379,
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler:        corev1.ProbeHandler{Exec: ping},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       10,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler:        corev1.ProbeHandler{Exec: ping},
+								InitialDelaySeconds: 2,
+								PeriodSeconds:       5,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *ClusterTesterReconciler) createCacheService(clusterTester *clusterv1.ClusterTester, namespace string) *corev1.Service {
+	labels := cacheLabels(clusterTester)
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName(clusterTester, "redis"),
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "redis",
+					Port:       6379,
+					TargetPort: intstr.FromInt(6379),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
 func (r *ClusterTesterReconciler) updateStatusError(ctx context.Context, clusterTester *clusterv1.ClusterTester, reason string, err error) (ctrl.Result, error) {
 	clusterTester.Status.Phase = "Failed"
+	if isNameConflict(err) {
+		reason = "NameConflict"
+	}
 
 	errorCondition := metav1.Condition{
 		Type:    "Ready",
@@ -673,12 +1660,16 @@ func parseQuantity(s string) *resource.Quantity {
 	return &q
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// SetupWithManager sets up the controller with the Manager. The objects
+// are watched through both their owner references and their owner labels,
+// as objects in another namespace only have the labels, and objects created
+// by earlier versions of the operator only the references.
 func (r *ClusterTesterReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&clusterv1.ClusterTester{}).
-		Owns(&appsv1.Deployment{}).
-		Owns(&corev1.Service{}).
-		Owns(&corev1.PersistentVolumeClaim{}).
-		Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.ClusterTester{})
+	for _, obj := range ownedObjects() {
+		b = b.Owns(obj).
+			Watches(obj, handler.EnqueueRequestsFromMapFunc(requestForOwner))
+	}
+	return b.Complete(r)
 }
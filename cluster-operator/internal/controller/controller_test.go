@@ -2,15 +2,22 @@ package controller
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	clusterv1 "github.com/cdcent/cluster-tester/cluster-operator/api/v1"
 )
@@ -27,6 +34,9 @@ func TestClusterTesterReconciler_BasicReconcile(t *testing.T) {
 	if err := appsv1.AddToScheme(scheme); err != nil {
 		t.Fatalf("Failed to add apps v1 scheme: %v", err)
 	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add RBAC v1 scheme: %v", err)
+	}
 
 	// Create a ClusterTester resource
 	clusterTester := &clusterv1.ClusterTester{
@@ -35,12 +45,10 @@ func TestClusterTesterReconciler_BasicReconcile(t *testing.T) {
 			Namespace: "default",
 		},
 		Spec: clusterv1.ClusterTesterSpec{
-			Services: []clusterv1.ServiceConfig{
-				{
-					Name:  "test-service",
-					Image: "test-image:latest",
-					Port:  8080,
-				},
+			CoffeeShop: clusterv1.ServiceConfig{
+				Enabled: true,
+				Image:   "test-image",
+				Tag:     "latest",
 			},
 		},
 	}
@@ -49,6 +57,7 @@ func TestClusterTesterReconciler_BasicReconcile(t *testing.T) {
 	fakeClient := fake.NewClientBuilder().
 		WithScheme(scheme).
 		WithObjects(clusterTester).
+		WithStatusSubresource(clusterTester).
 		Build()
 
 	reconciler := &ClusterTesterReconciler{
@@ -76,13 +85,13 @@ func TestClusterTesterReconciler_BasicReconcile(t *testing.T) {
 	// Verify that Deployment was created
 	deployment := &appsv1.Deployment{}
 	err = fakeClient.Get(ctx, types.NamespacedName{
-		Name:      "test-service",
+		Name:      "test-cluster-coffee-shop",
 		Namespace: "default",
 	}, deployment)
 	if err != nil {
-		t.Errorf("Expected Deployment 'test-service' to be created: %v", err)
+		t.Errorf("Expected Deployment 'test-cluster-coffee-shop' to be created: %v", err)
 	} else {
-		t.Logf("✓ Deployment 'test-service' created successfully")
+		t.Logf("✓ Deployment 'test-cluster-coffee-shop' created successfully")
 
 		// Verify deployment details
 		if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 1 {
@@ -102,21 +111,23 @@ func TestClusterTesterReconciler_BasicReconcile(t *testing.T) {
 	// Verify that Service was created
 	service := &corev1.Service{}
 	err = fakeClient.Get(ctx, types.NamespacedName{
-		Name:      "test-service",
+		Name:      "test-cluster-coffee-shop",
 		Namespace: "default",
 	}, service)
 	if err != nil {
-		t.Errorf("Expected Service 'test-service' to be created: %v", err)
+		t.Errorf("Expected Service 'test-cluster-coffee-shop' to be created: %v", err)
 	} else {
-		t.Logf("✓ Service 'test-service' created successfully")
+		t.Logf("✓ Service 'test-cluster-coffee-shop' created successfully")
 
 		// Verify service details
 		if service.Spec.Type != corev1.ServiceTypeClusterIP {
 			t.Errorf("Expected service type ClusterIP, got %s", service.Spec.Type)
 		}
 
-		if len(service.Spec.Ports) != 1 || service.Spec.Ports[0].Port != 8080 {
-			t.Errorf("Expected service port 8080, got %v", service.Spec.Ports)
+		if len(service.Spec.Ports) != 2 || service.Spec.Ports[0].Port != 8080 || service.Spec.Ports[1].Port != 9090 {
+			t.Errorf("Expected service ports 8080 and 9090, got %v", service.Spec.Ports)
+		} else if p := service.Spec.Ports[1].AppProtocol; p == nil || *p != "kubernetes.io/h2c" {
+			t.Errorf("Expected the gRPC port to have app protocol kubernetes.io/h2c, got %v", p)
 		}
 	}
 }
@@ -132,6 +143,9 @@ func TestClusterTesterReconciler_MultipleServices(t *testing.T) {
 	if err := appsv1.AddToScheme(scheme); err != nil {
 		t.Fatalf("Failed to add schemes: %v", err)
 	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add schemes: %v", err)
+	}
 
 	clusterTester := &clusterv1.ClusterTester{
 		ObjectMeta: metav1.ObjectMeta{
@@ -139,17 +153,15 @@ func TestClusterTesterReconciler_MultipleServices(t *testing.T) {
 			Namespace: "default",
 		},
 		Spec: clusterv1.ClusterTesterSpec{
-			Services: []clusterv1.ServiceConfig{
-				{
-					Name:  "service-a",
-					Image: "image-a:latest",
-					Port:  8080,
-				},
-				{
-					Name:  "service-b",
-					Image: "image-b:latest",
-					Port:  8081,
-				},
+			CoffeeShop: clusterv1.ServiceConfig{
+				Enabled: true,
+				Image:   "image-a",
+				Tag:     "latest",
+			},
+			PetStore: clusterv1.ServiceConfig{
+				Enabled: true,
+				Image:   "image-b",
+				Tag:     "latest",
 			},
 		},
 	}
@@ -157,6 +169,7 @@ func TestClusterTesterReconciler_MultipleServices(t *testing.T) {
 	fakeClient := fake.NewClientBuilder().
 		WithScheme(scheme).
 		WithObjects(clusterTester).
+		WithStatusSubresource(clusterTester).
 		Build()
 
 	reconciler := &ClusterTesterReconciler{
@@ -178,7 +191,7 @@ func TestClusterTesterReconciler_MultipleServices(t *testing.T) {
 	}
 
 	// Verify both services were created
-	serviceNames := []string{"service-a", "service-b"}
+	serviceNames := []string{"multi-service-test-coffee-shop", "multi-service-test-pet-store"}
 	for _, serviceName := range serviceNames {
 		// Check Deployment
 		deployment := &appsv1.Deployment{}
@@ -205,3 +218,1219 @@ func TestClusterTesterReconciler_MultipleServices(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateDeployment_GRPCProbes(t *testing.T) {
+	clusterTester := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "probe-test",
+			Namespace: "default",
+		},
+	}
+
+	reconciler := &ClusterTesterReconciler{}
+	config := clusterv1.ServiceConfig{Enabled: true, Image: "coffee-shop", Tag: "latest"}
+	deployment := reconciler.createDeployment(clusterTester, "coffee-shop", config, "default")
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if len(container.Ports) != 2 || container.Ports[1].Name != "grpc" || container.Ports[1].ContainerPort != 9090 {
+		t.Errorf("Expected a grpc container port 9090, got %v", container.Ports)
+	}
+	if container.LivenessProbe == nil || container.LivenessProbe.GRPC == nil {
+		t.Fatalf("Expected a gRPC liveness probe")
+	}
+	if s := container.LivenessProbe.GRPC.Service; container.LivenessProbe.GRPC.Port != 9090 || s == nil || *s != "liveness" {
+		t.Errorf("Expected the liveness probe to check service liveness on port 9090, got %v", container.LivenessProbe.GRPC)
+	}
+	if container.ReadinessProbe == nil || container.ReadinessProbe.GRPC == nil {
+		t.Fatalf("Expected a gRPC readiness probe")
+	}
+	if s := container.ReadinessProbe.GRPC.Service; container.ReadinessProbe.GRPC.Port != 9090 || s != nil {
+		t.Errorf("Expected the readiness probe to check the server on port 9090, got %v", container.ReadinessProbe.GRPC)
+	}
+}
+
+func TestReconcileService_AddsTheGRPCPortToExistingServices(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add ClusterTester scheme: %v", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add core v1 scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add apps v1 scheme: %v", err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add RBAC v1 scheme: %v", err)
+	}
+
+	clusterTester := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{Name: "grpc-test", Namespace: "default"},
+	}
+	existing := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "grpc-test-coffee-shop", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{{Name: "http", Port: 8080, TargetPort: intstr.FromInt(8080), NodePort: 30080}},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterTester, existing).Build()
+	reconciler := &ClusterTesterReconciler{Client: fakeClient, Scheme: scheme}
+
+	config := clusterv1.ServiceConfig{Enabled: true, Image: "coffee-shop", Tag: "latest"}
+	status, err := reconciler.reconcileService(context.Background(), clusterTester, "coffee-shop", config)
+	if err != nil {
+		t.Fatalf("reconcileService failed: %v", err)
+	}
+	if status.GRPCEndpoint != "grpc-test-coffee-shop.default.svc.cluster.local:9090" {
+		t.Errorf("Expected the gRPC endpoint in the status, got %q", status.GRPCEndpoint)
+	}
+
+	service := &corev1.Service{}
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{Name: "grpc-test-coffee-shop", Namespace: "default"}, service); err != nil {
+		t.Fatalf("Getting service: %v", err)
+	}
+	if len(service.Spec.Ports) != 2 || service.Spec.Ports[1].Name != "grpc" {
+		t.Fatalf("Expected the grpc port to be added, got %v", service.Spec.Ports)
+	}
+	if service.Spec.Ports[0].NodePort != 30080 {
+		t.Errorf("Expected the http port to keep its node port, got %d", service.Spec.Ports[0].NodePort)
+	}
+}
+
+func TestCreateDeployment_DatabaseMigrations(t *testing.T) {
+	clusterTester := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "migration-test",
+			Namespace: "default",
+		},
+	}
+
+	reconciler := &ClusterTesterReconciler{}
+	config := clusterv1.ServiceConfig{Enabled: true, Image: "electronics-store", Tag: "v1"}
+	deployment := reconciler.createDeployment(clusterTester, "electronics-store", config, "default")
+
+	initContainers := deployment.Spec.Template.Spec.InitContainers
+	if len(initContainers) != 2 {
+		t.Fatalf("Expected 2 init containers, got %d", len(initContainers))
+	}
+	for i, want := range []string{"up", "seed"} {
+		c := initContainers[i]
+		if c.Image != "electronics-store:v1" {
+			t.Errorf("Expected init container image electronics-store:v1, got %s", c.Image)
+		}
+		if len(c.Command) != 3 || c.Command[1] != "migrate" || c.Command[2] != want {
+			t.Errorf("Expected init container %d to run 'migrate %s', got %v", i, want, c.Command)
+		}
+	}
+
+	autoMigrate := ""
+	for _, env := range deployment.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == "DB_AUTO_MIGRATE" {
+			autoMigrate = env.Value
+		}
+	}
+	if autoMigrate != "false" {
+		t.Errorf("Expected DB_AUTO_MIGRATE=false on the app container, got %q", autoMigrate)
+	}
+
+	other := reconciler.createDeployment(clusterTester, "coffee-shop", clusterv1.ServiceConfig{Image: "coffee-shop", Tag: "latest"}, "default")
+	if len(other.Spec.Template.Spec.InitContainers) != 0 {
+		t.Errorf("Expected no init containers for coffee-shop")
+	}
+}
+
+func TestCreateDeployment_Seed(t *testing.T) {
+	clusterTester := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "seed-test",
+			Namespace: "default",
+		},
+	}
+	reconciler := &ClusterTesterReconciler{}
+	seed := &clusterv1.SeedConfig{ConfigMap: "pets", Key: "pets.csv", Generate: 5000}
+
+	envOf := func(c corev1.Container) map[string]string {
+		env := make(map[string]string)
+		for _, e := range c.Env {
+			env[e.Name] = e.Value
+		}
+		return env
+	}
+
+	deployment := reconciler.createDeployment(clusterTester, "pet-store", clusterv1.ServiceConfig{Image: "pet-store", Tag: "v1", Seed: seed}, "default")
+	podSpec := deployment.Spec.Template.Spec
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].ConfigMap == nil || podSpec.Volumes[0].ConfigMap.Name != "pets" {
+		t.Fatalf("Expected a volume for ConfigMap pets, got %+v", podSpec.Volumes)
+	}
+	app := podSpec.Containers[0]
+	if len(app.VolumeMounts) != 1 || app.VolumeMounts[0].MountPath != seedMountPath {
+		t.Errorf("Expected the seed volume mounted at %s, got %+v", seedMountPath, app.VolumeMounts)
+	}
+	env := envOf(app)
+	if env["SEED_FILE"] != seedMountPath+"/pets.csv" || env["SEED_MODE"] != "replace" || env["SEED_GENERATE"] != "5000" {
+		t.Errorf("Unexpected seed environment %v", env)
+	}
+
+	// Database-backed services load fixtures in the seed init container.
+	deployment = reconciler.createDeployment(clusterTester, "electronics-store", clusterv1.ServiceConfig{Image: "electronics-store", Tag: "v1", Seed: seed}, "default")
+	podSpec = deployment.Spec.Template.Spec
+	if _, ok := envOf(podSpec.Containers[0])["SEED_FILE"]; ok {
+		t.Errorf("Expected no SEED_FILE on the electronics-store app container")
+	}
+	if env := envOf(podSpec.InitContainers[1]); env["SEED_FILE"] != seedMountPath+"/pets.csv" || env["DB_HOST"] == "" {
+		t.Errorf("Expected the seed init container to get the seed and database settings, got %v", env)
+	}
+	if _, ok := envOf(podSpec.InitContainers[0])["SEED_FILE"]; ok {
+		t.Errorf("Expected no SEED_FILE on the migrate init container")
+	}
+}
+
+func TestCreateDeployment_Logging(t *testing.T) {
+	reconciler := &ClusterTesterReconciler{}
+	config := clusterv1.ServiceConfig{Image: "coffee-shop", Tag: "latest"}
+
+	envOf := func(clusterTester *clusterv1.ClusterTester) map[string]corev1.EnvVar {
+		deployment := reconciler.createDeployment(clusterTester, "coffee-shop", config, "default")
+		env := make(map[string]corev1.EnvVar)
+		for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+			env[e.Name] = e
+		}
+		return env
+	}
+
+	env := envOf(&clusterv1.ClusterTester{ObjectMeta: metav1.ObjectMeta{Name: "log-test", Namespace: "default"}})
+	if env["LOG_LEVEL"].Value != "info" || env["LOG_FORMAT"].Value != "json" {
+		t.Errorf("Expected JSON logs at info by default, got LOG_LEVEL=%q LOG_FORMAT=%q", env["LOG_LEVEL"].Value, env["LOG_FORMAT"].Value)
+	}
+	if pod := env["POD_NAME"].ValueFrom; pod == nil || pod.FieldRef == nil || pod.FieldRef.FieldPath != "metadata.name" {
+		t.Errorf("Expected POD_NAME from the downward API, got %+v", env["POD_NAME"])
+	}
+
+	env = envOf(&clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{Name: "log-test", Namespace: "default"},
+		Spec: clusterv1.ClusterTesterSpec{
+			Global: clusterv1.GlobalConfig{LogLevel: "debug", LogFormat: "text"},
+		},
+	})
+	if env["LOG_LEVEL"].Value != "debug" || env["LOG_FORMAT"].Value != "text" {
+		t.Errorf("Expected spec.global to set LOG_LEVEL=debug LOG_FORMAT=text, got %q %q", env["LOG_LEVEL"].Value, env["LOG_FORMAT"].Value)
+	}
+}
+
+func TestReconcile_AuthSecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add ClusterTester scheme: %v", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add core v1 scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add apps v1 scheme: %v", err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add RBAC v1 scheme: %v", err)
+	}
+
+	clusterTester := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-test", Namespace: "default"},
+		Spec: clusterv1.ClusterTesterSpec{
+			CoffeeShop: clusterv1.ServiceConfig{Enabled: true, Image: "coffee-shop", Tag: "latest"},
+			Global: clusterv1.GlobalConfig{
+				Auth: &clusterv1.AuthConfig{Enabled: true, JWKSConfigMap: "idp-keys", Audience: "cluster-tester"},
+			},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(clusterTester).
+		WithStatusSubresource(clusterTester).
+		Build()
+	reconciler := &ClusterTesterReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "auth-test", Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	secret := &corev1.Secret{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "auth-test-api-keys", Namespace: "default"}, secret); err != nil {
+		t.Fatalf("Expected Secret 'auth-test-api-keys' to be created: %v", err)
+	}
+	readKey, writeKey := string(secret.Data[readKeyKey]), string(secret.Data[writeKeyKey])
+	if len(readKey) != 64 || len(writeKey) != 64 || readKey == writeKey {
+		t.Errorf("Expected two distinct generated keys, got %q and %q", readKey, writeKey)
+	}
+	want := readKey + " read reader\n" + writeKey + " read write writer\n"
+	if got := string(secret.Data[apiKeysFileKey]); got != want {
+		t.Errorf("Expected key file %q, got %q", want, got)
+	}
+	if len(secret.OwnerReferences) != 1 || secret.OwnerReferences[0].Name != "auth-test" {
+		t.Errorf("Expected the Secret to be owned by the ClusterTester, got %+v", secret.OwnerReferences)
+	}
+
+	// Reconciling again keeps the keys clients already use.
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Second reconcile failed: %v", err)
+	}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "auth-test-api-keys", Namespace: "default"}, secret); err != nil {
+		t.Fatalf("Failed to get Secret: %v", err)
+	}
+	if string(secret.Data[readKeyKey]) != readKey {
+		t.Errorf("Expected the read key to be kept across reconciles")
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "auth-test-coffee-shop", Namespace: "default"}, deployment); err != nil {
+		t.Fatalf("Expected Deployment 'auth-test-coffee-shop' to be created: %v", err)
+	}
+	podSpec := deployment.Spec.Template.Spec
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].Projected == nil || len(podSpec.Volumes[0].Projected.Sources) != 2 {
+		t.Fatalf("Expected a projected volume with the key Secret and the JWKS, got %+v", podSpec.Volumes)
+	}
+	sources := podSpec.Volumes[0].Projected.Sources
+	if sources[0].Secret == nil || sources[0].Secret.Name != "auth-test-api-keys" || sources[1].ConfigMap == nil || sources[1].ConfigMap.Name != "idp-keys" {
+		t.Errorf("Unexpected auth volume sources %+v", sources)
+	}
+	app := podSpec.Containers[0]
+	if len(app.VolumeMounts) != 1 || app.VolumeMounts[0].MountPath != authMountPath {
+		t.Errorf("Expected the auth volume mounted at %s, got %+v", authMountPath, app.VolumeMounts)
+	}
+	env := make(map[string]string)
+	for _, e := range app.Env {
+		env[e.Name] = e.Value
+	}
+	if env["AUTH_API_KEYS_FILE"] != authMountPath+"/api-keys" || env["AUTH_JWKS_FILE"] != authMountPath+"/jwks.json" || env["AUTH_JWT_AUDIENCE"] != "cluster-tester" {
+		t.Errorf("Unexpected auth environment %v", env)
+	}
+	if _, ok := env["AUTH_JWT_ISSUER"]; ok {
+		t.Errorf("Expected no AUTH_JWT_ISSUER without spec.global.auth.issuer")
+	}
+}
+
+func TestCreateDeployment_RateLimit(t *testing.T) {
+	clusterTester := &clusterv1.ClusterTester{ObjectMeta: metav1.ObjectMeta{Name: "limit-test", Namespace: "default"}}
+	reconciler := &ClusterTesterReconciler{}
+
+	envOf := func(config clusterv1.ServiceConfig) map[string]string {
+		deployment := reconciler.createDeployment(clusterTester, "restaurant", config, "default")
+		env := make(map[string]string)
+		for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+			env[e.Name] = e.Value
+		}
+		return env
+	}
+
+	env := envOf(clusterv1.ServiceConfig{Image: "restaurant", Tag: "v1"})
+	for _, name := range []string{"RATE_LIMIT_RPS", "RATE_LIMIT_BURST", "MAX_BODY_BYTES"} {
+		if _, ok := env[name]; ok {
+			t.Errorf("Expected no %s without spec.rateLimit", name)
+		}
+	}
+
+	env = envOf(clusterv1.ServiceConfig{
+		Image:     "restaurant",
+		Tag:       "v1",
+		RateLimit: &clusterv1.RateLimitConfig{RequestsPerSecond: 50, Burst: 100, MaxBodyBytes: 65536},
+	})
+	if env["RATE_LIMIT_RPS"] != "50" || env["RATE_LIMIT_BURST"] != "100" || env["MAX_BODY_BYTES"] != "65536" {
+		t.Errorf("Unexpected rate limit environment %v", env)
+	}
+
+	env = envOf(clusterv1.ServiceConfig{Image: "restaurant", Tag: "v1", RateLimit: &clusterv1.RateLimitConfig{RequestsPerSecond: 20}})
+	if _, ok := env["RATE_LIMIT_BURST"]; env["RATE_LIMIT_RPS"] != "20" || ok {
+		t.Errorf("Expected only RATE_LIMIT_RPS to be set, got %v", env)
+	}
+}
+
+func TestCreateDeployment_ServiceDependencies(t *testing.T) {
+	reconciler := &ClusterTesterReconciler{}
+	config := clusterv1.ServiceConfig{Image: "restaurant", Tag: "v1"}
+
+	envOf := func(clusterTester *clusterv1.ClusterTester, serviceName string) map[string]corev1.EnvVar {
+		deployment := reconciler.createDeployment(clusterTester, serviceName, config, "shop")
+		env := make(map[string]corev1.EnvVar)
+		for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+			env[e.Name] = e
+		}
+		return env
+	}
+
+	clusterTester := &clusterv1.ClusterTester{ObjectMeta: metav1.ObjectMeta{Name: "deps-test", Namespace: "default"}}
+	if _, ok := envOf(clusterTester, "restaurant")["COFFEE_SHOP_URL"]; ok {
+		t.Error("Expected no COFFEE_SHOP_URL while coffee-shop is disabled")
+	}
+
+	clusterTester.Spec.CoffeeShop.Enabled = true
+	env := envOf(clusterTester, "restaurant")
+	if got, want := env["COFFEE_SHOP_URL"].Value, "http://deps-test-coffee-shop.shop.svc.cluster.local:8080"; got != want {
+		t.Errorf("Expected COFFEE_SHOP_URL=%q, got %q", want, got)
+	}
+	if _, ok := env["COFFEE_SHOP_API_KEY"]; ok {
+		t.Error("Expected no COFFEE_SHOP_API_KEY without auth")
+	}
+	if _, ok := envOf(clusterTester, "pet-store")["COFFEE_SHOP_URL"]; ok {
+		t.Error("Expected COFFEE_SHOP_URL only for the services that call coffee-shop")
+	}
+
+	clusterTester.Spec.Global.Auth = &clusterv1.AuthConfig{Enabled: true}
+	key := envOf(clusterTester, "restaurant")["COFFEE_SHOP_API_KEY"].ValueFrom
+	if key == nil || key.SecretKeyRef == nil || key.SecretKeyRef.Name != "deps-test-api-keys" || key.SecretKeyRef.Key != readKeyKey {
+		t.Errorf("Expected COFFEE_SHOP_API_KEY from the read key of the API key secret, got %+v", key)
+	}
+}
+
+func TestCreateDeployment_GraphQLGateway(t *testing.T) {
+	clusterTester := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway-test", Namespace: "default"},
+		Spec: clusterv1.ClusterTesterSpec{
+			CoffeeShop:     clusterv1.ServiceConfig{Enabled: true},
+			PetStore:       clusterv1.ServiceConfig{Enabled: true},
+			GraphQLGateway: clusterv1.ServiceConfig{Enabled: true},
+		},
+	}
+	reconciler := &ClusterTesterReconciler{}
+
+	services := reconciler.getServiceConfigs(clusterTester)
+	config, ok := services["graphql-gateway"]
+	if !ok || config.Image != "graphql-gateway" || config.Tag != "latest" {
+		t.Fatalf("Expected a graphql-gateway service with default image, got %+v", config)
+	}
+
+	deployment := reconciler.createDeployment(clusterTester, "graphql-gateway", config, "shop")
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if len(container.Ports) != 1 || container.Ports[0].Name != "http" {
+		t.Errorf("Expected only the http container port, got %v", container.Ports)
+	}
+	if container.LivenessProbe.HTTPGet == nil || container.LivenessProbe.HTTPGet.Path != "/livez" {
+		t.Errorf("Expected an HTTP liveness probe on /livez, got %+v", container.LivenessProbe.ProbeHandler)
+	}
+	if container.ReadinessProbe.HTTPGet == nil || container.ReadinessProbe.HTTPGet.Path != "/readyz" {
+		t.Errorf("Expected an HTTP readiness probe on /readyz, got %+v", container.ReadinessProbe.ProbeHandler)
+	}
+
+	env := make(map[string]string)
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	if got, want := env["COFFEE_SHOP_URL"], "http://gateway-test-coffee-shop.shop.svc.cluster.local:8080"; got != want {
+		t.Errorf("Expected COFFEE_SHOP_URL=%q, got %q", want, got)
+	}
+	if got, want := env["PET_STORE_URL"], "http://gateway-test-pet-store.shop.svc.cluster.local:8080"; got != want {
+		t.Errorf("Expected PET_STORE_URL=%q, got %q", want, got)
+	}
+	for _, name := range []string{"RESTAURANT_URL", "COLLEGE_ADMISSION_URL", "ELECTRONICS_STORE_URL"} {
+		if _, ok := env[name]; ok {
+			t.Errorf("Expected no %s while the service is disabled", name)
+		}
+	}
+
+	// The gateway forwards its callers' credentials and has no key of its
+	// own, even with auth enabled.
+	clusterTester.Spec.Global.Auth = &clusterv1.AuthConfig{Enabled: true}
+	for _, e := range reconciler.createDeployment(clusterTester, "graphql-gateway", config, "shop").Spec.Template.Spec.Containers[0].Env {
+		if strings.HasSuffix(e.Name, "_API_KEY") {
+			t.Errorf("Expected no %s on the gateway", e.Name)
+		}
+	}
+
+	service := reconciler.createService(clusterTester, "graphql-gateway", "shop")
+	if len(service.Spec.Ports) != 1 || service.Spec.Ports[0].Name != "http" {
+		t.Errorf("Expected only the http service port, got %v", service.Spec.Ports)
+	}
+}
+
+func TestReconcile_ServiceAccounts(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clusterv1.AddToScheme, corev1.AddToScheme, appsv1.AddToScheme, rbacv1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatalf("Failed to add schemes: %v", err)
+		}
+	}
+
+	clusterTester := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{Name: "rbac-test", Namespace: "default"},
+		Spec: clusterv1.ClusterTesterSpec{
+			CoffeeShop:       clusterv1.ServiceConfig{Enabled: true, Image: "coffee-shop", Tag: "latest"},
+			PetStore:         clusterv1.ServiceConfig{Enabled: true, Image: "pet-store", Tag: "latest"},
+			ElectronicsStore: clusterv1.ServiceConfig{Enabled: true, Image: "electronics-store", Tag: "latest"},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(clusterTester).
+		WithStatusSubresource(clusterTester).
+		Build()
+	reconciler := &ClusterTesterReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "rbac-test", Namespace: "default"}}
+
+	for i := 0; i < 2; i++ {
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile %d failed: %v", i+1, err)
+		}
+	}
+
+	for _, serviceName := range []string{"rbac-test-coffee-shop", "rbac-test-pet-store", "rbac-test-electronics-store"} {
+		account := &corev1.ServiceAccount{}
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: "default"}, account); err != nil {
+			t.Fatalf("Expected ServiceAccount '%s' to be created: %v", serviceName, err)
+		}
+		if len(account.OwnerReferences) != 1 || account.OwnerReferences[0].Name != "rbac-test" {
+			t.Errorf("Expected ServiceAccount '%s' to be owned by the ClusterTester, got %+v", serviceName, account.OwnerReferences)
+		}
+		deployment := &appsv1.Deployment{}
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: "default"}, deployment); err != nil {
+			t.Fatalf("Expected Deployment '%s' to be created: %v", serviceName, err)
+		}
+		if got := deployment.Spec.Template.Spec.ServiceAccountName; got != serviceName {
+			t.Errorf("Expected Deployment '%s' to run as ServiceAccount '%s', got %q", serviceName, serviceName, got)
+		}
+	}
+
+	// Only the leader-elected services get API access. Pet-store keeps its
+	// pets in each pod, so every replica sweeps its own reservations.
+	for _, serviceName := range []string{"coffee-shop", "pet-store"} {
+		account := &corev1.ServiceAccount{}
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: "rbac-test-" + serviceName, Namespace: "default"}, account); err != nil {
+			t.Fatalf("Failed to get ServiceAccount: %v", err)
+		}
+		if account.AutomountServiceAccountToken == nil || *account.AutomountServiceAccountToken {
+			t.Errorf("Expected no token to be mounted for %s", serviceName)
+		}
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: "rbac-test-" + serviceName + "-leader-election", Namespace: "default"}, &rbacv1.Role{}); !errors.IsNotFound(err) {
+			t.Errorf("Expected no leader election Role for %s, got %v", serviceName, err)
+		}
+	}
+
+	role := &rbacv1.Role{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "rbac-test-electronics-store-leader-election", Namespace: "default"}, role); err != nil {
+		t.Fatalf("Expected Role 'rbac-test-electronics-store-leader-election' to be created: %v", err)
+	}
+	var verbs []string
+	for _, rule := range role.Rules {
+		if len(rule.APIGroups) != 1 || rule.APIGroups[0] != "coordination.k8s.io" || len(rule.Resources) != 1 || rule.Resources[0] != "leases" {
+			t.Errorf("Expected the Role to cover leases only, got %+v", rule)
+		}
+		if len(rule.ResourceNames) > 0 && (len(rule.ResourceNames) != 1 || rule.ResourceNames[0] != "rbac-test-mysql-migrations") {
+			t.Errorf("Expected the Role to be limited to the migrations Lease, got %v", rule.ResourceNames)
+		}
+		verbs = append(verbs, rule.Verbs...)
+	}
+	if strings.Join(verbs, ",") != "create,get,update" {
+		t.Errorf("Expected the Role to allow create, get and update, got %v", verbs)
+	}
+
+	binding := &rbacv1.RoleBinding{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "rbac-test-electronics-store-leader-election", Namespace: "default"}, binding); err != nil {
+		t.Fatalf("Expected RoleBinding 'rbac-test-electronics-store-leader-election' to be created: %v", err)
+	}
+	if binding.RoleRef.Name != role.Name || len(binding.Subjects) != 1 || binding.Subjects[0].Kind != rbacv1.ServiceAccountKind || binding.Subjects[0].Name != "rbac-test-electronics-store" {
+		t.Errorf("Expected the RoleBinding to bind the Role to the electronics-store ServiceAccount, got %+v", binding)
+	}
+}
+
+func TestCreateDeployment_LeaderElection(t *testing.T) {
+	clusterTester := &clusterv1.ClusterTester{ObjectMeta: metav1.ObjectMeta{Name: "leader-test", Namespace: "default"}}
+	reconciler := &ClusterTesterReconciler{}
+
+	envOf := func(c corev1.Container) map[string]corev1.EnvVar {
+		env := make(map[string]corev1.EnvVar)
+		for _, e := range c.Env {
+			env[e.Name] = e
+		}
+		return env
+	}
+
+	// Both electronics stores migrate the same database, so they share a Lease.
+	var containers []corev1.Container
+	for _, serviceName := range []string{"electronics-store", "electronics-store-tracing"} {
+		podSpec := reconciler.createDeployment(clusterTester, serviceName, clusterv1.ServiceConfig{Image: serviceName, Tag: "v1"}, "default").Spec.Template.Spec
+		containers = append(append(containers, podSpec.Containers[0]), podSpec.InitContainers...)
+	}
+	for _, c := range containers {
+		env := envOf(c)
+		if env["LEADER_ELECTION_LEASE"].Value != "leader-test-mysql-migrations" {
+			t.Errorf("Expected LEADER_ELECTION_LEASE=leader-test-mysql-migrations on container %s, got %q", c.Name, env["LEADER_ELECTION_LEASE"].Value)
+		}
+		for _, name := range []string{"POD_NAME", "POD_NAMESPACE"} {
+			if env[name].ValueFrom == nil || env[name].ValueFrom.FieldRef == nil {
+				t.Errorf("Expected %s from the downward API on container %s", name, c.Name)
+			}
+		}
+		count := 0
+		for _, e := range c.Env {
+			if e.Name == "POD_NAME" {
+				count++
+			}
+		}
+		if count != 1 {
+			t.Errorf("Expected POD_NAME once on container %s, got %d", c.Name, count)
+		}
+	}
+
+	deployment := reconciler.createDeployment(clusterTester, "coffee-shop", clusterv1.ServiceConfig{Image: "coffee-shop", Tag: "v1"}, "default")
+	if _, ok := envOf(deployment.Spec.Template.Spec.Containers[0])["LEADER_ELECTION_LEASE"]; ok {
+		t.Errorf("Expected no LEADER_ELECTION_LEASE for coffee-shop, which runs no singleton jobs")
+	}
+}
+
+func TestReconcile_Messaging(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add ClusterTester scheme: %v", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add core v1 scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add apps v1 scheme: %v", err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add RBAC v1 scheme: %v", err)
+	}
+
+	clusterTester := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{Name: "messaging-test", Namespace: "default"},
+		Spec: clusterv1.ClusterTesterSpec{
+			CoffeeShop:     clusterv1.ServiceConfig{Enabled: true},
+			Restaurant:     clusterv1.ServiceConfig{Enabled: true},
+			GraphQLGateway: clusterv1.ServiceConfig{Enabled: true},
+			Messaging:      clusterv1.MessagingConfig{Enabled: true},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(clusterTester).
+		WithStatusSubresource(clusterTester).
+		Build()
+	reconciler := &ClusterTesterReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "messaging-test", Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	broker := &appsv1.Deployment{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "messaging-test-nats", Namespace: "default"}, broker); err != nil {
+		t.Fatalf("Expected Deployment 'messaging-test-nats' to be created: %v", err)
+	}
+	if got := broker.Spec.Template.Spec.Containers[0].Image; got != "nats:2.10-alpine" {
+		t.Errorf("Expected the default broker image nats:2.10-alpine, got %q", got)
+	}
+	if len(broker.OwnerReferences) != 1 || broker.OwnerReferences[0].Name != "messaging-test" {
+		t.Errorf("Expected the broker to be owned by the ClusterTester, got %+v", broker.OwnerReferences)
+	}
+	service := &corev1.Service{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "messaging-test-nats", Namespace: "default"}, service); err != nil {
+		t.Fatalf("Expected Service 'messaging-test-nats' to be created: %v", err)
+	}
+	if len(service.Spec.Ports) != 1 || service.Spec.Ports[0].Port != 4222 {
+		t.Errorf("Expected the client port 4222, got %v", service.Spec.Ports)
+	}
+
+	natsURLOf := func(name string) (string, bool) {
+		deployment := &appsv1.Deployment{}
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, deployment); err != nil {
+			t.Fatalf("Expected Deployment %q to be created: %v", name, err)
+		}
+		for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+			if e.Name == "NATS_URL" {
+				return e.Value, true
+			}
+		}
+		return "", false
+	}
+	for _, name := range []string{"messaging-test-coffee-shop", "messaging-test-restaurant"} {
+		if got, _ := natsURLOf(name); got != "nats://messaging-test-nats.default.svc.cluster.local:4222" {
+			t.Errorf("Expected %s to get the broker's NATS_URL, got %q", name, got)
+		}
+	}
+	if _, ok := natsURLOf("messaging-test-graphql-gateway"); ok {
+		t.Error("Expected no NATS_URL for the gateway, which publishes no events")
+	}
+
+	// Without spec.messaging the services publish to no broker.
+	clusterTester.Spec.Messaging.Enabled = false
+	for _, e := range reconciler.createDeployment(clusterTester, "coffee-shop", clusterv1.ServiceConfig{}, "default").Spec.Template.Spec.Containers[0].Env {
+		if e.Name == "NATS_URL" {
+			t.Errorf("Expected no NATS_URL without spec.messaging, got %q", e.Value)
+		}
+	}
+}
+
+func TestReconcile_Cache(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add ClusterTester scheme: %v", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add core v1 scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add apps v1 scheme: %v", err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add RBAC v1 scheme: %v", err)
+	}
+
+	clusterTester := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{Name: "cache-test", Namespace: "default"},
+		Spec: clusterv1.ClusterTesterSpec{
+			CoffeeShop:              clusterv1.ServiceConfig{Enabled: true},
+			ElectronicsStore:        clusterv1.ServiceConfig{Enabled: true},
+			ElectronicsStoreTracing: clusterv1.ServiceConfig{Enabled: true},
+			Cache:                   clusterv1.CacheConfig{Enabled: true, TTLSeconds: 5},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(clusterTester).
+		WithStatusSubresource(clusterTester).
+		Build()
+	reconciler := &ClusterTesterReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cache-test", Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	redis := &appsv1.Deployment{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "cache-test-redis", Namespace: "default"}, redis); err != nil {
+		t.Fatalf("Expected Deployment 'cache-test-redis' to be created: %v", err)
+	}
+	if got := redis.Spec.Template.Spec.Containers[0].Image; got != "redis:7-alpine" {
+		t.Errorf("Expected the default cache image redis:7-alpine, got %q", got)
+	}
+	if len(redis.OwnerReferences) != 1 || redis.OwnerReferences[0].Name != "cache-test" {
+		t.Errorf("Expected the cache to be owned by the ClusterTester, got %+v", redis.OwnerReferences)
+	}
+	service := &corev1.Service{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "cache-test-redis", Namespace: "default"}, service); err != nil {
+		t.Fatalf("Expected Service 'cache-test-redis' to be created: %v", err)
+	}
+	if len(service.Spec.Ports) != 1 || service.Spec.Ports[0].Port != 6379 {
+		t.Errorf("Expected the port 6379, got %v", service.Spec.Ports)
+	}
+
+	envOf := func(name string) map[string]string {
+		deployment := &appsv1.Deployment{}
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, deployment); err != nil {
+			t.Fatalf("Expected Deployment %q to be created: %v", name, err)
+		}
+		env := map[string]string{}
+		for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+			env[e.Name] = e.Value
+		}
+		return env
+	}
+	for _, name := range []string{"cache-test-electronics-store", "cache-test-electronics-store-tracing"} {
+		env := envOf(name)
+		if got := env["REDIS_ADDR"]; got != "cache-test-redis.default.svc.cluster.local:6379" {
+			t.Errorf("Expected %s to get the cache's REDIS_ADDR, got %q", name, got)
+		}
+		if got := env["CACHE_TTL_SECONDS"]; got != "5" {
+			t.Errorf("Expected %s to cache reads for 5 seconds, got %q", name, got)
+		}
+	}
+	if got, ok := envOf("cache-test-coffee-shop")["REDIS_ADDR"]; ok {
+		t.Errorf("Expected no REDIS_ADDR for the coffee-shop, which caches nothing, got %q", got)
+	}
+}
+
+func TestReconcile_CrossNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add ClusterTester scheme: %v", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add core v1 scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add apps v1 scheme: %v", err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add RBAC v1 scheme: %v", err)
+	}
+
+	clusterTester := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{Name: "cross-test", Namespace: "default"},
+		Spec: clusterv1.ClusterTesterSpec{
+			CoffeeShop:       clusterv1.ServiceConfig{Enabled: true},
+			ElectronicsStore: clusterv1.ServiceConfig{Enabled: true},
+			Database:         clusterv1.DatabaseConfig{Enabled: true},
+			Global:           clusterv1.GlobalConfig{Namespace: "testers", CreateNamespace: true},
+		},
+	}
+	// An object the ClusterTester did not create, which must survive it
+	unowned := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unowned", Namespace: "testers"}}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(clusterTester, unowned).
+		WithStatusSubresource(clusterTester).
+		Build()
+	reconciler := &ClusterTesterReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cross-test", Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "testers"}, namespace); err != nil {
+		t.Fatalf("Expected Namespace 'testers' to be created: %v", err)
+	}
+	deployment := &appsv1.Deployment{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "cross-test-coffee-shop", Namespace: "testers"}, deployment); err != nil {
+		t.Fatalf("Expected Deployment 'cross-test-coffee-shop' in the target namespace: %v", err)
+	}
+	if len(deployment.OwnerReferences) != 0 {
+		t.Errorf("Expected no owner reference across namespaces, got %+v", deployment.OwnerReferences)
+	}
+	if deployment.Labels[ownerNameLabel] != "cross-test" || deployment.Labels[ownerNamespaceLabel] != "default" {
+		t.Errorf("Expected the owner labels on the deployment, got %v", deployment.Labels)
+	}
+	if _, ok := deployment.Spec.Selector.MatchLabels[ownerNameLabel]; ok {
+		t.Errorf("Expected the owner labels to stay out of the selector, got %v", deployment.Spec.Selector.MatchLabels)
+	}
+	requests := requestForOwner(ctx, deployment)
+	if len(requests) != 1 || requests[0].NamespacedName != req.NamespacedName {
+		t.Errorf("Expected the deployment's events to reconcile the ClusterTester, got %v", requests)
+	}
+
+	if err := fakeClient.Get(ctx, req.NamespacedName, clusterTester); err != nil {
+		t.Fatalf("Failed to get ClusterTester: %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(clusterTester, cleanupFinalizer) {
+		t.Fatalf("Expected the %s finalizer, got %v", cleanupFinalizer, clusterTester.Finalizers)
+	}
+
+	// Deleting the ClusterTester deletes what it created in the target
+	// namespace, and the namespace itself.
+	if err := fakeClient.Delete(ctx, clusterTester); err != nil {
+		t.Fatalf("Failed to delete ClusterTester: %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile of the deletion failed: %v", err)
+	}
+
+	for _, list := range ownedLists() {
+		if err := fakeClient.List(ctx, list, client.MatchingLabels(ownerLabels(clusterTester))); err != nil {
+			t.Fatalf("Failed to list %T: %v", list, err)
+		}
+		if n := meta.LenList(list); n != 0 {
+			t.Errorf("Expected the owned %T to be deleted, %d left", list, n)
+		}
+	}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "testers"}, namespace); !errors.IsNotFound(err) {
+		t.Errorf("Expected the created namespace to be deleted, got %v", err)
+	}
+	if err := fakeClient.Get(ctx, client.ObjectKeyFromObject(unowned), &corev1.Secret{}); err != nil {
+		t.Errorf("Expected the unowned secret to be kept: %v", err)
+	}
+	if err := fakeClient.Get(ctx, req.NamespacedName, &clusterv1.ClusterTester{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected the ClusterTester to be gone once cleaned up, got %v", err)
+	}
+}
+
+func TestReconcile_UnwatchedNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add ClusterTester scheme: %v", err)
+	}
+
+	clusterTester := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{Name: "unwatched-test", Namespace: "default"},
+		Spec: clusterv1.ClusterTesterSpec{
+			CoffeeShop: clusterv1.ServiceConfig{Enabled: true},
+			Global:     clusterv1.GlobalConfig{Namespace: "elsewhere"},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(clusterTester).
+		WithStatusSubresource(clusterTester).
+		Build()
+	reconciler := &ClusterTesterReconciler{Client: fakeClient, Scheme: scheme, WatchNamespaces: []string{"default"}}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "unwatched-test", Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err == nil {
+		t.Fatal("Expected Reconcile to fail for a namespace the manager does not watch")
+	}
+	if err := fakeClient.Get(ctx, req.NamespacedName, clusterTester); err != nil {
+		t.Fatalf("Failed to get ClusterTester: %v", err)
+	}
+	condition := meta.FindStatusCondition(clusterTester.Status.Conditions, "Ready")
+	if clusterTester.Status.Phase != "Failed" || condition == nil || condition.Reason != "NamespaceNotWatched" {
+		t.Errorf("Expected phase Failed with reason NamespaceNotWatched, got %q and %+v", clusterTester.Status.Phase, condition)
+	}
+}
+
+func TestReconcile_SideBySide(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add ClusterTester scheme: %v", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add core v1 scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add apps v1 scheme: %v", err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add RBAC v1 scheme: %v", err)
+	}
+
+	spec := clusterv1.ClusterTesterSpec{
+		ElectronicsStore: clusterv1.ServiceConfig{Enabled: true},
+		Database:         clusterv1.DatabaseConfig{Enabled: true},
+	}
+	first := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{Name: "first", Namespace: "default"},
+		Spec:       spec,
+	}
+	second := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{Name: "second", Namespace: "default"},
+		Spec:       *spec.DeepCopy(),
+	}
+	second.Spec.Global.NamePrefix = "qa"
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(first, second).
+		WithStatusSubresource(first, second).
+		Build()
+	reconciler := &ClusterTesterReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	for _, name := range []string{"first", "second"} {
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}}
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile of %s failed: %v", name, err)
+		}
+	}
+
+	for prefix, owner := range map[string]string{"first": "first", "qa": "second"} {
+		deployment := &appsv1.Deployment{}
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: prefix + "-electronics-store", Namespace: "default"}, deployment); err != nil {
+			t.Fatalf("Expected Deployment '%s-electronics-store' to be created: %v", prefix, err)
+		}
+		if deployment.Labels[ownerNameLabel] != owner {
+			t.Errorf("Expected Deployment '%s-electronics-store' to belong to %s, got %v", prefix, owner, deployment.Labels)
+		}
+		var dbHost string
+		for _, env := range deployment.Spec.Template.Spec.Containers[0].Env {
+			if env.Name == "DB_HOST" {
+				dbHost = env.Value
+			}
+		}
+		if dbHost != prefix+"-mysql" {
+			t.Errorf("Expected DB_HOST=%s-mysql on '%s-electronics-store', got %q", prefix, prefix, dbHost)
+		}
+		for _, obj := range []client.Object{&appsv1.Deployment{}, &corev1.Service{}} {
+			if err := fakeClient.Get(ctx, types.NamespacedName{Name: prefix + "-mysql", Namespace: "default"}, obj); err != nil {
+				t.Errorf("Expected %T '%s-mysql' to be created: %v", obj, prefix, err)
+			}
+		}
+		claim := &corev1.PersistentVolumeClaim{}
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: prefix + "-mysql-pvc", Namespace: "default"}, claim); err != nil {
+			t.Errorf("Expected PersistentVolumeClaim '%s-mysql-pvc' to be created: %v", prefix, err)
+		}
+	}
+}
+
+func TestReconcile_SameNameFromOtherNamespaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add ClusterTester scheme: %v", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add core v1 scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add apps v1 scheme: %v", err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add RBAC v1 scheme: %v", err)
+	}
+
+	// Two teams deploy a ClusterTester named "shop" into one namespace,
+	// with prefixes that keep the object names apart.
+	var objects []client.Object
+	for _, team := range []string{"team-a", "team-b"} {
+		objects = append(objects, &clusterv1.ClusterTester{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: team},
+			Spec: clusterv1.ClusterTesterSpec{
+				CoffeeShop: clusterv1.ServiceConfig{Enabled: true},
+				Database:   clusterv1.DatabaseConfig{Enabled: true},
+				Global:     clusterv1.GlobalConfig{Namespace: "shared", NamePrefix: team},
+			},
+		})
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(append(objects, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shared"}})...).
+		WithStatusSubresource(objects...).
+		Build()
+	reconciler := &ClusterTesterReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	for _, team := range []string{"team-a", "team-b"} {
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "shop", Namespace: team}}
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile of %s/shop failed: %v", team, err)
+		}
+	}
+
+	selects := func(selector, labels map[string]string) bool {
+		for key, value := range selector {
+			if labels[key] != value {
+				return false
+			}
+		}
+		return true
+	}
+	for _, base := range []string{"coffee-shop", "mysql"} {
+		names := map[string]string{"team-a": "team-a-" + base, "team-b": "team-b-" + base}
+		pods := map[string]map[string]string{}
+		for team, name := range names {
+			deployment := &appsv1.Deployment{}
+			if err := fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "shared"}, deployment); err != nil {
+				t.Fatalf("Expected Deployment '%s' to be created: %v", name, err)
+			}
+			if !selects(deployment.Spec.Selector.MatchLabels, deployment.Spec.Template.Labels) {
+				t.Errorf("Expected Deployment '%s' to select its own pods, got %v", name, deployment.Spec.Selector.MatchLabels)
+			}
+			pods[team] = deployment.Spec.Template.Labels
+		}
+		for team, other := range map[string]string{"team-a": "team-b", "team-b": "team-a"} {
+			deployment := &appsv1.Deployment{}
+			if err := fakeClient.Get(ctx, types.NamespacedName{Name: names[team], Namespace: "shared"}, deployment); err != nil {
+				t.Fatalf("Failed to get Deployment '%s': %v", names[team], err)
+			}
+			if selects(deployment.Spec.Selector.MatchLabels, pods[other]) {
+				t.Errorf("Expected Deployment '%s' not to select the pods of %s/shop", names[team], other)
+			}
+			service := &corev1.Service{}
+			if err := fakeClient.Get(ctx, types.NamespacedName{Name: names[team], Namespace: "shared"}, service); err != nil {
+				t.Fatalf("Expected Service '%s' to be created: %v", names[team], err)
+			}
+			if !selects(service.Spec.Selector, pods[team]) || selects(service.Spec.Selector, pods[other]) {
+				t.Errorf("Expected Service '%s' to select the pods of %s/shop only, got %v", names[team], team, service.Spec.Selector)
+			}
+		}
+	}
+
+	// Deployments from before the owner's namespace was selected on keep
+	// their selectors, which cannot change.
+	key := types.NamespacedName{Name: "team-a-coffee-shop", Namespace: "shared"}
+	deployment := &appsv1.Deployment{}
+	if err := fakeClient.Get(ctx, key, deployment); err != nil {
+		t.Fatalf("Failed to get Deployment 'team-a-coffee-shop': %v", err)
+	}
+	delete(deployment.Spec.Selector.MatchLabels, ownerNamespaceLabel)
+	if err := fakeClient.Update(ctx, deployment); err != nil {
+		t.Fatalf("Failed to update Deployment 'team-a-coffee-shop': %v", err)
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "shop", Namespace: "team-a"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile of team-a/shop failed: %v", err)
+	}
+	if err := fakeClient.Get(ctx, key, deployment); err != nil {
+		t.Fatalf("Failed to get Deployment 'team-a-coffee-shop': %v", err)
+	}
+	if _, ok := deployment.Spec.Selector.MatchLabels[ownerNamespaceLabel]; ok {
+		t.Errorf("Expected the existing selector to be kept, got %v", deployment.Spec.Selector.MatchLabels)
+	}
+}
+
+func TestReconcile_NameConflict(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add ClusterTester scheme: %v", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add core v1 scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add apps v1 scheme: %v", err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add RBAC v1 scheme: %v", err)
+	}
+
+	// Both ClusterTesters want the prefix shared
+	var clusterTesters []client.Object
+	for _, name := range []string{"owner", "intruder"} {
+		clusterTesters = append(clusterTesters, &clusterv1.ClusterTester{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: clusterv1.ClusterTesterSpec{
+				CoffeeShop: clusterv1.ServiceConfig{Enabled: true},
+				Global:     clusterv1.GlobalConfig{NamePrefix: "shared"},
+			},
+		})
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(clusterTesters...).
+		WithStatusSubresource(clusterTesters...).
+		Build()
+	reconciler := &ClusterTesterReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "owner", Namespace: "default"}}); err != nil {
+		t.Fatalf("Reconcile of the first ClusterTester failed: %v", err)
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "intruder", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err == nil {
+		t.Fatal("Expected Reconcile to fail for names another ClusterTester owns")
+	}
+
+	intruder := &clusterv1.ClusterTester{}
+	if err := fakeClient.Get(ctx, req.NamespacedName, intruder); err != nil {
+		t.Fatalf("Failed to get ClusterTester: %v", err)
+	}
+	condition := meta.FindStatusCondition(intruder.Status.Conditions, "Ready")
+	if intruder.Status.Phase != "Failed" || condition == nil || condition.Reason != "NameConflict" {
+		t.Errorf("Expected phase Failed with reason NameConflict, got %q and %+v", intruder.Status.Phase, condition)
+	}
+	deployment := &appsv1.Deployment{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "shared-coffee-shop", Namespace: "default"}, deployment); err != nil {
+		t.Fatalf("Failed to get Deployment: %v", err)
+	}
+	if deployment.Labels[ownerNameLabel] != "owner" {
+		t.Errorf("Expected the deployment to stay with its owner, got %v", deployment.Labels)
+	}
+}
+
+func TestReconcile_InvalidName(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add ClusterTester scheme: %v", err)
+	}
+
+	// ClusterTester names may have dots, which Service names may not
+	clusterTester := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{Name: "team.qa", Namespace: "default"},
+		Spec: clusterv1.ClusterTesterSpec{
+			CoffeeShop: clusterv1.ServiceConfig{Enabled: true},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(clusterTester).
+		WithStatusSubresource(clusterTester).
+		Build()
+	reconciler := &ClusterTesterReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "team.qa", Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err == nil {
+		t.Fatal("Expected Reconcile to fail for a name that does not fit in a Service name")
+	}
+	if err := fakeClient.Get(ctx, req.NamespacedName, clusterTester); err != nil {
+		t.Fatalf("Failed to get ClusterTester: %v", err)
+	}
+	condition := meta.FindStatusCondition(clusterTester.Status.Conditions, "Ready")
+	if clusterTester.Status.Phase != "Failed" || condition == nil || condition.Reason != "InvalidName" {
+		t.Errorf("Expected phase Failed with reason InvalidName, got %q and %+v", clusterTester.Status.Phase, condition)
+	}
+}
+
+func TestReconcile_LegacyNames(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add ClusterTester scheme: %v", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add core v1 scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add apps v1 scheme: %v", err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add RBAC v1 scheme: %v", err)
+	}
+
+	clusterTester := &clusterv1.ClusterTester{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default", UID: "test-cluster-uid"},
+		Spec: clusterv1.ClusterTesterSpec{
+			CoffeeShop: clusterv1.ServiceConfig{Enabled: true},
+			Database:   clusterv1.DatabaseConfig{Enabled: true},
+		},
+	}
+	// Objects an earlier version of the operator created under the bare
+	// service names, controlled by the ClusterTester or carrying its owner
+	// labels, next to ones with those names that belong elsewhere
+	controller := true
+	controlledBy := func(name, uid string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "ClusterTester",
+			Name:       name,
+			UID:        types.UID(uid),
+			Controller: &controller,
+		}}
+	}
+	legacy := []client.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "coffee-shop", Namespace: "default", OwnerReferences: controlledBy("test-cluster", "test-cluster-uid")}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "coffee-shop", Namespace: "default", Labels: ownerLabels(clusterTester)}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "coffee-shop", Namespace: "default", OwnerReferences: controlledBy("test-cluster", "test-cluster-uid")}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "default", OwnerReferences: controlledBy("test-cluster", "test-cluster-uid")}},
+	}
+	claim := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "mysql-pvc", Namespace: "default", OwnerReferences: controlledBy("test-cluster", "test-cluster-uid")}}
+	unrelated := []client.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "pet-store", Namespace: "default"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "default", OwnerReferences: controlledBy("test-cluster", "recreated-uid")}},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(append(append([]client.Object{clusterTester, claim}, legacy...), unrelated...)...).
+		WithStatusSubresource(clusterTester).
+		Build()
+	reconciler := &ClusterTesterReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-cluster", Namespace: "default"}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	for _, obj := range legacy {
+		if err := fakeClient.Get(ctx, client.ObjectKeyFromObject(obj), obj); !errors.IsNotFound(err) {
+			t.Errorf("Expected %T %s to be deleted, got %v", obj, obj.GetName(), err)
+		}
+	}
+	for _, obj := range unrelated {
+		if err := fakeClient.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			t.Errorf("Expected %T %s to be kept, got %v", obj, obj.GetName(), err)
+		}
+	}
+
+	// The database keeps its claim, and with it its data.
+	if err := fakeClient.Get(ctx, client.ObjectKeyFromObject(claim), claim); err != nil {
+		t.Errorf("Expected the legacy claim to be kept, got %v", err)
+	}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "test-cluster-mysql-pvc", Namespace: "default"}, &corev1.PersistentVolumeClaim{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected no second claim for the database, got %v", err)
+	}
+	mysql := &appsv1.Deployment{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Name: "test-cluster-mysql", Namespace: "default"}, mysql); err != nil {
+		t.Fatalf("Failed to get Deployment: %v", err)
+	}
+	if volumes := mysql.Spec.Template.Spec.Volumes; len(volumes) != 1 || volumes[0].PersistentVolumeClaim == nil || volumes[0].PersistentVolumeClaim.ClaimName != "mysql-pvc" {
+		t.Errorf("Expected the database to mount the legacy claim, got %+v", volumes)
+	}
+
+	for _, obj := range []client.Object{&appsv1.Deployment{}, &corev1.Service{}} {
+		for _, name := range []string{"test-cluster-coffee-shop", "test-cluster-mysql"} {
+			if err := fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, obj); err != nil {
+				t.Errorf("Expected %T %s to replace the legacy object, got %v", obj, name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,364 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	clusterv1 "github.com/cdcent/cluster-tester/cluster-operator/api/v1"
+)
+
+// Owner references cannot cross namespaces, so every object the operator
+// creates also names its ClusterTester in these labels. They route the
+// object's events to the ClusterTester and find the objects to delete with
+// it when they are in another namespace, where garbage collection does not
+// reach.
+const (
+	ownerNameLabel      = "cluster.cdcent.io/owner-name"
+	ownerNamespaceLabel = "cluster.cdcent.io/owner-namespace"
+)
+
+// cleanupFinalizer keeps a ClusterTester that deploys to another namespace
+// until the operator has deleted the objects it created there.
+const cleanupFinalizer = "cluster.cdcent.io/cleanup"
+
+// errNameConflict is wrapped by the errors about objects that another
+// ClusterTester owns under the name this one needs.
+var errNameConflict = errors.New("name conflict")
+
+// isNameConflict reports whether err is about an object of another
+// ClusterTester, which the ClusterTester then fails with the NameConflict
+// reason.
+func isNameConflict(err error) bool {
+	return errors.Is(err, errNameConflict)
+}
+
+// resourceName returns the name of the object of clusterTester for base,
+// such as coffee-shop or mysql: base prefixed with spec.global.namePrefix,
+// or the ClusterTester's name, so that several ClusterTesters can deploy to
+// one namespace.
+func resourceName(clusterTester *clusterv1.ClusterTester, base string) string {
+	prefix := clusterTester.Spec.Global.NamePrefix
+	if prefix == "" {
+		prefix = clusterTester.Name
+	}
+	return prefix + "-" + base
+}
+
+// checkNames reports whether the Services clusterTester needs can have the
+// names resourceName gives them. Service names are DNS labels, which a long
+// ClusterTester name or one with dots does not fit in.
+func checkNames(clusterTester *clusterv1.ClusterTester, services map[string]clusterv1.ServiceConfig) error {
+	var bases []string
+	for serviceName, config := range services {
+		if config.Enabled {
+			bases = append(bases, serviceName)
+		}
+	}
+	if clusterTester.Spec.Database.Enabled {
+		bases = append(bases, "mysql")
+	}
+	if clusterTester.Spec.Messaging.Enabled {
+		bases = append(bases, "nats")
+	}
+	if clusterTester.Spec.Cache.Enabled {
+		bases = append(bases, "redis")
+	}
+	slices.Sort(bases)
+	for _, base := range bases {
+		name := resourceName(clusterTester, base)
+		if errs := validation.IsDNS1035Label(name); len(errs) > 0 {
+			return fmt.Errorf("service name %q is invalid, set spec.global.namePrefix to a shorter prefix: %s", name, errs[0])
+		}
+	}
+	return nil
+}
+
+// checkOwner returns an error wrapping errNameConflict when found, an
+// existing object with the name of one of clusterTester's, belongs to
+// another ClusterTester or is controlled by another object. Objects without
+// an owner are taken over.
+func checkOwner(clusterTester *clusterv1.ClusterTester, found client.Object) error {
+	labels := found.GetLabels()
+	if name, ok := labels[ownerNameLabel]; ok && (name != clusterTester.Name || labels[ownerNamespaceLabel] != clusterTester.Namespace) {
+		return fmt.Errorf("%w: %T %s/%s belongs to ClusterTester %s/%s", errNameConflict, found, found.GetNamespace(), found.GetName(), labels[ownerNamespaceLabel], name)
+	}
+	if ref := metav1.GetControllerOf(found); ref != nil && (ref.Kind != "ClusterTester" || ref.Name != clusterTester.Name) {
+		return fmt.Errorf("%w: %T %s/%s is controlled by %s %s", errNameConflict, found, found.GetNamespace(), found.GetName(), ref.Kind, ref.Name)
+	}
+	return nil
+}
+
+// createIfNotOwned creates obj unless an object of its kind and name exists,
+// which must then not belong to another ClusterTester.
+func (r *ClusterTesterReconciler) createIfNotOwned(ctx context.Context, clusterTester *clusterv1.ClusterTester, obj client.Object) error {
+	found := obj.DeepCopyObject().(client.Object)
+	err := r.Get(ctx, client.ObjectKeyFromObject(obj), found)
+	if err == nil {
+		return checkOwner(clusterTester, found)
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+	log.FromContext(ctx).Info("Creating object", "type", fmt.Sprintf("%T", obj), "name", obj.GetName())
+	return r.Create(ctx, obj)
+}
+
+// ownedBy reports whether obj belongs to clusterTester, by its owner labels
+// or by its controller reference, which is all that objects created before
+// the owner labels have.
+func ownedBy(clusterTester *clusterv1.ClusterTester, obj client.Object) bool {
+	labels := obj.GetLabels()
+	if labels[ownerNameLabel] == clusterTester.Name && labels[ownerNamespaceLabel] == clusterTester.Namespace {
+		return true
+	}
+	ref := metav1.GetControllerOf(obj)
+	return ref != nil && ref.Kind == "ClusterTester" && ref.Name == clusterTester.Name && ref.UID == clusterTester.UID
+}
+
+// legacyClaimName is the name the database's PersistentVolumeClaim had
+// before resourceName.
+const legacyClaimName = "mysql-pvc"
+
+// databaseClaimName returns the name of the PersistentVolumeClaim of
+// clusterTester's database. A ClusterTester that has a claim from before
+// resourceName keeps using it, and with it the data in the database; the
+// others get a claim named by resourceName.
+func (r *ClusterTesterReconciler) databaseClaimName(ctx context.Context, clusterTester *clusterv1.ClusterTester) (string, error) {
+	claim := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: legacyClaimName, Namespace: targetNamespace(clusterTester)}, claim)
+	if err == nil && ownedBy(clusterTester, claim) {
+		return legacyClaimName, nil
+	}
+	if client.IgnoreNotFound(err) != nil {
+		return "", err
+	}
+	return resourceName(clusterTester, "mysql-pvc"), nil
+}
+
+// legacyObjects returns the objects clusterTester had in its target
+// namespace before resourceName prefixed their names: a Deployment and
+// Service named after each of services and after mysql, nats and redis, the
+// services' ServiceAccounts and leader election Roles and, when
+// spec.global.namePrefix is set, the API key Secret named after the
+// ClusterTester. The database's claim is not among them, as the database
+// keeps using it.
+func legacyObjects(clusterTester *clusterv1.ClusterTester, services []string) []client.Object {
+	namespace := targetNamespace(clusterTester)
+	objectMeta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: namespace}
+	}
+
+	var objs []client.Object
+	for _, name := range slices.Concat(services, []string{"mysql", "nats", "redis"}) {
+		objs = append(objs, &appsv1.Deployment{ObjectMeta: objectMeta(name)}, &corev1.Service{ObjectMeta: objectMeta(name)})
+	}
+	for _, name := range services {
+		objs = append(objs,
+			&corev1.ServiceAccount{ObjectMeta: objectMeta(name)},
+			&rbacv1.Role{ObjectMeta: objectMeta(name + "-leader-election")},
+			&rbacv1.RoleBinding{ObjectMeta: objectMeta(name + "-leader-election")},
+		)
+	}
+	if secret := clusterTester.Name + "-api-keys"; secret != apiKeySecretName(clusterTester) {
+		objs = append(objs, &corev1.Secret{ObjectMeta: objectMeta(secret)})
+	}
+	return objs
+}
+
+// removeLegacyObjects deletes the objects of clusterTester that still have
+// the names legacyObjects lists. Left alone, an upgraded ClusterTester would
+// run them next to their prefixed replacements, including a second MySQL.
+// Objects with those names that belong to anything else are kept.
+func (r *ClusterTesterReconciler) removeLegacyObjects(ctx context.Context, clusterTester *clusterv1.ClusterTester, services []string) error {
+	logger := log.FromContext(ctx)
+	for _, obj := range legacyObjects(clusterTester, services) {
+		if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if !ownedBy(clusterTester, obj) {
+			continue
+		}
+		logger.Info("Deleting object with an unprefixed name", "type", fmt.Sprintf("%T", obj), "name", obj.GetName(), "namespace", obj.GetNamespace())
+		if err := r.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// targetNamespace returns the namespace the objects of clusterTester are
+// created in: spec.global.namespace, or its own.
+func targetNamespace(clusterTester *clusterv1.ClusterTester) string {
+	if clusterTester.Spec.Global.Namespace != "" {
+		return clusterTester.Spec.Global.Namespace
+	}
+	return clusterTester.Namespace
+}
+
+// crossNamespace reports whether clusterTester deploys to another namespace
+// than its own.
+func crossNamespace(clusterTester *clusterv1.ClusterTester) bool {
+	return targetNamespace(clusterTester) != clusterTester.Namespace
+}
+
+func ownerLabels(clusterTester *clusterv1.ClusterTester) map[string]string {
+	return map[string]string{
+		ownerNameLabel:      clusterTester.Name,
+		ownerNamespaceLabel: clusterTester.Namespace,
+	}
+}
+
+// setOwner labels obj with its owner and, when they share a namespace, also
+// makes clusterTester its controller, so that it is garbage collected.
+func (r *ClusterTesterReconciler) setOwner(clusterTester *clusterv1.ClusterTester, obj client.Object) error {
+	// The labels map may also be a Deployment's selector, which must not
+	// change, so it is copied.
+	labels := maps.Clone(obj.GetLabels())
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	maps.Copy(labels, ownerLabels(clusterTester))
+	obj.SetLabels(labels)
+
+	if obj.GetNamespace() != clusterTester.Namespace {
+		return nil
+	}
+	return controllerutil.SetControllerReference(clusterTester, obj, r.Scheme)
+}
+
+// ownedObjects returns an object of each kind the operator creates for a
+// ClusterTester.
+func ownedObjects() []client.Object {
+	return []client.Object{
+		&appsv1.Deployment{},
+		&corev1.Service{},
+		&corev1.PersistentVolumeClaim{},
+		&corev1.Secret{},
+		&corev1.ServiceAccount{},
+		&rbacv1.Role{},
+		&rbacv1.RoleBinding{},
+	}
+}
+
+// ownedLists returns a list of each kind in ownedObjects.
+func ownedLists() []client.ObjectList {
+	return []client.ObjectList{
+		&appsv1.DeploymentList{},
+		&corev1.ServiceList{},
+		&corev1.PersistentVolumeClaimList{},
+		&corev1.SecretList{},
+		&corev1.ServiceAccountList{},
+		&rbacv1.RoleList{},
+		&rbacv1.RoleBindingList{},
+	}
+}
+
+// requestForOwner maps an object to a request for the ClusterTester its
+// owner labels name, if any.
+func requestForOwner(_ context.Context, obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	name, namespace := labels[ownerNameLabel], labels[ownerNamespaceLabel]
+	if name == "" || namespace == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}}
+}
+
+// watched reports whether the manager's cache holds the objects of
+// namespace.
+func (r *ClusterTesterReconciler) watched(namespace string) bool {
+	return len(r.WatchNamespaces) == 0 || slices.Contains(r.WatchNamespaces, namespace)
+}
+
+// reconcileNamespace creates the target namespace unless it exists. A
+// namespace the operator creates carries the owner labels, which mark it
+// for deletion with the ClusterTester.
+func (r *ClusterTesterReconciler) reconcileNamespace(ctx context.Context, clusterTester *clusterv1.ClusterTester) error {
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: targetNamespace(clusterTester),
+			Labels: map[string]string{
+				"app.kubernetes.io/part-of":    "cluster-tester",
+				"app.kubernetes.io/managed-by": "cluster-tester-operator",
+			},
+		},
+	}
+	maps.Copy(namespace.Labels, ownerLabels(clusterTester))
+	return r.createIfNotFound(ctx, namespace)
+}
+
+// cleanup deletes the objects labelled with clusterTester as their owner in
+// every namespace, and the target namespace if the operator created it.
+// Objects in a namespace the manager does not watch are left alone.
+func (r *ClusterTesterReconciler) cleanup(ctx context.Context, clusterTester *clusterv1.ClusterTester) error {
+	logger := log.FromContext(ctx)
+	owner := client.MatchingLabels(ownerLabels(clusterTester))
+
+	for _, list := range ownedLists() {
+		if err := r.List(ctx, list, owner); err != nil {
+			return err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			obj := item.(client.Object)
+			logger.Info("Deleting object", "type", fmt.Sprintf("%T", obj), "name", obj.GetName(), "namespace", obj.GetNamespace())
+			if err := r.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+
+	if !clusterTester.Spec.Global.CreateNamespace || !crossNamespace(clusterTester) {
+		return nil
+	}
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: targetNamespace(clusterTester)}, namespace); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	for key, value := range ownerLabels(clusterTester) {
+		if namespace.Labels[key] != value {
+			// The namespace existed before the ClusterTester.
+			return nil
+		}
+	}
+	logger.Info("Deleting namespace", "namespace", namespace.Name)
+	return client.IgnoreNotFound(r.Delete(ctx, namespace))
+}
@@ -0,0 +1,235 @@
+// Package api implements the HTTP and gRPC APIs of the coffee shop service.
+// NewRouter builds the complete router on top of a Store, and NewServers a
+// gRPC server next to it, so tests and the service binary run the same
+// handlers.
+package api
+
+//go:generate go run -C ../../tools/openapi-gen . -dir ../../coffee-shop/api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+const (
+	serviceName    = "coffee-shop"
+	serviceVersion = "1.0.0"
+)
+
+// Config holds the router options that do not depend on the store.
+type Config struct {
+	// ValidateRequests rejects requests that do not match the OpenAPI spec.
+	ValidateRequests bool
+
+	// ValidateResponses logs responses that do not match the OpenAPI spec.
+	ValidateResponses bool
+
+	// Readiness is reported by /readyz and /health. A nil Readiness is
+	// replaced by one that is not yet warmed up.
+	Readiness *Readiness
+
+	// Logger receives one record per request. A nil Logger uses
+	// slog.Default.
+	Logger *slog.Logger
+
+	// Auth identifies the callers of the data and admin endpoints. Reads
+	// need the read scope and other methods the write scope. A nil Auth
+	// leaves the API open; health checks and docs are always open.
+	Auth Authenticator
+
+	// Limits rate limits the data and admin endpoints and caps request
+	// bodies. The zero value sets no limits.
+	Limits Limits
+
+	// Events configures /events and the webhooks.
+	Events EventOptions
+
+	// Bus also carries every event to the other services. A nil Bus
+	// publishes them on /events and to webhooks only.
+	Bus Bus
+}
+
+// ConfigFromEnv reads the router options from the environment: requests are
+// validated unless OPENAPI_VALIDATE_REQUESTS=false, responses are checked
+// when OPENAPI_VALIDATE_RESPONSES=true, LOG_LEVEL and LOG_FORMAT select
+// the logger, the limits are read as described by LimitsFromEnv, and the
+// change feed is configured as described by EventOptionsFromEnv.
+func ConfigFromEnv() Config {
+	return Config{
+		ValidateRequests:  envBool("OPENAPI_VALIDATE_REQUESTS", true),
+		ValidateResponses: envBool("OPENAPI_VALIDATE_RESPONSES", false),
+		Logger:            LoggerFromEnv(),
+		Limits:            LimitsFromEnv(),
+		Events:            EventOptionsFromEnv(),
+	}
+}
+
+// handler serves the API from a store.
+type handler struct {
+	store  Store
+	checks []namedCheck
+	events *events
+}
+
+// NewRouter registers every route on a new engine, serving data from store.
+// It panics if the embedded OpenAPI spec is invalid, which the package tests
+// rule out, or if one of Limits.TrustedProxies is not an address or range.
+//
+// @title Coffee Shop API
+// @version 1.0.0
+// @description This is a coffee shop service API
+// @contact.name API Support
+// @contact.url http://www.swagger.io/support
+// @contact.email support@swagger.io
+// @license.name Apache 2.0
+// @license.url http://www.apache.org/licenses/LICENSE-2.0.html
+// @host localhost:8080
+// @BasePath /
+// @schemes http
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.bearer BearerAuth
+// @bearerFormat JWT
+func NewRouter(store Store, cfg Config) *gin.Engine {
+	return newHandler(store, &cfg).router(cfg)
+}
+
+// NewServers returns the router and a gRPC server on top of the same
+// handler, so that both serve one store, and a change made through either is
+// published on the change feed of both.
+func NewServers(store Store, cfg Config) (*gin.Engine, *grpc.Server) {
+	h := newHandler(store, &cfg)
+	return h.router(cfg), newGRPCServer(h, cfg)
+}
+
+// newHandler fills in the defaults of cfg and returns a handler for store.
+func newHandler(store Store, cfg *Config) *handler {
+	if cfg.Readiness == nil {
+		cfg.Readiness = &Readiness{}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return &handler{
+		store:  store,
+		checks: readinessChecks(cfg.Readiness, store),
+		events: newEvents(cfg.Events, cfg.Bus, cfg.Readiness.stopping()),
+	}
+}
+
+// router registers every route of h on a new engine.
+func (h *handler) router(cfg Config) *gin.Engine {
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Limits.TrustedProxies); err != nil {
+		panic(fmt.Sprintf("setting trusted proxies: %v", err))
+	}
+	r.Use(requestLogger(cfg.Logger), gin.Recovery(), limitBody(cfg.Limits.MaxBodyBytes))
+	// Credentials are checked before requests are validated, so that callers
+	// without them learn nothing about the API. Clients are rate limited by
+	// IP before that and by caller after it; probes and docs are not.
+	public := r.Group("/")
+	api := r.Group("/", rateLimit(cfg.Limits, clientIP), authorize(cfg.Auth), rateLimit(cfg.Limits, callerOf))
+	if cfg.ValidateRequests {
+		doc, err := loadOpenAPISpec()
+		if err != nil {
+			panic(fmt.Sprintf("loading OpenAPI spec: %v", err))
+		}
+		validator, err := validateOpenAPI(doc, cfg.ValidateResponses)
+		if err != nil {
+			panic(fmt.Sprintf("building OpenAPI validator: %v", err))
+		}
+		public.Use(validator)
+		api.Use(validator)
+	}
+
+	// Health check endpoints
+	public.GET("/health", h.healthCheck)
+	public.GET("/livez", h.livenessCheck)
+	public.GET("/readyz", h.readinessCheck)
+
+	// OpenAPI specification and Swagger UI
+	public.GET("/openapi.json", getOpenAPISpec)
+	public.GET("/docs", serveDocs)
+
+	api.GET("/coffees", h.getCoffees)
+	api.GET("/coffees/:id", h.getCoffeeByID)
+	api.POST("/coffees", h.createCoffee)
+	api.DELETE("/coffees/:id", h.deleteCoffee)
+	api.PUT("/coffees/:id", h.updateCoffee)
+	api.PATCH("/coffees/:id", h.patchCoffee)
+
+	// Change feed
+	api.GET("/events", h.streamEvents)
+	api.GET("/webhooks", h.getWebhooks)
+	api.GET("/webhooks/:id", h.getWebhookByID)
+	api.POST("/webhooks", h.createWebhook)
+	api.DELETE("/webhooks/:id", h.deleteWebhook)
+
+	// Bulk data seeding
+	api.GET("/admin/fixtures", h.exportCoffeeFixtures)
+	api.POST("/admin/fixtures", h.importCoffeeFixtures)
+	api.POST("/admin/fixtures/generate", h.generateCoffeeFixtures)
+
+	return r
+}
+
+// getOpenAPISpec serves the generated specification.
+//
+// @Summary OpenAPI specification
+// @Description Returns the OpenAPI 3.0 description of this API
+// @Tags docs
+// @Success 200 {object} object "OpenAPI document"
+// @Router /openapi.json [get]
+func getOpenAPISpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", []byte(openAPISpec))
+}
+
+// serveDocs serves Swagger UI from a CDN, pointed at /openapi.json.
+//
+// @Summary API documentation
+// @Description Swagger UI for this API
+// @Tags docs
+// @Produce html
+// @Success 200 {string} string "Swagger UI page"
+// @Router /docs [get]
+func serveDocs(c *gin.Context) {
+	html := `<!DOCTYPE html>
+<html>
+<head>
+    <title>Coffee Shop API Documentation</title>
+    <link rel="stylesheet" type="text/css" href="https://unpkg.com/swagger-ui-dist@3.52.5/swagger-ui.css" />
+</head>
+<body>
+    <div id="swagger-ui"></div>
+    <script src="https://unpkg.com/swagger-ui-dist@3.52.5/swagger-ui-bundle.js"></script>
+    <script>
+        SwaggerUIBundle({
+            url: '/openapi.json',
+            dom_id: '#swagger-ui',
+            presets: [
+                SwaggerUIBundle.presets.apis,
+                SwaggerUIBundle.presets.standalone
+            ]
+        });
+    </script>
+</body>
+</html>`
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+}
+
+// parseID reads the numeric id path parameter. The OpenAPI validator already
+// rejects other values unless it is disabled.
+func parseID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be an integer"})
+		return 0, false
+	}
+	return id, true
+}
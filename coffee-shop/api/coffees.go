@@ -0,0 +1,423 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Coffee is a drink on the menu.
+type Coffee struct {
+	ID    int     `json:"id" example:"1"`
+	Name  string  `json:"name" binding:"required" example:"Espresso"`
+	Price float64 `json:"price" binding:"min=0" minimum:"0" example:"2.99"`
+}
+
+// CoffeePatch documents the JSON Merge Patch for a coffee: the members it
+// has replace those of the coffee, and null removes them.
+type CoffeePatch struct {
+	Name  string  `json:"name,omitempty" nullable:"true" example:"Espresso"`
+	Price float64 `json:"price,omitempty" nullable:"true" minimum:"0" example:"2.99"`
+}
+
+// DefaultCoffees returns the built-in menu the service starts with.
+func DefaultCoffees() []Coffee {
+	return []Coffee{
+		{1, "Espresso", 2.99},
+		{2, "Americano", 2.49},
+		{3, "Latte", 3.49},
+		{4, "Cappuccino", 3.49},
+		{5, "Mocha", 3.99},
+		{6, "Macchiato", 3.19},
+		{7, "Flat White", 3.29},
+		{8, "Cold Brew", 3.49},
+		{9, "Frappuccino", 4.49},
+		{10, "Affogato", 3.99},
+		{11, "Iced Coffee", 2.99},
+		{12, "Nitro Cold Brew", 3.99},
+		{13, "Cortado", 3.29},
+		{14, "Red Eye", 3.99},
+		{15, "Turkish Coffee", 2.99},
+	}
+}
+
+// Store persists the coffees served by the API. Get, Update and Delete
+// return ErrNotFound for unknown IDs.
+type Store interface {
+	// List returns every coffee in insertion order.
+	List(ctx context.Context) ([]Coffee, error)
+
+	// Get returns a coffee with its version, which changes whenever the
+	// coffee does.
+	Get(ctx context.Context, id int) (Coffee, int64, error)
+
+	// Create stores a coffee, assigning an ID when it has none, and returns
+	// it as stored. It returns ErrConflict when the ID is taken.
+	Create(ctx context.Context, coffee Coffee) (Coffee, error)
+
+	// Update replaces the coffee with the given ID and returns it as stored
+	// with its new version. Update and Delete return ErrVersionMismatch
+	// unless ifVersion is anyVersion or the current version.
+	Update(ctx context.Context, id int, coffee Coffee, ifVersion int64) (Coffee, int64, error)
+	Delete(ctx context.Context, id int, ifVersion int64) error
+
+	// Count, Import and Export back the admin fixture endpoints, as
+	// described by fixtureSet.
+	Count(ctx context.Context) (int, error)
+	Import(ctx context.Context, coffees []Coffee, replace bool) (int, error)
+	Export(ctx context.Context) ([]Coffee, error)
+}
+
+// NewMemoryStore returns a Store that keeps coffees in memory, starting with
+// a copy of coffees.
+func NewMemoryStore(coffees []Coffee) Store {
+	return newMemoryStore(coffees, func(c *Coffee) *int { return &c.ID })
+}
+
+// LoadSeedFixtures applies the start-up dataset configured through the
+// environment to store.
+func LoadSeedFixtures(ctx context.Context, store Store) error {
+	return loadSeedFixtures(ctx, coffeeFixtures{store})
+}
+
+// coffeeSortFields are the fields GET /coffees can be sorted by.
+var coffeeSortFields = map[string]comparator[Coffee]{
+	"id":    func(a, b Coffee) int { return cmp.Compare(a.ID, b.ID) },
+	"name":  func(a, b Coffee) int { return strings.Compare(a.Name, b.Name) },
+	"price": func(a, b Coffee) int { return cmp.Compare(a.Price, b.Price) },
+}
+
+// getCoffees lists coffees matching the filters, one page at a time.
+//
+// @Summary Get all coffees
+// @Description Returns a list of all available coffees
+// @ID getCoffees
+// @Tags coffees
+// @Param name query string false "Case-insensitive substring of the name"
+// @Param minPrice query number false "Minimum price (inclusive)"
+// @Param maxPrice query number false "Maximum price (inclusive)"
+// @Param sort query string false "Field to sort by; prefix with '-' for descending order" Enums(id,name,price,-id,-name,-price)
+// @Param limit query integer false "Maximum number of items to return" default(100) minimum(1) maximum(1000)
+// @Param offset query integer false "Number of items to skip" default(0) minimum(0)
+// @Success 200 {array} Coffee "List of coffees"
+// @Header 200 {integer} X-Total-Count "Total number of items matching the filters"
+// @Header 200 {string} Link "RFC 8288 links to the first, prev, next and last pages"
+// @Failure 400 {object} map[string]string "Invalid query parameter"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /coffees [get]
+func (h *handler) getCoffees(c *gin.Context) {
+	q, err := parseListQuery(c, sortFieldNames(coffeeSortFields))
+	minPrice, minErr := queryFloat(c, "minPrice")
+	maxPrice, maxErr := queryFloat(c, "maxPrice")
+	if err := errors.Join(err, minErr, maxErr); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.ToLower(c.Query("name"))
+
+	coffees, err := h.store.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	matches := make([]Coffee, 0, len(coffees))
+	for _, coffee := range coffees {
+		if name != "" && !strings.Contains(strings.ToLower(coffee.Name), name) {
+			continue
+		}
+		if minPrice != nil && coffee.Price < *minPrice {
+			continue
+		}
+		if maxPrice != nil && coffee.Price > *maxPrice {
+			continue
+		}
+		matches = append(matches, coffee)
+	}
+	c.JSON(http.StatusOK, sortAndPage(c, matches, q, coffeeSortFields))
+}
+
+// getCoffeeByID returns a single coffee.
+//
+// @Summary Get coffee by ID
+// @Description Returns a single coffee
+// @ID getCoffeeById
+// @Tags coffees
+// @Param id path integer true "Coffee ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} Coffee "Coffee details"
+// @Success 304 "Cached copy is current"
+// @Header 200,304 {string} ETag "Version of the coffee"
+// @Failure 404 {object} map[string]string "Coffee not found"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /coffees/{id} [get]
+func (h *handler) getCoffeeByID(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	coffee, version, err := h.store.Get(c.Request.Context(), id)
+	if err != nil {
+		coffeeError(c, err)
+		return
+	}
+	if notModified(c, version) {
+		return
+	}
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, coffee)
+}
+
+// createCoffee adds a coffee to the menu.
+//
+// @Summary Create a new coffee
+// @Description Add a new coffee to the menu. A coffee without an id is assigned one; an id that is already taken is rejected; replace a record with PUT.
+// @ID createCoffee
+// @Tags coffees
+// @Accept json
+// @Param coffee body Coffee true "Coffee to add"
+// @Success 201 {object} Coffee "Coffee created successfully"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 409 {object} map[string]string "Coffee already exists"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /coffees [post]
+func (h *handler) createCoffee(c *gin.Context) {
+	var newCoffee Coffee
+	if err := c.ShouldBindJSON(&newCoffee); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	created, err := h.store.Create(c.Request.Context(), newCoffee)
+	if err != nil {
+		coffeeError(c, err)
+		return
+	}
+	h.events.publish(EventCreated, "coffees", created.ID, created)
+	c.JSON(http.StatusCreated, created)
+}
+
+// deleteCoffee removes a coffee from the menu.
+//
+// @Summary Delete a coffee
+// @Description Remove a coffee from the menu. With If-Match, only the given version is removed.
+// @ID deleteCoffee
+// @Tags coffees
+// @Param id path integer true "Coffee ID"
+// @Param If-Match header string false "ETag the coffee must have"
+// @Success 200 {object} map[string]string "Coffee deleted"
+// @Failure 404 {object} map[string]string "Coffee not found"
+// @Failure 412 {object} map[string]string "Coffee has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /coffees/{id} [delete]
+func (h *handler) deleteCoffee(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c, h.currentVersion(c, id))
+	if !ok {
+		return
+	}
+	if err := h.store.Delete(c.Request.Context(), id, ifVersion); err != nil {
+		coffeeError(c, err)
+		return
+	}
+	h.events.publish(EventDeleted, "coffees", id, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Coffee deleted"})
+}
+
+// updateCoffee replaces a coffee.
+//
+// @Summary Update a coffee
+// @Description Replace an existing coffee; the id in the path takes precedence over the body. With If-Match, only the given version is replaced.
+// @ID updateCoffee
+// @Tags coffees
+// @Accept json
+// @Param id path integer true "Coffee ID"
+// @Param If-Match header string false "ETag the coffee must have"
+// @Param coffee body Coffee true "Updated coffee"
+// @Success 200 {object} Coffee "Coffee updated"
+// @Header 200 {string} ETag "New version of the coffee"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Coffee not found"
+// @Failure 412 {object} map[string]string "Coffee has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /coffees/{id} [put]
+func (h *handler) updateCoffee(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var updatedCoffee Coffee
+	if err := c.ShouldBindJSON(&updatedCoffee); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c, h.currentVersion(c, id))
+	if !ok {
+		return
+	}
+	updated, version, err := h.store.Update(c.Request.Context(), id, updatedCoffee, ifVersion)
+	if err != nil {
+		coffeeError(c, err)
+		return
+	}
+	h.events.publish(EventUpdated, "coffees", id, updated)
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// patchCoffee changes some fields of a coffee.
+//
+// @Summary Patch a coffee
+// @Description Change some fields of a coffee with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902). The patched coffee must be valid; the id in the path takes precedence. With If-Match, only the given version is patched.
+// @ID patchCoffee
+// @Tags coffees
+// @Accept merge-patch,json-patch
+// @Param id path integer true "Coffee ID"
+// @Param If-Match header string false "ETag the coffee must have"
+// @Param patch body CoffeePatch true "Merge patch or JSON Patch to apply" accept(merge-patch)
+// @Param operations body []PatchOperation true "Operations to apply" accept(json-patch)
+// @Success 200 {object} Coffee "Coffee updated"
+// @Header 200 {string} ETag "New version of the coffee"
+// @Failure 400 {object} map[string]string "Malformed patch"
+// @Failure 404 {object} map[string]string "Coffee not found"
+// @Failure 409 {object} map[string]string "Patch does not apply to the coffee"
+// @Failure 412 {object} map[string]string "Coffee has changed"
+// @Failure 415 {object} map[string]string "Unsupported patch format"
+// @Failure 422 {object} map[string]string "Patched coffee is invalid"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /coffees/{id} [patch]
+func (h *handler) patchCoffee(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	updated, version, ok := patchRecord(c, h.store, id, "Coffee", coffeeError)
+	if !ok {
+		return
+	}
+	h.events.publish(EventUpdated, "coffees", id, updated)
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// coffeeError maps a store error to a response.
+func coffeeError(c *gin.Context, err error) {
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coffee not found"})
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		preconditionFailed(c)
+		return
+	}
+	if errors.Is(err, ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Coffee already exists"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// currentVersion returns a lookup of the version of coffee id, for
+// ifMatchVersion.
+func (h *handler) currentVersion(c *gin.Context, id int) func() (int64, error) {
+	return func() (int64, error) {
+		_, version, err := h.store.Get(c.Request.Context(), id)
+		return version, err
+	}
+}
+
+// coffeeFixtures exposes the store to the fixture endpoints.
+type coffeeFixtures struct {
+	Store
+}
+
+var (
+	coffeeStyles = []string{"Iced", "Hot", "Vanilla", "Caramel", "Hazelnut", "Oat Milk", "Honey", "Spiced"}
+	coffeeDrinks = []string{"Latte", "Americano", "Cappuccino", "Mocha", "Macchiato", "Cold Brew", "Flat White", "Cortado"}
+)
+
+func (coffeeFixtures) Generate(start, n int) []Coffee {
+	items := make([]Coffee, n)
+	for i := range items {
+		items[i] = Coffee{
+			Name:  fmt.Sprintf("%s %s %d", coffeeStyles[rand.IntN(len(coffeeStyles))], coffeeDrinks[rand.IntN(len(coffeeDrinks))], start+i+1),
+			Price: float64(199+rand.IntN(400)) / 100,
+		}
+	}
+	return items
+}
+
+// exportCoffeeFixtures writes every coffee as JSON or CSV.
+//
+// @Summary Export fixtures
+// @Description Export every record as JSON or CSV
+// @ID exportFixtures
+// @Tags admin
+// @Produce json,csv
+// @Param format query string false "Export format" Enums(json,csv) default(json)
+// @Success 200 {array} Coffee "All records"
+// @Failure 400 {object} map[string]string "Unsupported format"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /admin/fixtures [get]
+func (h *handler) exportCoffeeFixtures(c *gin.Context) {
+	exportFixtures(c, coffeeFixtures{h.store})
+}
+
+// importCoffeeFixtures bulk-loads coffees from the request body.
+//
+// @Summary Import fixtures
+// @Description Bulk-load records from a JSON array or CSV file. Records without an id are assigned one; records with an existing id overwrite it. Records are validated as on create and the first invalid one is rejected with its index. The body may be up to 64 MiB whatever the service's body limit.
+// @ID importFixtures
+// @Tags admin
+// @Accept json,csv
+// @Param mode query string false "append keeps existing records; replace removes them first" Enums(append,replace) default(append)
+// @Param coffees body []Coffee true "Records to import; CSV files need a header row naming the JSON fields"
+// @Success 200 {object} fixtureResult "Records stored"
+// @Failure 400 {object} map[string]string "Invalid records or mode"
+// @Failure 415 {object} map[string]string "Unsupported content type"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /admin/fixtures [post]
+func (h *handler) importCoffeeFixtures(c *gin.Context) {
+	importFixtures(c, coffeeFixtures{h.store})
+}
+
+// generateCoffeeFixtures stores synthetic coffees.
+//
+// @Summary Generate fixtures
+// @Description Store count synthetic records
+// @ID generateFixtures
+// @Tags admin
+// @Param count query integer true "Number of records to generate" minimum(1) maximum(100000)
+// @Param mode query string false "append keeps existing records; replace removes them first" Enums(append,replace) default(append)
+// @Success 200 {object} fixtureResult "Records stored"
+// @Failure 400 {object} map[string]string "Invalid count or mode"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /admin/fixtures/generate [post]
+func (h *handler) generateCoffeeFixtures(c *gin.Context) {
+	generateFixtures(c, coffeeFixtures{h.store})
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"coffee-shop/api/pb"
+)
+
+// registerGRPCServices registers the gRPC services of h on srv.
+func registerGRPCServices(srv *grpc.Server, h *handler) {
+	pb.RegisterCoffeeServiceServer(srv, coffeeService{h: h})
+}
+
+// coffeeService serves the coffees over gRPC, as the /coffees endpoints do.
+type coffeeService struct {
+	pb.UnimplementedCoffeeServiceServer
+	h *handler
+}
+
+func (s coffeeService) ListCoffees(ctx context.Context, req *pb.ListCoffeesRequest) (*pb.ListCoffeesResponse, error) {
+	coffees, err := s.h.store.List(ctx)
+	if err != nil {
+		return nil, rpcError(err, "Coffee")
+	}
+	page, err := rpcPage(coffees, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ListCoffeesResponse{Total: int32(len(coffees))}
+	for _, coffee := range page {
+		resp.Coffees = append(resp.Coffees, coffeeToPB(coffee))
+	}
+	return resp, nil
+}
+
+func (s coffeeService) GetCoffee(ctx context.Context, req *pb.GetCoffeeRequest) (*pb.Coffee, error) {
+	coffee, _, err := s.h.store.Get(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, rpcError(err, "Coffee")
+	}
+	return coffeeToPB(coffee), nil
+}
+
+func (s coffeeService) CreateCoffee(ctx context.Context, req *pb.CreateCoffeeRequest) (*pb.Coffee, error) {
+	coffee, err := coffeeFromPB(req.GetCoffee())
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.h.store.Create(ctx, coffee)
+	if err != nil {
+		return nil, rpcError(err, "Coffee")
+	}
+	s.h.events.publish(EventCreated, "coffees", created.ID, created)
+	return coffeeToPB(created), nil
+}
+
+func (s coffeeService) UpdateCoffee(ctx context.Context, req *pb.UpdateCoffeeRequest) (*pb.Coffee, error) {
+	coffee, err := coffeeFromPB(req.GetCoffee())
+	if err != nil {
+		return nil, err
+	}
+	id := int(req.GetId())
+	updated, _, err := s.h.store.Update(ctx, id, coffee, anyVersion)
+	if err != nil {
+		return nil, rpcError(err, "Coffee")
+	}
+	s.h.events.publish(EventUpdated, "coffees", id, updated)
+	return coffeeToPB(updated), nil
+}
+
+func (s coffeeService) DeleteCoffee(ctx context.Context, req *pb.DeleteCoffeeRequest) (*pb.DeleteCoffeeResponse, error) {
+	id := int(req.GetId())
+	if err := s.h.store.Delete(ctx, id, anyVersion); err != nil {
+		return nil, rpcError(err, "Coffee")
+	}
+	s.h.events.publish(EventDeleted, "coffees", id, nil)
+	return &pb.DeleteCoffeeResponse{}, nil
+}
+
+func coffeeToPB(c Coffee) *pb.Coffee {
+	return &pb.Coffee{Id: int64(c.ID), Name: c.Name, Price: c.Price}
+}
+
+// coffeeFromPB converts and validates a coffee sent over gRPC.
+func coffeeFromPB(c *pb.Coffee) (Coffee, error) {
+	coffee := Coffee{ID: int(c.GetId()), Name: c.GetName(), Price: c.GetPrice()}
+	if err := validateRPC(coffee); err != nil {
+		return coffee, err
+	}
+	if coffee.Price < 0 {
+		return coffee, status.Error(codes.InvalidArgument, "price must not be negative")
+	}
+	return coffee, nil
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds how long a single readiness check may take.
+const readinessTimeout = 2 * time.Second
+
+// Readiness holds the lifecycle state reported by the readiness checks. The
+// zero value is not yet warmed up.
+type Readiness struct {
+	// warmedUp is set once the store has been loaded and the service can
+	// answer requests with real data.
+	warmedUp atomic.Bool
+
+	// draining is set when shutdown begins so that readiness fails and the
+	// pod is taken out of the Service endpoints before the listener closes.
+	draining atomic.Bool
+
+	// stop is closed when the server shuts down, to end the responses that
+	// stay open, such as event streams, which shutdown would wait for.
+	stopInit  sync.Once
+	stopClose sync.Once
+	stop      chan struct{}
+}
+
+// SetWarmedUp marks the store as loaded.
+func (r *Readiness) SetWarmedUp() {
+	r.warmedUp.Store(true)
+}
+
+// SetDraining marks the service as shutting down.
+func (r *Readiness) SetDraining() {
+	r.draining.Store(true)
+}
+
+// stopping returns the channel that is closed when the server shuts down.
+func (r *Readiness) stopping() <-chan struct{} {
+	r.stopInit.Do(func() { r.stop = make(chan struct{}) })
+	return r.stop
+}
+
+// setStopping closes the channel returned by stopping.
+func (r *Readiness) setStopping() {
+	r.stopping()
+	r.stopClose.Do(func() { close(r.stop) })
+}
+
+// checkResult is the outcome of a single readiness check.
+type checkResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status" enums:"ok,failed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// healthStatus is the body of the health endpoints.
+type healthStatus struct {
+	Status  string        `json:"status" example:"ready"`
+	Service string        `json:"service"`
+	Version string        `json:"version" example:"1.0.0"`
+	Checks  []checkResult `json:"checks,omitempty"`
+}
+
+// namedCheck is a dependency check run by /readyz and /health.
+type namedCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// pinger is implemented by stores backed by an external database.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// readinessChecks returns the checks for r and store, in the order they are
+// reported. Stores that implement pinger get a database check first.
+func readinessChecks(r *Readiness, store any) []namedCheck {
+	var checks []namedCheck
+	if p, ok := store.(pinger); ok {
+		checks = append(checks, namedCheck{"database", p.Ping})
+	}
+	return append(checks,
+		namedCheck{"warmup", func(context.Context) error {
+			if !r.warmedUp.Load() {
+				return errors.New("store is still warming up")
+			}
+			return nil
+		}},
+		namedCheck{"draining", func(context.Context) error {
+			if r.draining.Load() {
+				return errors.New("service is shutting down")
+			}
+			return nil
+		}},
+	)
+}
+
+// runReadinessChecks runs every readiness check and reports whether all of
+// them passed.
+func (h *handler) runReadinessChecks(ctx context.Context) ([]checkResult, bool) {
+	results := make([]checkResult, 0, len(h.checks))
+	ready := true
+	for _, rc := range h.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+		err := rc.check(checkCtx)
+		cancel()
+
+		result := checkResult{Name: rc.name, Status: "ok"}
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			ready = false
+		}
+		results = append(results, result)
+	}
+	return results, ready
+}
+
+// livenessCheck only reports that the process is serving HTTP. It does not
+// look at dependencies, so an outage elsewhere never restarts the pod.
+//
+// @Summary Liveness check
+// @Description Reports that the process is running; does not check dependencies
+// @Tags health
+// @Success 200 {object} healthStatus "Process is alive"
+// @Router /livez [get]
+func (h *handler) livenessCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, healthStatus{
+		Status:  "alive",
+		Service: serviceName,
+		Version: serviceVersion,
+	})
+}
+
+// readinessCheck reports whether the service should receive traffic.
+//
+// @Summary Readiness check
+// @Description Reports whether the service can accept traffic, with the result of each dependency check
+// @Tags health
+// @Success 200 {object} healthStatus "Service is ready"
+// @Failure 503 {object} healthStatus "Service is not ready"
+// @Router /readyz [get]
+func (h *handler) readinessCheck(c *gin.Context) {
+	checks, ready := h.runReadinessChecks(c.Request.Context())
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not ready", http.StatusServiceUnavailable
+	}
+	c.JSON(code, healthStatus{
+		Status:  status,
+		Service: serviceName,
+		Version: serviceVersion,
+		Checks:  checks,
+	})
+}
+
+// healthCheck is kept for existing clients. It runs the readiness checks but
+// keeps the original healthy/unhealthy status values.
+//
+// @Summary Health check
+// @Description Legacy health endpoint; reports the same checks as /readyz
+// @Tags health
+// @Success 200 {object} healthStatus "Service is healthy"
+// @Failure 503 {object} healthStatus "Service is unhealthy"
+// @Router /health [get]
+func (h *handler) healthCheck(c *gin.Context) {
+	checks, ready := h.runReadinessChecks(c.Request.Context())
+	status, code := "healthy", http.StatusOK
+	if !ready {
+		status, code = "unhealthy", http.StatusServiceUnavailable
+	}
+	c.JSON(code, healthStatus{
+		Status:  status,
+		Service: serviceName,
+		Version: serviceVersion,
+		Checks:  checks,
+	})
+}
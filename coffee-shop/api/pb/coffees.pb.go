@@ -0,0 +1,662 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: coffees.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Coffee struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id    int64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  string  `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Price float64 `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
+}
+
+func (x *Coffee) Reset() {
+	*x = Coffee{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coffees_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Coffee) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Coffee) ProtoMessage() {}
+
+func (x *Coffee) ProtoReflect() protoreflect.Message {
+	mi := &file_coffees_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Coffee.ProtoReflect.Descriptor instead.
+func (*Coffee) Descriptor() ([]byte, []int) {
+	return file_coffees_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Coffee) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Coffee) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Coffee) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+type ListCoffeesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// limit is the page size, 100 when unset and at most 1000.
+	Limit  int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset int32 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+}
+
+func (x *ListCoffeesRequest) Reset() {
+	*x = ListCoffeesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coffees_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListCoffeesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCoffeesRequest) ProtoMessage() {}
+
+func (x *ListCoffeesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coffees_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCoffeesRequest.ProtoReflect.Descriptor instead.
+func (*ListCoffeesRequest) Descriptor() ([]byte, []int) {
+	return file_coffees_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListCoffeesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListCoffeesRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListCoffeesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Coffees []*Coffee `protobuf:"bytes,1,rep,name=coffees,proto3" json:"coffees,omitempty"`
+	// total is the number of coffees on every page.
+	Total int32 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+}
+
+func (x *ListCoffeesResponse) Reset() {
+	*x = ListCoffeesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coffees_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListCoffeesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCoffeesResponse) ProtoMessage() {}
+
+func (x *ListCoffeesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coffees_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCoffeesResponse.ProtoReflect.Descriptor instead.
+func (*ListCoffeesResponse) Descriptor() ([]byte, []int) {
+	return file_coffees_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListCoffeesResponse) GetCoffees() []*Coffee {
+	if x != nil {
+		return x.Coffees
+	}
+	return nil
+}
+
+func (x *ListCoffeesResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type GetCoffeeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetCoffeeRequest) Reset() {
+	*x = GetCoffeeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coffees_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCoffeeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCoffeeRequest) ProtoMessage() {}
+
+func (x *GetCoffeeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coffees_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCoffeeRequest.ProtoReflect.Descriptor instead.
+func (*GetCoffeeRequest) Descriptor() ([]byte, []int) {
+	return file_coffees_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetCoffeeRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreateCoffeeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Coffee *Coffee `protobuf:"bytes,1,opt,name=coffee,proto3" json:"coffee,omitempty"`
+}
+
+func (x *CreateCoffeeRequest) Reset() {
+	*x = CreateCoffeeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coffees_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateCoffeeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCoffeeRequest) ProtoMessage() {}
+
+func (x *CreateCoffeeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coffees_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCoffeeRequest.ProtoReflect.Descriptor instead.
+func (*CreateCoffeeRequest) Descriptor() ([]byte, []int) {
+	return file_coffees_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateCoffeeRequest) GetCoffee() *Coffee {
+	if x != nil {
+		return x.Coffee
+	}
+	return nil
+}
+
+type UpdateCoffeeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id     int64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Coffee *Coffee `protobuf:"bytes,2,opt,name=coffee,proto3" json:"coffee,omitempty"`
+}
+
+func (x *UpdateCoffeeRequest) Reset() {
+	*x = UpdateCoffeeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coffees_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateCoffeeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCoffeeRequest) ProtoMessage() {}
+
+func (x *UpdateCoffeeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coffees_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCoffeeRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCoffeeRequest) Descriptor() ([]byte, []int) {
+	return file_coffees_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UpdateCoffeeRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateCoffeeRequest) GetCoffee() *Coffee {
+	if x != nil {
+		return x.Coffee
+	}
+	return nil
+}
+
+type DeleteCoffeeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *DeleteCoffeeRequest) Reset() {
+	*x = DeleteCoffeeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coffees_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteCoffeeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCoffeeRequest) ProtoMessage() {}
+
+func (x *DeleteCoffeeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coffees_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCoffeeRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCoffeeRequest) Descriptor() ([]byte, []int) {
+	return file_coffees_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteCoffeeRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeleteCoffeeResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DeleteCoffeeResponse) Reset() {
+	*x = DeleteCoffeeResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coffees_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteCoffeeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCoffeeResponse) ProtoMessage() {}
+
+func (x *DeleteCoffeeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coffees_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCoffeeResponse.ProtoReflect.Descriptor instead.
+func (*DeleteCoffeeResponse) Descriptor() ([]byte, []int) {
+	return file_coffees_proto_rawDescGZIP(), []int{7}
+}
+
+var File_coffees_proto protoreflect.FileDescriptor
+
+var file_coffees_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x0d, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x76, 0x31, 0x22, 0x42,
+	0x0a, 0x06, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x70, 0x72, 0x69,
+	0x63, 0x65, 0x22, 0x42, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06,
+	0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x22, 0x5c, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f,
+	0x66, 0x66, 0x65, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2f, 0x0a,
+	0x07, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x43,
+	0x6f, 0x66, 0x66, 0x65, 0x65, 0x52, 0x07, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x73, 0x12, 0x14,
+	0x0a, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x74,
+	0x6f, 0x74, 0x61, 0x6c, 0x22, 0x22, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x66, 0x66, 0x65,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x22, 0x44, 0x0a, 0x13, 0x43, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x2d, 0x0a, 0x06, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x15, 0x2e, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x76, 0x31, 0x2e,
+	0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x52, 0x06, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x22, 0x54,
+	0x0a, 0x13, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x2d, 0x0a, 0x06, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x73, 0x68,
+	0x6f, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x52, 0x06, 0x63, 0x6f,
+	0x66, 0x66, 0x65, 0x65, 0x22, 0x25, 0x0a, 0x13, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x43, 0x6f,
+	0x66, 0x66, 0x65, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x22, 0x16, 0x0a, 0x14, 0x44,
+	0x65, 0x6c, 0x65, 0x74, 0x65, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x32, 0x99, 0x03, 0x0a, 0x0d, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x54, 0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x66,
+	0x66, 0x65, 0x65, 0x73, 0x12, 0x21, 0x2e, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x73, 0x68, 0x6f,
+	0x70, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65,
+	0x73, 0x68, 0x6f, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x66, 0x66,
+	0x65, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x09, 0x47,
+	0x65, 0x74, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x12, 0x1f, 0x2e, 0x63, 0x6f, 0x66, 0x66, 0x65,
+	0x65, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x66, 0x66,
+	0x65, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x63, 0x6f, 0x66, 0x66,
+	0x65, 0x65, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65,
+	0x12, 0x49, 0x0a, 0x0c, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65,
+	0x12, 0x22, 0x2e, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x76, 0x31,
+	0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x73, 0x68, 0x6f,
+	0x70, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x12, 0x49, 0x0a, 0x0c, 0x55,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x12, 0x22, 0x2e, 0x63, 0x6f,
+	0x66, 0x66, 0x65, 0x65, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x15, 0x2e, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x76, 0x31, 0x2e,
+	0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x12, 0x57, 0x0a, 0x0c, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x12, 0x22, 0x2e, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x73,
+	0x68, 0x6f, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x43, 0x6f, 0x66,
+	0x66, 0x65, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23, 0x2e, 0x63, 0x6f, 0x66,
+	0x66, 0x65, 0x65, 0x73, 0x68, 0x6f, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x43, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42,
+	0x14, 0x5a, 0x12, 0x63, 0x6f, 0x66, 0x66, 0x65, 0x65, 0x2d, 0x73, 0x68, 0x6f, 0x70, 0x2f, 0x61,
+	0x70, 0x69, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_coffees_proto_rawDescOnce sync.Once
+	file_coffees_proto_rawDescData = file_coffees_proto_rawDesc
+)
+
+func file_coffees_proto_rawDescGZIP() []byte {
+	file_coffees_proto_rawDescOnce.Do(func() {
+		file_coffees_proto_rawDescData = protoimpl.X.CompressGZIP(file_coffees_proto_rawDescData)
+	})
+	return file_coffees_proto_rawDescData
+}
+
+var file_coffees_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_coffees_proto_goTypes = []any{
+	(*Coffee)(nil),               // 0: coffeeshop.v1.Coffee
+	(*ListCoffeesRequest)(nil),   // 1: coffeeshop.v1.ListCoffeesRequest
+	(*ListCoffeesResponse)(nil),  // 2: coffeeshop.v1.ListCoffeesResponse
+	(*GetCoffeeRequest)(nil),     // 3: coffeeshop.v1.GetCoffeeRequest
+	(*CreateCoffeeRequest)(nil),  // 4: coffeeshop.v1.CreateCoffeeRequest
+	(*UpdateCoffeeRequest)(nil),  // 5: coffeeshop.v1.UpdateCoffeeRequest
+	(*DeleteCoffeeRequest)(nil),  // 6: coffeeshop.v1.DeleteCoffeeRequest
+	(*DeleteCoffeeResponse)(nil), // 7: coffeeshop.v1.DeleteCoffeeResponse
+}
+var file_coffees_proto_depIdxs = []int32{
+	0, // 0: coffeeshop.v1.ListCoffeesResponse.coffees:type_name -> coffeeshop.v1.Coffee
+	0, // 1: coffeeshop.v1.CreateCoffeeRequest.coffee:type_name -> coffeeshop.v1.Coffee
+	0, // 2: coffeeshop.v1.UpdateCoffeeRequest.coffee:type_name -> coffeeshop.v1.Coffee
+	1, // 3: coffeeshop.v1.CoffeeService.ListCoffees:input_type -> coffeeshop.v1.ListCoffeesRequest
+	3, // 4: coffeeshop.v1.CoffeeService.GetCoffee:input_type -> coffeeshop.v1.GetCoffeeRequest
+	4, // 5: coffeeshop.v1.CoffeeService.CreateCoffee:input_type -> coffeeshop.v1.CreateCoffeeRequest
+	5, // 6: coffeeshop.v1.CoffeeService.UpdateCoffee:input_type -> coffeeshop.v1.UpdateCoffeeRequest
+	6, // 7: coffeeshop.v1.CoffeeService.DeleteCoffee:input_type -> coffeeshop.v1.DeleteCoffeeRequest
+	2, // 8: coffeeshop.v1.CoffeeService.ListCoffees:output_type -> coffeeshop.v1.ListCoffeesResponse
+	0, // 9: coffeeshop.v1.CoffeeService.GetCoffee:output_type -> coffeeshop.v1.Coffee
+	0, // 10: coffeeshop.v1.CoffeeService.CreateCoffee:output_type -> coffeeshop.v1.Coffee
+	0, // 11: coffeeshop.v1.CoffeeService.UpdateCoffee:output_type -> coffeeshop.v1.Coffee
+	7, // 12: coffeeshop.v1.CoffeeService.DeleteCoffee:output_type -> coffeeshop.v1.DeleteCoffeeResponse
+	8, // [8:13] is the sub-list for method output_type
+	3, // [3:8] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_coffees_proto_init() }
+func file_coffees_proto_init() {
+	if File_coffees_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_coffees_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Coffee); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coffees_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*ListCoffeesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coffees_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*ListCoffeesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coffees_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*GetCoffeeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coffees_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*CreateCoffeeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coffees_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*UpdateCoffeeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coffees_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*DeleteCoffeeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coffees_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*DeleteCoffeeResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_coffees_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_coffees_proto_goTypes,
+		DependencyIndexes: file_coffees_proto_depIdxs,
+		MessageInfos:      file_coffees_proto_msgTypes,
+	}.Build()
+	File_coffees_proto = out.File
+	file_coffees_proto_rawDesc = nil
+	file_coffees_proto_goTypes = nil
+	file_coffees_proto_depIdxs = nil
+}
@@ -0,0 +1,291 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: coffees.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CoffeeService_ListCoffees_FullMethodName  = "/coffeeshop.v1.CoffeeService/ListCoffees"
+	CoffeeService_GetCoffee_FullMethodName    = "/coffeeshop.v1.CoffeeService/GetCoffee"
+	CoffeeService_CreateCoffee_FullMethodName = "/coffeeshop.v1.CoffeeService/CreateCoffee"
+	CoffeeService_UpdateCoffee_FullMethodName = "/coffeeshop.v1.CoffeeService/UpdateCoffee"
+	CoffeeService_DeleteCoffee_FullMethodName = "/coffeeshop.v1.CoffeeService/DeleteCoffee"
+)
+
+// CoffeeServiceClient is the client API for CoffeeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CoffeeService manages the same coffees as the /coffees REST endpoints.
+// Reads need the read scope and writes the write scope, as on the REST API.
+type CoffeeServiceClient interface {
+	// ListCoffees returns one page of coffees, in menu order.
+	ListCoffees(ctx context.Context, in *ListCoffeesRequest, opts ...grpc.CallOption) (*ListCoffeesResponse, error)
+	// GetCoffee returns a coffee, or NOT_FOUND.
+	GetCoffee(ctx context.Context, in *GetCoffeeRequest, opts ...grpc.CallOption) (*Coffee, error)
+	// CreateCoffee adds a coffee. A coffee without an id is assigned one; an
+	// id that is already taken is rejected with ALREADY_EXISTS.
+	CreateCoffee(ctx context.Context, in *CreateCoffeeRequest, opts ...grpc.CallOption) (*Coffee, error)
+	// UpdateCoffee replaces a coffee; the id of the request takes precedence.
+	UpdateCoffee(ctx context.Context, in *UpdateCoffeeRequest, opts ...grpc.CallOption) (*Coffee, error)
+	// DeleteCoffee removes a coffee.
+	DeleteCoffee(ctx context.Context, in *DeleteCoffeeRequest, opts ...grpc.CallOption) (*DeleteCoffeeResponse, error)
+}
+
+type coffeeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCoffeeServiceClient(cc grpc.ClientConnInterface) CoffeeServiceClient {
+	return &coffeeServiceClient{cc}
+}
+
+func (c *coffeeServiceClient) ListCoffees(ctx context.Context, in *ListCoffeesRequest, opts ...grpc.CallOption) (*ListCoffeesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCoffeesResponse)
+	err := c.cc.Invoke(ctx, CoffeeService_ListCoffees_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coffeeServiceClient) GetCoffee(ctx context.Context, in *GetCoffeeRequest, opts ...grpc.CallOption) (*Coffee, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Coffee)
+	err := c.cc.Invoke(ctx, CoffeeService_GetCoffee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coffeeServiceClient) CreateCoffee(ctx context.Context, in *CreateCoffeeRequest, opts ...grpc.CallOption) (*Coffee, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Coffee)
+	err := c.cc.Invoke(ctx, CoffeeService_CreateCoffee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coffeeServiceClient) UpdateCoffee(ctx context.Context, in *UpdateCoffeeRequest, opts ...grpc.CallOption) (*Coffee, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Coffee)
+	err := c.cc.Invoke(ctx, CoffeeService_UpdateCoffee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coffeeServiceClient) DeleteCoffee(ctx context.Context, in *DeleteCoffeeRequest, opts ...grpc.CallOption) (*DeleteCoffeeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteCoffeeResponse)
+	err := c.cc.Invoke(ctx, CoffeeService_DeleteCoffee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CoffeeServiceServer is the server API for CoffeeService service.
+// All implementations must embed UnimplementedCoffeeServiceServer
+// for forward compatibility.
+//
+// CoffeeService manages the same coffees as the /coffees REST endpoints.
+// Reads need the read scope and writes the write scope, as on the REST API.
+type CoffeeServiceServer interface {
+	// ListCoffees returns one page of coffees, in menu order.
+	ListCoffees(context.Context, *ListCoffeesRequest) (*ListCoffeesResponse, error)
+	// GetCoffee returns a coffee, or NOT_FOUND.
+	GetCoffee(context.Context, *GetCoffeeRequest) (*Coffee, error)
+	// CreateCoffee adds a coffee. A coffee without an id is assigned one; an
+	// id that is already taken is rejected with ALREADY_EXISTS.
+	CreateCoffee(context.Context, *CreateCoffeeRequest) (*Coffee, error)
+	// UpdateCoffee replaces a coffee; the id of the request takes precedence.
+	UpdateCoffee(context.Context, *UpdateCoffeeRequest) (*Coffee, error)
+	// DeleteCoffee removes a coffee.
+	DeleteCoffee(context.Context, *DeleteCoffeeRequest) (*DeleteCoffeeResponse, error)
+	mustEmbedUnimplementedCoffeeServiceServer()
+}
+
+// UnimplementedCoffeeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCoffeeServiceServer struct{}
+
+func (UnimplementedCoffeeServiceServer) ListCoffees(context.Context, *ListCoffeesRequest) (*ListCoffeesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCoffees not implemented")
+}
+func (UnimplementedCoffeeServiceServer) GetCoffee(context.Context, *GetCoffeeRequest) (*Coffee, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCoffee not implemented")
+}
+func (UnimplementedCoffeeServiceServer) CreateCoffee(context.Context, *CreateCoffeeRequest) (*Coffee, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCoffee not implemented")
+}
+func (UnimplementedCoffeeServiceServer) UpdateCoffee(context.Context, *UpdateCoffeeRequest) (*Coffee, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCoffee not implemented")
+}
+func (UnimplementedCoffeeServiceServer) DeleteCoffee(context.Context, *DeleteCoffeeRequest) (*DeleteCoffeeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCoffee not implemented")
+}
+func (UnimplementedCoffeeServiceServer) mustEmbedUnimplementedCoffeeServiceServer() {}
+func (UnimplementedCoffeeServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeCoffeeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CoffeeServiceServer will
+// result in compilation errors.
+type UnsafeCoffeeServiceServer interface {
+	mustEmbedUnimplementedCoffeeServiceServer()
+}
+
+func RegisterCoffeeServiceServer(s grpc.ServiceRegistrar, srv CoffeeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCoffeeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CoffeeService_ServiceDesc, srv)
+}
+
+func _CoffeeService_ListCoffees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCoffeesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoffeeServiceServer).ListCoffees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CoffeeService_ListCoffees_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoffeeServiceServer).ListCoffees(ctx, req.(*ListCoffeesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoffeeService_GetCoffee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCoffeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoffeeServiceServer).GetCoffee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CoffeeService_GetCoffee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoffeeServiceServer).GetCoffee(ctx, req.(*GetCoffeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoffeeService_CreateCoffee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCoffeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoffeeServiceServer).CreateCoffee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CoffeeService_CreateCoffee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoffeeServiceServer).CreateCoffee(ctx, req.(*CreateCoffeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoffeeService_UpdateCoffee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCoffeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoffeeServiceServer).UpdateCoffee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CoffeeService_UpdateCoffee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoffeeServiceServer).UpdateCoffee(ctx, req.(*UpdateCoffeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoffeeService_DeleteCoffee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCoffeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoffeeServiceServer).DeleteCoffee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CoffeeService_DeleteCoffee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoffeeServiceServer).DeleteCoffee(ctx, req.(*DeleteCoffeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CoffeeService_ServiceDesc is the grpc.ServiceDesc for CoffeeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CoffeeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "coffeeshop.v1.CoffeeService",
+	HandlerType: (*CoffeeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListCoffees",
+			Handler:    _CoffeeService_ListCoffees_Handler,
+		},
+		{
+			MethodName: "GetCoffee",
+			Handler:    _CoffeeService_GetCoffee_Handler,
+		},
+		{
+			MethodName: "CreateCoffee",
+			Handler:    _CoffeeService_CreateCoffee_Handler,
+		},
+		{
+			MethodName: "UpdateCoffee",
+			Handler:    _CoffeeService_UpdateCoffee_Handler,
+		},
+		{
+			MethodName: "DeleteCoffee",
+			Handler:    _CoffeeService_DeleteCoffee_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "coffees.proto",
+}
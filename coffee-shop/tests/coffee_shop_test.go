@@ -0,0 +1,1005 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"coffee-shop/api"
+	"coffee-shop/api/pb"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newRouter returns the service router over a fresh in-memory store.
+func newRouter(t *testing.T) (*gin.Engine, api.Store) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store := api.NewMemoryStore(api.DefaultCoffees())
+	return api.NewRouter(store, api.Config{ValidateRequests: true}), store
+}
+
+func do(r http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &v), w.Body.String())
+	return v
+}
+
+func TestGetCoffees(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "GET", "/coffees", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Espresso")
+	assert.Contains(t, w.Body.String(), "Americano")
+	assert.Contains(t, w.Body.String(), "Latte")
+	assert.Equal(t, "15", w.Header().Get("X-Total-Count"))
+}
+
+func TestGetCoffee(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "GET", "/coffees/1", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, api.Coffee{ID: 1, Name: "Espresso", Price: 2.99}, decode[api.Coffee](t, w))
+}
+
+func TestGetNonExistentCoffee(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "GET", "/coffees/99", "")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Coffee not found")
+}
+
+func TestCreateCoffeeAssignsID(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "POST", "/coffees", `{"name":"Ristretto","price":3.19}`)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	created := decode[api.Coffee](t, w)
+	assert.Equal(t, api.Coffee{ID: 16, Name: "Ristretto", Price: 3.19}, created)
+
+	stored, _, err := store.Get(context.Background(), 16)
+	require.NoError(t, err)
+	assert.Equal(t, created, stored)
+}
+
+func TestCreateCoffeeWithTakenIDIsRejected(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "POST", "/coffees", `{"id":1,"name":"Double Espresso","price":3.49}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	coffees, err := store.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, coffees, 15)
+	assert.Equal(t, api.DefaultCoffees()[0], coffees[0], "replacing a record is left to PUT")
+
+	w = do(r, "POST", "/coffees", `{"id":100,"name":"Double Espresso","price":3.49}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, 100, decode[api.Coffee](t, w).ID)
+}
+
+func TestCreateCoffeeRejectsInvalidBody(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "POST", "/coffees", `{"price":3.19}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	n, err := store.Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 15, n)
+}
+
+func TestUpdateCoffee(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "PUT", "/coffees/3", `{"id":42,"name":"Latte","price":3.79}`)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.Coffee{ID: 3, Name: "Latte", Price: 3.79}, decode[api.Coffee](t, w))
+	stored, _, err := store.Get(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3.79, stored.Price)
+}
+
+func TestUpdateNonExistentCoffee(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "PUT", "/coffees/99", `{"name":"Ghost","price":1}`)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	_, _, err := store.Get(context.Background(), 99)
+	assert.ErrorIs(t, err, api.ErrNotFound)
+}
+
+func TestDeleteCoffee(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "DELETE", "/coffees/2", "")
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	_, _, err := store.Get(context.Background(), 2)
+	assert.ErrorIs(t, err, api.ErrNotFound)
+	assert.Equal(t, http.StatusNotFound, do(r, "GET", "/coffees/2", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(r, "DELETE", "/coffees/2", "").Code)
+}
+
+// failingStore is a Store whose reads fail.
+type failingStore struct {
+	api.Store
+}
+
+var errStorage = errors.New("storage unavailable")
+
+func (failingStore) List(context.Context) ([]api.Coffee, error) {
+	return nil, errStorage
+}
+
+func (failingStore) Get(context.Context, int) (api.Coffee, int64, error) {
+	return api.Coffee{}, 0, errStorage
+}
+
+func TestStoreErrorsAreReported(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(failingStore{}, api.Config{ValidateRequests: true})
+
+	for _, path := range []string{"/coffees", "/coffees/1"} {
+		w := do(r, "GET", path, "")
+		assert.Equal(t, http.StatusInternalServerError, w.Code, path)
+		assert.Contains(t, w.Body.String(), errStorage.Error(), path)
+	}
+}
+
+func TestReadinessFollowsLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	readiness := &api.Readiness{}
+	r := api.NewRouter(api.NewMemoryStore(nil), api.Config{Readiness: readiness})
+
+	assert.Equal(t, http.StatusServiceUnavailable, do(r, "GET", "/readyz", "").Code)
+	readiness.SetWarmedUp()
+	assert.Equal(t, http.StatusOK, do(r, "GET", "/readyz", "").Code)
+	readiness.SetDraining()
+	assert.Equal(t, http.StatusServiceUnavailable, do(r, "GET", "/readyz", "").Code)
+	assert.Equal(t, http.StatusOK, do(r, "GET", "/livez", "").Code)
+}
+
+func TestRequestsAreLogged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultCoffees()), api.Config{
+		Logger: api.NewLogger(&logs, "info", "json"),
+	})
+
+	req := httptest.NewRequest("GET", "/coffees/99", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "req-42", w.Header().Get("X-Request-ID"))
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record), logs.String())
+	assert.Equal(t, "request", record["msg"])
+	assert.Equal(t, "coffee-shop", record["service"])
+	assert.Equal(t, "req-42", record["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.Equal(t, "/coffees/:id", record["route"])
+	assert.Equal(t, float64(404), record["status"])
+	assert.Contains(t, record, "latency_ms")
+
+	// Requests without an X-Request-ID get a generated one.
+	w = do(r, "GET", "/coffees", "")
+	assert.Regexp(t, "^[0-9a-f]{32}$", w.Header().Get("X-Request-ID"))
+}
+
+// withAuth returns the service router requiring the given credentials.
+func withAuth(t *testing.T, auth api.Authenticator) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	return api.NewRouter(api.NewMemoryStore(api.DefaultCoffees()), api.Config{ValidateRequests: true, Auth: auth})
+}
+
+func doWithHeader(r http.Handler, method, path, body, header, value string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set(header, value)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeysNeedTheMethodScope(t *testing.T) {
+	r := withAuth(t, api.APIKeys{
+		"reader-key": {Subject: "reader", Scopes: []string{api.ScopeRead}},
+		"writer-key": {Subject: "writer", Scopes: []string{api.ScopeWrite}},
+	})
+	body := `{"name":"Ristretto","price":3.19}`
+
+	w := do(r, "GET", "/coffees", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusUnauthorized, doWithHeader(r, "GET", "/coffees", "", "X-API-Key", "wrong").Code)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/coffees", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "POST", "/coffees", body, "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "POST", "/admin/fixtures/generate", "", "X-API-Key", "reader-key").Code)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/coffees/1", "", "X-API-Key", "writer-key").Code)
+	assert.Equal(t, http.StatusCreated, doWithHeader(r, "POST", "/coffees", body, "X-API-Key", "writer-key").Code)
+
+	// Health checks and docs stay open.
+	for _, path := range []string{"/livez", "/openapi.json", "/docs"} {
+		assert.Equal(t, http.StatusOK, do(r, "GET", path, "").Code, path)
+	}
+}
+
+func TestAPIKeysFromEnv(t *testing.T) {
+	keys := filepath.Join(t.TempDir(), "api-keys")
+	require.NoError(t, os.WriteFile(keys, []byte("# ci keys\nreader-key read ci-reader\n\nwriter-key read write\n"), 0o600))
+	t.Setenv("AUTH_API_KEYS_FILE", keys)
+
+	auth, err := api.AuthFromEnv()
+	require.NoError(t, err)
+	r := withAuth(t, auth)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/coffees", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "DELETE", "/coffees/1", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/coffees/1", "", "X-API-Key", "writer-key").Code)
+
+	t.Setenv("AUTH_API_KEYS_FILE", "")
+	auth, err = api.AuthFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, auth, "no configuration leaves the API open")
+}
+
+func TestBearerTokensAreVerifiedAgainstJWKS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"EC","crv":"P-256","kid":"test","use":"sig","x":%q,"y":%q}]}`,
+		base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))))
+	auth, err := api.NewJWTAuth([]byte(jwks), "https://issuer.test", "coffee-shop")
+	require.NoError(t, err)
+	r := withAuth(t, auth)
+
+	sign := func(claims map[string]any) string {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","kid":"test","typ":"JWT"}`))
+		payload, err := json.Marshal(claims)
+		require.NoError(t, err)
+		input := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(input))
+		sr, ss, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		require.NoError(t, err)
+		sig := append(sr.FillBytes(make([]byte, 32)), ss.FillBytes(make([]byte, 32))...)
+		return "Bearer " + input + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+	claims := func(scope, aud string, exp time.Duration) map[string]any {
+		return map[string]any{
+			"sub":   "client-1",
+			"iss":   "https://issuer.test",
+			"aud":   aud,
+			"exp":   time.Now().Add(exp).Unix(),
+			"scope": scope,
+		}
+	}
+
+	reader := sign(claims("read", "coffee-shop", time.Hour))
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/coffees", "", "Authorization", reader).Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "DELETE", "/coffees/1", "", "Authorization", reader).Code)
+
+	writer := sign(claims("read write", "coffee-shop", time.Hour))
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/coffees/1", "", "Authorization", writer).Code)
+
+	for name, token := range map[string]string{
+		"expired":        sign(claims("read", "coffee-shop", -time.Hour)),
+		"other audience": sign(claims("read", "pet-store", time.Hour)),
+		"tampered":       reader[:len(reader)-4] + "AAAA",
+	} {
+		w := doWithHeader(r, "GET", "/coffees", "", "Authorization", token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+	}
+}
+
+func TestRateLimitPerClientIPAndCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultCoffees()), api.Config{
+		Auth:   api.APIKeys{"shared-key": {Subject: "load-test", Scopes: []string{api.ScopeRead}}},
+		Limits: api.Limits{RequestsPerSecond: 1, Burst: 2},
+	})
+	from := func(ip, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = ip + ":40000"
+		req.Header.Set("X-API-Key", "shared-key")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/coffees").Code)
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/coffees/1").Code)
+	w := from("10.0.0.1", "/coffees")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// Probes are never limited.
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/livez").Code)
+
+	// A new IP gets its own bucket, but the key it shares is spent.
+	assert.Equal(t, http.StatusTooManyRequests, from("10.0.0.2", "/coffees").Code)
+}
+
+func TestForwardedForIsOnlyTrustedFromProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(proxies ...string) http.Handler {
+		return api.NewRouter(api.NewMemoryStore(api.DefaultCoffees()), api.Config{
+			Limits: api.Limits{RequestsPerSecond: 1, Burst: 1, TrustedProxies: proxies},
+		})
+	}
+	get := func(r http.Handler, peer, forwardedFor string) int {
+		req := httptest.NewRequest("GET", "/coffees/1", nil)
+		req.RemoteAddr = peer + ":40000"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// By default a client cannot leave its bucket by naming another address.
+	r := newRouter()
+	assert.Equal(t, http.StatusOK, get(r, "203.0.113.7", "10.0.0.1"))
+	assert.Equal(t, http.StatusTooManyRequests, get(r, "203.0.113.7", "10.0.0.2"))
+
+	// Behind a trusted proxy, each forwarded client has its own bucket.
+	r = newRouter("192.0.2.0/24")
+	assert.Equal(t, http.StatusOK, get(r, "192.0.2.10", "10.0.0.1"))
+	assert.Equal(t, http.StatusOK, get(r, "192.0.2.10", "10.0.0.2"))
+	assert.Equal(t, http.StatusTooManyRequests, get(r, "192.0.2.10", "10.0.0.1"))
+}
+
+func TestLargeBodiesAreRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultCoffees()), api.Config{
+		ValidateRequests: true,
+		Limits:           api.Limits{MaxBodyBytes: 64},
+	})
+
+	w := do(r, "POST", "/coffees", `{"name":"`+strings.Repeat("x", 64)+`","price":3.19}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	req := httptest.NewRequest("POST", "/coffees", io.MultiReader(strings.NewReader(`{"name":"`+strings.Repeat("x", 64)), strings.NewReader(`","price":3.19}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, "bodies without a Content-Length are capped too")
+
+	assert.Equal(t, http.StatusCreated, do(r, "POST", "/coffees", `{"name":"Ristretto","price":3.19}`).Code)
+
+	w = do(r, "POST", "/admin/fixtures", `[{"name":"`+strings.Repeat("x", 64)+`","price":3.19}]`)
+	assert.Equal(t, http.StatusOK, w.Code, "fixture imports are not held to the cap: %s", w.Body.String())
+}
+
+func TestFixtureImportsAreValidated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Seed files never reach the OpenAPI validator, so the import has to
+	// check the records itself.
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultCoffees()), api.Config{})
+
+	w := do(r, "POST", "/admin/fixtures", `[{"name":"Ristretto","price":3.19},{"name":"","price":2.5}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "record 1")
+
+	w = doWithHeader(r, "POST", "/admin/fixtures", "name,price\nRistretto,3.19\nLungo,-2\n", "Content-Type", "text/csv")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "record 1")
+
+	stored := decode[[]api.Coffee](t, do(r, "GET", "/admin/fixtures", ""))
+	assert.Len(t, stored, len(api.DefaultCoffees()), "nothing is stored from a rejected import")
+}
+
+func TestConditionalRequestsUseETags(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "GET", "/coffees/3", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	tag := w.Header().Get("ETag")
+	require.NotEmpty(t, tag)
+
+	w = doWithHeader(r, "GET", "/coffees/3", "", "If-None-Match", tag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, tag, w.Header().Get("ETag"))
+
+	w = doWithHeader(r, "PUT", "/coffees/3", `{"name":"Latte","price":3.79}`, "If-Match", tag)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	updated := w.Header().Get("ETag")
+	assert.NotEqual(t, tag, updated)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/coffees/3", "", "If-None-Match", tag).Code)
+
+	// A client still holding the old version loses the race.
+	w = doWithHeader(r, "PUT", "/coffees/3", `{"name":"Mocha","price":4.19}`, "If-Match", tag)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, http.StatusPreconditionFailed, doWithHeader(r, "DELETE", "/coffees/3", "", "If-Match", tag).Code)
+	assert.Equal(t, http.StatusPreconditionFailed, doWithHeader(r, "DELETE", "/coffees/3", "", "If-Match", "W/"+updated).Code)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/coffees/3", "", "If-Match", tag+", "+updated).Code)
+	assert.Equal(t, http.StatusNotFound, doWithHeader(r, "DELETE", "/coffees/3", "", "If-Match", updated).Code)
+}
+
+func TestPatchCoffee(t *testing.T) {
+	r, store := newRouter(t)
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		return doWithHeader(r, "PATCH", "/coffees/1", body, "Content-Type", contentType)
+	}
+
+	w := patch("application/merge-patch+json", `{"price":3.19}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.Coffee{ID: 1, Name: "Espresso", Price: 3.19}, decode[api.Coffee](t, w))
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+
+	w = patch("application/json-patch+json", `[{"op":"test","path":"/name","value":"Espresso"},{"op":"replace","path":"/name","value":"Doppio"}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	stored, _, err := store.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, api.Coffee{ID: 1, Name: "Doppio", Price: 3.19}, stored)
+
+	// The patched coffee is validated, and nothing is stored when it fails.
+	assert.Equal(t, http.StatusUnprocessableEntity, patch("application/merge-patch+json", `{"name":null}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("application/merge-patch+json", `{"price":-1}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, patch("application/json-patch+json", `[{"op":"add","path":"/origin","value":"Kenya"}]`).Code)
+	assert.Equal(t, http.StatusConflict, patch("application/json-patch+json", `[{"op":"test","path":"/name","value":"Espresso"}]`).Code)
+	assert.Equal(t, http.StatusConflict, patch("application/json-patch+json", `[{"op":"remove","path":"/origin"}]`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("application/json-patch+json", `[{"op":"replace","path":"name","value":"Mocha"}]`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("application/json", `{"price":1}`).Code)
+	stored, _, err = store.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, api.Coffee{ID: 1, Name: "Doppio", Price: 3.19}, stored)
+
+	assert.Equal(t, http.StatusNotFound, doWithHeader(r, "PATCH", "/coffees/99", `{"price":1}`, "Content-Type", "application/merge-patch+json").Code)
+}
+
+func TestPatchCoffeeHonoursIfMatch(t *testing.T) {
+	r, _ := newRouter(t)
+	tag := do(r, "GET", "/coffees/1", "").Header().Get("ETag")
+	require.Equal(t, http.StatusOK, do(r, "PUT", "/coffees/1", `{"name":"Espresso","price":3.09}`).Code)
+
+	req := httptest.NewRequest("PATCH", "/coffees/1", strings.NewReader(`{"price":2.79}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", tag)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, 3.09, decode[api.Coffee](t, do(r, "GET", "/coffees/1", "")).Price)
+}
+
+// newEventsServer serves the router over HTTP, so that event streams can be
+// read while other requests change the coffees.
+func newEventsServer(t *testing.T, opts api.EventOptions) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultCoffees()), api.Config{ValidateRequests: true, Events: opts})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func send(t *testing.T, srv *httptest.Server, method, path, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// sseEvent is an event as read off a stream.
+type sseEvent struct {
+	id, name string
+	data     api.Event
+}
+
+// readEvents reads n events off a stream, skipping comments.
+func readEvents(t *testing.T, lines *bufio.Scanner, n int) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var ev sseEvent
+	for len(events) < n && lines.Scan() {
+		line := lines.Text()
+		switch {
+		case line == "":
+			if ev.name != "" {
+				events = append(events, ev)
+			}
+			ev = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev.data))
+		}
+	}
+	require.Len(t, events, n, "stream ended early: %v", lines.Err())
+	return events
+}
+
+func TestEventStream(t *testing.T) {
+	srv := newEventsServer(t, api.EventOptions{Heartbeat: 10 * time.Millisecond})
+
+	resp := send(t, srv, "GET", "/events?resource=coffees", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	lines := bufio.NewScanner(resp.Body)
+
+	require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/coffees", `{"name":"Ristretto","price":3.19}`).StatusCode)
+	require.Equal(t, http.StatusOK, send(t, srv, "PUT", "/coffees/1", `{"name":"Espresso","price":2.99}`).StatusCode)
+	require.Equal(t, http.StatusOK, send(t, srv, "DELETE", "/coffees/2", "").StatusCode)
+
+	// Heartbeats between events are skipped.
+	events := readEvents(t, lines, 3)
+	assert.Equal(t, []string{"created", "updated", "deleted"}, []string{events[0].name, events[1].name, events[2].name})
+	assert.Equal(t, []string{"1", "2", "3"}, []string{events[0].id, events[1].id, events[2].id})
+	assert.Equal(t, "coffees", events[0].data.Resource)
+	assert.Equal(t, "Ristretto", events[0].data.Data.(map[string]any)["name"])
+	assert.Equal(t, 1, events[1].data.ResourceID)
+	assert.Equal(t, 2.99, events[1].data.Data.(map[string]any)["price"])
+	assert.Nil(t, events[2].data.Data)
+
+	// A client that reconnects gets the events it missed, then the stream
+	// ends when it does not follow.
+	req, err := http.NewRequest("GET", srv.URL+"/events?follow=false", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err = srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resumed := readEvents(t, bufio.NewScanner(bytes.NewReader(body)), 2)
+	assert.Equal(t, []string{"2", "3"}, []string{resumed[0].id, resumed[1].id})
+}
+
+func TestEventStreamResetsWhenEventsWereDropped(t *testing.T) {
+	srv := newEventsServer(t, api.EventOptions{History: 2})
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/coffees", `{"name":"Ristretto","price":3.19}`).StatusCode)
+	}
+
+	body, err := io.ReadAll(send(t, srv, "GET", "/events?last_event_id=0&follow=false", "").Body)
+	require.NoError(t, err)
+	events := readEvents(t, bufio.NewScanner(bytes.NewReader(body)), 3)
+	assert.Equal(t, "reset", events[0].name, "the first event was dropped from the history")
+	assert.Equal(t, []string{"2", "3"}, []string{events[1].id, events[2].id})
+
+	// So are IDs from before a restart.
+	body, err = io.ReadAll(send(t, srv, "GET", "/events?last_event_id=99&follow=false", "").Body)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(body), "event: reset\n"), string(body))
+
+	body, err = io.ReadAll(send(t, srv, "GET", "/events?last_event_id=3&follow=false", "").Body)
+	require.NoError(t, err)
+	assert.Empty(t, string(body))
+}
+
+func TestWebhookDeliveriesAreSignedAndRetried(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+	var delivered []api.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		if err := api.VerifySignature("s3cret", r.Header.Get(api.SignatureHeader), body, time.Minute); err != nil {
+			t.Errorf("delivery not signed: %v", err)
+		}
+		if err := api.VerifySignature("other", r.Header.Get(api.SignatureHeader), body, time.Minute); err == nil {
+			t.Error("signature verified with the wrong secret")
+		}
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event api.Event
+		json.Unmarshal(body, &event)
+		if event.Type == api.EventDeleted {
+			// Not worth retrying.
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		assert.Equal(t, r.Header.Get("X-Event-ID"), strconv.FormatInt(event.ID, 10))
+		delivered = append(delivered, event)
+	}))
+	defer receiver.Close()
+
+	srv := newEventsServer(t, api.EventOptions{WebhookBackoff: time.Millisecond})
+	resp := send(t, srv, "POST", "/webhooks", fmt.Sprintf(`{"url":%q,"secret":"s3cret","resources":["coffees"]}`, receiver.URL))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var hook api.Webhook
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&hook))
+	assert.Equal(t, "s3cret", hook.Secret)
+
+	require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/coffees", `{"name":"Ristretto","price":3.19}`).StatusCode)
+	require.Equal(t, http.StatusOK, send(t, srv, "DELETE", "/coffees/2", "").StatusCode)
+
+	require.Eventually(t, func() bool {
+		var got api.Webhook
+		json.NewDecoder(send(t, srv, "GET", fmt.Sprintf("/webhooks/%d", hook.ID), "").Body).Decode(&got)
+		return got.Delivered == 1 && got.Failed == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, 3, attempts)
+	require.Len(t, delivered, 1)
+	assert.Equal(t, api.EventCreated, delivered[0].Type)
+	mu.Unlock()
+
+	var listed []api.Webhook
+	require.NoError(t, json.NewDecoder(send(t, srv, "GET", "/webhooks", "").Body).Decode(&listed))
+	require.Len(t, listed, 1)
+	assert.Empty(t, listed[0].Secret, "the secret was returned after the webhook was created")
+	assert.Contains(t, listed[0].LastError, "410")
+
+	require.Equal(t, http.StatusOK, send(t, srv, "DELETE", fmt.Sprintf("/webhooks/%d", hook.ID), "").StatusCode)
+	assert.Equal(t, http.StatusNotFound, send(t, srv, "GET", fmt.Sprintf("/webhooks/%d", hook.ID), "").StatusCode)
+	assert.Equal(t, http.StatusBadRequest, send(t, srv, "POST", "/webhooks", `{"url":"https://hooks.example.com","resources":["cats"]}`).StatusCode)
+}
+
+func TestVerifySignatureRejectsOldDeliveries(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	old := time.Now().Add(-time.Hour).Unix()
+	fmt.Fprintf(mac, "%d.", old)
+	mac.Write(body)
+	header := fmt.Sprintf("t=%d,v1=%x", old, mac.Sum(nil))
+
+	assert.NoError(t, api.VerifySignature("s3cret", header, body, 2*time.Hour))
+	assert.ErrorIs(t, api.VerifySignature("s3cret", header, body, time.Minute), api.ErrBadSignature)
+	assert.ErrorIs(t, api.VerifySignature("s3cret", header, []byte(`{"id":2}`), 2*time.Hour), api.ErrBadSignature)
+	assert.ErrorIs(t, api.VerifySignature("s3cret", "", body, time.Minute), api.ErrBadSignature)
+}
+
+// collect subscribes to pattern on bus and returns the messages received so
+// far, as subject and event.
+func collect(t *testing.T, bus api.Bus, pattern string) func() map[string]api.Event {
+	t.Helper()
+	var mu sync.Mutex
+	received := map[string]api.Event{}
+	unsubscribe, err := bus.Subscribe(pattern, func(subject string, data []byte) {
+		var event api.Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			t.Errorf("%s: %v", subject, err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		received[subject] = event
+	})
+	require.NoError(t, err)
+	t.Cleanup(unsubscribe)
+	return func() map[string]api.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return maps.Clone(received)
+	}
+}
+
+func TestChangesArePublishedOnTheBus(t *testing.T) {
+	bus := api.NewLocalBus()
+	defer bus.Close()
+	received := collect(t, bus, "coffee-shop.coffees.*")
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultCoffees()), api.Config{ValidateRequests: true, Bus: bus})
+
+	require.Equal(t, http.StatusOK, do(r, "PUT", "/coffees/1", `{"name":"Espresso","price":3.49}`).Code)
+	require.Equal(t, http.StatusOK, do(r, "DELETE", "/coffees/2", "").Code)
+
+	require.Eventually(t, func() bool { return len(received()) == 2 }, time.Second, 5*time.Millisecond)
+	updated := received()["coffee-shop.coffees.updated"]
+	assert.Equal(t, 1, updated.ResourceID)
+	assert.Equal(t, 3.49, updated.Data.(map[string]any)["price"])
+	assert.Equal(t, 2, received()["coffee-shop.coffees.deleted"].ResourceID)
+}
+
+func TestLocalBusMatchesWildcards(t *testing.T) {
+	bus := api.NewLocalBus()
+	all := collect(t, bus, "coffee-shop.>")
+	updates := collect(t, bus, "*.coffees.updated")
+	exact := collect(t, bus, "coffee-shop.coffees")
+
+	for _, subject := range []string{"coffee-shop.coffees.updated", "coffee-shop.coffees.deleted", "restaurant.menu.updated", "coffee-shop"} {
+		require.NoError(t, bus.Publish(subject, []byte(`{}`)))
+	}
+	// Close delivers the messages published before it.
+	require.NoError(t, bus.Close())
+
+	assert.ElementsMatch(t, []string{"coffee-shop.coffees.updated", "coffee-shop.coffees.deleted"}, slices.Collect(maps.Keys(all())))
+	assert.ElementsMatch(t, []string{"coffee-shop.coffees.updated"}, slices.Collect(maps.Keys(updates())))
+	assert.Empty(t, exact())
+	assert.Error(t, bus.Publish("coffee-shop.coffees.updated", nil))
+}
+
+// natsServer speaks just enough of the NATS protocol to relay messages
+// between the clients connected to it.
+type natsServer struct {
+	net.Listener
+	mu   sync.Mutex
+	subs map[string]map[string]net.Conn // sid, by subject, of each conn
+}
+
+func newNATSServer(t *testing.T) *natsServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &natsServer{Listener: l, subs: map[string]map[string]net.Conn{}}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *natsServer) serve(conn net.Conn) {
+	defer conn.Close()
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"version\":\"2.10.0\",\"proto\":1,\"max_payload\":1048576}\r\n")
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			s.write(conn, "PONG\r\n")
+		case "SUB":
+			s.mu.Lock()
+			if s.subs[fields[1]] == nil {
+				s.subs[fields[1]] = map[string]net.Conn{}
+			}
+			s.subs[fields[1]][fields[len(fields)-1]] = conn
+			s.mu.Unlock()
+		case "PUB":
+			n, _ := strconv.Atoi(fields[len(fields)-1])
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			s.mu.Lock()
+			for pattern, sids := range s.subs {
+				if pattern != fields[1] && !(strings.HasSuffix(pattern, ".>") && strings.HasPrefix(fields[1], strings.TrimSuffix(pattern, ">"))) {
+					continue
+				}
+				for sid, c := range sids {
+					fmt.Fprintf(c, "MSG %s %s %d\r\n%s", fields[1], sid, n, payload)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *natsServer) write(conn net.Conn, msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	io.WriteString(conn, msg)
+}
+
+func TestNATSBusPublishesThroughTheServer(t *testing.T) {
+	srv := newNATSServer(t)
+	url := "nats://" + srv.Addr().String()
+	subscriber, err := api.NewNATSBus(url)
+	require.NoError(t, err)
+	defer subscriber.Close()
+	received := collect(t, subscriber, "coffee-shop.>")
+	publisher, err := api.NewNATSBus(url)
+	require.NoError(t, err)
+
+	require.NoError(t, publisher.Publish("coffee-shop.coffees.created", []byte(`{"id":7,"resource_id":16}`)))
+	require.NoError(t, publisher.Close())
+
+	require.Eventually(t, func() bool { return len(received()) == 1 }, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 16, received()["coffee-shop.coffees.created"].ResourceID)
+}
+
+// newGRPCClient serves the gRPC server of NewServers over an in-memory
+// listener and returns a connection to it, with the router next to it.
+func newGRPCClient(t *testing.T, cfg api.Config) (*gin.Engine, *grpc.ClientConn) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r, srv := api.NewServers(api.NewMemoryStore(api.DefaultCoffees()), cfg)
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return r, conn
+}
+
+func TestGRPCCoffees(t *testing.T) {
+	r, conn := newGRPCClient(t, api.Config{})
+	client := pb.NewCoffeeServiceClient(conn)
+	ctx := context.Background()
+
+	list, err := client.ListCoffees(ctx, &pb.ListCoffeesRequest{Limit: 5, Offset: 10})
+	require.NoError(t, err)
+	assert.EqualValues(t, 15, list.Total)
+	require.Len(t, list.Coffees, 5)
+	assert.Equal(t, "Iced Coffee", list.Coffees[0].Name)
+
+	coffee, err := client.GetCoffee(ctx, &pb.GetCoffeeRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Espresso", coffee.Name)
+
+	created, err := client.CreateCoffee(ctx, &pb.CreateCoffeeRequest{Coffee: &pb.Coffee{Name: "Ristretto", Price: 3.19}})
+	require.NoError(t, err)
+	assert.EqualValues(t, 16, created.Id)
+	// Both APIs serve the same store.
+	assert.Equal(t, "Ristretto", decode[api.Coffee](t, do(r, "GET", "/coffees/16", "")).Name)
+
+	updated, err := client.UpdateCoffee(ctx, &pb.UpdateCoffeeRequest{Id: 16, Coffee: &pb.Coffee{Name: "Ristretto", Price: 3.29}})
+	require.NoError(t, err)
+	assert.Equal(t, 3.29, updated.Price)
+
+	_, err = client.DeleteCoffee(ctx, &pb.DeleteCoffeeRequest{Id: 16})
+	require.NoError(t, err)
+	_, err = client.GetCoffee(ctx, &pb.GetCoffeeRequest{Id: 16})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CreateCoffee(ctx, &pb.CreateCoffeeRequest{Coffee: &pb.Coffee{Price: 3.19}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.CreateCoffee(ctx, &pb.CreateCoffeeRequest{Coffee: &pb.Coffee{Name: "Ristretto", Price: -1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.ListCoffees(ctx, &pb.ListCoffeesRequest{Limit: 1001})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCHealthFollowsReadiness(t *testing.T) {
+	readiness := &api.Readiness{}
+	_, conn := newGRPCClient(t, api.Config{Readiness: readiness})
+	health := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("liveness"))
+
+	readiness.SetWarmedUp()
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("coffeeshop.v1.CoffeeService"))
+
+	readiness.SetDraining()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("liveness"))
+
+	_, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCReflectionListsTheServices(t *testing.T) {
+	_, conn := newGRPCClient(t, api.Config{})
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	var names []string
+	for _, s := range resp.GetListServicesResponse().Service {
+		names = append(names, s.Name)
+	}
+	assert.Contains(t, names, "coffeeshop.v1.CoffeeService")
+	assert.Contains(t, names, "grpc.health.v1.Health")
+}
+
+func TestGRPCCallsNeedTheMethodScope(t *testing.T) {
+	_, conn := newGRPCClient(t, api.Config{Auth: api.APIKeys{
+		"reader-key": {Subject: "reader", Scopes: []string{api.ScopeRead}},
+		"writer-key": {Subject: "writer", Scopes: []string{api.ScopeWrite}},
+	}})
+	client := pb.NewCoffeeServiceClient(conn)
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+	}
+	create := &pb.CreateCoffeeRequest{Coffee: &pb.Coffee{Name: "Ristretto", Price: 3.19}}
+
+	_, err := client.GetCoffee(context.Background(), &pb.GetCoffeeRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.GetCoffee(withKey("wrong"), &pb.GetCoffeeRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.GetCoffee(withKey("reader-key"), &pb.GetCoffeeRequest{Id: 1})
+	assert.NoError(t, err)
+	_, err = client.CreateCoffee(withKey("reader-key"), create)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.CreateCoffee(withKey("writer-key"), create)
+	assert.NoError(t, err)
+
+	// Health checks stay open.
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "liveness"})
+	assert.NoError(t, err)
+}
+
+func TestGRPCChangesArePublished(t *testing.T) {
+	r, conn := newGRPCClient(t, api.Config{})
+	_, err := pb.NewCoffeeServiceClient(conn).DeleteCoffee(context.Background(), &pb.DeleteCoffeeRequest{Id: 2})
+	require.NoError(t, err)
+
+	w := do(r, "GET", "/events?last_event_id=0&follow=false", "")
+	events := readEvents(t, bufio.NewScanner(w.Body), 1)
+	assert.Equal(t, "deleted", events[0].name)
+	assert.Equal(t, 2, events[0].data.ResourceID)
+}
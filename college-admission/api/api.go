@@ -0,0 +1,291 @@
+// Package api implements the HTTP API of the college admission service.
+// NewRouter builds the complete router on top of a Store, so tests and the
+// service binary run the same handlers.
+package api
+
+//go:generate go run -C ../../tools/openapi-gen . -dir ../../college-admission/api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+const (
+	serviceName    = "college-admission"
+	serviceVersion = "1.0.0"
+)
+
+// Config holds the router options that do not depend on the store.
+type Config struct {
+	// ValidateRequests rejects requests that do not match the OpenAPI spec.
+	ValidateRequests bool
+
+	// ValidateResponses logs responses that do not match the OpenAPI spec.
+	ValidateResponses bool
+
+	// Readiness is reported by /readyz and /health. A nil Readiness is
+	// replaced by one that is not yet warmed up.
+	Readiness *Readiness
+
+	// Logger receives one record per request. A nil Logger uses
+	// slog.Default.
+	Logger *slog.Logger
+
+	// Auth identifies the callers of the data and admin endpoints. Reads
+	// need the read scope and other methods the write scope. A nil Auth
+	// leaves the API open; health checks and docs are always open.
+	Auth Authenticator
+
+	// Limits rate limits the data and admin endpoints and caps request
+	// bodies. The zero value sets no limits.
+	Limits Limits
+
+	// History keeps the status changes of applications. A nil History
+	// keeps them in memory.
+	History HistoryStore
+
+	// Capacity limits the accepted applications per course. A nil
+	// Capacity leaves every course unlimited.
+	Capacity CourseCapacity
+
+	// Events configures /events and the webhooks.
+	Events EventOptions
+
+	// Bus also carries every event to the other services. A nil Bus
+	// publishes them on /events and to webhooks only.
+	Bus Bus
+}
+
+// ConfigFromEnv reads the router options from the environment: requests are
+// validated unless OPENAPI_VALIDATE_REQUESTS=false, responses are checked
+// when OPENAPI_VALIDATE_RESPONSES=true, LOG_LEVEL and LOG_FORMAT select
+// the logger, the limits are read as described by LimitsFromEnv, the
+// course capacities as described by CourseCapacityFromEnv and the change
+// feed as described by EventOptionsFromEnv.
+func ConfigFromEnv() Config {
+	return Config{
+		ValidateRequests:  envBool("OPENAPI_VALIDATE_REQUESTS", true),
+		ValidateResponses: envBool("OPENAPI_VALIDATE_RESPONSES", false),
+		Logger:            LoggerFromEnv(),
+		Limits:            LimitsFromEnv(),
+		Capacity:          CourseCapacityFromEnv(),
+		Events:            EventOptionsFromEnv(),
+	}
+}
+
+// handler serves the API from a store.
+type handler struct {
+	store    Store
+	history  HistoryStore
+	capacity CourseCapacity
+	checks   []namedCheck
+	now      func() time.Time
+
+	// decisions serializes writes that change statuses, so that two
+	// acceptances cannot both take the last seat of a course.
+	decisions sync.Mutex
+	events    *events
+}
+
+// NewRouter registers every route on a new engine, serving data from store.
+// It panics if the embedded OpenAPI spec is invalid, which the package tests
+// rule out, or if one of Limits.TrustedProxies is not an address or range.
+//
+// @title College Admission API
+// @version 1.0.0
+// @description This is a college admission service API
+// @contact.name API Support
+// @contact.url http://www.swagger.io/support
+// @contact.email support@swagger.io
+// @license.name Apache 2.0
+// @license.url http://www.apache.org/licenses/LICENSE-2.0.html
+// @host localhost:8080
+// @BasePath /
+// @schemes http
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.bearer BearerAuth
+// @bearerFormat JWT
+func NewRouter(store Store, cfg Config) *gin.Engine {
+	return newHandler(store, &cfg).router(cfg)
+}
+
+// NewServers returns the router and a gRPC server on top of the same
+// handler, so that both serve one store, and a change made through either is
+// published on the change feed of both.
+func NewServers(store Store, cfg Config) (*gin.Engine, *grpc.Server) {
+	h := newHandler(store, &cfg)
+	return h.router(cfg), newGRPCServer(h, cfg)
+}
+
+// newHandler fills in the defaults of cfg and returns a handler for store.
+func newHandler(store Store, cfg *Config) *handler {
+	if cfg.Readiness == nil {
+		cfg.Readiness = &Readiness{}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	if cfg.History == nil {
+		cfg.History = NewHistoryStore(nil)
+	}
+	return &handler{
+		store:    store,
+		history:  cfg.History,
+		capacity: cfg.Capacity,
+		checks:   readinessChecks(cfg.Readiness, store),
+		now:      time.Now,
+		events:   newEvents(cfg.Events, cfg.Bus, cfg.Readiness.stopping()),
+	}
+}
+
+// router registers every route of h on a new engine.
+func (h *handler) router(cfg Config) *gin.Engine {
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Limits.TrustedProxies); err != nil {
+		panic(fmt.Sprintf("setting trusted proxies: %v", err))
+	}
+	r.Use(requestLogger(cfg.Logger), gin.Recovery(), limitBody(cfg.Limits.MaxBodyBytes))
+	// Credentials are checked before requests are validated, so that callers
+	// without them learn nothing about the API. Clients are rate limited by
+	// IP before that and by caller after it; probes and docs are not.
+	public := r.Group("/")
+	api := r.Group("/", rateLimit(cfg.Limits, clientIP), authorize(cfg.Auth), rateLimit(cfg.Limits, callerOf))
+	if cfg.ValidateRequests {
+		doc, err := loadOpenAPISpec()
+		if err != nil {
+			panic(fmt.Sprintf("loading OpenAPI spec: %v", err))
+		}
+		validator, err := validateOpenAPI(doc, cfg.ValidateResponses)
+		if err != nil {
+			panic(fmt.Sprintf("building OpenAPI validator: %v", err))
+		}
+		public.Use(validator)
+		api.Use(validator)
+	}
+
+	// Health check endpoints
+	public.GET("/health", h.healthCheck)
+	public.GET("/livez", h.livenessCheck)
+	public.GET("/readyz", h.readinessCheck)
+
+	// OpenAPI documentation endpoints
+	public.GET("/openapi.json", getOpenAPISpec)
+	public.GET("/docs", serveDocs)
+
+	api.GET("/applications", h.getApplications)
+	api.GET("/applications/:id", h.getApplicationByID)
+	api.POST("/applications", h.createApplication)
+	api.DELETE("/applications/:id", h.deleteApplication)
+	api.PUT("/applications/:id", h.updateApplication)
+	api.PATCH("/applications/:id", h.patchApplication)
+	api.GET("/applications/:id/history", h.getApplicationHistory)
+
+	// Decisions
+	api.POST("/applications/:id/review", h.reviewApplication)
+	api.POST("/applications/:id/accept", h.acceptApplication)
+	api.POST("/applications/:id/reject", h.rejectApplication)
+	api.POST("/applications/:id/waitlist", h.waitlistApplication)
+	api.POST("/applications/:id/withdraw", h.withdrawApplication)
+
+	// Change feed
+	api.GET("/events", h.streamEvents)
+	api.GET("/webhooks", h.getWebhooks)
+	api.GET("/webhooks/:id", h.getWebhookByID)
+	api.POST("/webhooks", h.createWebhook)
+	api.DELETE("/webhooks/:id", h.deleteWebhook)
+
+	// Bulk data seeding
+	api.GET("/admin/fixtures", h.exportApplicationFixtures)
+	api.POST("/admin/fixtures", h.importApplicationFixtures)
+	api.POST("/admin/fixtures/generate", h.generateApplicationFixtures)
+
+	return r
+}
+
+// getOpenAPISpec returns the OpenAPI specification as JSON
+//
+// @Summary OpenAPI specification
+// @Description Returns the OpenAPI 3.0 description of this API
+// @Tags docs
+// @Success 200 {object} object "OpenAPI document"
+// @Router /openapi.json [get]
+func getOpenAPISpec(c *gin.Context) {
+	c.Header("Content-Type", "application/json")
+	c.String(http.StatusOK, openAPISpec)
+}
+
+// serveDocs serves the Swagger UI documentation page
+//
+// @Summary API documentation
+// @Description Swagger UI for this API
+// @Tags docs
+// @Produce html
+// @Success 200 {string} string "Swagger UI page"
+// @Router /docs [get]
+func serveDocs(c *gin.Context) {
+	html := `<!DOCTYPE html>
+<html>
+<head>
+  <title>College Admission API Documentation</title>
+  <link rel="stylesheet" type="text/css" href="https://unpkg.com/swagger-ui-dist@3.52.5/swagger-ui.css" />
+  <style>
+    html {
+      box-sizing: border-box;
+      overflow: -moz-scrollbars-vertical;
+      overflow-y: scroll;
+    }
+    *, *:before, *:after {
+      box-sizing: inherit;
+    }
+    body {
+      margin:0;
+      background: #fafafa;
+    }
+  </style>
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@3.52.5/swagger-ui-bundle.js"></script>
+  <script src="https://unpkg.com/swagger-ui-dist@3.52.5/swagger-ui-standalone-preset.js"></script>
+  <script>
+    window.onload = function() {
+      const ui = SwaggerUIBundle({
+        url: '/openapi.json',
+        dom_id: '#swagger-ui',
+        deepLinking: true,
+        presets: [
+          SwaggerUIBundle.presets.apis,
+          SwaggerUIStandalonePreset
+        ],
+        plugins: [
+          SwaggerUIBundle.plugins.DownloadUrl
+        ],
+        layout: "StandaloneLayout"
+      });
+    };
+  </script>
+</body>
+</html>`
+	c.Header("Content-Type", "text/html")
+	c.String(http.StatusOK, html)
+}
+
+// parseID reads the numeric id path parameter. The OpenAPI validator already
+// rejects other values unless it is disabled.
+func parseID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be an integer"})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,495 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Application is a student's application to a course.
+type Application struct {
+	ID        int    `json:"id" example:"1"`
+	FirstName string `json:"first_name" binding:"required" example:"John"`
+	LastName  string `json:"last_name" binding:"required" example:"Doe"`
+	Age       int    `json:"age" binding:"min=0" minimum:"0" example:"18"`
+	Course    string `json:"course" example:"Computer Science"`
+
+	// Status is set by the decision endpoints; see transitions.
+	Status ApplicationStatus `json:"status" readonly:"true" enums:"submitted,under_review,accepted,rejected,waitlisted,withdrawn" example:"submitted"`
+}
+
+// ApplicationPatch documents the JSON Merge Patch for an application: the
+// members it has replace those of the application, and null removes them.
+type ApplicationPatch struct {
+	FirstName string `json:"first_name,omitempty" nullable:"true" example:"John"`
+	LastName  string `json:"last_name,omitempty" nullable:"true" example:"Doe"`
+	Age       int    `json:"age,omitempty" nullable:"true" minimum:"0" example:"18"`
+	Course    string `json:"course,omitempty" nullable:"true" example:"Computer Science"`
+}
+
+// DefaultApplications returns the built-in applications the service starts with.
+func DefaultApplications() []Application {
+	return []Application{
+		{1, "John", "Doe", 18, "Computer Science", StatusSubmitted},
+		{2, "Jane", "Smith", 19, "Mechanical Engineering", StatusSubmitted},
+		{3, "Bob", "Brown", 17, "Civil Engineering", StatusSubmitted},
+		{4, "Alice", "Johnson", 20, "Electrical Engineering", StatusSubmitted},
+		{5, "Charlie", "Davis", 21, "Business Administration", StatusSubmitted},
+		{6, "David", "Wilson", 22, "Mathematics", StatusSubmitted},
+		{7, "Eve", "Clark", 18, "Physics", StatusSubmitted},
+		{8, "Frank", "Moore", 19, "Chemistry", StatusSubmitted},
+		{9, "Grace", "Taylor", 17, "Biology", StatusSubmitted},
+		{10, "Henry", "Anderson", 20, "Psychology", StatusSubmitted},
+		{11, "Ivy", "Thomas", 21, "Philosophy", StatusSubmitted},
+		{12, "Jack", "Jackson", 22, "Sociology", StatusSubmitted},
+		{13, "Kathy", "White", 18, "History", StatusSubmitted},
+		{14, "Leo", "Harris", 19, "Political Science", StatusSubmitted},
+		{15, "Mia", "Martin", 17, "Art", StatusSubmitted},
+	}
+}
+
+// Store persists the applications served by the API. Get, Update and Delete
+// return ErrNotFound for unknown IDs.
+type Store interface {
+	// List returns every application in insertion order.
+	List(ctx context.Context) ([]Application, error)
+
+	// Get returns a application with its version, which changes whenever the
+	// application does.
+	Get(ctx context.Context, id int) (Application, int64, error)
+
+	// Create stores an application, assigning an ID when it has none, and
+	// returns it as stored. It returns ErrConflict when the ID is taken.
+	Create(ctx context.Context, application Application) (Application, error)
+
+	// Update replaces the application with the given ID and returns it as stored
+	// with its new version. Update and Delete return ErrVersionMismatch
+	// unless ifVersion is anyVersion or the current version.
+	Update(ctx context.Context, id int, application Application, ifVersion int64) (Application, int64, error)
+	Delete(ctx context.Context, id int, ifVersion int64) error
+
+	// Count, Import and Export back the admin fixture endpoints, as
+	// described by fixtureSet.
+	Count(ctx context.Context) (int, error)
+	Import(ctx context.Context, applications []Application, replace bool) (int, error)
+	Export(ctx context.Context) ([]Application, error)
+}
+
+// NewMemoryStore returns a Store that keeps applications in memory, starting
+// with a copy of applications.
+func NewMemoryStore(applications []Application) Store {
+	return newMemoryStore(applications, func(a *Application) *int { return &a.ID })
+}
+
+// LoadSeedFixtures applies the start-up dataset configured through the
+// environment to store.
+func LoadSeedFixtures(ctx context.Context, store Store) error {
+	return loadSeedFixtures(ctx, applicationFixtures{store})
+}
+
+// applicationSortFields are the fields GET /applications can be sorted by.
+var applicationSortFields = map[string]comparator[Application]{
+	"id":         func(a, b Application) int { return cmp.Compare(a.ID, b.ID) },
+	"first_name": func(a, b Application) int { return strings.Compare(a.FirstName, b.FirstName) },
+	"last_name":  func(a, b Application) int { return strings.Compare(a.LastName, b.LastName) },
+	"age":        func(a, b Application) int { return cmp.Compare(a.Age, b.Age) },
+	"course":     func(a, b Application) int { return strings.Compare(a.Course, b.Course) },
+	"status":     func(a, b Application) int { return strings.Compare(string(statusOf(a)), string(statusOf(b))) },
+}
+
+// getApplications lists applications matching the filters, one page at a
+// time.
+//
+// @Summary Get all applications
+// @Description Returns a list of all applications
+// @Tags applications
+// @Param name query string false "Case-insensitive substring of the applicant's full name"
+// @Param course query string false "Exact course, case-insensitive"
+// @Param status query string false "Status" Enums(submitted,under_review,accepted,rejected,waitlisted,withdrawn)
+// @Param minAge query integer false "Minimum age (inclusive)"
+// @Param maxAge query integer false "Maximum age (inclusive)"
+// @Param sort query string false "Field to sort by; prefix with '-' for descending order" Enums(age,course,first_name,id,last_name,status,-age,-course,-first_name,-id,-last_name,-status)
+// @Param limit query integer false "Maximum number of items to return" default(100) minimum(1) maximum(1000)
+// @Param offset query integer false "Number of items to skip" default(0) minimum(0)
+// @Success 200 {array} Application "List of applications"
+// @Header 200 {integer} X-Total-Count "Total number of items matching the filters"
+// @Header 200 {string} Link "RFC 8288 links to the first, prev, next and last pages"
+// @Failure 400 {object} map[string]string "Invalid query parameter"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /applications [get]
+func (h *handler) getApplications(c *gin.Context) {
+	q, err := parseListQuery(c, sortFieldNames(applicationSortFields))
+	minAge, minErr := queryInt(c, "minAge")
+	maxAge, maxErr := queryInt(c, "maxAge")
+	if err := errors.Join(err, minErr, maxErr); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.ToLower(c.Query("name"))
+	course := c.Query("course")
+	status := ApplicationStatus(c.Query("status"))
+
+	applications, err := h.store.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	matches := make([]Application, 0, len(applications))
+	for _, app := range applications {
+		fullName := strings.ToLower(app.FirstName + " " + app.LastName)
+		if name != "" && !strings.Contains(fullName, name) {
+			continue
+		}
+		if course != "" && !strings.EqualFold(app.Course, course) {
+			continue
+		}
+		if status != "" && statusOf(app) != status {
+			continue
+		}
+		if minAge != nil && app.Age < *minAge {
+			continue
+		}
+		if maxAge != nil && app.Age > *maxAge {
+			continue
+		}
+		matches = append(matches, app)
+	}
+	c.JSON(http.StatusOK, sortAndPage(c, matches, q, applicationSortFields))
+}
+
+// getApplicationByID returns a single application.
+//
+// @Summary Get application by ID
+// @Description Returns a single application
+// @Tags applications
+// @Param id path integer true "Application ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} Application "Application details"
+// @Success 304 "Cached copy is current"
+// @Header 200,304 {string} ETag "Version of the application"
+// @Failure 404 {object} map[string]string "Application not found"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /applications/{id} [get]
+func (h *handler) getApplicationByID(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	application, version, err := h.store.Get(c.Request.Context(), id)
+	if err != nil {
+		applicationError(c, err)
+		return
+	}
+	if notModified(c, version) {
+		return
+	}
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, application)
+}
+
+// createApplication submits an application.
+//
+// @Summary Create a new application
+// @Description Add a new application, which starts out submitted. An application without an id is assigned one; an id that is already taken is rejected; replace a record with PUT.
+// @Tags applications
+// @Accept json
+// @Param application body Application true "Application to add"
+// @Success 201 {object} Application "Application created successfully"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 409 {object} map[string]string "Application already exists"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /applications [post]
+func (h *handler) createApplication(c *gin.Context) {
+	var newApp Application
+	if err := c.ShouldBindJSON(&newApp); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	created, err := h.submit(c.Request.Context(), newApp)
+	if err != nil {
+		applicationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// submit stores app as a new submitted application and publishes it. An
+// app whose ID is taken gets ErrConflict: only the decision endpoints move an
+// existing application, and rejected and withdrawn ones are final.
+func (h *handler) submit(ctx context.Context, app Application) (Application, error) {
+	app.Status = StatusSubmitted
+
+	h.decisions.Lock()
+	defer h.decisions.Unlock()
+	created, err := h.store.Create(ctx, app)
+	if err == nil {
+		err = h.recordChange(ctx, created.ID, "", StatusSubmitted, "")
+	}
+	if err != nil {
+		return created, err
+	}
+	h.events.publish(EventCreated, "applications", created.ID, created)
+	return created, nil
+}
+
+// deleteApplication withdraws an application.
+//
+// @Summary Delete a application
+// @Description Remove a application. With If-Match, only the given version is removed. The seat of an accepted application goes to the applicant waitlisted longest for its course.
+// @Tags applications
+// @Param id path integer true "Application ID"
+// @Param If-Match header string false "ETag the application must have"
+// @Success 200 {object} map[string]string "Application deleted"
+// @Failure 404 {object} map[string]string "Application not found"
+// @Failure 412 {object} map[string]string "Application has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /applications/{id} [delete]
+func (h *handler) deleteApplication(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c, h.currentVersion(c, id))
+	if !ok {
+		return
+	}
+	if err := h.withdraw(c.Request.Context(), id, ifVersion); err != nil {
+		applicationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Application deleted"})
+}
+
+// withdraw deletes application id and publishes that. Its seat, if it was
+// accepted, goes to the waiting list.
+func (h *handler) withdraw(ctx context.Context, id int, ifVersion int64) error {
+	h.decisions.Lock()
+	defer h.decisions.Unlock()
+	app, _, err := h.store.Get(ctx, id)
+	if err == nil {
+		err = h.store.Delete(ctx, id, ifVersion)
+	}
+	if err == nil && statusOf(app) == StatusAccepted {
+		err = h.fillSeats(ctx, app.Course)
+	}
+	if err != nil {
+		return err
+	}
+	h.events.publish(EventDeleted, "applications", id, nil)
+	return nil
+}
+
+// updateApplication replaces an application.
+//
+// @Summary Update a application
+// @Description Replace an existing application; the id in the path takes precedence over the body and the status is kept. Accepted and waitlisted applications cannot change course. With If-Match, only the given version is replaced.
+// @Tags applications
+// @Accept json
+// @Param id path integer true "Application ID"
+// @Param If-Match header string false "ETag the application must have"
+// @Param application body Application true "Updated application"
+// @Success 200 {object} Application "Application updated"
+// @Header 200 {string} ETag "New version of the application"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Application not found"
+// @Failure 409 {object} map[string]string "Course cannot change"
+// @Failure 412 {object} map[string]string "Application has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /applications/{id} [put]
+func (h *handler) updateApplication(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var updatedApp Application
+	if err := c.ShouldBindJSON(&updatedApp); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c, h.currentVersion(c, id))
+	if !ok {
+		return
+	}
+	updated, version, err := editableApplications{h.store}.Update(c.Request.Context(), id, updatedApp, ifVersion)
+	if err != nil {
+		applicationError(c, err)
+		return
+	}
+	h.events.publish(EventUpdated, "applications", id, updated)
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// patchApplication changes some fields of an application.
+//
+// @Summary Patch an application
+// @Description Change some fields of an application with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902). The patched application must be valid; the id in the path and the status take precedence, and accepted and waitlisted applications cannot change course. With If-Match, only the given version is patched.
+// @ID patchApplication
+// @Tags applications
+// @Accept merge-patch,json-patch
+// @Param id path integer true "Application ID"
+// @Param If-Match header string false "ETag the application must have"
+// @Param patch body ApplicationPatch true "Merge patch or JSON Patch to apply" accept(merge-patch)
+// @Param operations body []PatchOperation true "Operations to apply" accept(json-patch)
+// @Success 200 {object} Application "Application updated"
+// @Header 200 {string} ETag "New version of the application"
+// @Failure 400 {object} map[string]string "Malformed patch"
+// @Failure 404 {object} map[string]string "Application not found"
+// @Failure 409 {object} map[string]string "Patch does not apply to the application or course cannot change"
+// @Failure 412 {object} map[string]string "Application has changed"
+// @Failure 415 {object} map[string]string "Unsupported patch format"
+// @Failure 422 {object} map[string]string "Patched application is invalid"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /applications/{id} [patch]
+func (h *handler) patchApplication(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	updated, version, ok := patchRecord(c, editableApplications{h.store}, id, "Application", applicationError)
+	if !ok {
+		return
+	}
+	h.events.publish(EventUpdated, "applications", id, updated)
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// applicationError maps a store error to a response.
+func applicationError(c *gin.Context, err error) {
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		preconditionFailed(c)
+		return
+	}
+	if errors.Is(err, ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Application already exists"})
+		return
+	}
+	if errors.Is(err, ErrInvalidTransition) || errors.Is(err, ErrCourseFull) || errors.Is(err, ErrCourseLocked) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// currentVersion returns a lookup of the version of application id, for
+// ifMatchVersion.
+func (h *handler) currentVersion(c *gin.Context, id int) func() (int64, error) {
+	return func() (int64, error) {
+		_, version, err := h.store.Get(c.Request.Context(), id)
+		return version, err
+	}
+}
+
+// applicationFixtures exposes the store to the fixture endpoints.
+type applicationFixtures struct {
+	Store
+}
+
+// Import stores records without a status as submitted.
+func (f applicationFixtures) Import(ctx context.Context, items []Application, replace bool) (int, error) {
+	for i := range items {
+		items[i].Status = statusOf(items[i])
+	}
+	return f.Store.Import(ctx, items, replace)
+}
+
+var (
+	applicantFirstNames = []string{"John", "Jane", "Bob", "Alice", "Charlie", "David", "Eve", "Frank", "Grace", "Henry"}
+	applicantLastNames  = []string{"Doe", "Smith", "Brown", "Johnson", "Davis", "Wilson", "Clark", "Moore", "Taylor", "Anderson"}
+	courses             = []string{"Computer Science", "Mechanical Engineering", "Mathematics", "Physics", "Biology", "History", "Art"}
+)
+
+// Generate numbers the last names, since applicants have no single name
+// field to make unique.
+func (applicationFixtures) Generate(start, n int) []Application {
+	items := make([]Application, n)
+	for i := range items {
+		items[i] = Application{
+			FirstName: applicantFirstNames[rand.IntN(len(applicantFirstNames))],
+			LastName:  fmt.Sprintf("%s %d", applicantLastNames[rand.IntN(len(applicantLastNames))], start+i+1),
+			Age:       rand.IntN(14) + 17,
+			Course:    courses[rand.IntN(len(courses))],
+			Status:    StatusSubmitted,
+		}
+	}
+	return items
+}
+
+// exportApplicationFixtures writes every application as JSON or CSV.
+//
+// @Summary Export fixtures
+// @Description Export every record as JSON or CSV
+// @ID exportFixtures
+// @Tags admin
+// @Produce json,csv
+// @Param format query string false "Export format" Enums(json,csv) default(json)
+// @Success 200 {array} Application "All records"
+// @Failure 400 {object} map[string]string "Unsupported format"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /admin/fixtures [get]
+func (h *handler) exportApplicationFixtures(c *gin.Context) {
+	exportFixtures(c, applicationFixtures{h.store})
+}
+
+// importApplicationFixtures bulk-loads applications from the request body.
+//
+// @Summary Import fixtures
+// @Description Bulk-load records from a JSON array or CSV file. Records without an id are assigned one; records with an existing id overwrite it. Records are validated as on create and the first invalid one is rejected with its index. The body may be up to 64 MiB whatever the service's body limit.
+// @ID importFixtures
+// @Tags admin
+// @Accept json,csv
+// @Param mode query string false "append keeps existing records; replace removes them first" Enums(append,replace) default(append)
+// @Param applications body []Application true "Records to import; CSV files need a header row naming the JSON fields"
+// @Success 200 {object} fixtureResult "Records stored"
+// @Failure 400 {object} map[string]string "Invalid records or mode"
+// @Failure 415 {object} map[string]string "Unsupported content type"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /admin/fixtures [post]
+func (h *handler) importApplicationFixtures(c *gin.Context) {
+	importFixtures(c, applicationFixtures{h.store})
+}
+
+// generateApplicationFixtures stores synthetic applications.
+//
+// @Summary Generate fixtures
+// @Description Store count synthetic records
+// @ID generateFixtures
+// @Tags admin
+// @Param count query integer true "Number of records to generate" minimum(1) maximum(100000)
+// @Param mode query string false "append keeps existing records; replace removes them first" Enums(append,replace) default(append)
+// @Success 200 {object} fixtureResult "Records stored"
+// @Failure 400 {object} map[string]string "Invalid count or mode"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /admin/fixtures/generate [post]
+func (h *handler) generateApplicationFixtures(c *gin.Context) {
+	generateFixtures(c, applicationFixtures{h.store})
+}
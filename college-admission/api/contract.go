@@ -0,0 +1,440 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// missingID is used as a path ID that no fixture will ever have.
+const missingID = math.MaxInt32
+
+// contractCase is a request generated from one operation of the spec and the
+// statuses it may be answered with.
+type contractCase struct {
+	Operation string
+	Name      string
+
+	Method      string
+	Path        string
+	Query       url.Values
+	ContentType string
+	Body        []byte
+
+	// Expect lists the acceptable statuses. When empty, any status the
+	// operation documents other than 400 is accepted.
+	Expect []int
+
+	// Mutating is set for valid requests that change data. They only run
+	// when writes are allowed.
+	Mutating bool
+}
+
+// contractResult is the outcome of one contract case.
+type contractResult struct {
+	Case    contractCase
+	Status  int
+	Skipped bool
+	Err     error
+}
+
+func (r contractResult) String() string {
+	label := fmt.Sprintf("%s: %s", r.Case.Operation, r.Case.Name)
+	switch {
+	case r.Skipped:
+		return fmt.Sprintf("SKIP %s (changes data; rerun with -write)", label)
+	case r.Err != nil:
+		return fmt.Sprintf("FAIL %s: %v", label, r.Err)
+	}
+	return fmt.Sprintf("PASS %s (%d)", label, r.Status)
+}
+
+// runContract loads the specification served at base and checks the service
+// against every case generated from it. Valid requests that change data are
+// skipped unless write is set.
+func runContract(ctx context.Context, client *http.Client, base string, write bool) ([]contractResult, error) {
+	doc, err := fetchOpenAPISpec(ctx, client, base)
+	if err != nil {
+		return nil, err
+	}
+	router, err := newSpecRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []contractResult
+	for _, tc := range contractCases(doc) {
+		if tc.Mutating && !write {
+			results = append(results, contractResult{Case: tc, Skipped: true})
+			continue
+		}
+		status, err := checkContractCase(ctx, client, base, router, tc)
+		results = append(results, contractResult{Case: tc, Status: status, Err: err})
+	}
+	return results, nil
+}
+
+// fetchOpenAPISpec downloads and validates the specification of the service
+// at base.
+func fetchOpenAPISpec(ctx context.Context, client *http.Client, base string) (*openapi3.T, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/openapi.json", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET /openapi.json: %s", resp.Status)
+	}
+
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing /openapi.json: %w", err)
+	}
+	if err := doc.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("invalid /openapi.json: %w", err)
+	}
+	return doc, nil
+}
+
+// checkContractCase sends tc and checks the status and the response against
+// the specification. It returns the status received.
+func checkContractCase(ctx context.Context, client *http.Client, base string, router routers.Router, tc contractCase) (int, error) {
+	u := base + tc.Path
+	if len(tc.Query) > 0 {
+		u += "?" + tc.Query.Encode()
+	}
+	var body io.Reader
+	if tc.Body != nil {
+		body = bytes.NewReader(tc.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, tc.Method, u, body)
+	if err != nil {
+		return 0, err
+	}
+	if tc.ContentType != "" {
+		req.Header.Set("Content-Type", tc.ContentType)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	route, pathParams, err := router.FindRoute(req)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return resp.StatusCode, fmt.Errorf("credentials rejected, pass -api-key: %s", bytes.TrimSpace(data))
+	case http.StatusForbidden:
+		return resp.StatusCode, fmt.Errorf("the API key lacks the write scope: %s", bytes.TrimSpace(data))
+	case http.StatusTooManyRequests:
+		return resp.StatusCode, fmt.Errorf("rate limited, raise the service's limit for contract runs: %s", bytes.TrimSpace(data))
+	}
+	if len(tc.Expect) > 0 && !slices.Contains(tc.Expect, resp.StatusCode) {
+		return resp.StatusCode, fmt.Errorf("got %d, want %s: %s", resp.StatusCode, joinStatuses(tc.Expect), bytes.TrimSpace(data))
+	}
+	if len(tc.Expect) == 0 && resp.StatusCode == http.StatusBadRequest {
+		return resp.StatusCode, fmt.Errorf("valid request rejected: %s", bytes.TrimSpace(data))
+	}
+
+	err = openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Body:   io.NopCloser(bytes.NewReader(data)),
+		Options: &openapi3filter.Options{
+			MultiError:            true,
+			IncludeResponseStatus: true,
+		},
+	})
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("response %d does not match the spec: %s", resp.StatusCode, validationMessage(err))
+	}
+	return resp.StatusCode, nil
+}
+
+func joinStatuses(statuses []int) string {
+	s := make([]string, len(statuses))
+	for i, status := range statuses {
+		s[i] = strconv.Itoa(status)
+	}
+	return strings.Join(s, " or ")
+}
+
+// contractCases generates a valid request and the error cases for every
+// operation in doc, in path and method order. Deletes run last so that they
+// do not remove the records the other cases look up.
+func contractCases(doc *openapi3.T) []contractCase {
+	var cases, deletes []contractCase
+	paths := doc.Paths.Map()
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		operations := paths[path].Operations()
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			if method == http.MethodDelete {
+				deletes = append(deletes, operationCases(path, method, operations[method])...)
+				continue
+			}
+			cases = append(cases, operationCases(path, method, operations[method])...)
+		}
+	}
+	return append(cases, deletes...)
+}
+
+// operationCases generates the cases for a single operation: one valid
+// request, then requests that break one rule of the spec each and must be
+// rejected with 400, then a lookup of a missing ID if 404 is documented.
+func operationCases(path, method string, op *openapi3.Operation) []contractCase {
+	pathValues := map[string]string{}
+	query := url.Values{}
+	var pathParams, queryParams []*openapi3.Parameter
+	for _, ref := range op.Parameters {
+		p := ref.Value
+		switch p.In {
+		case openapi3.ParameterInPath:
+			pathValues[p.Name] = fmt.Sprint(sampleValue(p.Schema))
+			pathParams = append(pathParams, p)
+		case openapi3.ParameterInQuery:
+			query.Set(p.Name, fmt.Sprint(sampleValue(p.Schema)))
+			queryParams = append(queryParams, p)
+		}
+	}
+
+	var contentType string
+	var body []byte
+	var bodySchema *openapi3.SchemaRef
+	if op.RequestBody != nil {
+		// A merge patch built from the examples is a valid PATCH; a JSON
+		// Patch built from them is not.
+		content := op.RequestBody.Value.Content
+		contentType = slices.Sorted(maps.Keys(content))[0]
+		for _, preferred := range []string{"application/json", mergePatchType} {
+			if content.Get(preferred) != nil {
+				contentType = preferred
+				break
+			}
+		}
+		bodySchema = content.Get(contentType).Schema
+		body, _ = json.Marshal(sampleValue(bodySchema))
+	}
+
+	valid := contractCase{
+		Operation:   op.OperationID,
+		Name:        "valid request",
+		Method:      method,
+		Path:        expandPath(path, pathValues),
+		Query:       query,
+		ContentType: contentType,
+		Body:        body,
+		Mutating:    method != http.MethodGet && method != http.MethodHead,
+	}
+	cases := []contractCase{valid}
+	invalid := func(name string, change func(tc *contractCase)) {
+		tc := valid
+		tc.Name = name
+		tc.Query = cloneValues(query)
+		tc.Expect = []int{http.StatusBadRequest}
+		tc.Mutating = false
+		change(&tc)
+		cases = append(cases, tc)
+	}
+
+	for _, p := range pathParams {
+		if isNumeric(p.Schema) {
+			invalid("non-numeric path parameter "+p.Name, func(tc *contractCase) {
+				tc.Path = expandPath(path, withValue(pathValues, p.Name, "not-a-number"))
+			})
+		}
+	}
+	for _, p := range queryParams {
+		s := p.Schema.Value
+		if p.Required {
+			invalid("missing query parameter "+p.Name, func(tc *contractCase) { tc.Query.Del(p.Name) })
+		}
+		if len(s.Enum) > 0 {
+			invalid("query parameter "+p.Name+" outside the enum", func(tc *contractCase) { tc.Query.Set(p.Name, "not-in-enum") })
+		}
+		if isNumeric(p.Schema) {
+			invalid("non-numeric query parameter "+p.Name, func(tc *contractCase) { tc.Query.Set(p.Name, "not-a-number") })
+			if s.Min != nil {
+				invalid("query parameter "+p.Name+" below the minimum", func(tc *contractCase) { tc.Query.Set(p.Name, fmt.Sprint(*s.Min-1)) })
+			}
+			if s.Max != nil {
+				invalid("query parameter "+p.Name+" above the maximum", func(tc *contractCase) { tc.Query.Set(p.Name, fmt.Sprint(*s.Max+1)) })
+			}
+		}
+	}
+	if bodySchema != nil && strings.Contains(contentType, "json") {
+		if op.RequestBody.Value.Required {
+			invalid("missing body", func(tc *contractCase) { tc.Body = []byte{} })
+		}
+		invalid("body of the wrong type", func(tc *contractCase) { tc.Body = []byte(`"not-a-valid-body"`) })
+		if s := bodySchema.Value; s.Type.Is(openapi3.TypeObject) && len(s.Required) > 0 {
+			invalid("body without required properties", func(tc *contractCase) { tc.Body = []byte(`{}`) })
+		}
+	}
+
+	if op.Responses.Status(http.StatusNotFound) != nil && len(pathParams) == 1 && isNumeric(pathParams[0].Schema) {
+		tc := valid
+		tc.Name = "missing ID"
+		tc.Path = expandPath(path, withValue(pathValues, pathParams[0].Name, strconv.Itoa(missingID)))
+		tc.Expect = []int{http.StatusNotFound}
+		if op.Responses.Status(http.StatusServiceUnavailable) != nil {
+			tc.Expect = append(tc.Expect, http.StatusServiceUnavailable)
+		}
+		tc.Mutating = false
+		cases = append(cases, tc)
+	}
+	return cases
+}
+
+func expandPath(path string, values map[string]string) string {
+	for name, value := range values {
+		path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(value))
+	}
+	return path
+}
+
+func withValue(values map[string]string, key, value string) map[string]string {
+	out := maps.Clone(values)
+	out[key] = value
+	return out
+}
+
+func cloneValues(v url.Values) url.Values {
+	out := make(url.Values, len(v))
+	for k, vs := range v {
+		out[k] = slices.Clone(vs)
+	}
+	return out
+}
+
+func isNumeric(ref *openapi3.SchemaRef) bool {
+	return ref != nil && ref.Value != nil && (ref.Value.Type.Is(openapi3.TypeInteger) || ref.Value.Type.Is(openapi3.TypeNumber))
+}
+
+// sampleValue returns a value that satisfies the schema, preferring its
+// example, default and first enum value.
+func sampleValue(ref *openapi3.SchemaRef) any {
+	if ref == nil || ref.Value == nil {
+		return nil
+	}
+	s := ref.Value
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	}
+
+	switch {
+	case s.Type.Is(openapi3.TypeObject):
+		obj := map[string]any{}
+		for name, prop := range s.Properties {
+			// Servers reject read-only properties in requests.
+			if prop.Value != nil && prop.Value.ReadOnly {
+				continue
+			}
+			obj[name] = sampleValue(prop)
+		}
+		return obj
+	case s.Type.Is(openapi3.TypeArray):
+		return []any{sampleValue(s.Items)}
+	case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+		if s.Min != nil {
+			return *s.Min
+		}
+		return 1
+	case s.Type.Is(openapi3.TypeBoolean):
+		return true
+	case s.Type.Is(openapi3.TypeString):
+		if s.Format == "date-time" {
+			return time.Now().UTC().Format(time.RFC3339)
+		}
+		return "sample"
+	}
+	return nil
+}
+
+// RunContractCommand implements the contract subcommand, which checks a
+// running service against the specification it serves. -url takes the
+// endpoint from the ClusterTester status as is:
+//
+//	<binary> contract -url <service>.<namespace>.svc.cluster.local:8080
+func RunContractCommand(args []string) error {
+	fs := flag.NewFlagSet("contract", flag.ContinueOnError)
+	base := fs.String("url", "http://localhost:8080", "base URL or host:port of the service, e.g. the endpoint reported in the ClusterTester status")
+	write := fs.Bool("write", false, "also send valid requests that change data")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of each request")
+	verbose := fs.Bool("v", false, "print passing and skipped cases too")
+	apiKey := fs.String("api-key", os.Getenv("API_KEY"), "API key with the write scope, for services that require credentials")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	target := strings.TrimSuffix(*base, "/")
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+	client := newClient(*timeout, *apiKey)
+
+	results, err := runContract(context.Background(), client, target, *write)
+	if err != nil {
+		return err
+	}
+	var passed, failed, skipped int
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			skipped++
+		case r.Err != nil:
+			failed++
+		default:
+			passed++
+		}
+		if *verbose || r.Err != nil {
+			fmt.Fprintln(os.Stdout, r)
+		}
+	}
+	fmt.Fprintf(os.Stdout, "%s: %d passed, %d failed, %d skipped\n", target, passed, failed, skipped)
+	if failed > 0 {
+		return fmt.Errorf("%d contract case(s) failed", failed)
+	}
+	return nil
+}
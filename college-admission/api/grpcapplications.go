@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"college-admission/api/pb"
+)
+
+// registerGRPCServices registers the gRPC services of h on srv.
+func registerGRPCServices(srv *grpc.Server, h *handler) {
+	pb.RegisterApplicationServiceServer(srv, applicationService{h: h})
+}
+
+// applicationService serves the applications over gRPC, as the
+// /applications endpoints do.
+type applicationService struct {
+	pb.UnimplementedApplicationServiceServer
+	h *handler
+}
+
+func (s applicationService) ListApplications(ctx context.Context, req *pb.ListApplicationsRequest) (*pb.ListApplicationsResponse, error) {
+	applications, err := s.h.store.List(ctx)
+	if err != nil {
+		return nil, applicationRPCError(err)
+	}
+	page, err := rpcPage(applications, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ListApplicationsResponse{Total: int32(len(applications))}
+	for _, application := range page {
+		resp.Applications = append(resp.Applications, applicationToPB(application))
+	}
+	return resp, nil
+}
+
+func (s applicationService) GetApplication(ctx context.Context, req *pb.GetApplicationRequest) (*pb.Application, error) {
+	application, _, err := s.h.store.Get(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, applicationRPCError(err)
+	}
+	return applicationToPB(application), nil
+}
+
+func (s applicationService) CreateApplication(ctx context.Context, req *pb.CreateApplicationRequest) (*pb.Application, error) {
+	application, err := applicationFromPB(req.GetApplication())
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.h.submit(ctx, application)
+	if err != nil {
+		return nil, applicationRPCError(err)
+	}
+	return applicationToPB(created), nil
+}
+
+func (s applicationService) UpdateApplication(ctx context.Context, req *pb.UpdateApplicationRequest) (*pb.Application, error) {
+	application, err := applicationFromPB(req.GetApplication())
+	if err != nil {
+		return nil, err
+	}
+	id := int(req.GetId())
+	updated, _, err := editableApplications{s.h.store}.Update(ctx, id, application, anyVersion)
+	if err != nil {
+		return nil, applicationRPCError(err)
+	}
+	s.h.events.publish(EventUpdated, "applications", id, updated)
+	return applicationToPB(updated), nil
+}
+
+func (s applicationService) DeleteApplication(ctx context.Context, req *pb.DeleteApplicationRequest) (*pb.DeleteApplicationResponse, error) {
+	if err := s.h.withdraw(ctx, int(req.GetId()), anyVersion); err != nil {
+		return nil, applicationRPCError(err)
+	}
+	return &pb.DeleteApplicationResponse{}, nil
+}
+
+// applicationRPCError maps a store or lifecycle error to a gRPC status, as
+// applicationError does to a response.
+func applicationRPCError(err error) error {
+	if errors.Is(err, ErrInvalidTransition) || errors.Is(err, ErrCourseFull) || errors.Is(err, ErrCourseLocked) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return rpcError(err, "Application")
+}
+
+func applicationToPB(a Application) *pb.Application {
+	return &pb.Application{
+		Id:        int64(a.ID),
+		FirstName: a.FirstName,
+		LastName:  a.LastName,
+		Age:       int32(a.Age),
+		Course:    a.Course,
+		Status:    string(statusOf(a)),
+	}
+}
+
+// applicationFromPB converts and validates an application sent over gRPC.
+// The status is output only, so it is dropped.
+func applicationFromPB(a *pb.Application) (Application, error) {
+	application := Application{
+		ID:        int(a.GetId()),
+		FirstName: a.GetFirstName(),
+		LastName:  a.GetLastName(),
+		Age:       int(a.GetAge()),
+		Course:    a.GetCourse(),
+	}
+	if err := validateRPC(application); err != nil {
+		return application, err
+	}
+	if application.Age < 0 {
+		return application, status.Error(codes.InvalidArgument, "age must not be negative")
+	}
+	return application, nil
+}
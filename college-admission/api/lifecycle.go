@@ -0,0 +1,459 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ApplicationStatus is the stage of an application. New applications are
+// submitted; the decision endpoints move them on as allowed by transitions.
+type ApplicationStatus string
+
+const (
+	StatusSubmitted   ApplicationStatus = "submitted"
+	StatusUnderReview ApplicationStatus = "under_review"
+	StatusAccepted    ApplicationStatus = "accepted"
+	StatusRejected    ApplicationStatus = "rejected"
+	StatusWaitlisted  ApplicationStatus = "waitlisted"
+	StatusWithdrawn   ApplicationStatus = "withdrawn"
+)
+
+// transitions lists the statuses each status may change to. Rejected and
+// withdrawn applications are final.
+var transitions = map[ApplicationStatus][]ApplicationStatus{
+	StatusSubmitted:   {StatusUnderReview, StatusWithdrawn},
+	StatusUnderReview: {StatusAccepted, StatusRejected, StatusWaitlisted, StatusWithdrawn},
+	StatusWaitlisted:  {StatusAccepted, StatusRejected, StatusWithdrawn},
+	StatusAccepted:    {StatusWithdrawn},
+}
+
+// ErrInvalidTransition is returned when an application cannot move from its
+// status to the requested one.
+var ErrInvalidTransition = errors.New("invalid transition")
+
+// ErrCourseFull is returned when a waitlisted application is accepted into
+// a course that has no seats left.
+var ErrCourseFull = errors.New("course is full")
+
+// ErrCourseLocked is returned when an accepted or waitlisted application is
+// moved to another course, which would bypass the course's capacity.
+var ErrCourseLocked = errors.New("course cannot change once an application is accepted or waitlisted")
+
+// StatusChange is an entry of the audit history of an application.
+type StatusChange struct {
+	ID            int               `json:"id" example:"1"`
+	ApplicationID int               `json:"application_id" example:"1"`
+	From          ApplicationStatus `json:"from,omitempty" enums:"submitted,under_review,accepted,rejected,waitlisted,withdrawn" example:"under_review"`
+	To            ApplicationStatus `json:"to" enums:"submitted,under_review,accepted,rejected,waitlisted,withdrawn" example:"accepted"`
+	Reason        string            `json:"reason,omitempty" example:"Strong references"`
+	At            time.Time         `json:"at" format:"date-time"`
+}
+
+// HistoryStore keeps the status changes of every application. Changes are
+// only ever added.
+type HistoryStore interface {
+	List(ctx context.Context) ([]StatusChange, error)
+	Create(ctx context.Context, change StatusChange) (StatusChange, error)
+}
+
+// NewHistoryStore returns a HistoryStore that keeps changes in memory,
+// starting with a copy of changes.
+func NewHistoryStore(changes []StatusChange) HistoryStore {
+	return newMemoryStore(changes, func(s *StatusChange) *int { return &s.ID })
+}
+
+// Decision is the optional body of the decision endpoints.
+type Decision struct {
+	// Reason is recorded in the history of the application.
+	Reason string `json:"reason,omitempty" example:"Strong references"`
+}
+
+// CourseCapacity limits the number of accepted applications per course.
+// Courses are matched case-insensitively; courses without an entry are
+// unlimited.
+type CourseCapacity map[string]int
+
+// CourseCapacityFromEnv reads COURSE_CAPACITY, a comma-separated list of
+// course=seats pairs such as "Computer Science=30,Physics=20". Invalid
+// entries are ignored.
+func CourseCapacityFromEnv() CourseCapacity {
+	capacity := CourseCapacity{}
+	for _, entry := range strings.Split(os.Getenv("COURSE_CAPACITY"), ",") {
+		course, seats, ok := strings.Cut(entry, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(seats))
+		if !ok || err != nil || n < 0 || strings.TrimSpace(course) == "" {
+			continue
+		}
+		capacity[strings.TrimSpace(course)] = n
+	}
+	return capacity
+}
+
+// seats returns the capacity of course and whether it is limited.
+func (c CourseCapacity) seats(course string) (int, bool) {
+	for name, n := range c {
+		if strings.EqualFold(name, course) {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// statusOf returns the status of app; records imported without one count as
+// submitted.
+func statusOf(app Application) ApplicationStatus {
+	return cmp.Or(app.Status, StatusSubmitted)
+}
+
+// describe returns status as prose for error messages.
+func (s ApplicationStatus) describe() string {
+	return strings.ReplaceAll(string(s), "_", " ")
+}
+
+// reviewApplication starts the review of an application.
+//
+// @Summary Review an application
+// @Description Move a submitted application to under_review.
+// @Tags applications
+// @Accept json
+// @Param id path integer true "Application ID"
+// @Param If-Match header string false "ETag the application must have"
+// @Param decision body Decision false "Reason for the history"
+// @Success 200 {object} Application "Application under review"
+// @Header 200 {string} ETag "New version of the application"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Application not found"
+// @Failure 409 {object} map[string]string "Transition not allowed"
+// @Failure 412 {object} map[string]string "Application has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /applications/{id}/review [post]
+func (h *handler) reviewApplication(c *gin.Context) {
+	h.decide(c, "review", StatusUnderReview)
+}
+
+// acceptApplication admits an applicant.
+//
+// @Summary Accept an application
+// @Description Accept an application under review or on the waitlist. When its course is at capacity, an application under review is waitlisted instead, and a waitlisted one gets 409.
+// @Tags applications
+// @Accept json
+// @Param id path integer true "Application ID"
+// @Param If-Match header string false "ETag the application must have"
+// @Param decision body Decision false "Reason for the history"
+// @Success 200 {object} Application "Application accepted or waitlisted"
+// @Header 200 {string} ETag "New version of the application"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Application not found"
+// @Failure 409 {object} map[string]string "Transition not allowed or course is full"
+// @Failure 412 {object} map[string]string "Application has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /applications/{id}/accept [post]
+func (h *handler) acceptApplication(c *gin.Context) {
+	h.decide(c, "accept", StatusAccepted)
+}
+
+// rejectApplication turns an applicant down.
+//
+// @Summary Reject an application
+// @Description Reject an application under review or on the waitlist.
+// @Tags applications
+// @Accept json
+// @Param id path integer true "Application ID"
+// @Param If-Match header string false "ETag the application must have"
+// @Param decision body Decision false "Reason for the history"
+// @Success 200 {object} Application "Application rejected"
+// @Header 200 {string} ETag "New version of the application"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Application not found"
+// @Failure 409 {object} map[string]string "Transition not allowed"
+// @Failure 412 {object} map[string]string "Application has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /applications/{id}/reject [post]
+func (h *handler) rejectApplication(c *gin.Context) {
+	h.decide(c, "reject", StatusRejected)
+}
+
+// waitlistApplication puts an applicant on the waitlist of their course.
+//
+// @Summary Waitlist an application
+// @Description Put an application under review on the waitlist of its course.
+// @Tags applications
+// @Accept json
+// @Param id path integer true "Application ID"
+// @Param If-Match header string false "ETag the application must have"
+// @Param decision body Decision false "Reason for the history"
+// @Success 200 {object} Application "Application waitlisted"
+// @Header 200 {string} ETag "New version of the application"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Application not found"
+// @Failure 409 {object} map[string]string "Transition not allowed"
+// @Failure 412 {object} map[string]string "Application has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /applications/{id}/waitlist [post]
+func (h *handler) waitlistApplication(c *gin.Context) {
+	h.decide(c, "waitlist", StatusWaitlisted)
+}
+
+// withdrawApplication withdraws an application at the applicant's request.
+//
+// @Summary Withdraw an application
+// @Description Withdraw an application that is not yet rejected. The seat of an accepted application goes to the applicant waitlisted longest for its course.
+// @Tags applications
+// @Accept json
+// @Param id path integer true "Application ID"
+// @Param If-Match header string false "ETag the application must have"
+// @Param decision body Decision false "Reason for the history"
+// @Success 200 {object} Application "Application withdrawn"
+// @Header 200 {string} ETag "New version of the application"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Application not found"
+// @Failure 409 {object} map[string]string "Transition not allowed"
+// @Failure 412 {object} map[string]string "Application has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /applications/{id}/withdraw [post]
+func (h *handler) withdrawApplication(c *gin.Context) {
+	h.decide(c, "withdraw", StatusWithdrawn)
+}
+
+// getApplicationHistory returns the audit history of an application.
+//
+// @Summary Get application history
+// @Description Returns every status change of an application, oldest first
+// @Tags applications
+// @Param id path integer true "Application ID"
+// @Success 200 {array} StatusChange "Status changes"
+// @Failure 404 {object} map[string]string "Application not found"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /applications/{id}/history [get]
+func (h *handler) getApplicationHistory(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	if _, _, err := h.store.Get(ctx, id); err != nil {
+		applicationError(c, err)
+		return
+	}
+	changes, err := h.history.List(ctx)
+	if err != nil {
+		applicationError(c, err)
+		return
+	}
+	history := []StatusChange{}
+	for _, change := range changes {
+		if change.ApplicationID == id {
+			history = append(history, change)
+		}
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// decide answers a decision endpoint, which moves an application to status
+// to.
+func (h *handler) decide(c *gin.Context, action string, to ApplicationStatus) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var decision Decision
+	if err := c.ShouldBindJSON(&decision); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c, h.currentVersion(c, id))
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	h.decisions.Lock()
+	defer h.decisions.Unlock()
+	app, version, err := h.changeStatus(ctx, id, ifVersion, action, to, decision.Reason)
+	if err != nil {
+		applicationError(c, err)
+		return
+	}
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, app)
+}
+
+// changeStatus moves application id to status to and records the change.
+// An application accepted into a full course is waitlisted instead, unless
+// it already is, and the seat of a withdrawn one is filled from the
+// waitlist; every change is published. The write is conditional on the
+// version that was checked, and is retried on a newer version unless the
+// client sent If-Match. The caller must hold h.decisions, so that capacity
+// checks do not race.
+func (h *handler) changeStatus(ctx context.Context, id int, ifVersion int64, action string, to ApplicationStatus, reason string) (Application, int64, error) {
+	for attempt := 1; ; attempt++ {
+		app, version, err := h.store.Get(ctx, id)
+		if err == nil && ifVersion != anyVersion && version != ifVersion {
+			err = ErrVersionMismatch
+		}
+		if err != nil {
+			return app, 0, err
+		}
+		from := statusOf(app)
+		if !slices.Contains(transitions[from], to) {
+			return app, 0, fmt.Errorf("%w: cannot %s an application that is %s", ErrInvalidTransition, action, from.describe())
+		}
+		next, note := to, reason
+		if to == StatusAccepted {
+			full, err := h.courseFull(ctx, app.Course)
+			if err != nil {
+				return app, 0, err
+			}
+			if full && from == StatusWaitlisted {
+				return app, 0, fmt.Errorf("%w: no seats left in %s", ErrCourseFull, app.Course)
+			}
+			if full {
+				next, note = StatusWaitlisted, cmp.Or(reason, "course is full")
+			}
+		}
+
+		app.Status = next
+		updated, version, err := h.store.Update(ctx, id, app, version)
+		if errors.Is(err, ErrVersionMismatch) && ifVersion == anyVersion && attempt < patchAttempts {
+			continue
+		}
+		if err != nil {
+			return updated, 0, err
+		}
+		if err := h.recordChange(ctx, id, from, next, note); err != nil {
+			return updated, 0, err
+		}
+		h.events.publish(EventUpdated, "applications", id, updated)
+		if from == StatusAccepted {
+			err = h.fillSeats(ctx, updated.Course)
+		}
+		return updated, version, err
+	}
+}
+
+// recordChange adds a status change to the history.
+func (h *handler) recordChange(ctx context.Context, id int, from, to ApplicationStatus, reason string) error {
+	_, err := h.history.Create(ctx, StatusChange{
+		ApplicationID: id,
+		From:          from,
+		To:            to,
+		Reason:        reason,
+		At:            h.now().UTC(),
+	})
+	return err
+}
+
+// courseFull reports whether course has as many accepted applications as
+// it has seats.
+func (h *handler) courseFull(ctx context.Context, course string) (bool, error) {
+	seats, limited := h.capacity.seats(course)
+	if !limited {
+		return false, nil
+	}
+	apps, err := h.store.List(ctx)
+	if err != nil {
+		return false, err
+	}
+	accepted := 0
+	for _, app := range apps {
+		if statusOf(app) == StatusAccepted && strings.EqualFold(app.Course, course) {
+			accepted++
+		}
+	}
+	return accepted >= seats, nil
+}
+
+// fillSeats accepts waitlisted applications to course while it has seats
+// left, longest waitlisted first. The caller must hold h.decisions.
+func (h *handler) fillSeats(ctx context.Context, course string) error {
+	if _, limited := h.capacity.seats(course); !limited {
+		return nil
+	}
+	apps, err := h.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	changes, err := h.history.List(ctx)
+	if err != nil {
+		return err
+	}
+	// History IDs grow with time, so the latest change to waitlisted of
+	// each application orders the waitlist.
+	waitlistedAt := map[int]int{}
+	for _, change := range changes {
+		if change.To == StatusWaitlisted {
+			waitlistedAt[change.ApplicationID] = change.ID
+		}
+	}
+	var waitlist []Application
+	for _, app := range apps {
+		if statusOf(app) == StatusWaitlisted && strings.EqualFold(app.Course, course) {
+			waitlist = append(waitlist, app)
+		}
+	}
+	slices.SortFunc(waitlist, func(a, b Application) int {
+		return cmp.Or(cmp.Compare(waitlistedAt[a.ID], waitlistedAt[b.ID]), cmp.Compare(a.ID, b.ID))
+	})
+
+	for _, app := range waitlist {
+		_, _, err := h.changeStatus(ctx, app.ID, anyVersion, "accept", StatusAccepted, "seat freed")
+		if errors.Is(err, ErrCourseFull) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// editableApplications is the store PUT and PATCH write through. It keeps
+// the stored status, which only the decision endpoints change, and keeps
+// accepted and waitlisted applications in their course.
+type editableApplications struct {
+	Store
+}
+
+func (s editableApplications) Update(ctx context.Context, id int, app Application, ifVersion int64) (Application, int64, error) {
+	for attempt := 1; ; attempt++ {
+		current, version, err := s.Get(ctx, id)
+		if err == nil && ifVersion != anyVersion && version != ifVersion {
+			err = ErrVersionMismatch
+		}
+		if err != nil {
+			return app, 0, err
+		}
+		app.Status = current.Status
+		if status := statusOf(current); (status == StatusAccepted || status == StatusWaitlisted) && !strings.EqualFold(app.Course, current.Course) {
+			return app, 0, ErrCourseLocked
+		}
+		updated, version, err := s.Store.Update(ctx, id, app, version)
+		if errors.Is(err, ErrVersionMismatch) && ifVersion == anyVersion && attempt < patchAttempts {
+			continue
+		}
+		return updated, version, err
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// listQuery holds the pagination and ordering options of a list request.
+type listQuery struct {
+	Limit  int
+	Offset int
+
+	// SortField is the field to order by. Empty keeps the store order.
+	SortField string
+	Desc      bool
+}
+
+// comparator orders two items by a single field.
+type comparator[T any] func(a, b T) int
+
+// parseListQuery reads limit, offset and sort from the query string. sortable
+// lists the field names accepted by sort=; a leading "-" sorts descending.
+func parseListQuery(c *gin.Context, sortable []string) (listQuery, error) {
+	q := listQuery{Limit: defaultPageSize}
+
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			return q, fmt.Errorf("limit must be an integer between 1 and %d", maxPageSize)
+		}
+		q.Limit = n
+	}
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("offset must be a non-negative integer")
+		}
+		q.Offset = n
+	}
+	if v := c.Query("sort"); v != "" {
+		q.Desc = strings.HasPrefix(v, "-")
+		q.SortField = strings.TrimPrefix(v, "-")
+		if !slices.Contains(sortable, q.SortField) {
+			return q, fmt.Errorf("sort must be one of %s, optionally prefixed with '-'", strings.Join(sortable, ", "))
+		}
+	}
+	return q, nil
+}
+
+// sortFieldNames returns the sort= values accepted for fields.
+func sortFieldNames[T any](fields map[string]comparator[T]) []string {
+	return slices.Sorted(maps.Keys(fields))
+}
+
+// sortAndPage orders a copy of items as requested, sets the pagination
+// headers for the full result and returns the requested page.
+func sortAndPage[T any](c *gin.Context, items []T, q listQuery, fields map[string]comparator[T]) []T {
+	sorted := slices.Clone(items)
+	if cmp, ok := fields[q.SortField]; ok {
+		slices.SortStableFunc(sorted, func(a, b T) int {
+			if q.Desc {
+				return cmp(b, a)
+			}
+			return cmp(a, b)
+		})
+	}
+
+	total := len(sorted)
+	setPageHeaders(c, q, total)
+
+	start := min(q.Offset, total)
+	end := min(start+q.Limit, total)
+	return sorted[start:end]
+}
+
+// setPageHeaders sets X-Total-Count and an RFC 8288 Link header with first,
+// prev, next and last relations.
+func setPageHeaders(c *gin.Context, q listQuery, total int) {
+	c.Header("X-Total-Count", strconv.Itoa(total))
+
+	var links []string
+	addLink := func(rel string, offset int) {
+		u := url.URL{Path: c.Request.URL.Path}
+		values := c.Request.URL.Query()
+		values.Set("limit", strconv.Itoa(q.Limit))
+		values.Set("offset", strconv.Itoa(offset))
+		u.RawQuery = values.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), rel))
+	}
+
+	addLink("first", 0)
+	if q.Offset > 0 {
+		addLink("prev", max(q.Offset-q.Limit, 0))
+	}
+	if q.Offset+q.Limit < total {
+		addLink("next", q.Offset+q.Limit)
+	}
+	last := 0
+	if total > 0 {
+		last = (total - 1) / q.Limit * q.Limit
+	}
+	addLink("last", last)
+
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+// queryFloat parses an optional numeric query parameter.
+func queryFloat(c *gin.Context, name string) (*float64, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", name)
+	}
+	return &f, nil
+}
+
+// queryInt parses an optional integer query parameter.
+func queryInt(c *gin.Context, name string) (*int, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an integer", name)
+	}
+	return &n, nil
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openAPITestRequests exercise every documented response shape that can be
+// reached without external dependencies.
+var openAPITestRequests = []struct {
+	method, path, body string
+	status             int
+}{
+	{"GET", "/livez", "", http.StatusOK},
+	{"GET", "/health", "", http.StatusServiceUnavailable},
+	{"GET", "/openapi.json", "", http.StatusOK},
+	{"GET", "/docs", "", http.StatusOK},
+	{"GET", "/applications?course=physics&sort=last_name&limit=5", "", http.StatusOK},
+	{"GET", "/applications/1", "", http.StatusOK},
+	{"GET", "/applications/999", "", http.StatusNotFound},
+	{"POST", "/applications", `{"id":99,"first_name":"Nina","last_name":"Lee","age":18,"course":"Art"}`, http.StatusCreated},
+	{"PUT", "/applications/99", `{"id":99,"first_name":"Nina","last_name":"Lee","age":19,"course":"Art"}`, http.StatusOK},
+	{"POST", "/applications/99/review", "", http.StatusOK},
+	{"POST", "/applications/99/accept", `{"reason":"Strong references"}`, http.StatusOK},
+	{"POST", "/applications/99/reject", "", http.StatusConflict},
+	{"GET", "/applications/99/history", "", http.StatusOK},
+	{"GET", "/applications?status=accepted&sort=-status", "", http.StatusOK},
+	{"DELETE", "/applications/99", "", http.StatusOK},
+	{"GET", "/events?follow=false", "", http.StatusOK},
+	{"GET", "/events?last_event_id=0&resource=applications&follow=false", "", http.StatusOK},
+	{"POST", "/webhooks", `{"url":"http://127.0.0.1:1/hooks","resources":["applications"]}`, http.StatusCreated},
+	{"GET", "/webhooks", "", http.StatusOK},
+	{"GET", "/webhooks/1", "", http.StatusOK},
+	{"GET", "/webhooks/99", "", http.StatusNotFound},
+	{"DELETE", "/webhooks/1", "", http.StatusOK},
+	{"GET", "/admin/fixtures", "", http.StatusOK},
+}
+
+// openAPIInvalidRequests are rejected by the validator before reaching the
+// handlers.
+var openAPIInvalidRequests = []struct {
+	method, path, body string
+}{
+	{"GET", "/applications?limit=0", ""},
+	{"GET", "/applications?sort=gpa", ""},
+	{"GET", "/applications?status=pending", ""},
+	{"POST", "/applications/1/accept", `{"reason":1}`},
+	{"GET", "/applications/abc", ""},
+	{"POST", "/applications", `{"first_name":"Nina","age":18}`},
+	{"GET", "/events?resource=courses", ""},
+	{"GET", "/events?last_event_id=-1", ""},
+	{"POST", "/webhooks", `{"resources":["applications"]}`},
+	{"POST", "/admin/fixtures/generate", ""},
+}
+
+// newTestRouter returns a router over a fresh store holding the default
+// applications, with request validation enabled.
+func newTestRouter(validateResponses bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return NewRouter(NewMemoryStore(DefaultApplications()), Config{
+		ValidateRequests:  true,
+		ValidateResponses: validateResponses,
+	})
+}
+
+var ginPathParam = regexp.MustCompile(`:(\w+)`)
+
+// openAPIPath converts a gin route path to OpenAPI path template syntax.
+func openAPIPath(path string) string {
+	return ginPathParam.ReplaceAllString(path, "{$1}")
+}
+
+func TestRoutesMatchOpenAPISpec(t *testing.T) {
+	doc, err := loadOpenAPISpec()
+	require.NoError(t, err)
+
+	var routes []string
+	for _, route := range newTestRouter(false).Routes() {
+		routes = append(routes, route.Method+" "+openAPIPath(route.Path))
+	}
+	var documented []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+	slices.Sort(routes)
+	slices.Sort(documented)
+
+	assert.Equal(t, documented, routes, "routes and OpenAPI operations differ; annotate the handler and run go generate")
+}
+
+func TestResponsesMatchOpenAPISpec(t *testing.T) {
+	r := newTestRouter(true)
+
+	for _, tc := range openAPITestRequests {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			saved := responseMismatch
+			defer func() { responseMismatch = saved }()
+			responseMismatch = func(c *gin.Context, err error) {
+				t.Errorf("response does not match the OpenAPI spec: %v", err)
+			}
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestInvalidRequestsAreRejected(t *testing.T) {
+	r := newTestRouter(false)
+
+	for _, tc := range openAPIInvalidRequests {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), `"error"`)
+		})
+	}
+}
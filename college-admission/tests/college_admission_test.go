@@ -0,0 +1,1016 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"college-admission/api"
+	"college-admission/api/pb"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newRouter returns the service router over a fresh in-memory store.
+func newRouter(t *testing.T) (*gin.Engine, api.Store) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store := api.NewMemoryStore(api.DefaultApplications())
+	return api.NewRouter(store, api.Config{ValidateRequests: true}), store
+}
+
+func do(r http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &v), w.Body.String())
+	return v
+}
+
+func TestGetApplications(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "GET", "/applications", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "John")
+	assert.Contains(t, w.Body.String(), "Jane")
+	assert.Contains(t, w.Body.String(), "Bob")
+	assert.Equal(t, "15", w.Header().Get("X-Total-Count"))
+}
+
+func TestGetApplication(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "GET", "/applications/1", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, api.Application{ID: 1, FirstName: "John", LastName: "Doe", Age: 18, Course: "Computer Science", Status: api.StatusSubmitted}, decode[api.Application](t, w))
+}
+
+func TestGetNonExistentApplication(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "GET", "/applications/99", "")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Application not found")
+}
+
+func TestCreateApplicationAssignsID(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "POST", "/applications", `{"first_name":"Nina","last_name":"Lopez","age":18,"course":"Biology"}`)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	created := decode[api.Application](t, w)
+	assert.Equal(t, api.Application{ID: 16, FirstName: "Nina", LastName: "Lopez", Age: 18, Course: "Biology", Status: api.StatusSubmitted}, created)
+
+	stored, _, err := store.Get(context.Background(), 16)
+	require.NoError(t, err)
+	assert.Equal(t, created, stored)
+}
+
+func TestCreateApplicationWithTakenIDIsRejected(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "POST", "/applications", `{"id":1,"first_name":"John","last_name":"Doe","age":18,"course":"Data Science"}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	applications, err := store.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, applications, 15)
+	assert.Equal(t, api.DefaultApplications()[0], applications[0], "replacing a record is left to PUT")
+
+	w = do(r, "POST", "/applications", `{"id":100,"first_name":"John","last_name":"Doe","age":18,"course":"Data Science"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, 100, decode[api.Application](t, w).ID)
+}
+
+func TestCreateApplicationRejectsInvalidBody(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "POST", "/applications", `{"first_name":"Nina","age":18}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	n, err := store.Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 15, n)
+}
+
+func TestUpdateApplication(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "PUT", "/applications/3", `{"id":42,"first_name":"Bob","last_name":"Brown","age":18,"course":"Architecture"}`)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.Application{ID: 3, FirstName: "Bob", LastName: "Brown", Age: 18, Course: "Architecture", Status: api.StatusSubmitted}, decode[api.Application](t, w))
+	stored, _, err := store.Get(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, "Architecture", stored.Course)
+}
+
+func TestUpdateNonExistentApplication(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "PUT", "/applications/99", `{"first_name":"No","last_name":"One"}`)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	_, _, err := store.Get(context.Background(), 99)
+	assert.ErrorIs(t, err, api.ErrNotFound)
+}
+
+func TestDeleteApplication(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "DELETE", "/applications/2", "")
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	_, _, err := store.Get(context.Background(), 2)
+	assert.ErrorIs(t, err, api.ErrNotFound)
+	assert.Equal(t, http.StatusNotFound, do(r, "GET", "/applications/2", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(r, "DELETE", "/applications/2", "").Code)
+}
+
+// failingStore is a Store whose reads fail.
+type failingStore struct {
+	api.Store
+}
+
+var errStorage = errors.New("storage unavailable")
+
+func (failingStore) List(context.Context) ([]api.Application, error) {
+	return nil, errStorage
+}
+
+func (failingStore) Get(context.Context, int) (api.Application, int64, error) {
+	return api.Application{}, 0, errStorage
+}
+
+func TestStoreErrorsAreReported(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(failingStore{}, api.Config{ValidateRequests: true})
+
+	for _, path := range []string{"/applications", "/applications/1"} {
+		w := do(r, "GET", path, "")
+		assert.Equal(t, http.StatusInternalServerError, w.Code, path)
+		assert.Contains(t, w.Body.String(), errStorage.Error(), path)
+	}
+}
+
+func TestReadinessFollowsLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	readiness := &api.Readiness{}
+	r := api.NewRouter(api.NewMemoryStore(nil), api.Config{Readiness: readiness})
+
+	assert.Equal(t, http.StatusServiceUnavailable, do(r, "GET", "/readyz", "").Code)
+	readiness.SetWarmedUp()
+	assert.Equal(t, http.StatusOK, do(r, "GET", "/readyz", "").Code)
+	readiness.SetDraining()
+	assert.Equal(t, http.StatusServiceUnavailable, do(r, "GET", "/readyz", "").Code)
+	assert.Equal(t, http.StatusOK, do(r, "GET", "/livez", "").Code)
+}
+
+func TestRequestsAreLogged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultApplications()), api.Config{
+		Logger: api.NewLogger(&logs, "info", "json"),
+	})
+
+	req := httptest.NewRequest("GET", "/applications/99", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "req-42", w.Header().Get("X-Request-ID"))
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record), logs.String())
+	assert.Equal(t, "request", record["msg"])
+	assert.Equal(t, "college-admission", record["service"])
+	assert.Equal(t, "req-42", record["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.Equal(t, "/applications/:id", record["route"])
+	assert.Equal(t, float64(404), record["status"])
+	assert.Contains(t, record, "latency_ms")
+
+	// Requests without an X-Request-ID get a generated one.
+	w = do(r, "GET", "/applications", "")
+	assert.Regexp(t, "^[0-9a-f]{32}$", w.Header().Get("X-Request-ID"))
+}
+
+// withAuth returns the service router requiring the given credentials.
+func withAuth(t *testing.T, auth api.Authenticator) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	return api.NewRouter(api.NewMemoryStore(api.DefaultApplications()), api.Config{ValidateRequests: true, Auth: auth})
+}
+
+func doWithHeader(r http.Handler, method, path, body, header, value string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set(header, value)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeysNeedTheMethodScope(t *testing.T) {
+	r := withAuth(t, api.APIKeys{
+		"reader-key": {Subject: "reader", Scopes: []string{api.ScopeRead}},
+		"writer-key": {Subject: "writer", Scopes: []string{api.ScopeWrite}},
+	})
+	body := `{"first_name":"Nina","last_name":"Lopez","age":18,"course":"Biology"}`
+
+	w := do(r, "GET", "/applications", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusUnauthorized, doWithHeader(r, "GET", "/applications", "", "X-API-Key", "wrong").Code)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/applications", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "POST", "/applications", body, "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "POST", "/admin/fixtures/generate", "", "X-API-Key", "reader-key").Code)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/applications/1", "", "X-API-Key", "writer-key").Code)
+	assert.Equal(t, http.StatusCreated, doWithHeader(r, "POST", "/applications", body, "X-API-Key", "writer-key").Code)
+
+	// Health checks and docs stay open.
+	for _, path := range []string{"/livez", "/openapi.json", "/docs"} {
+		assert.Equal(t, http.StatusOK, do(r, "GET", path, "").Code, path)
+	}
+}
+
+func TestAPIKeysFromEnv(t *testing.T) {
+	keys := filepath.Join(t.TempDir(), "api-keys")
+	require.NoError(t, os.WriteFile(keys, []byte("# ci keys\nreader-key read ci-reader\n\nwriter-key read write\n"), 0o600))
+	t.Setenv("AUTH_API_KEYS_FILE", keys)
+
+	auth, err := api.AuthFromEnv()
+	require.NoError(t, err)
+	r := withAuth(t, auth)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/applications", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "DELETE", "/applications/1", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/applications/1", "", "X-API-Key", "writer-key").Code)
+
+	t.Setenv("AUTH_API_KEYS_FILE", "")
+	auth, err = api.AuthFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, auth, "no configuration leaves the API open")
+}
+
+func TestBearerTokensAreVerifiedAgainstJWKS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"EC","crv":"P-256","kid":"test","use":"sig","x":%q,"y":%q}]}`,
+		base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))))
+	auth, err := api.NewJWTAuth([]byte(jwks), "https://issuer.test", "college-admission")
+	require.NoError(t, err)
+	r := withAuth(t, auth)
+
+	sign := func(claims map[string]any) string {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","kid":"test","typ":"JWT"}`))
+		payload, err := json.Marshal(claims)
+		require.NoError(t, err)
+		input := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(input))
+		sr, ss, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		require.NoError(t, err)
+		sig := append(sr.FillBytes(make([]byte, 32)), ss.FillBytes(make([]byte, 32))...)
+		return "Bearer " + input + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+	claims := func(scope, aud string, exp time.Duration) map[string]any {
+		return map[string]any{
+			"sub":   "client-1",
+			"iss":   "https://issuer.test",
+			"aud":   aud,
+			"exp":   time.Now().Add(exp).Unix(),
+			"scope": scope,
+		}
+	}
+
+	reader := sign(claims("read", "college-admission", time.Hour))
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/applications", "", "Authorization", reader).Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "DELETE", "/applications/1", "", "Authorization", reader).Code)
+
+	writer := sign(claims("read write", "college-admission", time.Hour))
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/applications/1", "", "Authorization", writer).Code)
+
+	for name, token := range map[string]string{
+		"expired":        sign(claims("read", "college-admission", -time.Hour)),
+		"other audience": sign(claims("read", "coffee-shop", time.Hour)),
+		"tampered":       reader[:len(reader)-4] + "AAAA",
+	} {
+		w := doWithHeader(r, "GET", "/applications", "", "Authorization", token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+	}
+}
+
+func TestRateLimitPerClientIPAndCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultApplications()), api.Config{
+		Auth:   api.APIKeys{"shared-key": {Subject: "load-test", Scopes: []string{api.ScopeRead}}},
+		Limits: api.Limits{RequestsPerSecond: 1, Burst: 2},
+	})
+	from := func(ip, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = ip + ":40000"
+		req.Header.Set("X-API-Key", "shared-key")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/applications").Code)
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/applications/1").Code)
+	w := from("10.0.0.1", "/applications")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// Probes are never limited.
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/livez").Code)
+
+	// A new IP gets its own bucket, but the key it shares is spent.
+	assert.Equal(t, http.StatusTooManyRequests, from("10.0.0.2", "/applications").Code)
+}
+
+func TestForwardedForIsOnlyTrustedFromProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(proxies ...string) http.Handler {
+		return api.NewRouter(api.NewMemoryStore(api.DefaultApplications()), api.Config{
+			Limits: api.Limits{RequestsPerSecond: 1, Burst: 1, TrustedProxies: proxies},
+		})
+	}
+	get := func(r http.Handler, peer, forwardedFor string) int {
+		req := httptest.NewRequest("GET", "/applications/1", nil)
+		req.RemoteAddr = peer + ":40000"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// By default a client cannot leave its bucket by naming another address.
+	r := newRouter()
+	assert.Equal(t, http.StatusOK, get(r, "203.0.113.7", "10.0.0.1"))
+	assert.Equal(t, http.StatusTooManyRequests, get(r, "203.0.113.7", "10.0.0.2"))
+
+	// Behind a trusted proxy, each forwarded client has its own bucket.
+	r = newRouter("192.0.2.0/24")
+	assert.Equal(t, http.StatusOK, get(r, "192.0.2.10", "10.0.0.1"))
+	assert.Equal(t, http.StatusOK, get(r, "192.0.2.10", "10.0.0.2"))
+	assert.Equal(t, http.StatusTooManyRequests, get(r, "192.0.2.10", "10.0.0.1"))
+}
+
+func TestLargeBodiesAreRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultApplications()), api.Config{
+		ValidateRequests: true,
+		Limits:           api.Limits{MaxBodyBytes: 128},
+	})
+
+	w := do(r, "POST", "/applications", `{"first_name":"`+strings.Repeat("x", 128)+`","last_name":"Lopez","age":18,"course":"Biology"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	req := httptest.NewRequest("POST", "/applications", io.MultiReader(strings.NewReader(`{"first_name":"`+strings.Repeat("x", 128)), strings.NewReader(`","last_name":"Lopez","age":18,"course":"Biology"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, "bodies without a Content-Length are capped too")
+
+	assert.Equal(t, http.StatusCreated, do(r, "POST", "/applications", `{"first_name":"Nina","last_name":"Lopez","age":18,"course":"Biology"}`).Code)
+
+	w = do(r, "POST", "/admin/fixtures", `[{"first_name":"`+strings.Repeat("x", 64)+`","last_name":"Lopez","age":18,"course":"Biology"}]`)
+	assert.Equal(t, http.StatusOK, w.Code, "fixture imports are not held to the cap: %s", w.Body.String())
+}
+
+func TestFixtureImportsAreValidated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Seed files never reach the OpenAPI validator, so the import has to
+	// check the records itself.
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultApplications()), api.Config{})
+
+	w := do(r, "POST", "/admin/fixtures", `[{"first_name":"Nina","last_name":"Lopez","age":18},{"first_name":"Omar","last_name":"","age":19}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "record 1")
+
+	w = doWithHeader(r, "POST", "/admin/fixtures", "first_name,last_name,age\nNina,Lopez,18\nOmar,Haddad,-1\n", "Content-Type", "text/csv")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "record 1")
+
+	stored := decode[[]api.Application](t, do(r, "GET", "/admin/fixtures", ""))
+	assert.Len(t, stored, len(api.DefaultApplications()), "nothing is stored from a rejected import")
+}
+
+func TestConditionalRequestsUseETags(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "GET", "/applications/3", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	tag := w.Header().Get("ETag")
+	require.NotEmpty(t, tag)
+
+	w = doWithHeader(r, "GET", "/applications/3", "", "If-None-Match", tag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, tag, w.Header().Get("ETag"))
+
+	w = doWithHeader(r, "PUT", "/applications/3", `{"first_name":"Bob","last_name":"Brown","age":18,"course":"Architecture"}`, "If-Match", tag)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	updated := w.Header().Get("ETag")
+	assert.NotEqual(t, tag, updated)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/applications/3", "", "If-None-Match", tag).Code)
+
+	// A client still holding the old version loses the race.
+	w = doWithHeader(r, "PUT", "/applications/3", `{"first_name":"Bob","last_name":"Brown","age":19,"course":"Architecture"}`, "If-Match", tag)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, http.StatusPreconditionFailed, doWithHeader(r, "DELETE", "/applications/3", "", "If-Match", tag).Code)
+	assert.Equal(t, http.StatusPreconditionFailed, doWithHeader(r, "DELETE", "/applications/3", "", "If-Match", "W/"+updated).Code)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/applications/3", "", "If-Match", tag+", "+updated).Code)
+	assert.Equal(t, http.StatusNotFound, doWithHeader(r, "DELETE", "/applications/3", "", "If-Match", updated).Code)
+}
+
+func TestPatchApplication(t *testing.T) {
+	r, store := newRouter(t)
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		return doWithHeader(r, "PATCH", "/applications/1", body, "Content-Type", contentType)
+	}
+
+	w := patch("application/merge-patch+json", `{"course":"Physics"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.Application{ID: 1, FirstName: "John", LastName: "Doe", Age: 18, Course: "Physics", Status: api.StatusSubmitted}, decode[api.Application](t, w))
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+
+	w = patch("application/json-patch+json", `[{"op":"test","path":"/first_name","value":"John"},{"op":"replace","path":"/first_name","value":"Johnny"}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	stored, _, err := store.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, api.Application{ID: 1, FirstName: "Johnny", LastName: "Doe", Age: 18, Course: "Physics", Status: api.StatusSubmitted}, stored)
+
+	// The patched application is validated, and nothing is stored when it fails.
+	assert.Equal(t, http.StatusUnprocessableEntity, patch("application/merge-patch+json", `{"first_name":null}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("application/merge-patch+json", `{"age":-1}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, patch("application/json-patch+json", `[{"op":"add","path":"/email","value":"john@example.com"}]`).Code)
+	assert.Equal(t, http.StatusConflict, patch("application/json-patch+json", `[{"op":"test","path":"/first_name","value":"John"}]`).Code)
+	assert.Equal(t, http.StatusConflict, patch("application/json-patch+json", `[{"op":"remove","path":"/email"}]`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("application/json-patch+json", `[{"op":"replace","path":"first_name","value":"Jack"}]`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("application/json", `{"age":20}`).Code)
+	stored, _, err = store.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, api.Application{ID: 1, FirstName: "Johnny", LastName: "Doe", Age: 18, Course: "Physics", Status: api.StatusSubmitted}, stored)
+
+	assert.Equal(t, http.StatusNotFound, doWithHeader(r, "PATCH", "/applications/99", `{"age":20}`, "Content-Type", "application/merge-patch+json").Code)
+}
+
+func TestPatchApplicationHonoursIfMatch(t *testing.T) {
+	r, _ := newRouter(t)
+	tag := do(r, "GET", "/applications/1", "").Header().Get("ETag")
+	require.Equal(t, http.StatusOK, do(r, "PUT", "/applications/1", `{"first_name":"John","last_name":"Doe","age":19,"course":"Computer Science"}`).Code)
+
+	req := httptest.NewRequest("PATCH", "/applications/1", strings.NewReader(`{"age":20}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", tag)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, 19, decode[api.Application](t, do(r, "GET", "/applications/1", "")).Age)
+}
+
+// newAdmissionsRouter returns the service router with two seats in Physics.
+func newAdmissionsRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	return api.NewRouter(api.NewMemoryStore(api.DefaultApplications()), api.Config{
+		ValidateRequests: true,
+		Capacity:         api.CourseCapacity{"physics": 2},
+	})
+}
+
+// admit creates an application to Physics and moves it under review.
+func admit(t *testing.T, r http.Handler, firstName string) int {
+	t.Helper()
+	w := do(r, "POST", "/applications", fmt.Sprintf(`{"first_name":%q,"last_name":"Park","age":18,"course":"Physics"}`, firstName))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	id := decode[api.Application](t, w).ID
+	require.Equal(t, http.StatusOK, do(r, "POST", fmt.Sprintf("/applications/%d/review", id), "").Code)
+	return id
+}
+
+func statusOf(t *testing.T, r http.Handler, id int) api.ApplicationStatus {
+	t.Helper()
+	return decode[api.Application](t, do(r, "GET", fmt.Sprintf("/applications/%d", id), "")).Status
+}
+
+func TestApplicationDecisionsAreAudited(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "POST", "/applications/1/review", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.StatusUnderReview, decode[api.Application](t, w).Status)
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+
+	w = do(r, "POST", "/applications/1/accept", `{"reason":"Strong references"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.StatusAccepted, decode[api.Application](t, w).Status)
+
+	w = do(r, "POST", "/applications/1/withdraw", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Withdrawn applications are final.
+	w = do(r, "POST", "/applications/1/accept", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "cannot accept an application that is withdrawn")
+	w = do(r, "POST", "/applications", `{"id":1,"first_name":"John","last_name":"Doe","age":18,"course":"Computer Science"}`)
+	assert.Equal(t, http.StatusConflict, w.Code, "a withdrawn application cannot be submitted again")
+	assert.Equal(t, http.StatusConflict, do(r, "POST", "/applications/1/review", "").Code)
+	assert.Equal(t, http.StatusConflict, do(r, "POST", "/applications/2/accept", "").Code, "submitted applications need a review first")
+	assert.Equal(t, http.StatusNotFound, do(r, "POST", "/applications/99/review", "").Code)
+
+	w = do(r, "GET", "/applications/1/history", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	history := decode[[]api.StatusChange](t, w)
+	require.Len(t, history, 3)
+	assert.Equal(t, []api.ApplicationStatus{api.StatusSubmitted, api.StatusUnderReview, api.StatusAccepted},
+		[]api.ApplicationStatus{history[0].From, history[1].From, history[2].From})
+	assert.Equal(t, []api.ApplicationStatus{api.StatusUnderReview, api.StatusAccepted, api.StatusWithdrawn},
+		[]api.ApplicationStatus{history[0].To, history[1].To, history[2].To})
+	assert.Equal(t, "Strong references", history[1].Reason)
+	assert.WithinDuration(t, time.Now(), history[2].At, time.Minute)
+
+	assert.Equal(t, []api.Application{decode[api.Application](t, do(r, "GET", "/applications/1", ""))},
+		decode[[]api.Application](t, do(r, "GET", "/applications?status=withdrawn", "")))
+}
+
+func TestDecisionsHonourIfMatch(t *testing.T) {
+	r, _ := newRouter(t)
+	tag := do(r, "GET", "/applications/1", "").Header().Get("ETag")
+	require.Equal(t, http.StatusOK, do(r, "PUT", "/applications/1", `{"first_name":"John","last_name":"Doe","age":19,"course":"Computer Science"}`).Code)
+
+	assert.Equal(t, http.StatusPreconditionFailed, doWithHeader(r, "POST", "/applications/1/review", "", "If-Match", tag).Code)
+	assert.Equal(t, api.StatusSubmitted, statusOf(t, r, 1))
+}
+
+func TestFullCoursesWaitlistApplicants(t *testing.T) {
+	r := newAdmissionsRouter(t)
+	first, second, third, fourth := admit(t, r, "Ann"), admit(t, r, "Ben"), admit(t, r, "Cat"), admit(t, r, "Dan")
+
+	for _, id := range []int{first, second, third, fourth} {
+		require.Equal(t, http.StatusOK, do(r, "POST", fmt.Sprintf("/applications/%d/accept", id), "").Code)
+	}
+	assert.Equal(t, api.StatusAccepted, statusOf(t, r, first))
+	assert.Equal(t, api.StatusAccepted, statusOf(t, r, second))
+	assert.Equal(t, api.StatusWaitlisted, statusOf(t, r, third))
+	assert.Equal(t, api.StatusWaitlisted, statusOf(t, r, fourth))
+	history := decode[[]api.StatusChange](t, do(r, "GET", fmt.Sprintf("/applications/%d/history", third), ""))
+	assert.Equal(t, "course is full", history[len(history)-1].Reason)
+
+	// Waitlisted applicants are not accepted past the capacity.
+	w := do(r, "POST", fmt.Sprintf("/applications/%d/accept", fourth), "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "no seats left in Physics")
+
+	// A freed seat goes to the applicant waitlisted longest.
+	require.Equal(t, http.StatusOK, do(r, "POST", fmt.Sprintf("/applications/%d/withdraw", first), "").Code)
+	assert.Equal(t, api.StatusAccepted, statusOf(t, r, third))
+	assert.Equal(t, api.StatusWaitlisted, statusOf(t, r, fourth))
+	history = decode[[]api.StatusChange](t, do(r, "GET", fmt.Sprintf("/applications/%d/history", third), ""))
+	assert.Equal(t, api.StatusChange{ID: history[len(history)-1].ID, ApplicationID: third, From: api.StatusWaitlisted, To: api.StatusAccepted, Reason: "seat freed", At: history[len(history)-1].At}, history[len(history)-1])
+
+	require.Equal(t, http.StatusOK, do(r, "DELETE", fmt.Sprintf("/applications/%d", second), "").Code)
+	assert.Equal(t, api.StatusAccepted, statusOf(t, r, fourth))
+}
+
+func TestEditsKeepStatusAndCourse(t *testing.T) {
+	r := newAdmissionsRouter(t)
+	id := admit(t, r, "Ann")
+	require.Equal(t, http.StatusOK, do(r, "POST", fmt.Sprintf("/applications/%d/accept", id), "").Code)
+
+	path := fmt.Sprintf("/applications/%d", id)
+	w := do(r, "PUT", path, `{"first_name":"Anne","last_name":"Park","age":18,"course":"physics"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.StatusAccepted, decode[api.Application](t, w).Status)
+
+	// Moving an accepted applicant to another course would bypass its
+	// capacity.
+	w = do(r, "PUT", path, `{"first_name":"Anne","last_name":"Park","age":18,"course":"Art"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doWithHeader(r, "PATCH", path, `{"course":"Art"}`, "Content-Type", "application/merge-patch+json")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doWithHeader(r, "PATCH", path, `[{"op":"replace","path":"/status","value":"submitted"}]`, "Content-Type", "application/json-patch+json")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.StatusAccepted, statusOf(t, r, id))
+}
+
+// newEventsServer serves the router over HTTP, so that event streams can be
+// read while other requests change the applications.
+func newEventsServer(t *testing.T, opts api.EventOptions) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultApplications()), api.Config{ValidateRequests: true, Events: opts})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func send(t *testing.T, srv *httptest.Server, method, path, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// sseEvent is an event as read off a stream.
+type sseEvent struct {
+	id, name string
+	data     api.Event
+}
+
+// readEvents reads n events off a stream, skipping comments.
+func readEvents(t *testing.T, lines *bufio.Scanner, n int) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var ev sseEvent
+	for len(events) < n && lines.Scan() {
+		line := lines.Text()
+		switch {
+		case line == "":
+			if ev.name != "" {
+				events = append(events, ev)
+			}
+			ev = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev.data))
+		}
+	}
+	require.Len(t, events, n, "stream ended early: %v", lines.Err())
+	return events
+}
+
+func TestEventStream(t *testing.T) {
+	srv := newEventsServer(t, api.EventOptions{Heartbeat: 10 * time.Millisecond})
+
+	resp := send(t, srv, "GET", "/events?resource=applications", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	lines := bufio.NewScanner(resp.Body)
+
+	require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/applications", `{"first_name":"Nina","last_name":"Lopez","age":18,"course":"Biology"}`).StatusCode)
+	require.Equal(t, http.StatusOK, send(t, srv, "POST", "/applications/1/review", ``).StatusCode)
+	require.Equal(t, http.StatusOK, send(t, srv, "DELETE", "/applications/2", "").StatusCode)
+
+	// Heartbeats between events are skipped.
+	events := readEvents(t, lines, 3)
+	assert.Equal(t, []string{"created", "updated", "deleted"}, []string{events[0].name, events[1].name, events[2].name})
+	assert.Equal(t, []string{"1", "2", "3"}, []string{events[0].id, events[1].id, events[2].id})
+	assert.Equal(t, "applications", events[0].data.Resource)
+	assert.Equal(t, "Nina", events[0].data.Data.(map[string]any)["first_name"])
+	assert.Equal(t, 1, events[1].data.ResourceID)
+	assert.Equal(t, "under_review", events[1].data.Data.(map[string]any)["status"])
+	assert.Nil(t, events[2].data.Data)
+
+	// A client that reconnects gets the events it missed, then the stream
+	// ends when it does not follow.
+	req, err := http.NewRequest("GET", srv.URL+"/events?follow=false", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err = srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resumed := readEvents(t, bufio.NewScanner(bytes.NewReader(body)), 2)
+	assert.Equal(t, []string{"2", "3"}, []string{resumed[0].id, resumed[1].id})
+}
+
+func TestEventStreamResetsWhenEventsWereDropped(t *testing.T) {
+	srv := newEventsServer(t, api.EventOptions{History: 2})
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/applications", `{"first_name":"Nina","last_name":"Lopez","age":18,"course":"Biology"}`).StatusCode)
+	}
+
+	body, err := io.ReadAll(send(t, srv, "GET", "/events?last_event_id=0&follow=false", "").Body)
+	require.NoError(t, err)
+	events := readEvents(t, bufio.NewScanner(bytes.NewReader(body)), 3)
+	assert.Equal(t, "reset", events[0].name, "the first event was dropped from the history")
+	assert.Equal(t, []string{"2", "3"}, []string{events[1].id, events[2].id})
+
+	// So are IDs from before a restart.
+	body, err = io.ReadAll(send(t, srv, "GET", "/events?last_event_id=99&follow=false", "").Body)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(body), "event: reset\n"), string(body))
+
+	body, err = io.ReadAll(send(t, srv, "GET", "/events?last_event_id=3&follow=false", "").Body)
+	require.NoError(t, err)
+	assert.Empty(t, string(body))
+}
+
+func TestWebhookDeliveriesAreSignedAndRetried(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+	var delivered []api.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		if err := api.VerifySignature("s3cret", r.Header.Get(api.SignatureHeader), body, time.Minute); err != nil {
+			t.Errorf("delivery not signed: %v", err)
+		}
+		if err := api.VerifySignature("other", r.Header.Get(api.SignatureHeader), body, time.Minute); err == nil {
+			t.Error("signature verified with the wrong secret")
+		}
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event api.Event
+		json.Unmarshal(body, &event)
+		if event.Type == api.EventDeleted {
+			// Not worth retrying.
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		assert.Equal(t, r.Header.Get("X-Event-ID"), strconv.FormatInt(event.ID, 10))
+		delivered = append(delivered, event)
+	}))
+	defer receiver.Close()
+
+	srv := newEventsServer(t, api.EventOptions{WebhookBackoff: time.Millisecond})
+	resp := send(t, srv, "POST", "/webhooks", fmt.Sprintf(`{"url":%q,"secret":"s3cret","resources":["applications"]}`, receiver.URL))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var hook api.Webhook
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&hook))
+	assert.Equal(t, "s3cret", hook.Secret)
+
+	require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/applications", `{"first_name":"Nina","last_name":"Lopez","age":18,"course":"Biology"}`).StatusCode)
+	require.Equal(t, http.StatusOK, send(t, srv, "DELETE", "/applications/2", "").StatusCode)
+
+	require.Eventually(t, func() bool {
+		var got api.Webhook
+		json.NewDecoder(send(t, srv, "GET", fmt.Sprintf("/webhooks/%d", hook.ID), "").Body).Decode(&got)
+		return got.Delivered == 1 && got.Failed == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, 3, attempts)
+	require.Len(t, delivered, 1)
+	assert.Equal(t, api.EventCreated, delivered[0].Type)
+	mu.Unlock()
+
+	var listed []api.Webhook
+	require.NoError(t, json.NewDecoder(send(t, srv, "GET", "/webhooks", "").Body).Decode(&listed))
+	require.Len(t, listed, 1)
+	assert.Empty(t, listed[0].Secret, "the secret was returned after the webhook was created")
+	assert.Contains(t, listed[0].LastError, "410")
+
+	require.Equal(t, http.StatusOK, send(t, srv, "DELETE", fmt.Sprintf("/webhooks/%d", hook.ID), "").StatusCode)
+	assert.Equal(t, http.StatusNotFound, send(t, srv, "GET", fmt.Sprintf("/webhooks/%d", hook.ID), "").StatusCode)
+	assert.Equal(t, http.StatusBadRequest, send(t, srv, "POST", "/webhooks", `{"url":"https://hooks.example.com","resources":["cats"]}`).StatusCode)
+}
+
+func TestVerifySignatureRejectsOldDeliveries(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	old := time.Now().Add(-time.Hour).Unix()
+	fmt.Fprintf(mac, "%d.", old)
+	mac.Write(body)
+	header := fmt.Sprintf("t=%d,v1=%x", old, mac.Sum(nil))
+
+	assert.NoError(t, api.VerifySignature("s3cret", header, body, 2*time.Hour))
+	assert.ErrorIs(t, api.VerifySignature("s3cret", header, body, time.Minute), api.ErrBadSignature)
+	assert.ErrorIs(t, api.VerifySignature("s3cret", header, []byte(`{"id":2}`), 2*time.Hour), api.ErrBadSignature)
+	assert.ErrorIs(t, api.VerifySignature("s3cret", "", body, time.Minute), api.ErrBadSignature)
+}
+
+// newGRPCClient serves the gRPC server of NewServers over an in-memory
+// listener and returns a connection to it, with the router next to it.
+func newGRPCClient(t *testing.T, cfg api.Config) (*gin.Engine, *grpc.ClientConn) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r, srv := api.NewServers(api.NewMemoryStore(api.DefaultApplications()), cfg)
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return r, conn
+}
+
+func TestGRPCApplications(t *testing.T) {
+	r, conn := newGRPCClient(t, api.Config{})
+	client := pb.NewApplicationServiceClient(conn)
+	ctx := context.Background()
+
+	list, err := client.ListApplications(ctx, &pb.ListApplicationsRequest{Limit: 5, Offset: 10})
+	require.NoError(t, err)
+	assert.EqualValues(t, 15, list.Total)
+	require.Len(t, list.Applications, 5)
+	assert.Equal(t, "Ivy", list.Applications[0].FirstName)
+
+	application, err := client.GetApplication(ctx, &pb.GetApplicationRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "John", application.FirstName)
+	assert.Equal(t, "submitted", application.Status)
+
+	created, err := client.CreateApplication(ctx, &pb.CreateApplicationRequest{Application: &pb.Application{
+		FirstName: "Ann", LastName: "Park", Age: 18, Course: "Physics", Status: "accepted",
+	}})
+	require.NoError(t, err)
+	assert.EqualValues(t, 16, created.Id)
+	assert.Equal(t, "submitted", created.Status)
+	// Both APIs serve the same store.
+	assert.Equal(t, "Ann", decode[api.Application](t, do(r, "GET", "/applications/16", "")).FirstName)
+
+	// Updates keep the decisions made through the REST API, and the course
+	// of an accepted applicant.
+	require.Equal(t, http.StatusOK, do(r, "POST", "/applications/16/review", "").Code)
+	require.Equal(t, http.StatusOK, do(r, "POST", "/applications/16/accept", "").Code)
+	updated, err := client.UpdateApplication(ctx, &pb.UpdateApplicationRequest{Id: 16, Application: &pb.Application{
+		FirstName: "Anne", LastName: "Park", Age: 18, Course: "Physics",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "Anne", updated.FirstName)
+	assert.Equal(t, "accepted", updated.Status)
+	_, err = client.UpdateApplication(ctx, &pb.UpdateApplicationRequest{Id: 16, Application: &pb.Application{
+		FirstName: "Anne", LastName: "Park", Age: 18, Course: "Art",
+	}})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = client.DeleteApplication(ctx, &pb.DeleteApplicationRequest{Id: 16})
+	require.NoError(t, err)
+	_, err = client.GetApplication(ctx, &pb.GetApplicationRequest{Id: 16})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CreateApplication(ctx, &pb.CreateApplicationRequest{Application: &pb.Application{FirstName: "Ann"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.CreateApplication(ctx, &pb.CreateApplicationRequest{Application: &pb.Application{FirstName: "Ann", LastName: "Park", Age: -1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.ListApplications(ctx, &pb.ListApplicationsRequest{Limit: 1001})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCHealthFollowsReadiness(t *testing.T) {
+	readiness := &api.Readiness{}
+	_, conn := newGRPCClient(t, api.Config{Readiness: readiness})
+	health := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("liveness"))
+
+	readiness.SetWarmedUp()
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("collegeadmission.v1.ApplicationService"))
+
+	readiness.SetDraining()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("liveness"))
+
+	_, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCReflectionListsTheServices(t *testing.T) {
+	_, conn := newGRPCClient(t, api.Config{})
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	var names []string
+	for _, s := range resp.GetListServicesResponse().Service {
+		names = append(names, s.Name)
+	}
+	assert.Contains(t, names, "collegeadmission.v1.ApplicationService")
+	assert.Contains(t, names, "grpc.health.v1.Health")
+}
+
+func TestGRPCCallsNeedTheMethodScope(t *testing.T) {
+	_, conn := newGRPCClient(t, api.Config{Auth: api.APIKeys{
+		"reader-key": {Subject: "reader", Scopes: []string{api.ScopeRead}},
+		"writer-key": {Subject: "writer", Scopes: []string{api.ScopeWrite}},
+	}})
+	client := pb.NewApplicationServiceClient(conn)
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+	}
+	create := &pb.CreateApplicationRequest{Application: &pb.Application{FirstName: "Ann", LastName: "Park", Age: 18, Course: "Physics"}}
+
+	_, err := client.GetApplication(context.Background(), &pb.GetApplicationRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.GetApplication(withKey("wrong"), &pb.GetApplicationRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.GetApplication(withKey("reader-key"), &pb.GetApplicationRequest{Id: 1})
+	assert.NoError(t, err)
+	_, err = client.CreateApplication(withKey("reader-key"), create)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.CreateApplication(withKey("writer-key"), create)
+	assert.NoError(t, err)
+
+	// Health checks stay open.
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "liveness"})
+	assert.NoError(t, err)
+}
+
+func TestGRPCChangesArePublished(t *testing.T) {
+	r, conn := newGRPCClient(t, api.Config{})
+	_, err := pb.NewApplicationServiceClient(conn).DeleteApplication(context.Background(), &pb.DeleteApplicationRequest{Id: 2})
+	require.NoError(t, err)
+
+	w := do(r, "GET", "/events?last_event_id=0&follow=false", "")
+	events := readEvents(t, bufio.NewScanner(w.Body), 1)
+	assert.Equal(t, "deleted", events[0].name)
+	assert.Equal(t, 2, events[0].data.ResourceID)
+}
+
+func TestChangesArePublishedOnTheBus(t *testing.T) {
+	bus := api.NewLocalBus()
+	defer bus.Close()
+	received := make(chan api.Event, 1)
+	_, err := bus.Subscribe("college-admission.applications.*", func(subject string, data []byte) {
+		var event api.Event
+		assert.Equal(t, "college-admission.applications.deleted", subject)
+		assert.NoError(t, json.Unmarshal(data, &event))
+		received <- event
+	})
+	require.NoError(t, err)
+	_, conn := newGRPCClient(t, api.Config{Bus: bus})
+	_, err = pb.NewApplicationServiceClient(conn).DeleteApplication(context.Background(), &pb.DeleteApplicationRequest{Id: 2})
+	require.NoError(t, err)
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "deleted", event.Type)
+		assert.Equal(t, 2, event.ResourceID)
+	case <-time.After(time.Second):
+		t.Fatal("no event on the bus")
+	}
+}
@@ -0,0 +1,50 @@
+#!/bin/bash
+
+# Runs the OpenAPI contract tests against the services of a deployed
+# ClusterTester. Each service image contains the contract tester, so it is
+# run inside the service's own pod against the endpoint reported in the
+# ClusterTester status.
+#
+# Usage: ./contract-test.sh <clustertester-name> [namespace] [contract flags]
+#
+# Valid requests that change data are skipped unless -write is passed. When
+# the ClusterTester requires credentials, the write key is read from its
+# <name>-api-keys Secret.
+
+set -e
+
+if [ -z "$1" ]; then
+    echo "Usage: $0 <clustertester-name> [namespace] [-write] [-v]"
+    exit 2
+fi
+name="$1"
+namespace="${2:-default}"
+shift $(( $# < 2 ? $# : 2 ))
+
+echo "🔍 Running contract tests against ClusterTester $namespace/$name..."
+
+endpoints=$(kubectl get clustertester "$name" -n "$namespace" \
+    -o jsonpath='{range .status.services[*]}{.name}={.endpoint}{"\n"}{end}')
+if [ -z "$endpoints" ]; then
+    echo "❌ ClusterTester $namespace/$name reports no services"
+    exit 1
+fi
+
+api_key=$(kubectl get secret "$name-api-keys" -n "$namespace" \
+    -o jsonpath='{.data.write-key}' 2>/dev/null | base64 -d || true)
+
+failed=0
+while IFS='=' read -r service endpoint; do
+    [ -z "$service" ] && continue
+    echo ""
+    echo "📝 $service ($endpoint)"
+    if kubectl exec -n "$namespace" "deployment/$service" -c "$service" -- \
+        env API_KEY="$api_key" sh -c 'exec ./*-be contract -url "$0" "$@"' "$endpoint" "$@"; then
+        echo "✅ $service matches its OpenAPI spec"
+    else
+        echo "❌ $service does not match its OpenAPI spec"
+        failed=1
+    fi
+done <<< "$endpoints"
+
+exit $failed
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Bus carries the events of the services between them, so that a service
+// can react to the changes of another without calling it. Every event
+// published on /events is also published on the bus, as the JSON of the
+// Event, under the subject EventSubject returns for it.
+type Bus interface {
+	// Publish sends data to the subscribers of subject. It does not wait
+	// for them.
+	Publish(subject string, data []byte) error
+
+	// Subscribe calls handle with every message published to a subject
+	// matching pattern, one at a time and in the order they were
+	// published, until unsubscribe is called. Patterns are NATS subjects:
+	// a * token matches any one token and a final > the remaining ones.
+	Subscribe(pattern string, handle func(subject string, data []byte)) (unsubscribe func(), err error)
+
+	// Close delivers the messages published so far and releases the bus.
+	Close() error
+}
+
+// EventSubject returns the subject the events of type typ on the resource
+// of service are published under, such as coffee-shop.coffees.updated.
+func EventSubject(service, resource, typ string) string {
+	return service + "." + resource + "." + typ
+}
+
+// BusFromEnv returns a bus connected to the NATS server at NATS_URL, which
+// the operator sets when spec.messaging is enabled. Without it,
+// EVENT_BUS=local returns an in-process bus and anything else nil, which
+// publishes no events. NATS_URL may list several servers separated by
+// commas.
+func BusFromEnv() (Bus, error) {
+	if url := os.Getenv("NATS_URL"); url != "" {
+		bus, err := NewNATSBus(url)
+		if err != nil {
+			return nil, err
+		}
+		return bus, nil
+	}
+	if os.Getenv("EVENT_BUS") == "local" {
+		return NewLocalBus(), nil
+	}
+	return nil, nil
+}
+
+// errBusClosed is returned by the methods of a closed LocalBus.
+var errBusClosed = errors.New("bus is closed")
+
+// LocalBus is a Bus within one process, for running without a broker and
+// in tests. Each subscription has its own unbounded queue, so publishers
+// never wait for slow subscribers.
+type LocalBus struct {
+	mu     sync.Mutex
+	subs   map[*localSubscription]bool
+	closed bool
+}
+
+// NewLocalBus returns an empty in-process bus.
+func NewLocalBus() *LocalBus {
+	return &LocalBus{subs: make(map[*localSubscription]bool)}
+}
+
+// localSubscription queues the messages of one subscriber for the goroutine
+// that hands them over.
+type localSubscription struct {
+	pattern []string
+	handle  func(subject string, data []byte)
+
+	mu      sync.Mutex
+	queue   []localMessage
+	stopped bool
+	wake    chan struct{}
+	done    chan struct{}
+}
+
+type localMessage struct {
+	subject string
+	data    []byte
+}
+
+// Publish queues data for every subscription whose pattern matches subject.
+func (b *LocalBus) Publish(subject string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return errBusClosed
+	}
+	tokens := strings.Split(subject, ".")
+	for sub := range b.subs {
+		if subjectMatches(sub.pattern, tokens) {
+			sub.enqueue(localMessage{subject, data})
+		}
+	}
+	return nil
+}
+
+// Subscribe starts handing the messages matching pattern to handle.
+func (b *LocalBus) Subscribe(pattern string, handle func(subject string, data []byte)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, errBusClosed
+	}
+	sub := &localSubscription{
+		pattern: strings.Split(pattern, "."),
+		handle:  handle,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	b.subs[sub] = true
+	go sub.run()
+	return func() {
+		b.mu.Lock()
+		delete(b.subs, sub)
+		b.mu.Unlock()
+		sub.stop(false)
+	}, nil
+}
+
+// Close hands the queued messages to the subscribers and stops them.
+func (b *LocalBus) Close() error {
+	b.mu.Lock()
+	subs := b.subs
+	b.subs = nil
+	b.closed = true
+	b.mu.Unlock()
+	for sub := range subs {
+		sub.stop(true)
+	}
+	return nil
+}
+
+func (s *localSubscription) enqueue(m localMessage) {
+	s.mu.Lock()
+	s.queue = append(s.queue, m)
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// stop ends the subscription once the queued messages have been handed
+// over, or at once, dropping them, unless drain is set.
+func (s *localSubscription) stop(drain bool) {
+	s.mu.Lock()
+	s.stopped = true
+	if !drain {
+		s.queue = nil
+	}
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	<-s.done
+}
+
+func (s *localSubscription) run() {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		queue, stopped := s.queue, s.stopped
+		s.queue = nil
+		s.mu.Unlock()
+		for _, m := range queue {
+			s.handle(m.subject, m.data)
+		}
+		if stopped && len(queue) == 0 {
+			return
+		}
+		if len(queue) == 0 {
+			<-s.wake
+		}
+	}
+}
+
+// subjectMatches reports whether the tokens of a subject match those of a
+// pattern.
+func subjectMatches(pattern, subject []string) bool {
+	for i, p := range pattern {
+		if p == ">" && i == len(pattern)-1 {
+			return len(subject) > i
+		}
+		if i >= len(subject) || (p != "*" && p != subject[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(subject)
+}
+
+// NATSBus is a Bus backed by a NATS server, which carries the events
+// between the replicas of all the services.
+type NATSBus struct {
+	conn *nats.Conn
+}
+
+// NewNATSBus connects to the NATS server at url. A server that cannot be
+// reached yet does not fail it: the connection keeps being retried in the
+// background, and messages published meanwhile are buffered.
+func NewNATSBus(url string) (*NATSBus, error) {
+	conn, err := nats.Connect(url,
+		nats.Name(serviceName),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(time.Second),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				slog.Warn("Disconnected from NATS", "error", err)
+			}
+		}),
+		nats.ReconnectHandler(func(c *nats.Conn) {
+			slog.Info("Reconnected to NATS", "url", c.ConnectedUrl())
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &NATSBus{conn: conn}, nil
+}
+
+// Publish sends data to the server, which passes it on to the subscribers.
+func (b *NATSBus) Publish(subject string, data []byte) error {
+	return b.conn.Publish(subject, data)
+}
+
+// Subscribe subscribes to pattern on the server. When connected, it waits
+// for the server to have the subscription; otherwise it is made on
+// connecting. Messages published while the service is disconnected are not
+// delivered to it.
+func (b *NATSBus) Subscribe(pattern string, handle func(subject string, data []byte)) (func(), error) {
+	sub, err := b.conn.Subscribe(pattern, func(m *nats.Msg) {
+		handle(m.Subject, m.Data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if b.conn.IsConnected() {
+		if err := b.conn.FlushTimeout(5 * time.Second); err != nil {
+			_ = sub.Unsubscribe()
+			return nil, err
+		}
+	}
+	return func() { _ = sub.Unsubscribe() }, nil
+}
+
+// Close flushes the messages published so far and closes the connection.
+func (b *NATSBus) Close() error {
+	err := b.conn.FlushTimeout(5 * time.Second)
+	b.conn.Close()
+	if errors.Is(err, nats.ErrConnectionClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// generationKey holds the generation of the cached product reads. The keys
+// carry no service name, so that electronics-store and
+// electronics-store-tracing, which share the products table, also share
+// the cached reads of it when they share a Redis server.
+const generationKey = "products:generation"
+
+// cachedStore reads the products through a cache. The keys of the cached
+// reads start with the current generation, and every write starts a new
+// one, so that a write drops all the pages and products read before it at
+// once. A read that races with a write is cached under the generation it
+// started in, which the write has already ended.
+//
+// The cache only speeds reads up: reads it fails are made from the store,
+// and failures to update it are logged.
+type cachedStore struct {
+	Store
+	cache Cache
+	ttl   time.Duration
+}
+
+// cachedPage is the encoding of a cached List.
+type cachedPage struct {
+	Products []Product `json:"products"`
+	Total    int       `json:"total"`
+}
+
+// cachedProduct is the encoding of a cached Get.
+type cachedProduct struct {
+	Product Product `json:"product"`
+	Version int64   `json:"version"`
+}
+
+// newCachedStore returns store read through cache, which keeps each read
+// for ttl.
+func newCachedStore(store Store, cache Cache, ttl time.Duration) *cachedStore {
+	return &cachedStore{Store: store, cache: cache, ttl: ttl}
+}
+
+func (s *cachedStore) List(ctx context.Context, q ProductQuery) ([]Product, int, error) {
+	query, err := json.Marshal(q)
+	if err != nil {
+		return nil, 0, err
+	}
+	var page cachedPage
+	key, hit := s.read(ctx, "list:"+string(query), &page)
+	if hit {
+		return page.Products, page.Total, nil
+	}
+	products, total, err := s.Store.List(ctx, q)
+	if err == nil {
+		s.write(ctx, key, cachedPage{products, total})
+	}
+	return products, total, err
+}
+
+func (s *cachedStore) Get(ctx context.Context, id int) (Product, int64, error) {
+	var cached cachedProduct
+	key, hit := s.read(ctx, strconv.Itoa(id), &cached)
+	if hit {
+		return cached.Product, cached.Version, nil
+	}
+	product, version, err := s.Store.Get(ctx, id)
+	if err == nil {
+		s.write(ctx, key, cachedProduct{product, version})
+	}
+	return product, version, err
+}
+
+func (s *cachedStore) Create(ctx context.Context, product Product) (Product, error) {
+	defer s.invalidate(ctx)
+	return s.Store.Create(ctx, product)
+}
+
+func (s *cachedStore) Update(ctx context.Context, id int, product Product, ifVersion int64) (Product, int64, error) {
+	defer s.invalidate(ctx)
+	return s.Store.Update(ctx, id, product, ifVersion)
+}
+
+func (s *cachedStore) Delete(ctx context.Context, id int, ifVersion int64) error {
+	defer s.invalidate(ctx)
+	return s.Store.Delete(ctx, id, ifVersion)
+}
+
+func (s *cachedStore) Import(ctx context.Context, products []Product, replace bool) (int, error) {
+	defer s.invalidate(ctx)
+	return s.Store.Import(ctx, products, replace)
+}
+
+// read looks up the read named name in the current generation and decodes
+// it into v. It returns the key of the read, which is empty when the
+// generation is unknown, and whether it was found.
+func (s *cachedStore) read(ctx context.Context, name string, v any) (string, bool) {
+	generation, ok, err := s.cache.Get(ctx, generationKey)
+	if err != nil {
+		requestLog(ctx).Warn("Reading the product cache failed", "error", err)
+		return "", false
+	}
+	if !ok {
+		// Nothing read before now can be trusted, so start a generation.
+		if generation = s.invalidate(ctx); generation == nil {
+			return "", false
+		}
+	}
+	key := "products:" + string(generation) + ":" + name
+	value, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		requestLog(ctx).Warn("Reading the product cache failed", "error", err)
+		return key, false
+	}
+	if !ok {
+		return key, false
+	}
+	if err := json.Unmarshal(value, v); err != nil {
+		requestLog(ctx).Warn("Decoding a cached product read failed", "key", key, "error", err)
+		return key, false
+	}
+	return key, true
+}
+
+// write caches v under key, unless the generation was unknown.
+func (s *cachedStore) write(ctx context.Context, key string, v any) {
+	if key == "" {
+		return
+	}
+	value, err := json.Marshal(v)
+	if err == nil {
+		err = s.cache.Set(ctx, key, value, s.ttl)
+	}
+	if err != nil {
+		requestLog(ctx).Warn("Writing the product cache failed", "key", key, "error", err)
+	}
+}
+
+// invalidate starts a new generation, which drops every cached read, and
+// returns it, or nil when the cache cannot be written. Writes call it even
+// when they fail, as a write that times out may still have been made.
+func (s *cachedStore) invalidate(ctx context.Context) []byte {
+	b := make([]byte, 8)
+	rand.Read(b)
+	generation := []byte(hex.EncodeToString(b))
+	// The generation outlives the reads, so that it is not dropped before
+	// them; a cache that evicts it anyway only costs misses. It is written
+	// even when the request has been cancelled, as its write may have been
+	// made.
+	if err := s.cache.Set(context.WithoutCancel(ctx), generationKey, generation, 0); err != nil {
+		requestLog(ctx).Warn("Invalidating the product cache failed", "error", err)
+		return nil
+	}
+	return generation
+}
@@ -0,0 +1,402 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrEmptyCart is returned by Checkout for a cart without items.
+var ErrEmptyCart = errors.New("cart is empty")
+
+// Cart collects the products a customer intends to buy. Stock is only
+// reserved when the cart is checked out.
+type Cart struct {
+	ID    int        `json:"id" example:"1"`
+	Items []LineItem `json:"items"`
+
+	// Total is the price of the items at the current product prices.
+	Total float64 `json:"total" example:"1059.97"`
+}
+
+// LineItem is a quantity of one product in a cart or an order. In an order,
+// the name and unit price are those of the product at checkout.
+type LineItem struct {
+	ProductID int     `json:"product_id" example:"1"`
+	Name      string  `json:"name" example:"Laptop"`
+	UnitPrice float64 `json:"unit_price" example:"999.99"`
+	Quantity  int     `json:"quantity" minimum:"1" example:"1"`
+}
+
+// CartItemQuantity is the body of PUT /carts/{id}/items/{productId}.
+type CartItemQuantity struct {
+	Quantity int `json:"quantity" binding:"required,min=1" minimum:"1" example:"2"`
+}
+
+// Order is a checked-out cart.
+type Order struct {
+	ID        int        `json:"id" example:"1"`
+	Items     []LineItem `json:"items"`
+	Total     float64    `json:"total" example:"1059.97"`
+	CreatedAt time.Time  `json:"created_at" format:"date-time"`
+}
+
+// StockShortage is a cart line that asks for more units than are in stock.
+type StockShortage struct {
+	ProductID int `json:"product_id" example:"1"`
+	Requested int `json:"requested" example:"3"`
+	Available int `json:"available" example:"2"`
+}
+
+// OversellError is returned by Checkout when the stock of some products does
+// not cover the cart. Nothing is changed.
+type OversellError struct {
+	Shortages []StockShortage
+}
+
+func (e *OversellError) Error() string {
+	ids := make([]string, len(e.Shortages))
+	for i, s := range e.Shortages {
+		ids[i] = fmt.Sprint(s.ProductID)
+	}
+	return "insufficient stock for products " + strings.Join(ids, ", ")
+}
+
+// checkoutConflict is the body of a checkout that would oversell.
+type checkoutConflict struct {
+	Error     string          `json:"error" example:"insufficient stock for products 1"`
+	Shortages []StockShortage `json:"shortages"`
+}
+
+// OrderQuery selects one page of orders.
+type OrderQuery struct {
+	// SortField is id or total. Empty orders by id.
+	SortField string
+	Desc      bool
+
+	Limit  int
+	Offset int
+}
+
+// CartStore persists carts and orders. Methods that take a cart, order or
+// product ID return an error wrapping ErrNotFound when it does not exist.
+type CartStore interface {
+	// CreateCart returns a new, empty cart.
+	CreateCart(ctx context.Context) (Cart, error)
+
+	// Cart returns a cart with its items priced at the current prices.
+	Cart(ctx context.Context, id int) (Cart, error)
+
+	// SetCartItem sets the quantity of a product in a cart and
+	// RemoveCartItem takes it out; both return the updated cart.
+	SetCartItem(ctx context.Context, cartID, productID, quantity int) (Cart, error)
+	RemoveCartItem(ctx context.Context, cartID, productID int) (Cart, error)
+
+	// DeleteCart removes a cart and its items.
+	DeleteCart(ctx context.Context, id int) error
+
+	// Checkout turns a cart into an order and takes its items out of stock,
+	// all or nothing. It returns ErrEmptyCart for a cart without items and
+	// an *OversellError when the stock does not cover it. The cart is
+	// removed by a successful checkout.
+	Checkout(ctx context.Context, cartID int) (Order, error)
+
+	// ListOrders returns one page of orders and the total number of orders.
+	ListOrders(ctx context.Context, q OrderQuery) ([]Order, int, error)
+
+	// Order returns an order.
+	Order(ctx context.Context, id int) (Order, error)
+}
+
+// requireCarts answers 503 when the router has no CartStore.
+func (h *handler) requireCarts(c *gin.Context) {
+	if h.carts == nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Carts not available"})
+		return
+	}
+	c.Next()
+}
+
+// createCart godoc
+// @Summary Create a cart
+// @Description Create an empty shopping cart
+// @ID createCart
+// @Tags carts
+// @Produce json
+// @Success 201 {object} Cart "Cart created"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /carts [post]
+func (h *handler) createCart(c *gin.Context) {
+	cart, err := h.carts.CreateCart(c.Request.Context())
+	if err != nil {
+		cartError(c, err)
+		return
+	}
+	h.events.publish(EventCreated, "carts", cart.ID, cart)
+	c.JSON(http.StatusCreated, cart)
+}
+
+// getCart godoc
+// @Summary Get a cart
+// @Description Get a cart with its items priced at the current product prices
+// @ID getCart
+// @Tags carts
+// @Produce json
+// @Param id path integer true "Cart ID"
+// @Success 200 {object} Cart "Cart"
+// @Failure 404 {object} map[string]string "Cart not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /carts/{id} [get]
+func (h *handler) getCart(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	cart, err := h.carts.Cart(c.Request.Context(), id)
+	if err != nil {
+		cartError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cart)
+}
+
+// deleteCart godoc
+// @Summary Delete a cart
+// @Description Abandon a cart and its items
+// @ID deleteCart
+// @Tags carts
+// @Param id path integer true "Cart ID"
+// @Success 200 {object} map[string]string "Cart deleted"
+// @Failure 404 {object} map[string]string "Cart not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /carts/{id} [delete]
+func (h *handler) deleteCart(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	if err := h.carts.DeleteCart(c.Request.Context(), id); err != nil {
+		cartError(c, err)
+		return
+	}
+	h.events.publish(EventDeleted, "carts", id, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Cart deleted"})
+}
+
+// setCartItem godoc
+// @Summary Set the quantity of a cart item
+// @Description Add a product to a cart or change its quantity. Stock is not checked until checkout.
+// @ID setCartItem
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Param id path integer true "Cart ID"
+// @Param productId path integer true "Product ID"
+// @Param quantity body CartItemQuantity true "Quantity of the product"
+// @Success 200 {object} Cart "Updated cart"
+// @Failure 400 {object} map[string]string "Invalid quantity"
+// @Failure 404 {object} map[string]string "Cart or product not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /carts/{id}/items/{productId} [put]
+func (h *handler) setCartItem(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	productID, ok := parseParam(c, "productId")
+	if !ok {
+		return
+	}
+	var body CartItemQuantity
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cart, err := h.carts.SetCartItem(c.Request.Context(), id, productID, body.Quantity)
+	if err != nil {
+		cartError(c, err)
+		return
+	}
+	h.events.publish(EventUpdated, "carts", id, cart)
+	c.JSON(http.StatusOK, cart)
+}
+
+// removeCartItem godoc
+// @Summary Remove a cart item
+// @Description Take a product out of a cart
+// @ID removeCartItem
+// @Tags carts
+// @Produce json
+// @Param id path integer true "Cart ID"
+// @Param productId path integer true "Product ID"
+// @Success 200 {object} Cart "Updated cart"
+// @Failure 404 {object} map[string]string "Cart not found or product not in the cart"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /carts/{id}/items/{productId} [delete]
+func (h *handler) removeCartItem(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	productID, ok := parseParam(c, "productId")
+	if !ok {
+		return
+	}
+	cart, err := h.carts.RemoveCartItem(c.Request.Context(), id, productID)
+	if err != nil {
+		cartError(c, err)
+		return
+	}
+	h.events.publish(EventUpdated, "carts", id, cart)
+	c.JSON(http.StatusOK, cart)
+}
+
+// checkoutCart godoc
+// @Summary Check out a cart
+// @Description Turn a cart into an order and take its items out of stock in one transaction. If the stock of any product does not cover the cart, nothing changes and the shortages are reported. The cart is removed once it is checked out.
+// @ID checkoutCart
+// @Tags carts
+// @Produce json
+// @Param id path integer true "Cart ID"
+// @Success 201 {object} Order "Order placed"
+// @Failure 404 {object} map[string]string "Cart not found"
+// @Failure 409 {object} checkoutConflict "Not enough stock"
+// @Failure 422 {object} map[string]string "Cart is empty"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /carts/{id}/checkout [post]
+func (h *handler) checkoutCart(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	order, err := h.carts.Checkout(c.Request.Context(), id)
+	if err != nil {
+		cartError(c, err)
+		return
+	}
+	// The checkout took the products out of stock without the store.
+	if h.cache != nil {
+		h.cache.invalidate(c.Request.Context())
+	}
+	h.events.publish(EventCreated, "orders", order.ID, order)
+	h.events.publish(EventDeleted, "carts", id, nil)
+	h.publishStock(c.Request.Context(), order)
+	c.JSON(http.StatusCreated, order)
+}
+
+// publishStock publishes the products an order took out of stock, as read
+// after the checkout. A product that cannot be read is skipped: the order
+// stands either way.
+func (h *handler) publishStock(ctx context.Context, order Order) {
+	for _, line := range order.Items {
+		product, _, err := h.store.Get(ctx, line.ProductID)
+		if err != nil {
+			requestLog(ctx).Warn("Reading checked out product failed", "product_id", line.ProductID, "error", err)
+			continue
+		}
+		h.events.publish(EventUpdated, "products", product.ID, product)
+	}
+}
+
+// getOrders godoc
+// @Summary Get all orders
+// @Description Get the list of placed orders
+// @ID getOrders
+// @Tags orders
+// @Param sort query string false "Field to sort by; prefix with '-' for descending order" Enums(id,total,-id,-total)
+// @Param limit query integer false "Maximum number of items to return" default(100) minimum(1) maximum(1000)
+// @Param offset query integer false "Number of items to skip" default(0) minimum(0)
+// @Success 200 {array} Order "List of orders"
+// @Header 200 {integer} X-Total-Count "Total number of orders"
+// @Header 200 {string} Link "RFC 8288 links to the first, prev, next and last pages"
+// @Failure 400 {object} map[string]string "Invalid query parameter"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /orders [get]
+func (h *handler) getOrders(c *gin.Context) {
+	q, err := parseListQuery(c, slices.Sorted(maps.Keys(orderSortColumns)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	orders, total, err := h.carts.ListOrders(c.Request.Context(), OrderQuery{
+		SortField: q.SortField,
+		Desc:      q.Desc,
+		Limit:     q.Limit,
+		Offset:    q.Offset,
+	})
+	if err != nil {
+		cartError(c, err)
+		return
+	}
+	setPageHeaders(c, q, total)
+	c.JSON(http.StatusOK, orders)
+}
+
+// getOrderByID godoc
+// @Summary Get order by ID
+// @Description Get a specific order by its ID
+// @ID getOrderByID
+// @Tags orders
+// @Param id path integer true "Order ID"
+// @Success 200 {object} Order "Order details"
+// @Failure 404 {object} map[string]string "Order not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /orders/{id} [get]
+func (h *handler) getOrderByID(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	order, err := h.carts.Order(c.Request.Context(), id)
+	if err != nil {
+		cartError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// cartError maps a CartStore error to a response.
+func cartError(c *gin.Context, err error) {
+	var oversell *OversellError
+	switch {
+	case errors.As(err, &oversell):
+		c.JSON(http.StatusConflict, checkoutConflict{Error: oversell.Error(), Shortages: oversell.Shortages})
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrEmptyCart):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
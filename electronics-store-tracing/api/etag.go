@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag formats a record version as a strong entity tag.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// entityTags splits an If-Match or If-None-Match header into its tags.
+func entityTags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// notModified answers 304 when the If-None-Match header of a read matches
+// version, comparing tags weakly as RFC 9110 requires. It reports whether
+// it did.
+func notModified(c *gin.Context, version int64) bool {
+	current := etag(version)
+	for _, tag := range entityTags(c.GetHeader("If-None-Match")) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == current {
+			c.Header("ETag", current)
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion returns the version a write requires the record to be at:
+// anyVersion without an If-Match header or with "*", otherwise the version
+// in the tag. current looks up the version of the record when the header
+// lists several tags. It answers 412 and returns false when no tag can
+// match, such as when all of them are weak.
+func ifMatchVersion(c *gin.Context, current func() (int64, error)) (int64, bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return anyVersion, true
+	}
+
+	var versions []int64
+	for _, tag := range entityTags(header) {
+		if tag == "*" {
+			return anyVersion, true
+		}
+		// Weak tags never match for writes.
+		v, err := strconv.ParseInt(strings.Trim(tag, `"`), 10, 64)
+		if err == nil && strings.HasPrefix(tag, `"`) && strings.HasSuffix(tag, `"`) && v > 0 {
+			versions = append(versions, v)
+		}
+	}
+	switch len(versions) {
+	case 0:
+		preconditionFailed(c)
+		return 0, false
+	case 1:
+		return versions[0], true
+	}
+
+	// The write still checks the version, so a change between this lookup
+	// and the write is caught too. When the lookup fails the write is made
+	// conditional on the first tag, and reports the error itself.
+	v, err := current()
+	if err != nil {
+		return versions[0], true
+	}
+	for _, candidate := range versions {
+		if candidate == v {
+			return v, true
+		}
+	}
+	preconditionFailed(c)
+	return 0, false
+}
+
+func preconditionFailed(c *gin.Context) {
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "If-Match does not match the current version"})
+}
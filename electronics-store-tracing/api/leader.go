@@ -0,0 +1,361 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Replicas of a service elect a leader to run jobs that must not run in
+// several pods at once. The election uses a Kubernetes Lease, through the
+// API server's REST interface and the pod's service account, so the service
+// needs no Kubernetes client library.
+
+// ErrLeadershipLost is returned by Lead when the replica stopped being the
+// leader while its job ran.
+var ErrLeadershipLost = errors.New("leadership lost")
+
+// Elector runs jobs on one replica at a time.
+type Elector interface {
+	// Lead blocks until this replica is the leader, then runs job with a
+	// context that is cancelled when ctx is done or leadership is lost.
+	// Leadership is given up when job returns. Lead returns job's error,
+	// ErrLeadershipLost if job returned nil after losing leadership, or
+	// ctx's error if ctx was done before the replica became the leader.
+	Lead(ctx context.Context, job func(ctx context.Context) error) error
+}
+
+// Standalone is the Elector of a replica that does not coordinate with
+// others. It is always the leader.
+var Standalone Elector = standalone{}
+
+type standalone struct{}
+
+func (standalone) Lead(ctx context.Context, job func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return job(ctx)
+}
+
+// LeaseElector elects a leader among the replicas that share a
+// coordination.k8s.io/v1 Lease.
+type LeaseElector struct {
+	// Server is the base URL of the Kubernetes API server, and Client the
+	// HTTP client that talks to it.
+	Server string
+	Client *http.Client
+	// Token returns the bearer token of the requests. It is called for
+	// every request, so that rotated service account tokens are picked up.
+	Token func() (string, error)
+
+	Namespace, Name string
+	// Identity names this replica in the Lease. It must be unique among the
+	// replicas; the pod name is.
+	Identity string
+
+	// Duration is how long the Lease stays held after its last renewal.
+	// The leader renews it every Renew and gives up leadership when it has
+	// not managed to for two thirds of Duration, before another replica
+	// may take over. The other replicas try to acquire it every Retry.
+	Duration, Renew, Retry time.Duration
+}
+
+// lease is the part of a Lease object the election reads and writes.
+type lease struct {
+	APIVersion string    `json:"apiVersion"`
+	Kind       string    `json:"kind"`
+	Metadata   leaseMeta `json:"metadata"`
+	Spec       leaseSpec `json:"spec"`
+}
+
+type leaseMeta struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int    `json:"leaseTransitions,omitempty"`
+}
+
+// microTime is the layout of the Lease's time fields.
+const microTime = "2006-01-02T15:04:05.000000Z07:00"
+
+// observation is what a replica last saw of a Lease held by another. The
+// Lease expires Duration after it last changed, as measured on the local
+// clock, so that clock skew between pods does not matter.
+type observation struct {
+	resourceVersion string
+	at              time.Time
+}
+
+func (e *LeaseElector) Lead(ctx context.Context, job func(ctx context.Context) error) error {
+	var seen observation
+	for {
+		held, err := e.acquire(ctx, &seen)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("Acquiring lease failed", "lease", e.Name, "error", err)
+		}
+		if held {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(e.Retry):
+		}
+	}
+	slog.Info("Became leader", "lease", e.Name, "identity", e.Identity)
+
+	leadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var lost bool
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		lost = e.keep(leadCtx, &seen)
+		cancel()
+	}()
+
+	err := job(leadCtx)
+	cancel()
+	wg.Wait()
+	if lost {
+		slog.Warn("Lost leadership", "lease", e.Name, "identity", e.Identity)
+		if err == nil {
+			err = ErrLeadershipLost
+		}
+		return err
+	}
+	e.release()
+	return err
+}
+
+// keep renews the Lease until ctx is done, and reports whether leadership
+// was lost first.
+func (e *LeaseElector) keep(ctx context.Context, seen *observation) bool {
+	ticker := time.NewTicker(e.Renew)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+		held, err := e.acquire(ctx, seen)
+		switch {
+		case held:
+			renewed = time.Now()
+		case ctx.Err() != nil:
+			return false
+		case err == nil:
+			// Another replica holds the Lease.
+			return true
+		case time.Since(renewed) > e.Duration*2/3:
+			slog.Warn("Renewing lease failed", "lease", e.Name, "error", err)
+			return true
+		}
+	}
+}
+
+// acquire takes or renews the Lease, creating it if needed. It returns false
+// without an error when another replica holds it.
+func (e *LeaseElector) acquire(ctx context.Context, seen *observation) (bool, error) {
+	now := time.Now()
+	var current lease
+	status, err := e.do(ctx, http.MethodGet, e.Name, nil, &current)
+	if err != nil {
+		return false, err
+	}
+	if status == http.StatusNotFound {
+		l := lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   leaseMeta{Name: e.Name, Namespace: e.Namespace},
+			Spec: leaseSpec{
+				HolderIdentity:       e.Identity,
+				LeaseDurationSeconds: e.durationSeconds(),
+				AcquireTime:          now.UTC().Format(microTime),
+				RenewTime:            now.UTC().Format(microTime),
+			},
+		}
+		status, err = e.do(ctx, http.MethodPost, "", l, nil)
+		return err == nil && status != http.StatusConflict, err
+	}
+
+	holder := current.Spec.HolderIdentity
+	if current.Metadata.ResourceVersion != seen.resourceVersion {
+		seen.resourceVersion, seen.at = current.Metadata.ResourceVersion, now
+	}
+	if holder != "" && holder != e.Identity && now.Before(seen.at.Add(e.Duration)) {
+		return false, nil
+	}
+
+	if holder != e.Identity {
+		current.Spec.AcquireTime = now.UTC().Format(microTime)
+		current.Spec.LeaseTransitions++
+	}
+	current.Spec.HolderIdentity = e.Identity
+	current.Spec.LeaseDurationSeconds = e.durationSeconds()
+	current.Spec.RenewTime = now.UTC().Format(microTime)
+	status, err = e.do(ctx, http.MethodPut, e.Name, current, &current)
+	if err != nil || status == http.StatusConflict {
+		// Conflict: another replica wrote the Lease since we read it.
+		return false, err
+	}
+	seen.resourceVersion, seen.at = current.Metadata.ResourceVersion, now
+	return true, nil
+}
+
+// durationSeconds is Duration as recorded in the Lease, for kubectl's
+// benefit: the replicas go by their own Duration.
+func (e *LeaseElector) durationSeconds() int {
+	return int((e.Duration + time.Second - 1) / time.Second)
+}
+
+// release clears the holder of the Lease, so that another replica can take
+// over without waiting for it to expire.
+func (e *LeaseElector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.Renew)
+	defer cancel()
+	var current lease
+	status, err := e.do(ctx, http.MethodGet, e.Name, nil, &current)
+	if err == nil && status == http.StatusOK && current.Spec.HolderIdentity == e.Identity {
+		current.Spec.HolderIdentity = ""
+		_, err = e.do(ctx, http.MethodPut, e.Name, current, nil)
+	}
+	if err != nil {
+		slog.Warn("Releasing lease failed", "lease", e.Name, "error", err)
+	}
+}
+
+// do sends a request for the Lease named name, or to the Lease collection
+// when name is empty. Not Found and Conflict are returned as statuses
+// rather than errors.
+func (e *LeaseElector) do(ctx context.Context, method, name string, body, out any) (int, error) {
+	url := e.Server + path.Join("/apis/coordination.k8s.io/v1/namespaces", e.Namespace, "leases", name)
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if e.Token != nil {
+		token, err := e.Token()
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusConflict:
+		return resp.StatusCode, nil
+	case resp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, fmt.Errorf("%s lease %s: %s: %s", method, e.Name, resp.Status, bytes.TrimSpace(msg))
+	case out != nil:
+		return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.StatusCode, nil
+}
+
+// serviceAccountDir is where Kubernetes mounts the pod's service account
+// credentials.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// ElectorFromEnv returns a LeaseElector for the Lease named by
+// LEADER_ELECTION_LEASE, in the namespace named by POD_NAMESPACE, with
+// POD_NAME as the identity. It returns Standalone when LEADER_ELECTION_LEASE
+// is unset. LEADER_ELECTION_LEASE_SECONDS sets the lease duration, 15 by
+// default.
+func ElectorFromEnv() (Elector, error) {
+	name := os.Getenv("LEADER_ELECTION_LEASE")
+	if name == "" {
+		return Standalone, nil
+	}
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("LEADER_ELECTION_LEASE is set outside a Kubernetes pod")
+	}
+	ca, err := os.ReadFile(path.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return nil, errors.New("no certificates in the service account CA bundle")
+	}
+
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace == "" {
+		b, err := os.ReadFile(path.Join(serviceAccountDir, "namespace"))
+		if err != nil {
+			return nil, err
+		}
+		namespace = strings.TrimSpace(string(b))
+	}
+	identity := os.Getenv("POD_NAME")
+	if identity == "" {
+		if identity, err = os.Hostname(); err != nil {
+			return nil, err
+		}
+	}
+
+	duration := envSeconds("LEADER_ELECTION_LEASE_SECONDS", 15*time.Second)
+	if duration < 3*time.Second {
+		duration = 3 * time.Second
+	}
+	return &LeaseElector{
+		Server: "https://" + net.JoinHostPort(host, port),
+		Client: &http.Client{
+			Timeout:   duration / 3,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}},
+		},
+		Token: func() (string, error) {
+			b, err := os.ReadFile(path.Join(serviceAccountDir, "token"))
+			return strings.TrimSpace(string(b)), err
+		},
+		Namespace: namespace,
+		Name:      name,
+		Identity:  identity,
+		Duration:  duration,
+		Renew:     duration / 5,
+		Retry:     duration / 5,
+	}, nil
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openAPITestRequests exercise the documented responses that can be reached
+// without a database.
+var openAPITestRequests = []struct {
+	method, path, body string
+	status             int
+}{
+	{"GET", "/livez", "", http.StatusOK},
+	{"GET", "/health", "", http.StatusServiceUnavailable},
+	{"GET", "/openapi.json", "", http.StatusOK},
+	{"GET", "/docs", "", http.StatusOK},
+	{"GET", "/readyz", "", http.StatusServiceUnavailable},
+	{"GET", "/products?sort=-price&limit=5", "", http.StatusServiceUnavailable},
+	{"GET", "/products/1", "", http.StatusServiceUnavailable},
+	{"POST", "/products", `{"name":"Laptop","price":999.99}`, http.StatusServiceUnavailable},
+	{"PUT", "/products/1", `{"name":"Laptop","price":899.99}`, http.StatusServiceUnavailable},
+	{"DELETE", "/products/1", "", http.StatusServiceUnavailable},
+	{"GET", "/admin/fixtures", "", http.StatusServiceUnavailable},
+	{"POST", "/carts", "", http.StatusServiceUnavailable},
+	{"PUT", "/carts/1/items/2", `{"quantity":2}`, http.StatusServiceUnavailable},
+	{"POST", "/carts/1/checkout", "", http.StatusServiceUnavailable},
+	{"GET", "/orders?sort=-total", "", http.StatusServiceUnavailable},
+	{"GET", "/events?follow=false", "", http.StatusOK},
+	{"GET", "/events?last_event_id=0&resource=products&follow=false", "", http.StatusOK},
+	{"POST", "/webhooks", `{"url":"http://127.0.0.1:1/hooks","resources":["products","orders"]}`, http.StatusCreated},
+	{"GET", "/webhooks", "", http.StatusOK},
+	{"GET", "/webhooks/1", "", http.StatusOK},
+	{"GET", "/webhooks/99", "", http.StatusNotFound},
+	{"DELETE", "/webhooks/1", "", http.StatusOK},
+}
+
+// openAPIInvalidRequests are rejected by the validator before reaching the
+// handlers.
+var openAPIInvalidRequests = []struct {
+	method, path, body string
+}{
+	{"GET", "/products?limit=0", ""},
+	{"GET", "/products?sort=stock", ""},
+	{"GET", "/products/abc", ""},
+	{"POST", "/products", `{"price":1}`},
+	{"PUT", "/products/1", `{"name":"Laptop","price":-1}`},
+	{"POST", "/admin/fixtures", `[{"price":-1}]`},
+	{"POST", "/admin/fixtures/generate", ""},
+	{"PUT", "/carts/1/items/2", `{"quantity":0}`},
+	{"GET", "/orders?sort=created_at", ""},
+	{"GET", "/events?resource=customers", ""},
+	{"GET", "/events?last_event_id=-1", ""},
+	{"POST", "/webhooks", `{"resources":["products"]}`},
+}
+
+// newTestRouter returns a router whose database has not been initialised, with
+// request validation enabled.
+func newTestRouter(validateResponses bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return NewRouter(NewSQLStore(nil), Config{
+		ValidateRequests:  true,
+		ValidateResponses: validateResponses,
+	})
+}
+
+var ginPathParam = regexp.MustCompile(`:(\w+)`)
+
+// openAPIPath converts a gin route path to OpenAPI path template syntax.
+func openAPIPath(path string) string {
+	return ginPathParam.ReplaceAllString(path, "{$1}")
+}
+
+func TestRoutesMatchOpenAPISpec(t *testing.T) {
+	doc, err := loadOpenAPISpec()
+	require.NoError(t, err)
+
+	var routes []string
+	for _, route := range newTestRouter(false).Routes() {
+		routes = append(routes, route.Method+" "+openAPIPath(route.Path))
+	}
+	var documented []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+	slices.Sort(routes)
+	slices.Sort(documented)
+
+	assert.Equal(t, documented, routes, "routes and OpenAPI operations differ; annotate the handler and run go generate")
+}
+
+func TestResponsesMatchOpenAPISpec(t *testing.T) {
+	r := newTestRouter(true)
+
+	for _, tc := range openAPITestRequests {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			saved := responseMismatch
+			defer func() { responseMismatch = saved }()
+			responseMismatch = func(c *gin.Context, err error) {
+				t.Errorf("response does not match the OpenAPI spec: %v", err)
+			}
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestInvalidRequestsAreRejected(t *testing.T) {
+	r := newTestRouter(false)
+
+	for _, tc := range openAPIInvalidRequests {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), `"error"`)
+		})
+	}
+}
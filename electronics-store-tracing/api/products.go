@@ -0,0 +1,450 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrNotFound is returned by a Store when no product has the requested
+	// ID.
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict is returned by a Store when a product name or ID is
+	// already taken by another product.
+	ErrConflict = errors.New("conflict")
+
+	// ErrVersionMismatch is returned by a conditional write when the
+	// product is no longer at the version the caller read.
+	ErrVersionMismatch = errors.New("version mismatch")
+)
+
+// errAssignedID rejects a new product that names its own ID, which could
+// otherwise take the place of a product created meanwhile.
+var errAssignedID = errors.New("id is assigned by the service, use PUT /products/{id} to replace a product")
+
+// anyVersion makes Update and Delete unconditional.
+const anyVersion = 0
+
+// Product is an item in the catalogue. Stock is the number of units that
+// can still be sold; checkout decrements it.
+type Product struct {
+	ID    int     `json:"id" example:"1"`
+	Name  string  `json:"name" binding:"required" example:"Laptop"`
+	Price float64 `json:"price" binding:"min=0" minimum:"0" example:"999.99"`
+	Stock int     `json:"stock" binding:"min=0" minimum:"0" example:"25"`
+}
+
+// ProductPatch documents the JSON Merge Patch for a product: the members it
+// has replace those of the product, and null removes them.
+type ProductPatch struct {
+	Name  string  `json:"name,omitempty" nullable:"true" example:"Laptop"`
+	Price float64 `json:"price,omitempty" nullable:"true" minimum:"0" example:"999.99"`
+	Stock int     `json:"stock,omitempty" nullable:"true" minimum:"0" example:"25"`
+}
+
+// ProductQuery selects one page of products. Nil price bounds and an empty
+// name do not filter.
+type ProductQuery struct {
+	// Name is a case-insensitive substring of the product name.
+	Name     string
+	MinPrice *float64
+	MaxPrice *float64
+
+	// SortField is one of id, name or price. Empty orders by id.
+	SortField string
+	Desc      bool
+
+	Limit  int
+	Offset int
+}
+
+// Store persists the product catalogue. Get, Update and Delete return
+// ErrNotFound for unknown IDs.
+type Store interface {
+	// List returns one page of the products matching q and the total number
+	// of matches.
+	List(ctx context.Context, q ProductQuery) ([]Product, int, error)
+
+	// Get returns a product with its version, which changes whenever the
+	// product does.
+	Get(ctx context.Context, id int) (Product, int64, error)
+
+	// Create stores a new product, assigning an ID when it has none, and
+	// returns it as stored. It returns ErrConflict when the ID or name is
+	// taken; only Import replaces stored products.
+	Create(ctx context.Context, product Product) (Product, error)
+
+	// Update replaces the product with the given ID and returns it as
+	// stored with its new version. Update and Delete return
+	// ErrVersionMismatch unless ifVersion is anyVersion or the current
+	// version.
+	Update(ctx context.Context, id int, product Product, ifVersion int64) (Product, int64, error)
+	Delete(ctx context.Context, id int, ifVersion int64) error
+
+	// Count, Import and Export back the admin fixture endpoints, as
+	// described by fixtureSet.
+	Count(ctx context.Context) (int, error)
+	Import(ctx context.Context, products []Product, replace bool) (int, error)
+	Export(ctx context.Context) ([]Product, error)
+}
+
+// LoadSeedFixtures applies the start-up dataset configured through the
+// environment to store.
+func LoadSeedFixtures(ctx context.Context, store Store) error {
+	return loadSeedFixtures(ctx, productFixtures{store})
+}
+
+// SeedSpec describes the start-up dataset LoadSeedFixtures applies: the
+// SEED_MODE and SEED_GENERATE settings and a hash of the SEED_FILE contents,
+// so that changing any of them changes the spec.
+func SeedSpec() (string, error) {
+	spec := fmt.Sprintf("mode=%s generate=%s", os.Getenv("SEED_MODE"), os.Getenv("SEED_GENERATE"))
+	if file := os.Getenv("SEED_FILE"); file != "" {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		spec += fmt.Sprintf(" file=%s sha256=%x", file, sha256.Sum256(body))
+	}
+	return spec, nil
+}
+
+// getProducts godoc
+// @Summary Get all products
+// @Description Get list of all available products with tracing
+// @Tags products
+// @Param name query string false "Case-insensitive substring of the name"
+// @Param minPrice query number false "Minimum price (inclusive)"
+// @Param maxPrice query number false "Maximum price (inclusive)"
+// @Param sort query string false "Field to sort by; prefix with '-' for descending order" Enums(id,name,price,-id,-name,-price)
+// @Param limit query integer false "Maximum number of items to return" default(100) minimum(1) maximum(1000)
+// @Param offset query integer false "Number of items to skip" default(0) minimum(0)
+// @Success 200 {array} Product "List of products"
+// @Header 200 {integer} X-Total-Count "Total number of items matching the filters"
+// @Header 200 {string} Link "RFC 8288 links to the first, prev, next and last pages"
+// @Failure 400 {object} map[string]string "Invalid query parameter"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /products [get]
+func (h *handler) getProducts(c *gin.Context) {
+	q, err := parseListQuery(c, slices.Sorted(maps.Keys(productSortColumns)))
+	minPrice, minErr := queryFloat(c, "minPrice")
+	maxPrice, maxErr := queryFloat(c, "maxPrice")
+	if err := errors.Join(err, minErr, maxErr); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	products, total, err := h.store.List(c.Request.Context(), ProductQuery{
+		Name:      c.Query("name"),
+		MinPrice:  minPrice,
+		MaxPrice:  maxPrice,
+		SortField: q.SortField,
+		Desc:      q.Desc,
+		Limit:     q.Limit,
+		Offset:    q.Offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	setPageHeaders(c, q, total)
+	c.JSON(http.StatusOK, products)
+}
+
+// getProductByID godoc
+// @Summary Get product by ID
+// @Description Get a specific product by its ID with tracing
+// @ID getProductByID
+// @Tags products
+// @Param id path integer true "Product ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} Product "Product details"
+// @Success 304 "Cached copy is current"
+// @Header 200,304 {string} ETag "Version of the product"
+// @Failure 404 {object} map[string]string "Product not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /products/{id} [get]
+func (h *handler) getProductByID(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	product, version, err := h.store.Get(c.Request.Context(), id)
+	if err != nil {
+		productError(c, err)
+		return
+	}
+	if notModified(c, version) {
+		return
+	}
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, product)
+}
+
+// createProduct godoc
+// @Summary Create a new product
+// @Description Add a product to the catalogue. The id is assigned by the service; use PUT to replace a product.
+// @ID createProduct
+// @Tags products
+// @Accept json
+// @Param product body Product true "Product to add"
+// @Success 201 {object} Product "Product created"
+// @Failure 400 {object} map[string]string "Invalid input, or an id"
+// @Failure 409 {object} map[string]string "Name taken by another product"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /products [post]
+func (h *handler) createProduct(c *gin.Context) {
+	var newProduct Product
+	if err := c.ShouldBindJSON(&newProduct); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if newProduct.ID != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errAssignedID.Error()})
+		return
+	}
+	created, err := h.store.Create(c.Request.Context(), newProduct)
+	if err != nil {
+		productError(c, err)
+		return
+	}
+	h.events.publish(EventCreated, "products", created.ID, created)
+	c.JSON(http.StatusCreated, created)
+}
+
+// updateProduct godoc
+// @Summary Update a product
+// @Description Replace an existing product; the id in the path takes precedence over the body. With If-Match, only the given version is replaced.
+// @ID updateProduct
+// @Tags products
+// @Accept json
+// @Param id path integer true "Product ID"
+// @Param If-Match header string false "ETag the product must have"
+// @Param product body Product true "Updated product"
+// @Success 200 {object} Product "Product updated"
+// @Header 200 {string} ETag "New version of the product"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Product not found"
+// @Failure 412 {object} map[string]string "Product has changed"
+// @Failure 409 {object} map[string]string "Name taken by another product"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /products/{id} [put]
+func (h *handler) updateProduct(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var updatedProduct Product
+	if err := c.ShouldBindJSON(&updatedProduct); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c, h.currentVersion(c, id))
+	if !ok {
+		return
+	}
+	updated, version, err := h.store.Update(c.Request.Context(), id, updatedProduct, ifVersion)
+	if err != nil {
+		productError(c, err)
+		return
+	}
+	h.events.publish(EventUpdated, "products", id, updated)
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// deleteProduct godoc
+// @Summary Delete a product
+// @Description Remove a product from the catalogue. With If-Match, only the given version is removed.
+// @ID deleteProduct
+// @Tags products
+// @Param id path integer true "Product ID"
+// @Param If-Match header string false "ETag the product must have"
+// @Success 200 {object} map[string]string "Product deleted"
+// @Failure 404 {object} map[string]string "Product not found"
+// @Failure 412 {object} map[string]string "Product has changed"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /products/{id} [delete]
+func (h *handler) deleteProduct(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c, h.currentVersion(c, id))
+	if !ok {
+		return
+	}
+	if err := h.store.Delete(c.Request.Context(), id, ifVersion); err != nil {
+		productError(c, err)
+		return
+	}
+	h.events.publish(EventDeleted, "products", id, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Product deleted"})
+}
+
+// patchProduct godoc
+// @Summary Patch a product
+// @Description Change some fields of a product with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902). The patched product must be valid; the id in the path takes precedence. With If-Match, only the given version is patched.
+// @ID patchProduct
+// @Tags products
+// @Accept merge-patch,json-patch
+// @Produce json
+// @Param id path integer true "Product ID"
+// @Param If-Match header string false "ETag the product must have"
+// @Param patch body ProductPatch true "Merge patch or JSON Patch to apply" accept(merge-patch)
+// @Param operations body []PatchOperation true "Operations to apply" accept(json-patch)
+// @Success 200 {object} Product "Product updated"
+// @Header 200 {string} ETag "New version of the product"
+// @Failure 400 {object} map[string]string "Malformed patch"
+// @Failure 404 {object} map[string]string "Product not found"
+// @Failure 409 {object} map[string]string "Patch does not apply to the product, or its name is taken"
+// @Failure 412 {object} map[string]string "Product has changed"
+// @Failure 415 {object} map[string]string "Unsupported patch format"
+// @Failure 422 {object} map[string]string "Patched product is invalid"
+// @Failure 500 {object} map[string]string "Database error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /products/{id} [patch]
+func (h *handler) patchProduct(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	updated, version, ok := patchRecord(c, h.store, id, "Product", productError)
+	if !ok {
+		return
+	}
+	h.events.publish(EventUpdated, "products", id, updated)
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// productError maps a store error to a response.
+func productError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case errors.Is(err, ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Product name already exists"})
+	case errors.Is(err, ErrVersionMismatch):
+		preconditionFailed(c)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// currentVersion returns a lookup of the version of product id, for
+// ifMatchVersion.
+func (h *handler) currentVersion(c *gin.Context, id int) func() (int64, error) {
+	return func() (int64, error) {
+		_, version, err := h.store.Get(c.Request.Context(), id)
+		return version, err
+	}
+}
+
+// productFixtures exposes the store to the fixture endpoints.
+type productFixtures struct {
+	Store
+}
+
+var (
+	productBrands = []string{"Acme", "Nova", "Pulse", "Vertex", "Zenith", "Orbit", "Quantum", "Apex"}
+	productKinds  = []string{"Laptop", "Smartphone", "Tablet", "Headphones", "Monitor", "Camera", "Speaker", "Router"}
+)
+
+func (productFixtures) Generate(start, n int) []Product {
+	items := make([]Product, n)
+	for i := range items {
+		items[i] = Product{
+			Name:  fmt.Sprintf("%s %s %d", productBrands[rand.IntN(len(productBrands))], productKinds[rand.IntN(len(productKinds))], start+i+1),
+			Price: float64(999+rand.IntN(300000)) / 100,
+			Stock: rand.IntN(200),
+		}
+	}
+	return items
+}
+
+// exportProductFixtures writes every product as JSON or CSV.
+//
+// @Summary Export fixtures
+// @Description Export every record as JSON or CSV
+// @ID exportFixtures
+// @Tags admin
+// @Produce json,csv
+// @Param format query string false "Export format" Enums(json,csv) default(json)
+// @Success 200 {array} Product "All records"
+// @Failure 400 {object} map[string]string "Unsupported format"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /admin/fixtures [get]
+func (h *handler) exportProductFixtures(c *gin.Context) {
+	exportFixtures(c, productFixtures{h.store})
+}
+
+// importProductFixtures bulk-loads products from the request body.
+//
+// @Summary Import fixtures
+// @Description Bulk-load records from a JSON array or CSV file. Records without an id are assigned one; records with an existing id overwrite it. Records are validated as on create and the first invalid one is rejected with its index. The body may be up to 64 MiB whatever the service's body limit.
+// @ID importFixtures
+// @Tags admin
+// @Accept json,csv
+// @Param mode query string false "append keeps existing records; replace removes them first" Enums(append,replace) default(append)
+// @Param products body []Product true "Records to import; CSV files need a header row naming the JSON fields"
+// @Success 200 {object} fixtureResult "Records stored"
+// @Failure 400 {object} map[string]string "Invalid records or mode"
+// @Failure 415 {object} map[string]string "Unsupported content type"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /admin/fixtures [post]
+func (h *handler) importProductFixtures(c *gin.Context) {
+	importFixtures(c, productFixtures{h.store})
+}
+
+// generateProductFixtures stores synthetic products.
+//
+// @Summary Generate fixtures
+// @Description Store count synthetic records
+// @ID generateFixtures
+// @Tags admin
+// @Param count query integer true "Number of records to generate" minimum(1) maximum(100000)
+// @Param mode query string false "append keeps existing records; replace removes them first" Enums(append,replace) default(append)
+// @Success 200 {object} fixtureResult "Records stored"
+// @Failure 400 {object} map[string]string "Invalid count or mode"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Failure 503 {object} map[string]string "Database not available"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /admin/fixtures/generate [post]
+func (h *handler) generateProductFixtures(c *gin.Context) {
+	generateFixtures(c, productFixtures{h.store})
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// productSortColumns maps the sort= values accepted by GET /products to
+// columns. Only whitelisted columns are ever interpolated into SQL.
+var productSortColumns = map[string]string{
+	"id":    "id",
+	"name":  "name",
+	"price": "price",
+}
+
+// productBatchSize is the number of rows written per INSERT when importing
+// fixtures.
+const productBatchSize = 500
+
+// mysqlDuplicateEntry is the MySQL error number for a unique key violation.
+const mysqlDuplicateEntry = 1062
+
+// productInsert is the plain INSERT of Create, which fails with a duplicate
+// entry when the id or name is taken.
+const productInsert = "INSERT INTO products (id, name, price, stock) VALUES (?, ?, ?, ?)"
+
+// The upsert used by Import is split around its VALUES rows. A row whose id
+// or name is taken updates that row and its version, and LAST_INSERT_ID
+// reports its id either way.
+const (
+	productUpsertPrefix = "INSERT INTO products (id, name, price, stock) VALUES "
+	productUpsertSuffix = " ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), name = VALUES(name), price = VALUES(price), stock = VALUES(stock), version = version + 1"
+)
+
+// sqlStore keeps the catalogue in the MySQL products table. The version
+// column counts the writes to each row, so a product that is deleted and
+// created again starts over at version 1.
+type sqlStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore returns a Store backed by the products table of db. The
+// schema is created by the migrations package.
+func NewSQLStore(db *sql.DB) Store {
+	return sqlStore{db: db}
+}
+
+// Ping reports whether the database is reachable; it adds the database
+// readiness check.
+func (s sqlStore) Ping(ctx context.Context) error {
+	if s.db == nil {
+		return errors.New("database connection not initialized")
+	}
+	return s.db.PingContext(ctx)
+}
+
+// where returns the WHERE clause and arguments for the filters of q.
+func (q ProductQuery) where() (string, []any) {
+	var conds []string
+	var args []any
+	if q.Name != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q.Name)
+		conds = append(conds, "name LIKE ?")
+		args = append(args, "%"+escaped+"%")
+	}
+	if q.MinPrice != nil {
+		conds = append(conds, "price >= ?")
+		args = append(args, *q.MinPrice)
+	}
+	if q.MaxPrice != nil {
+		conds = append(conds, "price <= ?")
+		args = append(args, *q.MaxPrice)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func (s sqlStore) List(ctx context.Context, q ProductQuery) ([]Product, int, error) {
+	where, args := q.where()
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM products"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order := "id"
+	if column, ok := productSortColumns[q.SortField]; ok {
+		order = column
+	}
+	if q.Desc {
+		order += " DESC"
+	}
+	query := "SELECT id, name, price, stock FROM products" + where + " ORDER BY " + order + " LIMIT ? OFFSET ?"
+	rows, err := s.db.QueryContext(ctx, query, append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	products := []Product{}
+	for rows.Next() {
+		var product Product
+		if err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock); err != nil {
+			return nil, 0, err
+		}
+		products = append(products, product)
+	}
+	return products, total, rows.Err()
+}
+
+func (s sqlStore) Get(ctx context.Context, id int) (Product, int64, error) {
+	var product Product
+	var version int64
+	err := s.db.QueryRowContext(ctx, "SELECT id, name, price, stock, version FROM products WHERE id = ?", id).Scan(&product.ID, &product.Name, &product.Price, &product.Stock, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return product, 0, ErrNotFound
+	}
+	return product, version, err
+}
+
+func (s sqlStore) Create(ctx context.Context, product Product) (Product, error) {
+	var id any
+	if product.ID != 0 {
+		id = product.ID
+	}
+	res, err := s.db.ExecContext(ctx, productInsert, id, product.Name, product.Price, product.Stock)
+	if err != nil {
+		return product, storeError(err)
+	}
+	newID, err := res.LastInsertId()
+	if err != nil {
+		return product, err
+	}
+	product.ID = int(newID)
+	return product, nil
+}
+
+// Update changes the row with the given ID, checking its version in the
+// same statement. LAST_INSERT_ID reports the new version. A write that
+// matches no row is told apart as a missing row or a stale version by a
+// second lookup.
+func (s sqlStore) Update(ctx context.Context, id int, product Product, ifVersion int64) (Product, int64, error) {
+	product.ID = id
+	query := "UPDATE products SET name = ?, price = ?, stock = ?, version = LAST_INSERT_ID(version + 1) WHERE id = ?"
+	args := []any{product.Name, product.Price, product.Stock, id}
+	if ifVersion != anyVersion {
+		query += " AND version = ?"
+		args = append(args, ifVersion)
+	}
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return product, 0, storeError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return product, 0, err
+	}
+	if n == 0 {
+		return product, 0, s.missed(ctx, id)
+	}
+	version, err := res.LastInsertId()
+	return product, version, err
+}
+
+func (s sqlStore) Delete(ctx context.Context, id int, ifVersion int64) error {
+	query := "DELETE FROM products WHERE id = ?"
+	args := []any{id}
+	if ifVersion != anyVersion {
+		query += " AND version = ?"
+		args = append(args, ifVersion)
+	}
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	if ifVersion == anyVersion {
+		return ErrNotFound
+	}
+	return s.missed(ctx, id)
+}
+
+// missed explains a write to product id that matched no row: ErrNotFound when
+// the row is gone, otherwise ErrVersionMismatch.
+func (s sqlStore) missed(ctx context.Context, id int) error {
+	if _, _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return ErrVersionMismatch
+}
+
+func (s sqlStore) Count(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM products").Scan(&n)
+	return n, err
+}
+
+// Import upserts the products in batches inside a single transaction, so a
+// failed import leaves the table unchanged.
+func (s sqlStore) Import(ctx context.Context, items []Product, replace bool) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if replace {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM products"); err != nil {
+			return 0, err
+		}
+	}
+	for batch := range slices.Chunk(items, productBatchSize) {
+		rows := make([]string, len(batch))
+		args := make([]any, 0, 4*len(batch))
+		for i, p := range batch {
+			rows[i] = "(?, ?, ?, ?)"
+			var id any
+			if p.ID != 0 {
+				id = p.ID
+			}
+			args = append(args, id, p.Name, p.Price, p.Stock)
+		}
+		query := productUpsertPrefix + strings.Join(rows, ", ") + productUpsertSuffix
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("importing products: %w", err)
+		}
+	}
+
+	var total int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM products").Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, tx.Commit()
+}
+
+func (s sqlStore) Export(ctx context.Context) ([]Product, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, price, stock FROM products ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []Product
+	for rows.Next() {
+		var p Product
+		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// storeError translates a unique key violation into ErrConflict.
+func storeError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return fmt.Errorf("%w: %s", ErrConflict, mysqlErr.Message)
+	}
+	return err
+}
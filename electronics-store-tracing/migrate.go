@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"electronics-store-tracing/api"
+	"electronics-store-tracing/migrations"
+)
+
+const migrateUsage = `usage: electronics-store-be migrate <command> [flags]
+
+commands:
+  up       apply all pending schema migrations
+  down     revert the most recent migrations (-steps N, default 1)
+  status   print the applied and pending migrations
+  seed     load the default data set, then the fixtures named by SEED_FILE
+           and SEED_GENERATE; runs once until the seed settings change`
+
+// runMigrate implements the "migrate" subcommand. It waits for the database
+// the same way the server does, so it can run as an init container that
+// starts before MySQL is ready. "up" and "seed" run while the replica is the
+// leader named by LEADER_ELECTION_LEASE, so that the init containers of
+// replicas starting together take turns.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n%s", migrateUsage)
+	}
+	command := args[0]
+
+	flags := flag.NewFlagSet("migrate "+command, flag.ContinueOnError)
+	steps := flags.Int("steps", 1, "number of migrations to revert (down only)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg := loadDBConfig()
+	conn, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	if err := waitForDB(ctx, conn, cfg.StartupTimeout); err != nil {
+		return fmt.Errorf("database %s not reachable: %w", cfg.Host, err)
+	}
+
+	migrator, err := migrations.New(conn)
+	if err != nil {
+		return err
+	}
+
+	elector, err := api.ElectorFromEnv()
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	switch command {
+	case "up":
+		var n int
+		err := elector.Lead(ctx, func(ctx context.Context) (err error) {
+			n, err = migrator.Up(ctx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s) in %s, schema is at version %d\n", n, time.Since(start), migrator.Latest())
+	case "down":
+		n, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migration(s) in %s\n", n, time.Since(start))
+	case "status":
+		applied, err := migrator.Applied(ctx)
+		if err != nil {
+			return err
+		}
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied versions: %v\n", applied)
+		for _, mig := range pending {
+			fmt.Printf("Pending: %d_%s\n", mig.Version, mig.Name)
+		}
+	case "seed":
+		spec, err := api.SeedSpec()
+		if err != nil {
+			return err
+		}
+		var seeded bool
+		err = elector.Lead(ctx, func(ctx context.Context) (err error) {
+			seeded, err = migrator.SeedOnce(ctx, spec, func(ctx context.Context) error {
+				return api.LoadSeedFixtures(ctx, api.NewSQLStore(conn))
+			})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if !seeded {
+			fmt.Printf("Seed data already loaded (%s)\n", spec)
+			return nil
+		}
+		fmt.Printf("Seed data loaded in %s\n", time.Since(start))
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", command, migrateUsage)
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS products (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL UNIQUE,
+	price DECIMAL(10, 2) NOT NULL
+);
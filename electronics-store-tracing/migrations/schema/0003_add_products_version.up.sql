@@ -0,0 +1,11 @@
+-- Every write to a product increments its version, which the API serves as
+-- the ETag of the product. The column is only added when it is missing, so
+-- that the migration can be re-run.
+SET @ddl = IF(
+	(SELECT COUNT(*) FROM information_schema.COLUMNS
+	 WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'products' AND COLUMN_NAME = 'version') = 0,
+	'ALTER TABLE products ADD COLUMN version BIGINT NOT NULL DEFAULT 1',
+	'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
@@ -0,0 +1,11 @@
+-- Units in stock. Checkout decrements it with the product row locked, so it
+-- never drops below zero. The column is only added when it is missing, so
+-- that the migration can be re-run.
+SET @ddl = IF(
+	(SELECT COUNT(*) FROM information_schema.COLUMNS
+	 WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'products' AND COLUMN_NAME = 'stock') = 0,
+	'ALTER TABLE products ADD COLUMN stock INT NOT NULL DEFAULT 0',
+	'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
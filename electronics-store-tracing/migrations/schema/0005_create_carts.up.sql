@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS carts (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
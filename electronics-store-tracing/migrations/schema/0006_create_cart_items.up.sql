@@ -0,0 +1,9 @@
+-- Removing a cart or a product removes its cart lines.
+CREATE TABLE IF NOT EXISTS cart_items (
+	cart_id INT NOT NULL,
+	product_id INT NOT NULL,
+	quantity INT NOT NULL,
+	PRIMARY KEY (cart_id, product_id),
+	FOREIGN KEY (cart_id) REFERENCES carts (id) ON DELETE CASCADE,
+	FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"electronics-store-tracing/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leaseServer fakes the Lease endpoints of the Kubernetes API server. It
+// keeps the leases as decoded JSON and checks resource versions on update.
+type leaseServer struct {
+	mu      sync.Mutex
+	leases  map[string]map[string]any
+	version int
+}
+
+func newLeaseServer(t *testing.T) (*leaseServer, *httptest.Server) {
+	t.Helper()
+	s := &leaseServer{leases: map[string]map[string]any{}}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func (s *leaseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const prefix = "/apis/coordination.k8s.io/v1/namespaces/test/leases"
+	if r.Header.Get("Authorization") != "Bearer token" || !strings.HasPrefix(r.URL.Path, prefix) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	var body map[string]any
+	if r.Method != http.MethodGet {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		name = body["metadata"].(map[string]any)["name"].(string)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, exists := s.leases[name]
+	switch {
+	case r.Method == http.MethodGet && !exists:
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	case r.Method == http.MethodPost && exists:
+		http.Error(w, "already exists", http.StatusConflict)
+		return
+	case r.Method == http.MethodPut && (!exists || body["metadata"].(map[string]any)["resourceVersion"] != current["metadata"].(map[string]any)["resourceVersion"]):
+		http.Error(w, "conflict", http.StatusConflict)
+		return
+	case r.Method != http.MethodGet:
+		s.version++
+		body["metadata"].(map[string]any)["resourceVersion"] = fmt.Sprint(s.version)
+		s.leases[name] = body
+		current = body
+	}
+	json.NewEncoder(w).Encode(current)
+}
+
+// holder returns the holder of the named lease.
+func (s *leaseServer) holder(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.leases[name]; ok {
+		holder, _ := l["spec"].(map[string]any)["holderIdentity"].(string)
+		return holder
+	}
+	return ""
+}
+
+// setHolder makes another replica the holder of the named lease.
+func (s *leaseServer) setHolder(name, holder string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	s.leases[name] = map[string]any{
+		"metadata": map[string]any{"name": name, "resourceVersion": fmt.Sprint(s.version)},
+		"spec":     map[string]any{"holderIdentity": holder},
+	}
+}
+
+func newElector(srv *httptest.Server, identity string) *api.LeaseElector {
+	return &api.LeaseElector{
+		Server:    srv.URL,
+		Client:    srv.Client(),
+		Token:     func() (string, error) { return "token", nil },
+		Namespace: "test",
+		Name:      "electronics-store",
+		Identity:  identity,
+		Duration:  300 * time.Millisecond,
+		Renew:     20 * time.Millisecond,
+		Retry:     10 * time.Millisecond,
+	}
+}
+
+func TestLeaseElectorRunsOneJobAtATime(t *testing.T) {
+	leases, srv := newLeaseServer(t)
+
+	var mu sync.Mutex
+	var running, ran []string
+	job := func(identity string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			running = append(running, identity)
+			assert.Len(t, running, 1, "jobs ran at the same time")
+			assert.Equal(t, identity, leases.holder("electronics-store"))
+			mu.Unlock()
+			time.Sleep(50 * time.Millisecond)
+			mu.Lock()
+			running = running[:0]
+			ran = append(ran, identity)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, identity := range []string{"electronics-store-a", "electronics-store-b", "electronics-store-c"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			assert.NoError(t, newElector(srv, identity).Lead(ctx, job(identity)))
+		}()
+	}
+	wg.Wait()
+
+	assert.ElementsMatch(t, []string{"electronics-store-a", "electronics-store-b", "electronics-store-c"}, ran)
+	// The last leader released the lease rather than letting it expire.
+	assert.Empty(t, leases.holder("electronics-store"))
+}
+
+func TestLeaseElectorTakesOverExpiredLeases(t *testing.T) {
+	leases, srv := newLeaseServer(t)
+	leases.setHolder("electronics-store", "electronics-store-gone")
+	elector := newElector(srv, "electronics-store-a")
+
+	start := time.Now()
+	err := elector.Lead(context.Background(), func(ctx context.Context) error {
+		assert.Equal(t, "electronics-store-a", leases.holder("electronics-store"))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), elector.Duration, "the lease was taken before it expired")
+
+	// A replica that gives up waiting returns its context's error.
+	leases.setHolder("electronics-store", "electronics-store-b")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = elector.Lead(ctx, func(ctx context.Context) error {
+		t.Error("the job ran without the lease")
+		return nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLeaseElectorCancelsJobsWhenTheLeaseIsLost(t *testing.T) {
+	leases, srv := newLeaseServer(t)
+
+	err := newElector(srv, "electronics-store-a").Lead(context.Background(), func(ctx context.Context) error {
+		leases.setHolder("electronics-store", "electronics-store-b")
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Error("the job was not cancelled")
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, api.ErrLeadershipLost)
+	assert.Equal(t, "electronics-store-b", leases.holder("electronics-store"), "a replica that lost the lease released it")
+}
@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"electronics-store-tracing/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migs, err := migrations.Load()
+	require.NoError(t, err)
+	require.NotEmpty(t, migs)
+
+	for i, mig := range migs {
+		assert.NotEmpty(t, mig.Up, "migration %d has no up script", mig.Version)
+		assert.NotEmpty(t, mig.Down, "migration %d has no down script", mig.Version)
+		if i > 0 {
+			assert.Greater(t, mig.Version, migs[i-1].Version)
+		}
+	}
+}
+
+func TestMigrateUpAppliesPendingMigrations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := migrations.New(db)
+	require.NoError(t, err)
+	migs, _ := migrations.Load()
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version FROM schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	for _, mig := range migs {
+		mock.ExpectBegin()
+		for range statements(mig.Up) {
+			mock.ExpectExec(".+").WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		mock.ExpectExec("INSERT INTO schema_migrations").
+			WithArgs(mig.Version, mig.Name).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+	}
+
+	n, err := migrator.Up(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, len(migs), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// unguardedDDL matches the DDL statements that fail when the change they make
+// is already there.
+var unguardedDDL = regexp.MustCompile(`(?i)^(ALTER TABLE|CREATE INDEX|CREATE UNIQUE INDEX|DROP INDEX|CREATE TABLE \w+ \(|DROP TABLE \w+$)`)
+
+// statements splits a script on the semicolons that end its lines, as the
+// migrator does, and drops the comment lines.
+func statements(script string) []string {
+	var stmts []string
+	for _, chunk := range strings.Split(script, ";\n") {
+		var lines []string
+		for _, line := range strings.Split(chunk, "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > 0 {
+			stmts = append(stmts, strings.TrimSuffix(strings.Join(lines, " "), ";"))
+		}
+	}
+	return stmts
+}
+
+func TestSchemaMigrationsCanBeRerun(t *testing.T) {
+	migs, err := migrations.Load()
+	require.NoError(t, err)
+
+	// MySQL commits DDL at once, so a crash before a migration is recorded
+	// runs it again on the next start.
+	for _, mig := range migs {
+		for _, script := range []string{mig.Up, mig.Down} {
+			for _, stmt := range statements(script) {
+				assert.False(t, unguardedDDL.MatchString(stmt), "migration %d_%s cannot be re-run: %s", mig.Version, mig.Name, stmt)
+			}
+		}
+	}
+}
+
+func TestMigrateUpSkipsAppliedMigrations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := migrations.New(db)
+	require.NoError(t, err)
+
+	rows := sqlmock.NewRows([]string{"version"})
+	migs, _ := migrations.Load()
+	for _, mig := range migs {
+		rows.AddRow(mig.Version)
+	}
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version FROM schema_migrations").WillReturnRows(rows)
+
+	n, err := migrator.Up(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrateDownRevertsLatest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := migrations.New(db)
+	require.NoError(t, err)
+	latest := migrator.Latest()
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(latest))
+	mock.ExpectBegin()
+	mock.ExpectExec("DROP TABLE IF EXISTS order_items").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM schema_migrations").WithArgs(latest).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err := migrator.Down(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeedIsInsertIgnore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := migrations.New(db)
+	require.NoError(t, err)
+
+	mock.ExpectExec("INSERT IGNORE INTO products").WillReturnResult(sqlmock.NewResult(15, 15))
+
+	require.NoError(t, migrator.Seed(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeedRunsOncePerSpec(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := migrations.New(db)
+	require.NoError(t, err)
+	loads := 0
+	load := func(context.Context) error {
+		loads++
+		return nil
+	}
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS seed_runs").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM seed_runs WHERE digest = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+	mock.ExpectExec("INSERT IGNORE INTO products").WillReturnResult(sqlmock.NewResult(15, 15))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO seed_runs (digest, spec) VALUES (?, ?)")).
+		WithArgs(sqlmock.AnyArg(), "mode=replace generate=100").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	seeded, err := migrator.SeedOnce(context.Background(), "mode=replace generate=100", load)
+	require.NoError(t, err)
+	assert.True(t, seeded)
+	assert.Equal(t, 1, loads)
+
+	// The pod restarts: the run is recorded, so nothing is replaced or
+	// appended again.
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS seed_runs").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM seed_runs WHERE digest = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+
+	seeded, err = migrator.SeedOnce(context.Background(), "mode=replace generate=100", load)
+	require.NoError(t, err)
+	assert.False(t, seeded)
+	assert.Equal(t, 1, loads)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,325 @@
+// Package api implements the HTTP and gRPC APIs of the electronics store
+// service. NewRouter builds the complete router on top of a Store, and
+// NewServers a gRPC server next to it, so tests and the service binary run
+// the same handlers.
+package api
+
+//go:generate go run -C ../../tools/openapi-gen . -dir ../../electronics-store/api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+const (
+	serviceName    = "electronics-store"
+	serviceVersion = "1.0.0"
+)
+
+// Config holds the router options that do not depend on the store.
+type Config struct {
+	// ValidateRequests rejects requests that do not match the OpenAPI spec.
+	ValidateRequests bool
+
+	// ValidateResponses logs responses that do not match the OpenAPI spec.
+	ValidateResponses bool
+
+	// Readiness is reported by /readyz and /health. A nil Readiness is
+	// replaced by one that is not yet warmed up.
+	Readiness *Readiness
+
+	// Logger receives one record per request. A nil Logger uses
+	// slog.Default.
+	Logger *slog.Logger
+
+	// Auth identifies the callers of the data and admin endpoints. Reads
+	// need the read scope and other methods the write scope. A nil Auth
+	// leaves the API open; health checks and docs are always open.
+	Auth Authenticator
+
+	// Limits rate limits the data and admin endpoints and caps request
+	// bodies. The zero value sets no limits.
+	Limits Limits
+
+	// Carts keeps the carts and orders. With a nil Carts the cart and order
+	// endpoints answer 503.
+	Carts CartStore
+
+	// Events configures /events and the webhooks.
+	Events EventOptions
+
+	// Bus also carries every event to the other services. A nil Bus
+	// publishes them on /events and to webhooks only.
+	Bus Bus
+
+	// Cache keeps the product reads for CacheTTL (default 30 seconds);
+	// every write to the products drops them. A nil Cache reads every
+	// product from the store.
+	Cache    Cache
+	CacheTTL time.Duration
+}
+
+// ConfigFromEnv reads the router options from the environment: requests are
+// validated unless OPENAPI_VALIDATE_REQUESTS=false, responses are checked
+// when OPENAPI_VALIDATE_RESPONSES=true, LOG_LEVEL and LOG_FORMAT select
+// the logger, the limits are read as described by LimitsFromEnv, the
+// change feed is configured as described by EventOptionsFromEnv, and
+// CACHE_TTL_SECONDS sets CacheTTL.
+func ConfigFromEnv() Config {
+	return Config{
+		ValidateRequests:  envBool("OPENAPI_VALIDATE_REQUESTS", true),
+		ValidateResponses: envBool("OPENAPI_VALIDATE_RESPONSES", false),
+		Logger:            LoggerFromEnv(),
+		Limits:            LimitsFromEnv(),
+		Events:            EventOptionsFromEnv(),
+		CacheTTL:          envSeconds("CACHE_TTL_SECONDS", 0),
+	}
+}
+
+// handler serves the API from a store.
+type handler struct {
+	store     Store
+	cache     *cachedStore
+	carts     CartStore
+	readiness *Readiness
+	checks    []namedCheck
+	events    *events
+}
+
+// NewRouter registers every route on a new engine, serving data from store.
+// The product and fixture routes answer 503 until cfg.Readiness is warmed
+// up. It panics if the embedded OpenAPI spec is invalid, which the package
+// tests rule out, or if one of Limits.TrustedProxies is not an address or
+// range.
+//
+// @title Electronics Store API
+// @version 1.0.0
+// @description This is an electronics store service API
+// @contact.name API Support
+// @contact.url http://www.swagger.io/support
+// @contact.email support@swagger.io
+// @license.name Apache 2.0
+// @license.url http://www.apache.org/licenses/LICENSE-2.0.html
+// @host localhost:8080
+// @BasePath /
+// @schemes http
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.bearer BearerAuth
+// @bearerFormat JWT
+func NewRouter(store Store, cfg Config) *gin.Engine {
+	return newHandler(store, &cfg).router(cfg)
+}
+
+// NewServers returns the router and a gRPC server on top of the same
+// handler, so that both serve one store, and a change made through either is
+// published on the change feed of both.
+func NewServers(store Store, cfg Config) (*gin.Engine, *grpc.Server) {
+	h := newHandler(store, &cfg)
+	return h.router(cfg), newGRPCServer(h, cfg)
+}
+
+// newHandler fills in the defaults of cfg and returns a handler for store.
+func newHandler(store Store, cfg *Config) *handler {
+	if cfg.Readiness == nil {
+		cfg.Readiness = &Readiness{}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = 30 * time.Second
+	}
+	h := &handler{
+		store:     store,
+		carts:     cfg.Carts,
+		readiness: cfg.Readiness,
+		checks:    readinessChecks(cfg.Readiness, store),
+		events:    newEvents(cfg.Events, cfg.Bus, cfg.Readiness.stopping()),
+	}
+	if cfg.Cache != nil {
+		h.cache = newCachedStore(store, cfg.Cache, cfg.CacheTTL)
+		h.store = h.cache
+	}
+	return h
+}
+
+// router registers every route of h on a new engine.
+func (h *handler) router(cfg Config) *gin.Engine {
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Limits.TrustedProxies); err != nil {
+		panic(fmt.Sprintf("setting trusted proxies: %v", err))
+	}
+	r.Use(requestLogger(cfg.Logger), gin.Recovery(), limitBody(cfg.Limits.MaxBodyBytes))
+	// Credentials are checked before requests are validated, so that callers
+	// without them learn nothing about the API. Clients are rate limited by
+	// IP before that and by caller after it; probes and docs are not.
+	public := r.Group("/")
+	api := r.Group("/", rateLimit(cfg.Limits, clientIP), authorize(cfg.Auth), rateLimit(cfg.Limits, callerOf))
+	if cfg.ValidateRequests {
+		doc, err := loadOpenAPISpec()
+		if err != nil {
+			panic(fmt.Sprintf("loading OpenAPI spec: %v", err))
+		}
+		validator, err := validateOpenAPI(doc, cfg.ValidateResponses)
+		if err != nil {
+			panic(fmt.Sprintf("building OpenAPI validator: %v", err))
+		}
+		public.Use(validator)
+		api.Use(validator)
+	}
+
+	// Health check endpoints
+	public.GET("/health", h.healthCheck)
+	public.GET("/livez", h.livenessCheck)
+	public.GET("/readyz", h.readinessCheck)
+
+	// OpenAPI documentation endpoints
+	public.GET("/openapi.json", getOpenAPISpec)
+	public.GET("/docs", serveDocs)
+
+	productRoutes := api.Group("/products", requireDatabase(cfg.Readiness))
+
+	// GET all products
+	productRoutes.GET("", h.getProducts)
+
+	// GET a product by ID
+	productRoutes.GET("/:id", h.getProductByID)
+
+	// Create, replace, patch and delete products
+	productRoutes.POST("", h.createProduct)
+	productRoutes.PUT("/:id", h.updateProduct)
+	productRoutes.PATCH("/:id", h.patchProduct)
+	productRoutes.DELETE("/:id", h.deleteProduct)
+
+	// Carts and the orders they are checked out into
+	cartRoutes := api.Group("/carts", requireDatabase(cfg.Readiness), h.requireCarts)
+	cartRoutes.POST("", h.createCart)
+	cartRoutes.GET("/:id", h.getCart)
+	cartRoutes.DELETE("/:id", h.deleteCart)
+	cartRoutes.PUT("/:id/items/:productId", h.setCartItem)
+	cartRoutes.DELETE("/:id/items/:productId", h.removeCartItem)
+	cartRoutes.POST("/:id/checkout", h.checkoutCart)
+
+	orderRoutes := api.Group("/orders", requireDatabase(cfg.Readiness), h.requireCarts)
+	orderRoutes.GET("", h.getOrders)
+	orderRoutes.GET("/:id", h.getOrderByID)
+
+	// Change feed
+	api.GET("/events", h.streamEvents)
+	api.GET("/webhooks", h.getWebhooks)
+	api.GET("/webhooks/:id", h.getWebhookByID)
+	api.POST("/webhooks", h.createWebhook)
+	api.DELETE("/webhooks/:id", h.deleteWebhook)
+
+	// Bulk data seeding
+	fixtureRoutes := api.Group("/admin/fixtures", requireDatabase(cfg.Readiness))
+	fixtureRoutes.GET("", h.exportProductFixtures)
+	fixtureRoutes.POST("", h.importProductFixtures)
+	fixtureRoutes.POST("/generate", h.generateProductFixtures)
+
+	return r
+}
+
+// getOpenAPISpec returns the OpenAPI specification as JSON
+//
+// @Summary OpenAPI specification
+// @Description Returns the OpenAPI 3.0 description of this API
+// @Tags docs
+// @Success 200 {object} object "OpenAPI document"
+// @Router /openapi.json [get]
+func getOpenAPISpec(c *gin.Context) {
+	c.Header("Content-Type", "application/json")
+	c.String(http.StatusOK, openAPISpec)
+}
+
+// serveDocs serves the Swagger UI documentation page
+//
+// @Summary API documentation
+// @Description Swagger UI for this API
+// @Tags docs
+// @Produce html
+// @Success 200 {string} string "Swagger UI page"
+// @Router /docs [get]
+func serveDocs(c *gin.Context) {
+	html := `<!DOCTYPE html>
+<html>
+<head>
+  <title>Electronics Store API Documentation</title>
+  <link rel="stylesheet" type="text/css" href="https://unpkg.com/swagger-ui-dist@3.52.5/swagger-ui.css" />
+  <style>
+    html {
+      box-sizing: border-box;
+      overflow: -moz-scrollbars-vertical;
+      overflow-y: scroll;
+    }
+    *, *:before, *:after {
+      box-sizing: inherit;
+    }
+    body {
+      margin:0;
+      background: #fafafa;
+    }
+  </style>
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@3.52.5/swagger-ui-bundle.js"></script>
+  <script src="https://unpkg.com/swagger-ui-dist@3.52.5/swagger-ui-standalone-preset.js"></script>
+  <script>
+    window.onload = function() {
+      const ui = SwaggerUIBundle({
+        url: '/openapi.json',
+        dom_id: '#swagger-ui',
+        deepLinking: true,
+        presets: [
+          SwaggerUIBundle.presets.apis,
+          SwaggerUIStandalonePreset
+        ],
+        plugins: [
+          SwaggerUIBundle.plugins.DownloadUrl
+        ],
+        layout: "StandaloneLayout"
+      });
+    };
+  </script>
+</body>
+</html>`
+	c.Header("Content-Type", "text/html")
+	c.String(http.StatusOK, html)
+}
+
+// parseID reads the numeric id path parameter. The OpenAPI validator already
+// rejects other values unless it is disabled.
+func parseID(c *gin.Context) (int, bool) {
+	return parseParam(c, "id")
+}
+
+// parseParam reads a numeric path parameter, like parseID.
+func parseParam(c *gin.Context, name string) (int, bool) {
+	v, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be an integer"})
+		return 0, false
+	}
+	return v, true
+}
+
+// requireDatabase rejects requests with 503 until the database has been
+// initialised, instead of letting handlers fail against a missing schema.
+func requireDatabase(r *Readiness) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !r.warmedUp.Load() {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Scopes granted to credentials. Reads need ScopeRead; every other method
+// needs ScopeWrite, which also grants reads.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// apiKeyHeader carries a static API key.
+const apiKeyHeader = "X-API-Key"
+
+// principalKey is the gin context key authorize stores the caller under.
+const principalKey = "principal"
+
+// ErrNoCredentials is returned by an Authenticator when the request carries
+// none of the credentials it accepts.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject identifies the caller in the logs.
+	Subject string
+	Scopes  []string
+}
+
+// allows reports whether p has scope, counting ScopeWrite as ScopeRead too.
+func (p Principal) allows(scope string) bool {
+	return slices.Contains(p.Scopes, scope) || scope == ScopeRead && slices.Contains(p.Scopes, ScopeWrite)
+}
+
+// Authenticator identifies the caller of a request. It returns
+// ErrNoCredentials when the request has no credentials of its kind, and
+// another error when it has invalid ones.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+// Authenticators accepts a request that any of its members accepts.
+type Authenticators []Authenticator
+
+func (a Authenticators) Authenticate(r *http.Request) (Principal, error) {
+	for _, auth := range a {
+		p, err := auth.Authenticate(r)
+		if !errors.Is(err, ErrNoCredentials) {
+			return p, err
+		}
+	}
+	return Principal{}, ErrNoCredentials
+}
+
+// APIKeys authenticates requests by the X-API-Key header. It maps each key
+// to the caller it identifies.
+type APIKeys map[string]Principal
+
+func (k APIKeys) Authenticate(r *http.Request) (Principal, error) {
+	key := r.Header.Get(apiKeyHeader)
+	if key == "" {
+		return Principal{}, ErrNoCredentials
+	}
+	// Compare against every key so that the time taken does not depend on
+	// which one matches.
+	var match Principal
+	found := false
+	for known, p := range k {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(known)) == 1 {
+			match, found = p, true
+		}
+	}
+	if !found {
+		return Principal{}, errors.New("invalid API key")
+	}
+	return match, nil
+}
+
+// LoadAPIKeys reads an API key file. Each line holds a key followed by its
+// scopes, separated by spaces; blank lines and lines starting with # are
+// skipped. A key is logged as the name given after its scopes, or as the
+// line number.
+//
+//	5f0c...e1 read write ci
+func LoadAPIKeys(name string) (APIKeys, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	keys := make(APIKeys)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		p := Principal{Subject: fmt.Sprintf("api-key:%d", line)}
+		for _, field := range fields[1:] {
+			if field == ScopeRead || field == ScopeWrite {
+				p.Scopes = append(p.Scopes, field)
+			} else {
+				p.Subject = "api-key:" + field
+			}
+		}
+		if len(p.Scopes) == 0 {
+			return nil, fmt.Errorf("%s:%d: key has no scopes", name, line)
+		}
+		keys[fields[0]] = p
+	}
+	return keys, scanner.Err()
+}
+
+// AuthFromEnv returns the authenticator configured by the environment, or
+// nil, which leaves the API open, when neither AUTH_API_KEYS_FILE nor
+// AUTH_JWKS_FILE is set. Bearer tokens are checked against
+// AUTH_JWT_ISSUER and AUTH_JWT_AUDIENCE when those are set.
+func AuthFromEnv() (Authenticator, error) {
+	var auth Authenticators
+	if name := os.Getenv("AUTH_API_KEYS_FILE"); name != "" {
+		keys, err := LoadAPIKeys(name)
+		if err != nil {
+			return nil, fmt.Errorf("loading API keys: %w", err)
+		}
+		auth = append(auth, keys)
+	}
+	if name := os.Getenv("AUTH_JWKS_FILE"); name != "" {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("loading JWKS: %w", err)
+		}
+		jwt, err := NewJWTAuth(data, os.Getenv("AUTH_JWT_ISSUER"), os.Getenv("AUTH_JWT_AUDIENCE"))
+		if err != nil {
+			return nil, fmt.Errorf("loading JWKS: %w", err)
+		}
+		auth = append(auth, jwt)
+	}
+	if len(auth) == 0 {
+		return nil, nil
+	}
+	return auth, nil
+}
+
+// authorize returns middleware that requires credentials from auth with the
+// scope the request method needs. A nil auth lets every request through.
+func authorize(auth Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if auth == nil {
+			c.Next()
+			return
+		}
+		p, err := auth.Authenticate(c.Request)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="`+serviceName+`"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		scope := ScopeWrite
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = ScopeRead
+		}
+		if !p.allows(scope) {
+			requestLog(c.Request.Context()).Warn("Request denied", "subject", p.Subject, "scope", scope)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing scope " + scope})
+			return
+		}
+		c.Set(principalKey, p)
+		c.Next()
+	}
+}
+
+// apiKeyTransport adds an API key to the requests of the command line
+// clients.
+type apiKeyTransport struct {
+	key  string
+	base http.RoundTripper
+}
+
+func (t apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(apiKeyHeader, t.key)
+	return t.base.RoundTrip(req)
+}
+
+// newClient returns an HTTP client that sends key with every request, or no
+// credentials when key is empty.
+func newClient(timeout time.Duration, key string) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	if key != "" {
+		client.Transport = apiKeyTransport{key: key, base: http.DefaultTransport}
+	}
+	return client
+}
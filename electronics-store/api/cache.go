@@ -0,0 +1,152 @@
+package api
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache keeps encoded reads of the store for a while, so that repeated
+// reads do not reach the database. Implementations are safe for concurrent
+// use.
+type Cache interface {
+	// Get returns the value stored under key, and false when there is none
+	// or it has expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores value under key for ttl, or until it is evicted when ttl
+	// is 0.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Close releases the cache.
+	Close() error
+}
+
+// CacheFromEnv returns a cache in the Redis server at REDIS_ADDR, which the
+// operator sets when spec.cache is enabled, with REDIS_PASSWORD if it needs
+// one. Without it, CACHE=lru returns an in-memory cache of CACHE_SIZE
+// entries (default 10000), and anything else nil, which caches nothing.
+func CacheFromEnv() (Cache, error) {
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		return NewRedisCache(addr, os.Getenv("REDIS_PASSWORD")), nil
+	}
+	if os.Getenv("CACHE") != "lru" {
+		return nil, nil
+	}
+	size := 10000
+	if v := os.Getenv("CACHE_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, errors.New("CACHE_SIZE must be a positive number of entries")
+		}
+		size = n
+	}
+	return NewLRUCache(size), nil
+}
+
+// LRUCache is a Cache in the memory of the process. It holds at most a
+// fixed number of entries and evicts the least recently used one to make
+// room. Each replica has its own, so a write through one replica leaves
+// the others serving their cached reads until they expire.
+type LRUCache struct {
+	size int
+	now  func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // of *lruEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time // zero for entries that do not expire
+}
+
+// NewLRUCache returns an empty cache of at most size entries.
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{size: max(size, 1), now: time.Now, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *LRUCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*lruEntry)
+	if !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true, nil
+}
+
+func (c *LRUCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &lruEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = c.now().Add(ttl)
+	}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+	return nil
+}
+
+func (c *LRUCache) Close() error { return nil }
+
+// RedisCache is a Cache in a Redis server, shared by every replica that
+// uses it, so that a write through one replica is seen by all of them.
+type RedisCache struct {
+	client *redis.Client
+}
+
+// NewRedisCache returns a cache in the Redis server at addr. It connects on
+// first use; a server that cannot be reached makes each read a miss.
+func NewRedisCache(addr, password string) *RedisCache {
+	return &RedisCache{client: redis.NewClient(&redis.Options{
+		Addr:         addr,
+		Password:     password,
+		DialTimeout:  time.Second,
+		ReadTimeout:  500 * time.Millisecond,
+		WriteTimeout: 500 * time.Millisecond,
+	})}
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestContract runs the contract cases generated from the spec against the
+// real router. Without a database the product and fixture endpoints answer
+// 503, which the spec documents.
+func TestContract(t *testing.T) {
+	srv := httptest.NewServer(newTestRouter(false))
+	defer srv.Close()
+
+	results, err := runContract(context.Background(), srv.Client(), srv.URL, true)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	for _, r := range results {
+		t.Run(r.Case.Operation+"/"+r.Case.Name, func(t *testing.T) {
+			if r.Err != nil {
+				t.Error(r.Err)
+			}
+		})
+	}
+}
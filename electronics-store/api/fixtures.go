@@ -0,0 +1,514 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	// maxFixtureBytes bounds the size of an uploaded fixture file. limitBody
+	// lets fixture imports through up to this size.
+	maxFixtureBytes = 64 << 20
+
+	// maxGenerateCount bounds a single synthetic data request.
+	maxGenerateCount = 100000
+
+	// fixturesTimeout bounds a single request made by the fixtures CLI.
+	fixturesTimeout = 5 * time.Minute
+)
+
+var fixtureContentTypes = map[string]string{
+	"json": "application/json",
+	"csv":  "text/csv",
+}
+
+// fixtureSet is the storage behind the admin fixture endpoints for records of
+// type T.
+type fixtureSet[T any] interface {
+	// Count returns the number of stored records.
+	Count(ctx context.Context) (int, error)
+
+	// Import stores items, removing all existing records first when replace
+	// is set. Items with a zero ID are assigned one and items whose ID or
+	// name is already taken overwrite the stored record. It returns the number of
+	// records stored afterwards.
+	Import(ctx context.Context, items []T, replace bool) (int, error)
+
+	// Export returns every stored record.
+	Export(ctx context.Context) ([]T, error)
+
+	// Generate returns n synthetic records without IDs, numbered from
+	// start+1 so that repeated calls produce distinct names.
+	Generate(start, n int) []T
+}
+
+// fixtureResult is the response of the import and generate endpoints.
+type fixtureResult struct {
+	// Imported is the number of records in the request.
+	Imported int `json:"imported" example:"1000"`
+
+	// Total is the number of records stored afterwards.
+	Total int `json:"total" example:"1015"`
+}
+
+// importFixtures loads a JSON array or CSV file from the request body into
+// set. The format follows the Content-Type header; mode=replace drops the
+// existing records.
+func importFixtures[T any](c *gin.Context, set fixtureSet[T]) {
+	replace, err := parseFixtureMode(c.Query("mode"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format, err := fixtureFormat(c.ContentType())
+	if err != nil {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxFixtureBytes)
+	items, err := decodeFixtures[T](body, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	total, err := set.Import(c.Request.Context(), items, replace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, fixtureResult{Imported: len(items), Total: total})
+}
+
+// generateFixtures stores count synthetic records in set.
+func generateFixtures[T any](c *gin.Context, set fixtureSet[T]) {
+	replace, err := parseFixtureMode(c.Query("mode"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	count, err := strconv.Atoi(c.Query("count"))
+	if err != nil || count < 1 || count > maxGenerateCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("count must be an integer between 1 and %d", maxGenerateCount)})
+		return
+	}
+
+	total, err := generateInto(c.Request.Context(), set, count, replace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, fixtureResult{Imported: count, Total: total})
+}
+
+// exportFixtures writes every record of set as JSON or, with format=csv, as
+// CSV.
+func exportFixtures[T any](c *gin.Context, set fixtureSet[T]) {
+	format, err := fixtureFormat(c.DefaultQuery("format", "json"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	items, err := set.Export(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", fixtureContentTypes[format])
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-fixtures.%s"`, serviceName, format))
+	if err := encodeFixtures(c.Writer, items, format); err != nil {
+		c.Error(err)
+	}
+}
+
+// generateInto stores n synthetic records and returns the new total.
+func generateInto[T any](ctx context.Context, set fixtureSet[T], n int, replace bool) (int, error) {
+	start := 0
+	if !replace {
+		var err error
+		if start, err = set.Count(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return set.Import(ctx, set.Generate(start, n), replace)
+}
+
+// loadSeedFixtures applies the start-up dataset configured through the
+// environment, which the operator sets from ServiceConfig.seed: SEED_FILE is
+// a JSON or CSV fixture file, SEED_GENERATE a number of synthetic records to
+// add and SEED_MODE=replace drops the built-in records first.
+func loadSeedFixtures[T any](ctx context.Context, set fixtureSet[T]) error {
+	replace, err := parseFixtureMode(os.Getenv("SEED_MODE"))
+	if err != nil {
+		return fmt.Errorf("SEED_MODE: %w", err)
+	}
+
+	if file := os.Getenv("SEED_FILE"); file != "" {
+		items, err := readFixtureFile[T](file)
+		if err != nil {
+			return err
+		}
+		total, err := set.Import(ctx, items, replace)
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "Loaded fixture records", "records", len(items), "file", file, "stored", total)
+		replace = false
+	}
+
+	if v := os.Getenv("SEED_GENERATE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("SEED_GENERATE must be a non-negative integer, got %q", v)
+		}
+		if n > 0 {
+			total, err := generateInto(ctx, set, n, replace)
+			if err != nil {
+				return err
+			}
+			slog.InfoContext(ctx, "Generated synthetic records", "records", n, "stored", total)
+		}
+	}
+	return nil
+}
+
+// readFixtureFile decodes a fixture file, choosing the format by extension.
+func readFixtureFile[T any](name string) ([]T, error) {
+	format, err := fixtureFormat(strings.TrimPrefix(filepath.Ext(name), "."))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	items, err := decodeFixtures[T](f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return items, nil
+}
+
+// parseFixtureMode reports whether mode asks to replace the existing records.
+func parseFixtureMode(mode string) (bool, error) {
+	switch mode {
+	case "", "append":
+		return false, nil
+	case "replace":
+		return true, nil
+	}
+	return false, fmt.Errorf("mode must be append or replace")
+}
+
+// fixtureFormat normalises a format name, file extension or media type to
+// "json" or "csv".
+func fixtureFormat(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "", "json", "application/json":
+		return "json", nil
+	case "csv", "text/csv":
+		return "csv", nil
+	}
+	return "", fmt.Errorf("unsupported fixture format %q, use json or csv", s)
+}
+
+// decodeFixtures reads a JSON array of records, or CSV whose header row
+// names the records' JSON fields, and checks every record against its binding
+// tags as POST does.
+func decodeFixtures[T any](r io.Reader, format string) ([]T, error) {
+	var items []T
+	if format == "csv" {
+		var err error
+		if items, err = decodeCSV[T](r); err != nil {
+			return nil, err
+		}
+	} else {
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&items); err != nil {
+			return nil, fmt.Errorf("invalid JSON fixtures: %w", err)
+		}
+	}
+
+	for i := range items {
+		if err := binding.Validator.ValidateStruct(&items[i]); err != nil {
+			return nil, fmt.Errorf("invalid fixture record %d: %w", i, err)
+		}
+	}
+	return items, nil
+}
+
+// encodeFixtures writes items in the given format.
+func encodeFixtures[T any](w io.Writer, items []T, format string) error {
+	if format == "csv" {
+		return encodeCSV(w, items)
+	}
+	if items == nil {
+		items = []T{}
+	}
+	return json.NewEncoder(w).Encode(items)
+}
+
+// csvColumn maps a CSV column to a struct field.
+type csvColumn struct {
+	name  string
+	field int
+}
+
+// csvColumns returns the exported fields of t in declaration order, named
+// after their JSON keys.
+func csvColumns(t reflect.Type) []csvColumn {
+	var columns []csvColumn
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{name: name, field: i})
+	}
+	return columns
+}
+
+func decodeCSV[T any](r io.Reader) ([]T, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV fixtures: %w", err)
+	}
+
+	byName := make(map[string]int)
+	for _, column := range csvColumns(reflect.TypeFor[T]()) {
+		byName[column.name] = column.field
+	}
+	fields := make([]int, len(header))
+	for i, name := range header {
+		field, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("invalid CSV fixtures: unknown column %q", name)
+		}
+		fields[i] = field
+	}
+
+	var items []T
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV fixtures: %w", err)
+		}
+
+		var item T
+		v := reflect.ValueOf(&item).Elem()
+		for i, value := range record {
+			if err := setCSVField(v.Field(fields[i]), value); err != nil {
+				line, _ := reader.FieldPos(i)
+				return nil, fmt.Errorf("invalid CSV fixtures: line %d, column %s: %w", line, header[i], err)
+			}
+		}
+		items = append(items, item)
+	}
+}
+
+func encodeCSV[T any](w io.Writer, items []T) error {
+	columns := csvColumns(reflect.TypeFor[T]())
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = column.name
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+	for _, item := range items {
+		v := reflect.ValueOf(item)
+		for i, column := range columns {
+			record[i] = formatCSVField(v.Field(column.field))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func setCSVField(field reflect.Value, value string) error {
+	value = strings.TrimSpace(value)
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+		return nil
+	}
+
+	if value == "" {
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		field.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+func formatCSVField(field reflect.Value) string {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'f', -1, field.Type().Bits())
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool())
+	}
+	return field.String()
+}
+
+const fixturesUsage = `usage: %s fixtures <command> [flags]
+
+commands:
+  import    load records from a .json or .csv file (-file, -mode)
+  generate  create synthetic records (-count, -mode)
+  export    write every record as JSON or CSV (-format, -out)
+
+The commands call the admin API of a running service, selected with -url.`
+
+// RunFixturesCommand implements the "fixtures" subcommand, a client for the admin
+// fixture endpoints.
+func RunFixturesCommand(args []string) error {
+	usage := fmt.Sprintf(fixturesUsage, filepath.Base(os.Args[0]))
+	if len(args) == 0 {
+		return fmt.Errorf("missing fixtures command\n%s", usage)
+	}
+	command := args[0]
+
+	flags := flag.NewFlagSet("fixtures "+command, flag.ContinueOnError)
+	baseURL := flags.String("url", "http://localhost:8080", "base URL of the service")
+	file := flags.String("file", "", "fixture file to import (import only)")
+	mode := flags.String("mode", "append", "append to or replace the existing records")
+	count := flags.Int("count", 1000, "number of records to generate (generate only)")
+	format := flags.String("format", "json", "json or csv (export only)")
+	out := flags.String("out", "", "file to export to, default stdout (export only)")
+	apiKey := flags.String("api-key", os.Getenv("API_KEY"), "API key, for services that require credentials")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	var req *http.Request
+	var err error
+	switch command {
+	case "import":
+		if *file == "" {
+			return fmt.Errorf("import requires -file")
+		}
+		fileFormat, err := fixtureFormat(strings.TrimPrefix(filepath.Ext(*file), "."))
+		if err != nil {
+			return err
+		}
+		body, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		query := url.Values{"mode": {*mode}}
+		req, err = http.NewRequest(http.MethodPost, *baseURL+"/admin/fixtures?"+query.Encode(), body)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", fixtureContentTypes[fileFormat])
+	case "generate":
+		query := url.Values{"mode": {*mode}, "count": {strconv.Itoa(*count)}}
+		req, err = http.NewRequest(http.MethodPost, *baseURL+"/admin/fixtures/generate?"+query.Encode(), nil)
+	case "export":
+		query := url.Values{"format": {*format}}
+		req, err = http.NewRequest(http.MethodGet, *baseURL+"/admin/fixtures?"+query.Encode(), nil)
+	default:
+		return fmt.Errorf("unknown fixtures command %q\n%s", command, usage)
+	}
+	if err != nil {
+		return err
+	}
+
+	client := newClient(fixturesTimeout, *apiKey)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if command != "export" {
+		// Print the JSON summary of an import or generate request.
+		_, err = io.Copy(os.Stdout, resp.Body)
+		fmt.Println()
+		return err
+	}
+	if *out == "" {
+		_, err = io.Copy(os.Stdout, resp.Body)
+		return err
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
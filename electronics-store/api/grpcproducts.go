@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"electronics-store/api/pb"
+)
+
+// registerGRPCServices registers the gRPC services of h on srv.
+func registerGRPCServices(srv *grpc.Server, h *handler) {
+	pb.RegisterProductServiceServer(srv, productService{h: h})
+}
+
+// productService serves the products over gRPC, as the /products endpoints
+// do. Like them, it answers UNAVAILABLE until the database is ready.
+type productService struct {
+	pb.UnimplementedProductServiceServer
+	h *handler
+}
+
+// ready is the gRPC counterpart of requireDatabase.
+func (s productService) ready() error {
+	if !s.h.readiness.warmedUp.Load() {
+		return status.Error(codes.Unavailable, "Database not available")
+	}
+	return nil
+}
+
+func (s productService) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	limit, offset, err := rpcPageBounds(req.GetLimit(), req.GetOffset())
+	if err != nil {
+		return nil, err
+	}
+	products, total, err := s.h.store.List(ctx, ProductQuery{Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, productRPCError(err)
+	}
+	resp := &pb.ListProductsResponse{Total: int32(total)}
+	for _, product := range products {
+		resp.Products = append(resp.Products, productToPB(product))
+	}
+	return resp, nil
+}
+
+func (s productService) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.Product, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	product, _, err := s.h.store.Get(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, productRPCError(err)
+	}
+	return productToPB(product), nil
+}
+
+func (s productService) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.Product, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	product, err := productFromPB(req.GetProduct())
+	if err != nil {
+		return nil, err
+	}
+	if product.ID != 0 {
+		return nil, status.Error(codes.InvalidArgument, errAssignedID.Error())
+	}
+	created, err := s.h.store.Create(ctx, product)
+	if err != nil {
+		return nil, productRPCError(err)
+	}
+	s.h.events.publish(EventCreated, "products", created.ID, created)
+	return productToPB(created), nil
+}
+
+func (s productService) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.Product, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	product, err := productFromPB(req.GetProduct())
+	if err != nil {
+		return nil, err
+	}
+	id := int(req.GetId())
+	updated, _, err := s.h.store.Update(ctx, id, product, anyVersion)
+	if err != nil {
+		return nil, productRPCError(err)
+	}
+	s.h.events.publish(EventUpdated, "products", id, updated)
+	return productToPB(updated), nil
+}
+
+func (s productService) DeleteProduct(ctx context.Context, req *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	id := int(req.GetId())
+	if err := s.h.store.Delete(ctx, id, anyVersion); err != nil {
+		return nil, productRPCError(err)
+	}
+	s.h.events.publish(EventDeleted, "products", id, nil)
+	return &pb.DeleteProductResponse{}, nil
+}
+
+// productRPCError maps a store error to a gRPC status, as productError does
+// to a response.
+func productRPCError(err error) error {
+	if errors.Is(err, ErrConflict) {
+		return status.Error(codes.AlreadyExists, "Product name already exists")
+	}
+	return rpcError(err, "Product")
+}
+
+func productToPB(p Product) *pb.Product {
+	return &pb.Product{Id: int64(p.ID), Name: p.Name, Price: p.Price, Stock: int32(p.Stock)}
+}
+
+// productFromPB converts and validates a product sent over gRPC.
+func productFromPB(p *pb.Product) (Product, error) {
+	product := Product{ID: int(p.GetId()), Name: p.GetName(), Price: p.GetPrice(), Stock: int(p.GetStock())}
+	if err := validateRPC(product); err != nil {
+		return product, err
+	}
+	if product.Price < 0 || product.Stock < 0 {
+		return product, status.Error(codes.InvalidArgument, "price and stock must not be negative")
+	}
+	return product, nil
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaxBodyBytes caps request bodies unless MAX_BODY_BYTES says
+// otherwise.
+const defaultMaxBodyBytes = 1 << 20
+
+// bulkRoutes take bodies of up to maxFixtureBytes even when MaxBodyBytes is
+// lower, so that fixture imports are not cut off by the cap meant for single
+// records.
+var bulkRoutes = map[string]bool{"/admin/fixtures": true}
+
+// Limits protects a service from clients that send too much.
+type Limits struct {
+	// RequestsPerSecond is the sustained rate allowed to each client IP and
+	// to each authenticated caller on the data and admin endpoints. Zero
+	// disables rate limiting.
+	RequestsPerSecond float64
+
+	// Burst is the number of requests a client may send at once. Zero
+	// allows one second worth of requests.
+	Burst int
+
+	// MaxBodyBytes caps request bodies; larger ones get 413. Fixture
+	// imports may always send up to maxFixtureBytes. Zero or less lifts the
+	// cap.
+	MaxBodyBytes int64
+
+	// TrustedProxies are the addresses and CIDR ranges of the proxies whose
+	// X-Forwarded-For and X-Real-IP headers name the client. Other requests
+	// are limited by their peer address, so that clients cannot pick their
+	// own bucket with a forged header. None are trusted by default.
+	TrustedProxies []string
+}
+
+// LimitsFromEnv reads RATE_LIMIT_RPS, RATE_LIMIT_BURST, MAX_BODY_BYTES and
+// the comma-separated TRUSTED_PROXIES. Rate limiting is off unless
+// RATE_LIMIT_RPS is set, and bodies are capped at 1 MiB by default.
+func LimitsFromEnv() Limits {
+	l := Limits{MaxBodyBytes: defaultMaxBodyBytes}
+	if v, err := strconv.ParseFloat(os.Getenv("RATE_LIMIT_RPS"), 64); err == nil && v > 0 {
+		l.RequestsPerSecond = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("RATE_LIMIT_BURST")); err == nil && v > 0 {
+		l.Burst = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("MAX_BODY_BYTES"), 10, 64); err == nil {
+		l.MaxBodyBytes = v
+	}
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			l.TrustedProxies = append(l.TrustedProxies, proxy)
+		}
+	}
+	return l
+}
+
+// tokenBucket holds the tokens left to one client.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket per client. Buckets that have refilled
+// are dropped, so idle clients cost nothing.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(l Limits) *rateLimiter {
+	burst := float64(l.Burst)
+	if burst <= 0 {
+		burst = math.Max(1, math.Ceil(l.RequestsPerSecond))
+	}
+	return &rateLimiter{
+		rate:    l.RequestsPerSecond,
+		burst:   burst,
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from the bucket of client. When the bucket is empty it
+// returns how long until the next token is available.
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if refill := time.Duration(l.burst / l.rate * float64(time.Second)); now.Sub(l.lastSweep) > refill {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) > refill {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// rateLimit returns middleware that answers 429 with Retry-After once the
+// client named by clientOf runs out of tokens. Requests clientOf returns no
+// name for are not limited.
+func rateLimit(l Limits, clientOf func(*gin.Context) string) gin.HandlerFunc {
+	if l.RequestsPerSecond <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	limiter := newRateLimiter(l)
+	return func(c *gin.Context) {
+		client := clientOf(c)
+		if client == "" {
+			c.Next()
+			return
+		}
+		if ok, wait := limiter.allow(client); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// clientIP names the client of a request by its IP address, which is taken
+// from the forwarding headers only for Limits.TrustedProxies.
+func clientIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// callerOf names the client of a request by the caller authorize found, if
+// any.
+func callerOf(c *gin.Context) string {
+	if p, ok := c.Get(principalKey); ok {
+		return "caller:" + p.(Principal).Subject
+	}
+	return ""
+}
+
+// limitBody returns middleware that answers 413 to requests whose body is
+// larger than limit bytes, or maxFixtureBytes on bulkRoutes if that is more.
+// The body is read up front so that the OpenAPI validator and the handlers
+// never see more than the limit.
+func limitBody(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := limit
+		if limit > 0 && bulkRoutes[c.FullPath()] {
+			limit = max(limit, maxFixtureBytes)
+		}
+		if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			tooLarge(c, limit)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, limit+1))
+		c.Request.Body.Close()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "reading request body: " + err.Error()})
+			return
+		}
+		if int64(len(body)) > limit {
+			tooLarge(c, limit)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+func tooLarge(c *gin.Context, limit int64) {
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body is larger than " + strconv.FormatInt(limit, 10) + " bytes"})
+}
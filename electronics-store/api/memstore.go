@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// memoryStore keeps records of type T in a slice guarded by a mutex. id
+// returns a pointer to the ID field of a record. It holds what belongs to
+// one replica rather than the database, such as the webhooks.
+//
+// Every write gives the records it touches the next value of a store-wide
+// revision counter as their version, so a version is never reused for
+// another state of a record, even after it is deleted and created again.
+type memoryStore[T any] struct {
+	mu       sync.RWMutex
+	items    []T
+	id       func(*T) *int
+	versions map[int]int64
+	revision int64
+}
+
+func newMemoryStore[T any](items []T, id func(*T) *int) *memoryStore[T] {
+	s := &memoryStore[T]{items: slices.Clone(items), id: id, versions: make(map[int]int64)}
+	s.bump(s.items)
+	return s
+}
+
+// List returns a copy of every record in insertion order.
+func (s *memoryStore[T]) List(context.Context) ([]T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.items), nil
+}
+
+// Get returns the record with the given ID and its version.
+func (s *memoryStore[T]) Get(_ context.Context, id int) (T, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if i := s.index(id); i >= 0 {
+		return s.items[i], s.versions[id], nil
+	}
+	var zero T
+	return zero, 0, ErrNotFound
+}
+
+// Create stores item, assigning the next free ID when it has none. It
+// returns ErrConflict when the ID is already taken.
+func (s *memoryStore[T]) Create(_ context.Context, item T) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id := *s.id(&item); id != 0 && s.index(id) >= 0 {
+		return item, ErrConflict
+	}
+	s.items = upsertByID(s.items, []T{item}, false, s.id)
+	i := len(s.items) - 1
+	if id := *s.id(&item); id != 0 {
+		i = s.index(id)
+	}
+	s.bump(s.items[i : i+1])
+	return s.items[i], nil
+}
+
+// Update replaces the record with the given ID and returns it with its new
+// version. The ID of item is set to id. Unless ifVersion is anyVersion, the
+// record must be at that version.
+func (s *memoryStore[T]) Update(_ context.Context, id int, item T, ifVersion int64) (T, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return item, 0, ErrNotFound
+	}
+	if ifVersion != anyVersion && s.versions[id] != ifVersion {
+		return item, 0, ErrVersionMismatch
+	}
+	*s.id(&item) = id
+	s.items[i] = item
+	s.bump(s.items[i : i+1])
+	return item, s.versions[id], nil
+}
+
+// Delete removes the record with the given ID. Unless ifVersion is
+// anyVersion, the record must be at that version.
+func (s *memoryStore[T]) Delete(_ context.Context, id int, ifVersion int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if ifVersion != anyVersion && s.versions[id] != ifVersion {
+		return ErrVersionMismatch
+	}
+	s.items = slices.Delete(s.items, i, i+1)
+	delete(s.versions, id)
+	return nil
+}
+
+// bump gives items the next revision as their version. The caller must hold
+// the lock.
+func (s *memoryStore[T]) bump(items []T) {
+	s.revision++
+	for i := range items {
+		s.versions[*s.id(&items[i])] = s.revision
+	}
+}
+
+// index returns the position of the record with the given ID, or -1. The
+// caller must hold the lock.
+func (s *memoryStore[T]) index(id int) int {
+	return slices.IndexFunc(s.items, func(item T) bool { return *s.id(&item) == id })
+}
+
+// upsertByID merges items into existing, assigning IDs to items that have
+// none. With replace set the result contains only items.
+func upsertByID[T any](existing, items []T, replace bool, id func(*T) *int) []T {
+	var merged []T
+	if !replace {
+		merged = existing
+	}
+
+	index := make(map[int]int, len(merged))
+	next := 0
+	for i := range merged {
+		index[*id(&merged[i])] = i
+		next = max(next, *id(&merged[i]))
+	}
+	for i := range items {
+		next = max(next, *id(&items[i]))
+	}
+
+	for _, item := range items {
+		itemID := id(&item)
+		if *itemID == 0 {
+			next++
+			*itemID = next
+		}
+		if i, ok := index[*itemID]; ok {
+			merged[i] = item
+			continue
+		}
+		index[*itemID] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
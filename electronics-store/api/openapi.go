@@ -0,0 +1,141 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	// Keep validation errors to the failing value instead of dumping the
+	// whole schema into API responses.
+	openapi3.SchemaErrorDetailsDisabled = true
+
+	// /docs serves an HTML page.
+	openapi3filter.RegisterBodyDecoder("text/html", openapi3filter.PlainBodyDecoder)
+
+	// /events streams text.
+	openapi3filter.RegisterBodyDecoder("text/event-stream", openapi3filter.PlainBodyDecoder)
+
+	// PATCH takes JSON Merge Patch as well as JSON Patch, which is known.
+	openapi3filter.RegisterBodyDecoder(mergePatchType, openapi3filter.JSONBodyDecoder)
+}
+
+// loadOpenAPISpec parses and validates the generated specification.
+func loadOpenAPISpec() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpec))
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// responseMismatch reports a response that does not match the specification.
+// Tests replace it to fail instead of logging.
+var responseMismatch = func(c *gin.Context, err error) {
+	requestLog(c.Request.Context()).Warn("Response does not match the OpenAPI spec", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+}
+
+// newSpecRouter returns a router that matches requests to the operations of
+// doc by path alone; the servers in the spec only describe local development.
+func newSpecRouter(doc *openapi3.T) (routers.Router, error) {
+	routing := *doc
+	routing.Servers = nil
+	return gorillamux.NewRouter(&routing)
+}
+
+// validateOpenAPI returns middleware that rejects requests which do not match
+// doc with 400. With validateResponses set it also checks every response and
+// reports mismatches through responseMismatch. Requests for paths that are
+// not in the specification are passed through to the router.
+func validateOpenAPI(doc *openapi3.T, validateResponses bool) (gin.HandlerFunc, error) {
+	router, err := newSpecRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	options := &openapi3filter.Options{
+		MultiError:            true,
+		IncludeResponseStatus: true,
+		// Credentials are checked by authorize on the routes that need them.
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": validationMessage(err)})
+			return
+		}
+		if !validateResponses {
+			c.Next()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		err = openapi3filter.ValidateResponse(c.Request.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 recorder.Status(),
+			Header:                 recorder.Header(),
+			Body:                   io.NopCloser(&recorder.body),
+			Options:                options,
+		})
+		if err != nil {
+			responseMismatch(c, err)
+		}
+	}, nil
+}
+
+// validationMessage flattens a request validation error into one line.
+func validationMessage(err error) string {
+	var multi openapi3.MultiError
+	if !errors.As(err, &multi) {
+		return err.Error()
+	}
+	messages := make([]string, 0, len(multi))
+	for _, e := range multi {
+		messages = append(messages, validationMessage(e))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// responseRecorder keeps a copy of the response body for validation.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,453 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+// Media types accepted by the PATCH endpoints.
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+// PatchOperation is one operation of a JSON Patch (RFC 6902).
+type PatchOperation struct {
+	// Op is the operation to apply.
+	Op string `json:"op" binding:"required" enums:"add,remove,replace,move,copy,test" example:"replace"`
+
+	// Path is a JSON Pointer to the location the operation applies to.
+	Path string `json:"path" binding:"required" example:"/name"`
+
+	// From is the location move and copy take the value from.
+	From string `json:"from,omitempty"`
+
+	// Value is the value add and replace write and test compares against.
+	Value json.RawMessage `json:"value,omitempty" nullable:"true"`
+}
+
+// patchAttempts bounds how often a PATCH without If-Match is reapplied when
+// the record changes between reading and writing it.
+const patchAttempts = 3
+
+// errUnsupportedPatch is returned by applyPatch for media types other than
+// mergePatchType and jsonPatchType.
+var errUnsupportedPatch = errors.New("PATCH takes " + mergePatchType + " or " + jsonPatchType)
+
+// patchError reports a patch that is well formed but cannot be applied to
+// the record, such as one whose test operation fails.
+type patchError struct {
+	msg string
+}
+
+func (e *patchError) Error() string { return e.msg }
+
+func patchErrorf(format string, args ...any) error {
+	return &patchError{msg: fmt.Sprintf(format, args...)}
+}
+
+// versionedStore is the part of a Store that PATCH needs.
+type versionedStore[T any] interface {
+	Get(ctx context.Context, id int) (T, int64, error)
+	Update(ctx context.Context, id int, item T, ifVersion int64) (T, int64, error)
+}
+
+// specSchemas holds the component schemas patched records are validated
+// against, whether or not requests are validated.
+var specSchemas = sync.OnceValues(func() (openapi3.Schemas, error) {
+	doc, err := loadOpenAPISpec()
+	if err != nil {
+		return nil, err
+	}
+	return doc.Components.Schemas, nil
+})
+
+// patchRecord applies the PATCH request c to record id of store and stores
+// the result, which must match the component schema named schema. The write
+// is conditional on the version the patch was applied to, and is retried
+// on a newer version unless the client sent If-Match. Store errors are
+// answered by storeError; it answers the request itself and returns false
+// on any error.
+func patchRecord[T any](c *gin.Context, store versionedStore[T], id int, schema string, storeError func(*gin.Context, error)) (T, int64, bool) {
+	var zero T
+	ctx := c.Request.Context()
+	ifVersion, ok := ifMatchVersion(c, func() (int64, error) {
+		_, version, err := store.Get(ctx, id)
+		return version, err
+	})
+	if !ok {
+		return zero, 0, false
+	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return zero, 0, false
+	}
+
+	for attempt := 1; ; attempt++ {
+		current, version, err := store.Get(ctx, id)
+		if err == nil && ifVersion != anyVersion && version != ifVersion {
+			err = ErrVersionMismatch
+		}
+		if err != nil {
+			storeError(c, err)
+			return zero, 0, false
+		}
+		patched, ok := patchedRecord(c, current, patch, schema)
+		if !ok {
+			return zero, 0, false
+		}
+		updated, version, err := store.Update(ctx, id, patched, version)
+		if errors.Is(err, ErrVersionMismatch) && ifVersion == anyVersion && attempt < patchAttempts {
+			continue
+		}
+		if err != nil {
+			storeError(c, err)
+			return zero, 0, false
+		}
+		return updated, version, true
+	}
+}
+
+// patchedRecord applies patch to current and checks the result against the
+// component schema named schema. Unknown members are rejected rather than
+// dropped.
+func patchedRecord[T any](c *gin.Context, current T, patch []byte, schema string) (T, bool) {
+	var patched T
+	doc, err := json.Marshal(current)
+	if err == nil {
+		doc, err = applyPatch(c.ContentType(), doc, patch)
+	}
+	var pe *patchError
+	switch {
+	case errors.Is(err, errUnsupportedPatch):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		return patched, false
+	case errors.As(err, &pe):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return patched, false
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return patched, false
+	}
+
+	schemas, err := specSchemas()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return patched, false
+	}
+	var value any
+	if err := json.Unmarshal(doc, &value); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return patched, false
+	}
+	if err := schemas[schema].Value.VisitJSON(value, openapi3.MultiErrors()); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "patched record is invalid: " + validationMessage(err)})
+		return patched, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patched); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "patched record is invalid: " + err.Error()})
+		return patched, false
+	}
+	return patched, true
+}
+
+// applyPatch applies patch, sent with the given Content-Type, to the JSON
+// document doc and returns the result. Malformed patches yield a plain
+// error and patches that do not apply to doc a *patchError.
+func applyPatch(contentType string, doc, patch []byte) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, errUnsupportedPatch
+	}
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	switch mediaType {
+	case mergePatchType:
+		var p any
+		if err := json.Unmarshal(patch, &p); err != nil {
+			return nil, fmt.Errorf("invalid merge patch: %w", err)
+		}
+		target = mergePatch(target, p)
+	case jsonPatchType:
+		var ops []PatchOperation
+		dec := json.NewDecoder(bytes.NewReader(patch))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&ops); err != nil {
+			return nil, fmt.Errorf("invalid JSON Patch: %w", err)
+		}
+		for i, op := range ops {
+			if target, err = op.apply(target); err != nil {
+				return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+			}
+		}
+	default:
+		return nil, errUnsupportedPatch
+	}
+	return json.Marshal(target)
+}
+
+// mergePatch applies a JSON Merge Patch (RFC 7396): members of an object
+// patch replace those of target, recursively, and null members remove them.
+// Any other patch replaces target.
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+// apply applies op to doc and returns the new document.
+func (op PatchOperation) apply(doc any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, errors.New("missing value")
+		}
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+		switch op.Op {
+		case "add":
+			return path.add(doc, value)
+		case "replace":
+			return path.replace(doc, value)
+		}
+		current, err := path.get(doc)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, patchErrorf("test failed")
+		}
+		return doc, nil
+	case "remove":
+		doc, _, err := path.remove(doc)
+		return doc, err
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		if op.Op == "move" {
+			if len(path) > len(from) && slices.Equal(path[:len(from)], from) {
+				return nil, patchErrorf("cannot move a value into itself")
+			}
+			doc, value, err := from.remove(doc)
+			if err != nil {
+				return nil, err
+			}
+			return path.add(doc, value)
+		}
+		value, err := from.get(doc)
+		if err != nil {
+			return nil, err
+		}
+		return path.add(doc, deepCopy(value))
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// jsonPointer is a parsed JSON Pointer (RFC 6901). The empty pointer refers
+// to the whole document.
+type jsonPointer []string
+
+func parsePointer(s string) (jsonPointer, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("invalid JSON Pointer %q", s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+func (p jsonPointer) get(doc any) (any, error) {
+	for _, token := range p {
+		var err error
+		if doc, err = child(doc, token); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// add sets the member p points to, or inserts into an array before the
+// index p ends with ("-" appends).
+func (p jsonPointer) add(doc, value any) (any, error) {
+	if len(p) == 0 {
+		return value, nil
+	}
+	return p.edit(doc, func(container any, token string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			c[token] = value
+			return c, nil
+		case []any:
+			i := len(c)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(c)+1); err != nil {
+					return nil, err
+				}
+			}
+			return append(c[:i], append([]any{value}, c[i:]...)...), nil
+		}
+		return nil, patchErrorf("%q is not inside an object or array", token)
+	})
+}
+
+// replace sets the existing member p points to.
+func (p jsonPointer) replace(doc, value any) (any, error) {
+	if len(p) == 0 {
+		return value, nil
+	}
+	return p.edit(doc, func(container any, token string) (any, error) {
+		if _, err := child(container, token); err != nil {
+			return nil, err
+		}
+		return setChild(container, token, value), nil
+	})
+}
+
+// remove deletes the member p points to and returns it too.
+func (p jsonPointer) remove(doc any) (any, any, error) {
+	if len(p) == 0 {
+		return nil, nil, patchErrorf("cannot remove the whole document")
+	}
+	var removed any
+	doc, err := p.edit(doc, func(container any, token string) (any, error) {
+		var err error
+		if removed, err = child(container, token); err != nil {
+			return nil, err
+		}
+		switch c := container.(type) {
+		case map[string]any:
+			delete(c, token)
+			return c, nil
+		case []any:
+			i, _ := arrayIndex(token, len(c))
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return container, nil
+	})
+	return doc, removed, err
+}
+
+// edit replaces the container of the last token of p with the result of fn
+// and returns the new document. p must not be empty.
+func (p jsonPointer) edit(doc any, fn func(container any, token string) (any, error)) (any, error) {
+	if len(p) == 1 {
+		return fn(doc, p[0])
+	}
+	next, err := child(doc, p[0])
+	if err != nil {
+		return nil, err
+	}
+	if next, err = p[1:].edit(next, fn); err != nil {
+		return nil, err
+	}
+	return setChild(doc, p[0], next), nil
+}
+
+// child returns the member of an object or element of an array that token
+// names.
+func child(container any, token string) (any, error) {
+	switch c := container.(type) {
+	case map[string]any:
+		v, ok := c[token]
+		if !ok {
+			return nil, patchErrorf("no member %q", token)
+		}
+		return v, nil
+	case []any:
+		i, err := arrayIndex(token, len(c))
+		if err != nil {
+			return nil, err
+		}
+		return c[i], nil
+	}
+	return nil, patchErrorf("%q is not inside an object or array", token)
+}
+
+// setChild sets an existing member or element and returns the container.
+func setChild(container any, token string, value any) any {
+	switch c := container.(type) {
+	case map[string]any:
+		c[token] = value
+	case []any:
+		i, _ := arrayIndex(token, len(c))
+		c[i] = value
+	}
+	return container
+}
+
+// arrayIndex parses an array index token, which must be below n.
+func arrayIndex(token string, n int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || token != strconv.Itoa(i) {
+		return 0, patchErrorf("invalid array index %q", token)
+	}
+	if i >= n {
+		return 0, patchErrorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+// deepCopy copies a decoded JSON value, so that copy does not alias it.
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = deepCopy(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = deepCopy(e)
+		}
+		return out
+	}
+	return v
+}
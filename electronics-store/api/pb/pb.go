@@ -0,0 +1,5 @@
+// Package pb holds the protobuf messages and gRPC stubs of the service,
+// generated from the .proto files in this directory.
+package pb
+
+//go:generate go run -C ../../../tools/proto-gen . -dir ../../electronics-store/api/pb
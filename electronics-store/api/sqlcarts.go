@@ -0,0 +1,321 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// orderSortColumns maps the sort= values accepted by GET /orders to columns.
+var orderSortColumns = map[string]string{
+	"id":    "id",
+	"total": "total",
+}
+
+// MySQL error numbers the cart store handles.
+const (
+	mysqlDeadlock        = 1213
+	mysqlNoReferencedRow = 1452
+)
+
+// checkoutAttempts bounds how often a checkout chosen as a deadlock victim
+// is run again.
+const checkoutAttempts = 3
+
+// sqlCartStore keeps carts and orders in the carts, cart_items, orders and
+// order_items tables, next to the products table of sqlStore.
+type sqlCartStore struct {
+	db *sql.DB
+}
+
+// NewSQLCartStore returns a CartStore backed by db. The schema is created by
+// the migrations package.
+func NewSQLCartStore(db *sql.DB) CartStore {
+	return sqlCartStore{db: db}
+}
+
+func (s sqlCartStore) CreateCart(ctx context.Context) (Cart, error) {
+	res, err := s.db.ExecContext(ctx, "INSERT INTO carts () VALUES ()")
+	if err != nil {
+		return Cart{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Cart{}, err
+	}
+	return Cart{ID: int(id), Items: []LineItem{}}, nil
+}
+
+func (s sqlCartStore) Cart(ctx context.Context, id int) (Cart, error) {
+	if err := s.cartExists(ctx, id); err != nil {
+		return Cart{}, err
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT ci.product_id, p.name, p.price, ci.quantity FROM cart_items ci JOIN products p ON p.id = ci.product_id WHERE ci.cart_id = ? ORDER BY ci.product_id", id)
+	if err != nil {
+		return Cart{}, err
+	}
+	defer rows.Close()
+
+	items := []LineItem{}
+	for rows.Next() {
+		var item LineItem
+		if err := rows.Scan(&item.ProductID, &item.Name, &item.UnitPrice, &item.Quantity); err != nil {
+			return Cart{}, err
+		}
+		items = append(items, item)
+	}
+	return Cart{ID: id, Items: items, Total: lineTotal(items)}, rows.Err()
+}
+
+// cartExists returns an error wrapping ErrNotFound unless cart id exists.
+func (s sqlCartStore) cartExists(ctx context.Context, id int) error {
+	var found int
+	err := s.db.QueryRowContext(ctx, "SELECT id FROM carts WHERE id = ?", id).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("cart %d: %w", id, ErrNotFound)
+	}
+	return err
+}
+
+// SetCartItem relies on the foreign keys of cart_items to reject unknown
+// carts and products, and tells the two apart with a second lookup.
+func (s sqlCartStore) SetCartItem(ctx context.Context, cartID, productID, quantity int) (Cart, error) {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO cart_items (cart_id, product_id, quantity) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE quantity = VALUES(quantity)", cartID, productID, quantity)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlNoReferencedRow {
+		if err := s.cartExists(ctx, cartID); err != nil {
+			return Cart{}, err
+		}
+		return Cart{}, fmt.Errorf("product %d: %w", productID, ErrNotFound)
+	}
+	if err != nil {
+		return Cart{}, err
+	}
+	return s.Cart(ctx, cartID)
+}
+
+func (s sqlCartStore) RemoveCartItem(ctx context.Context, cartID, productID int) (Cart, error) {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM cart_items WHERE cart_id = ? AND product_id = ?", cartID, productID)
+	if err != nil {
+		return Cart{}, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return Cart{}, err
+	}
+	if n == 0 {
+		if err := s.cartExists(ctx, cartID); err != nil {
+			return Cart{}, err
+		}
+		return Cart{}, fmt.Errorf("product %d in cart %d: %w", productID, cartID, ErrNotFound)
+	}
+	return s.Cart(ctx, cartID)
+}
+
+func (s sqlCartStore) DeleteCart(ctx context.Context, id int) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM carts WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("cart %d: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// Checkout runs checkout, trying again when MySQL picks it as the victim of
+// a deadlock, which rolls it back entirely.
+func (s sqlCartStore) Checkout(ctx context.Context, cartID int) (Order, error) {
+	for attempt := 1; ; attempt++ {
+		order, err := s.checkout(ctx, cartID)
+		var mysqlErr *mysql.MySQLError
+		if attempt < checkoutAttempts && errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDeadlock {
+			continue
+		}
+		return order, err
+	}
+}
+
+// checkout locks the cart and then the rows of its products with SELECT ...
+// FOR UPDATE, so that concurrent checkouts of the same products queue up
+// instead of both selling the last unit. The products are locked in ID order
+// to keep checkouts that share products from deadlocking.
+func (s sqlCartStore) checkout(ctx context.Context, cartID int) (Order, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Order{}, err
+	}
+	defer tx.Rollback()
+
+	var found int
+	err = tx.QueryRowContext(ctx, "SELECT id FROM carts WHERE id = ? FOR UPDATE", cartID).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Order{}, fmt.Errorf("cart %d: %w", cartID, ErrNotFound)
+	}
+	if err != nil {
+		return Order{}, err
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT p.id, p.name, p.price, p.stock, ci.quantity FROM cart_items ci JOIN products p ON p.id = ci.product_id WHERE ci.cart_id = ? ORDER BY ci.product_id FOR UPDATE", cartID)
+	if err != nil {
+		return Order{}, err
+	}
+	items := []LineItem{}
+	var shortages []StockShortage
+	for rows.Next() {
+		var item LineItem
+		var stock int
+		if err := rows.Scan(&item.ProductID, &item.Name, &item.UnitPrice, &stock, &item.Quantity); err != nil {
+			rows.Close()
+			return Order{}, err
+		}
+		if stock < item.Quantity {
+			shortages = append(shortages, StockShortage{ProductID: item.ProductID, Requested: item.Quantity, Available: stock})
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Order{}, err
+	}
+	if len(items) == 0 {
+		return Order{}, ErrEmptyCart
+	}
+	if len(shortages) > 0 {
+		return Order{}, &OversellError{Shortages: shortages}
+	}
+
+	// Selling a unit is a write to the product, so it gets a new version.
+	for _, item := range items {
+		if _, err := tx.ExecContext(ctx, "UPDATE products SET stock = stock - ?, version = version + 1 WHERE id = ?", item.Quantity, item.ProductID); err != nil {
+			return Order{}, err
+		}
+	}
+
+	order := Order{Items: items, Total: lineTotal(items), CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	res, err := tx.ExecContext(ctx, "INSERT INTO orders (total, created_at) VALUES (?, ?)", order.Total, order.CreatedAt)
+	if err != nil {
+		return Order{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Order{}, err
+	}
+	order.ID = int(id)
+
+	values := make([]string, len(items))
+	args := make([]any, 0, 5*len(items))
+	for i, item := range items {
+		values[i] = "(?, ?, ?, ?, ?)"
+		args = append(args, order.ID, item.ProductID, item.Name, item.UnitPrice, item.Quantity)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO order_items (order_id, product_id, name, unit_price, quantity) VALUES "+strings.Join(values, ", "), args...); err != nil {
+		return Order{}, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM carts WHERE id = ?", cartID); err != nil {
+		return Order{}, err
+	}
+	return order, tx.Commit()
+}
+
+func (s sqlCartStore) ListOrders(ctx context.Context, q OrderQuery) ([]Order, int, error) {
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM orders").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order := "id"
+	if column, ok := orderSortColumns[q.SortField]; ok {
+		order = column
+	}
+	if q.Desc {
+		order += " DESC"
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT id, total, created_at FROM orders ORDER BY "+order+" LIMIT ? OFFSET ?", q.Limit, q.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	orders := []Order{}
+	for rows.Next() {
+		o := Order{Items: []LineItem{}}
+		if err := rows.Scan(&o.ID, &o.Total, &o.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if err := s.loadOrderItems(ctx, orders); err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+func (s sqlCartStore) Order(ctx context.Context, id int) (Order, error) {
+	o := Order{Items: []LineItem{}}
+	err := s.db.QueryRowContext(ctx, "SELECT id, total, created_at FROM orders WHERE id = ?", id).Scan(&o.ID, &o.Total, &o.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return o, fmt.Errorf("order %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return o, err
+	}
+	orders := []Order{o}
+	if err := s.loadOrderItems(ctx, orders); err != nil {
+		return o, err
+	}
+	return orders[0], nil
+}
+
+// loadOrderItems fills in the items of orders with a single query.
+func (s sqlCartStore) loadOrderItems(ctx context.Context, orders []Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	index := make(map[int]int, len(orders))
+	marks := make([]string, len(orders))
+	args := make([]any, len(orders))
+	for i, o := range orders {
+		index[o.ID] = i
+		marks[i] = "?"
+		args[i] = o.ID
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT order_id, product_id, name, unit_price, quantity FROM order_items WHERE order_id IN ("+strings.Join(marks, ", ")+") ORDER BY order_id, product_id", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var orderID int
+		var item LineItem
+		if err := rows.Scan(&orderID, &item.ProductID, &item.Name, &item.UnitPrice, &item.Quantity); err != nil {
+			return err
+		}
+		if i, ok := index[orderID]; ok {
+			orders[i].Items = append(orders[i].Items, item)
+		}
+	}
+	return rows.Err()
+}
+
+// lineTotal returns the price of items, rounded to cents.
+func lineTotal(items []LineItem) float64 {
+	var total float64
+	for _, item := range items {
+		total += float64(item.Quantity) * item.UnitPrice
+	}
+	return math.Round(total*100) / 100
+}
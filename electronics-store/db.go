@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"electronics-store/migrations"
+)
+
+const (
+	// initialRetryDelay and maxRetryDelay bound the exponential backoff used
+	// while waiting for the database at startup.
+	initialRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 30 * time.Second
+
+	// pingTimeout bounds a single connection attempt.
+	pingTimeout = 5 * time.Second
+)
+
+// dbConfig holds the database connection settings. The operator injects the
+// DB_* variables; the defaults match the standalone deployment manifests.
+type dbConfig struct {
+	Host     string
+	Port     string
+	Name     string
+	User     string
+	Password string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// StartupTimeout is how long to keep retrying the initial connection,
+	// and then to wait for the schema when AutoMigrate is off, before giving
+	// up. Zero retries forever.
+	StartupTimeout time.Duration
+
+	// AutoMigrate applies pending migrations and seed data on startup.
+	AutoMigrate bool
+}
+
+func loadDBConfig() dbConfig {
+	return dbConfig{
+		Host:            envString("DB_HOST", "mysql"),
+		Port:            envString("DB_PORT", "3306"),
+		Name:            envString("DB_NAME", "electronics-store"),
+		User:            envString("DB_USER", "admin"),
+		Password:        envString("DB_PASSWORD", "password123"),
+		MaxOpenConns:    envInt("DB_MAX_OPEN_CONNS", 10),
+		MaxIdleConns:    envInt("DB_MAX_IDLE_CONNS", 5),
+		ConnMaxLifetime: envSeconds("DB_CONN_MAX_LIFETIME_SECONDS", 5*time.Minute),
+		StartupTimeout:  envSeconds("DB_STARTUP_TIMEOUT_SECONDS", 5*time.Minute),
+		AutoMigrate:     envBool("DB_AUTO_MIGRATE", true),
+	}
+}
+
+// DSN returns the go-sql-driver/mysql data source name for the config.
+func (c dbConfig) DSN() string {
+	cfg := mysql.NewConfig()
+	cfg.User = c.User
+	cfg.Passwd = c.Password
+	cfg.Net = "tcp"
+	cfg.Addr = c.Host + ":" + c.Port
+	cfg.DBName = c.Name
+	// Order timestamps are scanned into time.Time.
+	cfg.ParseTime = true
+	return cfg.FormatDSN()
+}
+
+// openDB creates the connection pool. It does not connect; use waitForDB to
+// block until the database is reachable.
+func openDB(c dbConfig) (*sql.DB, error) {
+	conn, err := sql.Open("mysql", c.DSN())
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(c.MaxOpenConns)
+	conn.SetMaxIdleConns(c.MaxIdleConns)
+	conn.SetConnMaxLifetime(c.ConnMaxLifetime)
+	return conn, nil
+}
+
+// waitForDB pings conn with exponential backoff until it responds or timeout
+// elapses. A zero timeout retries until ctx is cancelled.
+func waitForDB(ctx context.Context, conn *sql.DB, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	delay := initialRetryDelay
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+		err := conn.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		slog.Warn("Database not reachable, retrying", "attempt", attempt, "delay", delay.String(), "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// schemaPollInterval is the wait between checks for pending migrations.
+const schemaPollInterval = 5 * time.Second
+
+// waitForSchema waits until migrator has no pending migrations, which the
+// migrate init container applies, for at most timeout. Zero waits forever.
+func waitForSchema(ctx context.Context, migrator *migrations.Migrator, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for {
+		pending, err := migrator.Pending(ctx)
+		if err == nil && len(pending) == 0 {
+			return nil
+		}
+		slog.Info("Waiting for schema", "version", migrator.Latest(), "pending", len(pending), "error", err)
+		select {
+		case <-ctx.Done():
+			if err == nil {
+				err = fmt.Errorf("%d migrations pending", len(pending))
+			}
+			return fmt.Errorf("gave up waiting for the schema: %w", err)
+		case <-time.After(schemaPollInterval):
+		}
+	}
+}
+
+// envString reads key from the environment, falling back to def when unset.
+func envString(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// envInt reads an integer from the environment, falling back to def when the
+// variable is unset or invalid.
+func envInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// envSeconds reads a duration given in whole seconds from the environment,
+// falling back to def when the variable is unset or invalid.
+func envSeconds(key string, def time.Duration) time.Duration {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v < 0 {
+		return def
+	}
+	return time.Duration(v) * time.Second
+}
+
+// envBool reads a boolean from the environment, falling back to def when the
+// variable is unset or invalid.
+func envBool(key string, def bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
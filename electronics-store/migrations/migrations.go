@@ -0,0 +1,336 @@
+// Package migrations applies the electronics-store database schema and seed
+// data. Both are embedded in the binary so the same image can run them as an
+// init container or Job before the application starts.
+//
+// Schema migrations live in schema/ as NNNN_name.up.sql and NNNN_name.down.sql
+// pairs and are recorded in the schema_migrations table once applied. Seed
+// files live in seed/ and are executed in name order every time Seed is
+// called, so they must be idempotent. SeedOnce runs them, with the fixtures
+// of the service, once for each seed and records the run in seed_runs.
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed schema/*.sql
+var schemaFS embed.FS
+
+//go:embed seed/*.sql
+var seedFS embed.FS
+
+const createVersionTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INT NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+const createSeedTable = `CREATE TABLE IF NOT EXISTS seed_runs (
+	digest CHAR(64) NOT NULL PRIMARY KEY,
+	spec TEXT NOT NULL,
+	applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load returns the embedded schema migrations ordered by version.
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(schemaFS, "schema")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		m := fileName.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		body, err := schemaFS.ReadFile(path.Join("schema", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New returns a Migrator for the embedded migrations.
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest returns the highest known migration version.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Applied returns the versions recorded in schema_migrations, in ascending
+// order.
+func (m *Migrator) Applied(ctx context.Context) ([]int, error) {
+	if _, err := m.db.ExecContext(ctx, createVersionTable); err != nil {
+		return nil, fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// Pending returns the migrations that have not been applied yet.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[int]bool, len(applied))
+	for _, v := range applied {
+		done[v] = true
+	}
+
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if !done[mig.Version] {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending migrations in order and returns how many ran.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, mig := range pending {
+		err := m.apply(ctx, mig.Up, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)", mig.Version, mig.Name)
+		if err != nil {
+			return i, fmt.Errorf("applying migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+	}
+	return len(pending), nil
+}
+
+// Down reverts up to steps of the most recently applied migrations and
+// returns how many were reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	known := make(map[int]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+
+	reverted := 0
+	for i := len(applied) - 1; i >= 0 && reverted < steps; i-- {
+		mig, ok := known[applied[i]]
+		if !ok {
+			return reverted, fmt.Errorf("applied migration %d is not known to this binary", applied[i])
+		}
+		if mig.Down == "" {
+			return reverted, fmt.Errorf("migration %d_%s cannot be reverted", mig.Version, mig.Name)
+		}
+		if err := m.apply(ctx, mig.Down, "DELETE FROM schema_migrations WHERE version = ?", mig.Version); err != nil {
+			return reverted, fmt.Errorf("reverting migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+// Seed runs every embedded seed file in name order.
+func (m *Migrator) Seed(ctx context.Context) error {
+	entries, err := fs.ReadDir(seedFS, "seed")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		body, err := seedFS.ReadFile(path.Join("seed", entry.Name()))
+		if err != nil {
+			return err
+		}
+		for _, stmt := range splitStatements(string(body)) {
+			if _, err := m.db.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("seeding %s: %w", entry.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// SeedOnce runs Seed and then load, unless they already ran for the same
+// seed files and spec, which describes the data load adds. It reports
+// whether they ran. Pods restart, and each start runs the seed again, so
+// without this a seed that replaces the data would wipe what the tests have
+// written since, and one that appends would add its records again. A crash
+// after load but before the run is recorded runs it again on the next start.
+func (m *Migrator) SeedOnce(ctx context.Context, spec string, load func(context.Context) error) (bool, error) {
+	if _, err := m.db.ExecContext(ctx, createSeedTable); err != nil {
+		return false, fmt.Errorf("creating seed_runs: %w", err)
+	}
+	digest, err := seedDigest(spec)
+	if err != nil {
+		return false, err
+	}
+
+	var runs int
+	if err := m.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM seed_runs WHERE digest = ?", digest).Scan(&runs); err != nil {
+		return false, err
+	}
+	if runs > 0 {
+		return false, nil
+	}
+
+	if err := m.Seed(ctx); err != nil {
+		return false, err
+	}
+	if err := load(ctx); err != nil {
+		return false, err
+	}
+	if _, err := m.db.ExecContext(ctx, "INSERT INTO seed_runs (digest, spec) VALUES (?, ?)", digest, spec); err != nil {
+		return false, fmt.Errorf("recording seed run: %w", err)
+	}
+	return true, nil
+}
+
+// seedDigest hashes the embedded seed files and spec.
+func seedDigest(spec string) (string, error) {
+	entries, err := fs.ReadDir(seedFS, "seed")
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, entry := range entries {
+		body, err := seedFS.ReadFile(path.Join("seed", entry.Name()))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %d\n", entry.Name(), len(body))
+		h.Write(body)
+	}
+	h.Write([]byte(spec))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// apply runs script and the bookkeeping statement in one transaction. MySQL
+// commits DDL implicitly, so this only guarantees atomicity for DML scripts.
+// A crash between the DDL and the bookkeeping runs the script again on the
+// next start, so DDL scripts must be safe to re-run: they use IF NOT EXISTS
+// where MySQL has it, and otherwise look the change up in information_schema
+// before making it.
+func (m *Migrator) apply(ctx context.Context, script, record string, args ...any) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range splitStatements(script) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// splitStatements splits a script on semicolons that end a line. Chunks that
+// contain only comments are dropped.
+func splitStatements(script string) []string {
+	var stmts []string
+	var current strings.Builder
+	flush := func() {
+		stmt := strings.TrimSpace(current.String())
+		current.Reset()
+		for _, line := range strings.Split(stmt, "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "--") {
+				stmts = append(stmts, stmt)
+				return
+			}
+		}
+	}
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasSuffix(trimmed, ";") && !strings.HasPrefix(trimmed, "--") {
+			current.WriteString(strings.TrimSuffix(strings.TrimRight(line, " \t\r"), ";"))
+			flush()
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+	}
+	flush()
+	return stmts
+}
@@ -0,0 +1,8 @@
+SET @ddl = IF(
+	(SELECT COUNT(*) FROM information_schema.STATISTICS
+	 WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'products' AND INDEX_NAME = 'idx_products_price') > 0,
+	'DROP INDEX idx_products_price ON products',
+	'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
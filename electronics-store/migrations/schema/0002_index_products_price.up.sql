@@ -0,0 +1,11 @@
+-- MySQL has no CREATE INDEX IF NOT EXISTS, so the index is only created when
+-- information_schema does not list it, in case a crash kept an earlier run
+-- from being recorded.
+SET @ddl = IF(
+	(SELECT COUNT(*) FROM information_schema.STATISTICS
+	 WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'products' AND INDEX_NAME = 'idx_products_price') = 0,
+	'CREATE INDEX idx_products_price ON products (price)',
+	'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
@@ -0,0 +1,11 @@
+-- Order lines copy the name and price of the product at checkout and keep no
+-- reference to it, so orders outlive the products they contain.
+CREATE TABLE IF NOT EXISTS order_items (
+	order_id INT NOT NULL,
+	product_id INT NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	unit_price DECIMAL(10, 2) NOT NULL,
+	quantity INT NOT NULL,
+	PRIMARY KEY (order_id, product_id),
+	FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
@@ -0,0 +1,18 @@
+-- Default catalogue. INSERT IGNORE relies on the unique product name so the
+-- seed can be re-run safely.
+INSERT IGNORE INTO products (name, price, stock) VALUES
+	('Laptop', 999.99, 25),
+	('Smartphone', 699.99, 60),
+	('Tablet', 499.99, 40),
+	('Headphones', 199.99, 80),
+	('Smartwatch', 299.99, 50),
+	('Camera', 599.99, 30),
+	('Printer', 149.99, 20),
+	('Monitor', 249.99, 35),
+	('Keyboard', 49.99, 120),
+	('Mouse', 29.99, 150),
+	('Router', 89.99, 45),
+	('Speaker', 129.99, 55),
+	('Microphone', 99.99, 40),
+	('External Hard Drive', 79.99, 70),
+	('USB Flash Drive', 19.99, 200);
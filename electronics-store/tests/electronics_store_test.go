@@ -0,0 +1,1242 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"electronics-store/api"
+	"electronics-store/api/pb"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var productColumns = []string{"id", "name", "price", "stock"}
+
+// versionedProductColumns are the columns of a single product lookup.
+var versionedProductColumns = []string{"id", "name", "price", "stock", "version"}
+
+// newRouter returns the service router over a SQL store backed by sqlmock,
+// with the database marked as initialised.
+func newRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	t.Helper()
+	return newCachedRouter(t, nil)
+}
+
+// newCachedRouter is newRouter reading the products through cache.
+func newCachedRouter(t *testing.T, cache api.Cache) (*gin.Engine, sqlmock.Sqlmock) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	})
+
+	readiness := &api.Readiness{}
+	readiness.SetWarmedUp()
+	r := api.NewRouter(api.NewSQLStore(db), api.Config{
+		ValidateRequests: true,
+		Readiness:        readiness,
+		Carts:            api.NewSQLCartStore(db),
+		Cache:            cache,
+	})
+	return r, mock
+}
+
+func do(r http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &v), w.Body.String())
+	return v
+}
+
+func TestGetProducts(t *testing.T) {
+	r, mock := newRouter(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM products WHERE price <= ?")).
+		WithArgs(500.0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, price, stock FROM products WHERE price <= ? ORDER BY price DESC LIMIT ? OFFSET ?")).
+		WithArgs(500.0, 2, 0).
+		WillReturnRows(sqlmock.NewRows(productColumns).
+			AddRow(3, "Tablet", 499.99, 40).
+			AddRow(4, "Headphones", 199.99, 80))
+
+	w := do(r, "GET", "/products?maxPrice=500&sort=-price&limit=2", "")
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []api.Product{{ID: 3, Name: "Tablet", Price: 499.99, Stock: 40}, {ID: 4, Name: "Headphones", Price: 199.99, Stock: 80}}, decode[[]api.Product](t, w))
+	assert.Equal(t, "12", w.Header().Get("X-Total-Count"))
+}
+
+func TestGetProduct(t *testing.T) {
+	r, mock := newRouter(t)
+	mock.ExpectQuery("SELECT id, name, price, stock, version FROM products WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(versionedProductColumns).AddRow(1, "Laptop", 999.99, 25, 1))
+
+	w := do(r, "GET", "/products/1", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, api.Product{ID: 1, Name: "Laptop", Price: 999.99, Stock: 25}, decode[api.Product](t, w))
+}
+
+func TestGetNonExistentProduct(t *testing.T) {
+	r, mock := newRouter(t)
+	mock.ExpectQuery("SELECT id, name, price, stock, version FROM products WHERE id = ?").
+		WithArgs(99).
+		WillReturnRows(sqlmock.NewRows(versionedProductColumns))
+
+	w := do(r, "GET", "/products/99", "")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Product not found")
+}
+
+func TestCreateProductAssignsID(t *testing.T) {
+	r, mock := newRouter(t)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO products (id, name, price, stock) VALUES (?, ?, ?, ?)") + "$").
+		WithArgs(nil, "Drone", 799.99, 0).
+		WillReturnResult(sqlmock.NewResult(16, 1))
+
+	w := do(r, "POST", "/products", `{"name":"Drone","price":799.99}`)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, api.Product{ID: 16, Name: "Drone", Price: 799.99}, decode[api.Product](t, w))
+}
+
+func TestCreateProductRejectsInvalidBody(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "POST", "/products", `{"price":799.99}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreateProductDoesNotReplaceProducts(t *testing.T) {
+	r, mock := newRouter(t)
+
+	// Only PUT, with its If-Match check, replaces a product.
+	w := do(r, "POST", "/products", `{"id":1,"name":"Drone","price":799.99}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "PUT /products/{id}")
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO products (id, name, price, stock) VALUES (?, ?, ?, ?)") + "$").
+		WithArgs(nil, "Laptop", 799.99, 0).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'Laptop' for key 'name'"})
+	w = do(r, "POST", "/products", `{"name":"Laptop","price":799.99}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "Product name already exists")
+}
+
+func TestUpdateProduct(t *testing.T) {
+	r, mock := newRouter(t)
+	mock.ExpectExec("UPDATE products SET name = \\?, price = \\?, stock = \\?, version = LAST_INSERT_ID\\(version \\+ 1\\) WHERE id = \\?$").
+		WithArgs("Laptop", 899.99, 0, 1).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	w := do(r, "PUT", "/products/1", `{"id":42,"name":"Laptop","price":899.99}`)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.Product{ID: 1, Name: "Laptop", Price: 899.99}, decode[api.Product](t, w))
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+}
+
+func TestUpdateNonExistentProduct(t *testing.T) {
+	r, mock := newRouter(t)
+	mock.ExpectExec("UPDATE products SET").
+		WithArgs("Ghost", 1.0, 0, 99).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, name, price, stock, version FROM products WHERE id = ?").
+		WithArgs(99).
+		WillReturnError(sql.ErrNoRows)
+
+	w := do(r, "PUT", "/products/99", `{"name":"Ghost","price":1}`)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUpdateProductWithTakenNameConflicts(t *testing.T) {
+	r, mock := newRouter(t)
+	mock.ExpectExec("UPDATE products SET").
+		WithArgs("Laptop", 699.99, 0, 2).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'Laptop' for key 'name'"})
+
+	w := do(r, "PUT", "/products/2", `{"name":"Laptop","price":699.99}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "Product name already exists")
+}
+
+func TestDeleteProduct(t *testing.T) {
+	r, mock := newRouter(t)
+	mock.ExpectExec("DELETE FROM products WHERE id = \\?").
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM products WHERE id = \\?").
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.Equal(t, http.StatusOK, do(r, "DELETE", "/products/2", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(r, "DELETE", "/products/2", "").Code)
+}
+
+func TestDatabaseErrorsAreReported(t *testing.T) {
+	r, mock := newRouter(t)
+	mock.ExpectQuery("SELECT id, name, price, stock, version FROM products WHERE id = ?").
+		WithArgs(1).
+		WillReturnError(sql.ErrConnDone)
+
+	w := do(r, "GET", "/products/1", "")
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), sql.ErrConnDone.Error())
+}
+
+func TestProductsUnavailableUntilDatabaseIsReady(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	readiness := &api.Readiness{}
+	r := api.NewRouter(api.NewSQLStore(db), api.Config{Readiness: readiness})
+
+	assert.Equal(t, http.StatusServiceUnavailable, do(r, "GET", "/products", "").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, do(r, "POST", "/products", `{"name":"Drone","price":1}`).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRequestsAreLogged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	r := api.NewRouter(api.NewSQLStore(nil), api.Config{
+		Logger: api.NewLogger(&logs, "info", "json"),
+	})
+
+	req := httptest.NewRequest("GET", "/products/99", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "req-42", w.Header().Get("X-Request-ID"))
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record), logs.String())
+	assert.Equal(t, "request", record["msg"])
+	assert.Equal(t, "electronics-store", record["service"])
+	assert.Equal(t, "req-42", record["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.Equal(t, "/products/:id", record["route"])
+	assert.Equal(t, float64(503), record["status"])
+	assert.Contains(t, record, "latency_ms")
+
+	// Requests without an X-Request-ID get a generated one.
+	w = do(r, "GET", "/products", "")
+	assert.Regexp(t, "^[0-9a-f]{32}$", w.Header().Get("X-Request-ID"))
+}
+
+// withAuth returns the service router requiring the given credentials.
+func withAuth(t *testing.T, auth api.Authenticator) (*gin.Engine, sqlmock.Sqlmock) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	})
+
+	readiness := &api.Readiness{}
+	readiness.SetWarmedUp()
+	r := api.NewRouter(api.NewSQLStore(db), api.Config{ValidateRequests: true, Readiness: readiness, Auth: auth})
+	return r, mock
+}
+
+func doWithHeader(r http.Handler, method, path, body, header, value string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set(header, value)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func expectProduct(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT id, name, price, stock, version FROM products WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(versionedProductColumns).AddRow(1, "Laptop", 999.99, 25, 1))
+}
+
+func expectDelete(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("DELETE FROM products WHERE id = \\?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestAPIKeysNeedTheMethodScope(t *testing.T) {
+	r, mock := withAuth(t, api.APIKeys{
+		"reader-key": {Subject: "reader", Scopes: []string{api.ScopeRead}},
+		"writer-key": {Subject: "writer", Scopes: []string{api.ScopeWrite}},
+	})
+	body := `{"name":"Drone","price":799.99}`
+
+	w := do(r, "GET", "/products/1", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusUnauthorized, doWithHeader(r, "GET", "/products/1", "", "X-API-Key", "wrong").Code)
+
+	expectProduct(mock)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/products/1", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "POST", "/products", body, "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "POST", "/admin/fixtures/generate", "", "X-API-Key", "reader-key").Code)
+
+	expectProduct(mock)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/products/1", "", "X-API-Key", "writer-key").Code)
+	expectDelete(mock)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/products/1", "", "X-API-Key", "writer-key").Code)
+
+	// Health checks and docs stay open.
+	for _, path := range []string{"/livez", "/openapi.json", "/docs"} {
+		assert.Equal(t, http.StatusOK, do(r, "GET", path, "").Code, path)
+	}
+}
+
+func TestAPIKeysFromEnv(t *testing.T) {
+	keys := filepath.Join(t.TempDir(), "api-keys")
+	require.NoError(t, os.WriteFile(keys, []byte("# ci keys\nreader-key read ci-reader\n\nwriter-key read write\n"), 0o600))
+	t.Setenv("AUTH_API_KEYS_FILE", keys)
+
+	auth, err := api.AuthFromEnv()
+	require.NoError(t, err)
+	r, mock := withAuth(t, auth)
+
+	expectProduct(mock)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/products/1", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "DELETE", "/products/1", "", "X-API-Key", "reader-key").Code)
+	expectDelete(mock)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/products/1", "", "X-API-Key", "writer-key").Code)
+
+	t.Setenv("AUTH_API_KEYS_FILE", "")
+	auth, err = api.AuthFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, auth, "no configuration leaves the API open")
+}
+
+func TestBearerTokensAreVerifiedAgainstJWKS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"EC","crv":"P-256","kid":"test","use":"sig","x":%q,"y":%q}]}`,
+		base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))))
+	auth, err := api.NewJWTAuth([]byte(jwks), "https://issuer.test", "electronics-store")
+	require.NoError(t, err)
+	r, mock := withAuth(t, auth)
+
+	sign := func(claims map[string]any) string {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","kid":"test","typ":"JWT"}`))
+		payload, err := json.Marshal(claims)
+		require.NoError(t, err)
+		input := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(input))
+		sr, ss, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		require.NoError(t, err)
+		sig := append(sr.FillBytes(make([]byte, 32)), ss.FillBytes(make([]byte, 32))...)
+		return "Bearer " + input + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+	claims := func(scope, aud string, exp time.Duration) map[string]any {
+		return map[string]any{
+			"sub":   "client-1",
+			"iss":   "https://issuer.test",
+			"aud":   aud,
+			"exp":   time.Now().Add(exp).Unix(),
+			"scope": scope,
+		}
+	}
+
+	reader := sign(claims("read", "electronics-store", time.Hour))
+	expectProduct(mock)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/products/1", "", "Authorization", reader).Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "DELETE", "/products/1", "", "Authorization", reader).Code)
+
+	writer := sign(claims("read write", "electronics-store", time.Hour))
+	expectDelete(mock)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/products/1", "", "Authorization", writer).Code)
+
+	for name, token := range map[string]string{
+		"expired":        sign(claims("read", "electronics-store", -time.Hour)),
+		"other audience": sign(claims("read", "coffee-shop", time.Hour)),
+		"tampered":       reader[:len(reader)-4] + "AAAA",
+	} {
+		w := doWithHeader(r, "GET", "/products/1", "", "Authorization", token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+	}
+}
+
+func TestRateLimitPerClientIPAndCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	readiness := &api.Readiness{}
+	readiness.SetWarmedUp()
+	r := api.NewRouter(api.NewSQLStore(db), api.Config{
+		Readiness: readiness,
+		Auth:      api.APIKeys{"shared-key": {Subject: "load-test", Scopes: []string{api.ScopeRead}}},
+		Limits:    api.Limits{RequestsPerSecond: 1, Burst: 2},
+	})
+	from := func(ip, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = ip + ":40000"
+		req.Header.Set("X-API-Key", "shared-key")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	expectProduct(mock)
+	expectProduct(mock)
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/products/1").Code)
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/products/1").Code)
+	w := from("10.0.0.1", "/products/1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// Probes are never limited.
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/livez").Code)
+
+	// A new IP gets its own bucket, but the key it shares is spent.
+	assert.Equal(t, http.StatusTooManyRequests, from("10.0.0.2", "/products/1").Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForwardedForIsOnlyTrustedFromProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	readiness := &api.Readiness{}
+	readiness.SetWarmedUp()
+	newRouter := func(proxies ...string) http.Handler {
+		return api.NewRouter(api.NewSQLStore(db), api.Config{
+			Readiness: readiness,
+			Limits:    api.Limits{RequestsPerSecond: 1, Burst: 1, TrustedProxies: proxies},
+		})
+	}
+	get := func(r http.Handler, peer, forwardedFor string) int {
+		req := httptest.NewRequest("GET", "/products/1", nil)
+		req.RemoteAddr = peer + ":40000"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// By default a client cannot leave its bucket by naming another address.
+	r := newRouter()
+	expectProduct(mock)
+	assert.Equal(t, http.StatusOK, get(r, "203.0.113.7", "10.0.0.1"))
+	assert.Equal(t, http.StatusTooManyRequests, get(r, "203.0.113.7", "10.0.0.2"))
+
+	// Behind a trusted proxy, each forwarded client has its own bucket.
+	r = newRouter("192.0.2.0/24")
+	expectProduct(mock)
+	expectProduct(mock)
+	assert.Equal(t, http.StatusOK, get(r, "192.0.2.10", "10.0.0.1"))
+	assert.Equal(t, http.StatusOK, get(r, "192.0.2.10", "10.0.0.2"))
+	assert.Equal(t, http.StatusTooManyRequests, get(r, "192.0.2.10", "10.0.0.1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLargeBodiesAreRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	readiness := &api.Readiness{}
+	readiness.SetWarmedUp()
+	r := api.NewRouter(api.NewSQLStore(db), api.Config{
+		ValidateRequests: true,
+		Readiness:        readiness,
+		Limits:           api.Limits{MaxBodyBytes: 64},
+	})
+
+	w := do(r, "POST", "/products", `{"name":"`+strings.Repeat("x", 64)+`","price":799.99}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	req := httptest.NewRequest("POST", "/products", io.MultiReader(strings.NewReader(`{"name":"`+strings.Repeat("x", 64)), strings.NewReader(`","price":799.99}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, "bodies without a Content-Length are capped too")
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO products (id, name, price, stock) VALUES (?, ?, ?, ?)") + "$").
+		WithArgs(nil, "Drone", 799.99, 0).
+		WillReturnResult(sqlmock.NewResult(16, 1))
+	assert.Equal(t, http.StatusCreated, do(r, "POST", "/products", `{"name":"Drone","price":799.99}`).Code)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO products (id, name, price, stock) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE")).
+		WillReturnResult(sqlmock.NewResult(17, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM products")).
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(17))
+	mock.ExpectCommit()
+	w = do(r, "POST", "/admin/fixtures", `[{"name":"`+strings.Repeat("x", 64)+`","price":799.99}]`)
+	assert.Equal(t, http.StatusOK, w.Code, "fixture imports are not held to the cap: %s", w.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFixtureImportsAreValidated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	readiness := &api.Readiness{}
+	readiness.SetWarmedUp()
+	// Seed files never reach the OpenAPI validator, so the import has to
+	// check the records itself.
+	r := api.NewRouter(api.NewSQLStore(db), api.Config{Readiness: readiness})
+
+	w := do(r, "POST", "/admin/fixtures", `[{"name":"Drone","price":799.99},{"name":"","price":9.99}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "record 1")
+
+	w = doWithHeader(r, "POST", "/admin/fixtures", "name,price,stock\nDrone,799.99,3\nTablet,299.99,-1\n", "Content-Type", "text/csv")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "record 1")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "nothing is stored from a rejected import")
+}
+
+func TestConditionalRequestsUseProductVersions(t *testing.T) {
+	r, mock := newRouter(t)
+
+	expectProduct(mock)
+	w := do(r, "GET", "/products/1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	expectProduct(mock)
+	assert.Equal(t, http.StatusNotModified, doWithHeader(r, "GET", "/products/1", "", "If-None-Match", `"1"`).Code)
+
+	mock.ExpectExec("UPDATE products SET .* WHERE id = \\? AND version = \\?").
+		WithArgs("Laptop", 899.99, 0, 1, 1).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	w = doWithHeader(r, "PUT", "/products/1", `{"name":"Laptop","price":899.99}`, "If-Match", `"1"`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	// A stale version matches no row, and the lookup that follows tells it
+	// apart from a missing product.
+	mock.ExpectExec("UPDATE products SET .* WHERE id = \\? AND version = \\?").
+		WithArgs("Laptop", 799.99, 0, 1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectProduct(mock)
+	w = doWithHeader(r, "PUT", "/products/1", `{"name":"Laptop","price":799.99}`, "If-Match", `"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	mock.ExpectExec("DELETE FROM products WHERE id = \\? AND version = \\?").
+		WithArgs(1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectProduct(mock)
+	assert.Equal(t, http.StatusPreconditionFailed, doWithHeader(r, "DELETE", "/products/1", "", "If-Match", `"5"`).Code)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPatchProduct(t *testing.T) {
+	r, mock := newRouter(t)
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		return doWithHeader(r, "PATCH", "/products/1", body, "Content-Type", contentType)
+	}
+
+	// The write is conditional on the version the patch was applied to.
+	expectProduct(mock)
+	mock.ExpectExec("UPDATE products SET .* WHERE id = \\? AND version = \\?").
+		WithArgs("Laptop", 899.99, 25, 1, 1).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	w := patch("application/merge-patch+json", `{"price":899.99}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.Product{ID: 1, Name: "Laptop", Price: 899.99, Stock: 25}, decode[api.Product](t, w))
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	// A product that changes in between is read and patched again.
+	expectProduct(mock)
+	mock.ExpectExec("UPDATE products SET").
+		WithArgs("Notebook", 999.99, 25, 1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, name, price, stock, version FROM products WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(versionedProductColumns).AddRow(1, "Laptop", 949.99, 25, 2))
+	mock.ExpectQuery("SELECT id, name, price, stock, version FROM products WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(versionedProductColumns).AddRow(1, "Laptop", 949.99, 25, 2))
+	mock.ExpectExec("UPDATE products SET").
+		WithArgs("Notebook", 949.99, 25, 1, 2).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	w = patch("application/json-patch+json", `[{"op":"replace","path":"/name","value":"Notebook"}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.Product{ID: 1, Name: "Notebook", Price: 949.99, Stock: 25}, decode[api.Product](t, w))
+
+	// An invalid result is never written.
+	expectProduct(mock)
+	assert.Equal(t, http.StatusUnprocessableEntity, patch("application/merge-patch+json", `{"name":null}`).Code)
+
+	expectProduct(mock)
+	mock.ExpectExec("UPDATE products SET").
+		WithArgs("Mouse", 999.99, 25, 1, 1).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'Mouse' for key 'name'"})
+	assert.Equal(t, http.StatusConflict, patch("application/merge-patch+json", `{"name":"Mouse"}`).Code)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+var cartItemColumns = []string{"product_id", "name", "price", "quantity"}
+
+// expectCart expects the lookup of cart 7 and returns its items.
+func expectCart(mock sqlmock.Sqlmock, items *sqlmock.Rows) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM carts WHERE id = ?")).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery("SELECT ci.product_id, p.name, p.price, ci.quantity FROM cart_items ci JOIN products p").
+		WithArgs(7).
+		WillReturnRows(items)
+}
+
+func TestCartItems(t *testing.T) {
+	r, mock := newRouter(t)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO carts () VALUES ()")).WillReturnResult(sqlmock.NewResult(7, 1))
+	w := do(r, "POST", "/carts", "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, api.Cart{ID: 7, Items: []api.LineItem{}}, decode[api.Cart](t, w))
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO cart_items (cart_id, product_id, quantity) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE")).
+		WithArgs(7, 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectCart(mock, sqlmock.NewRows(cartItemColumns).AddRow(1, "Laptop", 999.99, 2).AddRow(10, "Mouse", 29.99, 1))
+	w = do(r, "PUT", "/carts/7/items/1", `{"quantity":2}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.Cart{
+		ID: 7,
+		Items: []api.LineItem{
+			{ProductID: 1, Name: "Laptop", UnitPrice: 999.99, Quantity: 2},
+			{ProductID: 10, Name: "Mouse", UnitPrice: 29.99, Quantity: 1},
+		},
+		Total: 2029.97,
+	}, decode[api.Cart](t, w))
+
+	// The foreign keys reject unknown products; a lookup of the cart tells
+	// them apart from unknown carts.
+	mock.ExpectExec("INSERT INTO cart_items").
+		WithArgs(7, 99, 1).
+		WillReturnError(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"})
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM carts WHERE id = ?")).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	w = do(r, "PUT", "/carts/7/items/99", `{"quantity":1}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "product 99")
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM cart_items WHERE cart_id = ? AND product_id = ?")).
+		WithArgs(8, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM carts WHERE id = ?")).
+		WithArgs(8).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	w = do(r, "DELETE", "/carts/8/items/1", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "cart 8")
+
+	assert.Equal(t, http.StatusBadRequest, do(r, "PUT", "/carts/7/items/1", `{"quantity":0}`).Code)
+}
+
+var checkoutColumns = []string{"id", "name", "price", "stock", "quantity"}
+
+// expectCheckoutLocks expects a checkout of cart 7 to begin and lock the
+// cart and the rows of its products.
+func expectCheckoutLocks(mock sqlmock.Sqlmock, items *sqlmock.Rows) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM carts WHERE id = ? FOR UPDATE")).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery("SELECT p.id, p.name, p.price, p.stock, ci.quantity FROM cart_items ci JOIN products p .* ORDER BY ci.product_id FOR UPDATE").
+		WithArgs(7).
+		WillReturnRows(items)
+}
+
+func TestCheckoutDecrementsStockInOneTransaction(t *testing.T) {
+	r, mock := newRouter(t)
+
+	expectCheckoutLocks(mock, sqlmock.NewRows(checkoutColumns).
+		AddRow(1, "Laptop", 999.99, 25, 2).
+		AddRow(10, "Mouse", 29.99, 1, 1))
+	updateStock := regexp.QuoteMeta("UPDATE products SET stock = stock - ?, version = version + 1 WHERE id = ?")
+	mock.ExpectExec(updateStock).WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(updateStock).WithArgs(1, 10).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO orders (total, created_at) VALUES (?, ?)")).
+		WithArgs(2029.97, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO order_items (order_id, product_id, name, unit_price, quantity) VALUES (?, ?, ?, ?, ?), (?, ?, ?, ?, ?)")).
+		WithArgs(5, 1, "Laptop", 999.99, 2, 5, 10, "Mouse", 29.99, 1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM carts WHERE id = ?")).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	w := do(r, "POST", "/carts/7/checkout", "")
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	order := decode[api.Order](t, w)
+	assert.Equal(t, 5, order.ID)
+	assert.Equal(t, 2029.97, order.Total)
+	assert.Equal(t, []api.LineItem{
+		{ProductID: 1, Name: "Laptop", UnitPrice: 999.99, Quantity: 2},
+		{ProductID: 10, Name: "Mouse", UnitPrice: 29.99, Quantity: 1},
+	}, order.Items)
+	assert.WithinDuration(t, time.Now(), order.CreatedAt, time.Minute)
+}
+
+func TestCheckoutReportsOversellWithoutChangingStock(t *testing.T) {
+	r, mock := newRouter(t)
+
+	expectCheckoutLocks(mock, sqlmock.NewRows(checkoutColumns).
+		AddRow(1, "Laptop", 999.99, 1, 2).
+		AddRow(10, "Mouse", 29.99, 150, 1).
+		AddRow(12, "Speaker", 129.99, 0, 3))
+	mock.ExpectRollback()
+
+	w := do(r, "POST", "/carts/7/checkout", "")
+
+	require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	var conflict struct {
+		Error     string              `json:"error"`
+		Shortages []api.StockShortage `json:"shortages"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &conflict))
+	assert.Equal(t, "insufficient stock for products 1, 12", conflict.Error)
+	assert.Equal(t, []api.StockShortage{
+		{ProductID: 1, Requested: 2, Available: 1},
+		{ProductID: 12, Requested: 3, Available: 0},
+	}, conflict.Shortages)
+}
+
+func TestCheckoutRetriesDeadlocks(t *testing.T) {
+	r, mock := newRouter(t)
+
+	// The first attempt is picked as a deadlock victim; the second finds
+	// the cart emptied in the meantime.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM carts WHERE id = \\? FOR UPDATE").
+		WithArgs(7).
+		WillReturnError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"})
+	mock.ExpectRollback()
+	expectCheckoutLocks(mock, sqlmock.NewRows(checkoutColumns))
+	mock.ExpectRollback()
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do(r, "POST", "/carts/7/checkout", "").Code)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM carts WHERE id = \\? FOR UPDATE").
+		WithArgs(8).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	assert.Equal(t, http.StatusNotFound, do(r, "POST", "/carts/8/checkout", "").Code)
+}
+
+func TestGetOrders(t *testing.T) {
+	r, mock := newRouter(t)
+	placed := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM orders")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, total, created_at FROM orders ORDER BY total DESC LIMIT ? OFFSET ?")).
+		WithArgs(2, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "total", "created_at"}).
+			AddRow(5, 2029.97, placed).
+			AddRow(3, 29.99, placed))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT order_id, product_id, name, unit_price, quantity FROM order_items WHERE order_id IN (?, ?) ORDER BY order_id, product_id")).
+		WithArgs(5, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "product_id", "name", "unit_price", "quantity"}).
+			AddRow(3, 10, "Mouse", 29.99, 1).
+			AddRow(5, 1, "Laptop", 999.99, 2).
+			AddRow(5, 10, "Mouse", 29.99, 1))
+
+	w := do(r, "GET", "/orders?sort=-total&limit=2", "")
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, []api.Order{
+		{ID: 5, Total: 2029.97, CreatedAt: placed, Items: []api.LineItem{
+			{ProductID: 1, Name: "Laptop", UnitPrice: 999.99, Quantity: 2},
+			{ProductID: 10, Name: "Mouse", UnitPrice: 29.99, Quantity: 1},
+		}},
+		{ID: 3, Total: 29.99, CreatedAt: placed, Items: []api.LineItem{
+			{ProductID: 10, Name: "Mouse", UnitPrice: 29.99, Quantity: 1},
+		}},
+	}, decode[[]api.Order](t, w))
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, total, created_at FROM orders WHERE id = ?")).
+		WithArgs(99).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "total", "created_at"}))
+	assert.Equal(t, http.StatusNotFound, do(r, "GET", "/orders/99", "").Code)
+}
+
+// sseEvent is an event as read off a stream.
+type sseEvent struct {
+	id, name string
+	data     api.Event
+}
+
+// readEvents reads n events off a stream, skipping comments.
+func readEvents(t *testing.T, lines *bufio.Scanner, n int) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var ev sseEvent
+	for len(events) < n && lines.Scan() {
+		line := lines.Text()
+		switch {
+		case line == "":
+			if ev.name != "" {
+				events = append(events, ev)
+			}
+			ev = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev.data))
+		}
+	}
+	require.Len(t, events, n, "stream ended early: %v", lines.Err())
+	return events
+}
+
+func TestCheckoutPublishesTheOrderAndTheStock(t *testing.T) {
+	r, mock := newRouter(t)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO products (id, name, price, stock) VALUES (?, ?, ?, ?)") + "$").
+		WithArgs(nil, "Drone", 799.99, 0).
+		WillReturnResult(sqlmock.NewResult(16, 1))
+	require.Equal(t, http.StatusCreated, do(r, "POST", "/products", `{"name":"Drone","price":799.99}`).Code)
+
+	expectCheckoutLocks(mock, sqlmock.NewRows(checkoutColumns).AddRow(1, "Laptop", 999.99, 25, 2))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE products SET stock = stock - ?, version = version + 1 WHERE id = ?")).
+		WithArgs(2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO orders (total, created_at) VALUES (?, ?)")).
+		WithArgs(1999.98, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO order_items (order_id, product_id, name, unit_price, quantity) VALUES (?, ?, ?, ?, ?)")).
+		WithArgs(5, 1, "Laptop", 999.99, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM carts WHERE id = ?")).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT id, name, price, stock, version FROM products WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(versionedProductColumns).AddRow(1, "Laptop", 999.99, 23, 2))
+	require.Equal(t, http.StatusCreated, do(r, "POST", "/carts/7/checkout", "").Code)
+
+	w := do(r, "GET", "/events?last_event_id=0&follow=false", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	events := readEvents(t, bufio.NewScanner(w.Body), 4)
+	var got []string
+	for _, ev := range events {
+		got = append(got, fmt.Sprintf("%s %s %s/%d", ev.id, ev.name, ev.data.Resource, ev.data.ResourceID))
+	}
+	assert.Equal(t, []string{"1 created products/16", "2 created orders/5", "3 deleted carts/7", "4 updated products/1"}, got)
+	assert.Equal(t, 23.0, events[3].data.Data.(map[string]any)["stock"])
+
+	w = do(r, "GET", "/events?resource=orders&last_event_id=1&follow=false", "")
+	events = readEvents(t, bufio.NewScanner(w.Body), 1)
+	assert.Equal(t, "2", events[0].id)
+}
+
+func TestWebhookDeliveriesAreSignedAndRetried(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+	var delivered []api.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		if err := api.VerifySignature("s3cret", r.Header.Get(api.SignatureHeader), body, time.Minute); err != nil {
+			t.Errorf("delivery not signed: %v", err)
+		}
+		if err := api.VerifySignature("other", r.Header.Get(api.SignatureHeader), body, time.Minute); err == nil {
+			t.Error("signature verified with the wrong secret")
+		}
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event api.Event
+		json.Unmarshal(body, &event)
+		if event.Type == api.EventDeleted {
+			// Not worth retrying.
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		assert.Equal(t, r.Header.Get("X-Event-ID"), strconv.FormatInt(event.ID, 10))
+		delivered = append(delivered, event)
+	}))
+	defer receiver.Close()
+
+	r, mock := newRouter(t)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO products (id, name, price, stock) VALUES (?, ?, ?, ?)") + "$").
+		WithArgs(nil, "Drone", 799.99, 0).
+		WillReturnResult(sqlmock.NewResult(16, 1))
+	mock.ExpectExec("DELETE FROM products WHERE id = \\?").
+		WithArgs(16).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := do(r, "POST", "/webhooks", fmt.Sprintf(`{"url":%q,"secret":"s3cret","resources":["products"]}`, receiver.URL))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	hook := decode[api.Webhook](t, w)
+	assert.Equal(t, "s3cret", hook.Secret)
+
+	require.Equal(t, http.StatusCreated, do(r, "POST", "/products", `{"name":"Drone","price":799.99}`).Code)
+	require.Equal(t, http.StatusOK, do(r, "DELETE", "/products/16", "").Code)
+
+	require.Eventually(t, func() bool {
+		got := decode[api.Webhook](t, do(r, "GET", fmt.Sprintf("/webhooks/%d", hook.ID), ""))
+		return got.Delivered == 1 && got.Failed == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, 3, attempts)
+	require.Len(t, delivered, 1)
+	assert.Equal(t, api.EventCreated, delivered[0].Type)
+	mu.Unlock()
+
+	listed := decode[[]api.Webhook](t, do(r, "GET", "/webhooks", ""))
+	require.Len(t, listed, 1)
+	assert.Empty(t, listed[0].Secret, "the secret was returned after the webhook was created")
+	assert.Contains(t, listed[0].LastError, "410")
+
+	require.Equal(t, http.StatusOK, do(r, "DELETE", fmt.Sprintf("/webhooks/%d", hook.ID), "").Code)
+	assert.Equal(t, http.StatusNotFound, do(r, "GET", fmt.Sprintf("/webhooks/%d", hook.ID), "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(r, "POST", "/webhooks", `{"url":"https://hooks.example.com","resources":["cats"]}`).Code)
+}
+
+func TestVerifySignatureRejectsOldDeliveries(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	old := time.Now().Add(-time.Hour).Unix()
+	fmt.Fprintf(mac, "%d.", old)
+	mac.Write(body)
+	header := fmt.Sprintf("t=%d,v1=%x", old, mac.Sum(nil))
+
+	assert.NoError(t, api.VerifySignature("s3cret", header, body, 2*time.Hour))
+	assert.ErrorIs(t, api.VerifySignature("s3cret", header, body, time.Minute), api.ErrBadSignature)
+	assert.ErrorIs(t, api.VerifySignature("s3cret", header, []byte(`{"id":2}`), 2*time.Hour), api.ErrBadSignature)
+	assert.ErrorIs(t, api.VerifySignature("s3cret", "", body, time.Minute), api.ErrBadSignature)
+}
+
+// newGRPCClient serves the gRPC server of NewServers over an in-memory
+// listener, on a SQL store backed by sqlmock, and returns a connection to it.
+func newGRPCClient(t *testing.T, cfg api.Config) (*grpc.ClientConn, sqlmock.Sqlmock) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	})
+	_, srv := api.NewServers(api.NewSQLStore(db), cfg)
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn, mock
+}
+
+// warmedUp returns a Readiness whose database has been initialised.
+func warmedUp() *api.Readiness {
+	readiness := &api.Readiness{}
+	readiness.SetWarmedUp()
+	return readiness
+}
+
+func TestGRPCProducts(t *testing.T) {
+	conn, mock := newGRPCClient(t, api.Config{Readiness: warmedUp()})
+	client := pb.NewProductServiceClient(conn)
+	ctx := context.Background()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM products")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(15))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, price, stock FROM products ORDER BY id LIMIT ? OFFSET ?")).
+		WithArgs(2, 4).
+		WillReturnRows(sqlmock.NewRows(productColumns).
+			AddRow(5, "Smartwatch", 249.99, 60).
+			AddRow(6, "Camera", 549.99, 15))
+	list, err := client.ListProducts(ctx, &pb.ListProductsRequest{Limit: 2, Offset: 4})
+	require.NoError(t, err)
+	assert.EqualValues(t, 15, list.Total)
+	require.Len(t, list.Products, 2)
+	assert.Equal(t, "Smartwatch", list.Products[0].Name)
+
+	expectProduct(mock)
+	product, err := client.GetProduct(ctx, &pb.GetProductRequest{Id: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 25, product.Stock)
+
+	mock.ExpectExec("INSERT INTO products").
+		WithArgs(nil, "Drone", 799.99, 3).
+		WillReturnResult(sqlmock.NewResult(16, 1))
+	created, err := client.CreateProduct(ctx, &pb.CreateProductRequest{Product: &pb.Product{Name: "Drone", Price: 799.99, Stock: 3}})
+	require.NoError(t, err)
+	assert.EqualValues(t, 16, created.Id)
+
+	mock.ExpectExec("UPDATE products SET").
+		WithArgs("Laptop", 699.99, 0, 2).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'Laptop' for key 'name'"})
+	_, err = client.UpdateProduct(ctx, &pb.UpdateProductRequest{Id: 2, Product: &pb.Product{Name: "Laptop", Price: 699.99}})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	mock.ExpectExec("DELETE FROM products WHERE id = \\?").
+		WithArgs(16).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = client.DeleteProduct(ctx, &pb.DeleteProductRequest{Id: 16})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CreateProduct(ctx, &pb.CreateProductRequest{Product: &pb.Product{Id: 1, Name: "Drone", Price: 1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.CreateProduct(ctx, &pb.CreateProductRequest{Product: &pb.Product{Price: 1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.CreateProduct(ctx, &pb.CreateProductRequest{Product: &pb.Product{Name: "Drone", Stock: -1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.ListProducts(ctx, &pb.ListProductsRequest{Limit: 1001})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCProductsUnavailableUntilDatabaseIsReady(t *testing.T) {
+	readiness := &api.Readiness{}
+	conn, _ := newGRPCClient(t, api.Config{Readiness: readiness})
+	health := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	_, err := pb.NewProductServiceClient(conn).ListProducts(ctx, &pb.ListProductsRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	resp, err := health.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+	resp, err = health.Check(ctx, &healthpb.HealthCheckRequest{Service: "liveness"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	readiness.SetWarmedUp()
+	resp, err = health.Check(ctx, &healthpb.HealthCheckRequest{Service: "electronicsstore.v1.ProductService"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+}
+
+func TestGRPCCallsNeedTheMethodScope(t *testing.T) {
+	conn, mock := newGRPCClient(t, api.Config{Readiness: warmedUp(), Auth: api.APIKeys{
+		"reader-key": {Subject: "reader", Scopes: []string{api.ScopeRead}},
+	}})
+	client := pb.NewProductServiceClient(conn)
+	withKey := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "reader-key")
+
+	_, err := client.GetProduct(context.Background(), &pb.GetProductRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	expectProduct(mock)
+	_, err = client.GetProduct(withKey, &pb.GetProductRequest{Id: 1})
+	assert.NoError(t, err)
+	_, err = client.DeleteProduct(withKey, &pb.DeleteProductRequest{Id: 1})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestChangesArePublishedOnTheBus(t *testing.T) {
+	bus := api.NewLocalBus()
+	defer bus.Close()
+	received := make(chan api.Event, 1)
+	_, err := bus.Subscribe("electronics-store.products.*", func(subject string, data []byte) {
+		var event api.Event
+		assert.Equal(t, "electronics-store.products.deleted", subject)
+		assert.NoError(t, json.Unmarshal(data, &event))
+		received <- event
+	})
+	require.NoError(t, err)
+	conn, mock := newGRPCClient(t, api.Config{Readiness: warmedUp(), Bus: bus})
+	mock.ExpectExec("DELETE FROM products WHERE id = \\?").
+		WithArgs(16).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = pb.NewProductServiceClient(conn).DeleteProduct(context.Background(), &pb.DeleteProductRequest{Id: 16})
+	require.NoError(t, err)
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "deleted", event.Type)
+		assert.Equal(t, 16, event.ResourceID)
+	case <-time.After(time.Second):
+		t.Fatal("no event on the bus")
+	}
+}
+
+func TestProductReadsAreCachedUntilAWrite(t *testing.T) {
+	r, mock := newCachedRouter(t, api.NewLRUCache(100))
+	expectProduct := func(name string, price float64) {
+		mock.ExpectQuery("SELECT id, name, price, stock, version FROM products WHERE id = ?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(versionedProductColumns).AddRow(1, name, price, 25, 1))
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM products")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, price, stock FROM products")).
+		WillReturnRows(sqlmock.NewRows(productColumns).AddRow(1, "Laptop", 999.99, 25))
+	expectProduct("Laptop", 999.99)
+
+	for range 3 {
+		w := do(r, "GET", "/products", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []api.Product{{ID: 1, Name: "Laptop", Price: 999.99, Stock: 25}}, decode[[]api.Product](t, w))
+		assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+
+		w = do(r, "GET", "/products/1", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, api.Product{ID: 1, Name: "Laptop", Price: 999.99, Stock: 25}, decode[api.Product](t, w))
+	}
+
+	mock.ExpectExec("UPDATE products SET").
+		WithArgs("Laptop", 899.99, 25, 1).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	w := do(r, "PUT", "/products/1", `{"name":"Laptop","price":899.99,"stock":25}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	expectProduct("Laptop", 899.99)
+	w = do(r, "GET", "/products/1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 899.99, decode[api.Product](t, w).Price)
+}
+
+func TestRedisCacheIsSharedByTheReplicas(t *testing.T) {
+	redis := miniredis.RunT(t)
+	newReplica := func() (*gin.Engine, sqlmock.Sqlmock) {
+		cache := api.NewRedisCache(redis.Addr(), "")
+		t.Cleanup(func() { cache.Close() })
+		return newCachedRouter(t, cache)
+	}
+	first, firstMock := newReplica()
+	second, secondMock := newReplica()
+
+	firstMock.ExpectQuery("SELECT id, name, price, stock, version FROM products WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(versionedProductColumns).AddRow(1, "Laptop", 999.99, 25, 1))
+	w := do(first, "GET", "/products/1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// The second replica reads what the first one cached.
+	w = do(second, "GET", "/products/1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.Product{ID: 1, Name: "Laptop", Price: 999.99, Stock: 25}, decode[api.Product](t, w))
+
+	// A write through the second replica drops the reads of the first.
+	secondMock.ExpectExec("DELETE FROM products WHERE id = ?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	w = do(second, "DELETE", "/products/1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	firstMock.ExpectQuery("SELECT id, name, price, stock, version FROM products WHERE id = ?").
+		WithArgs(1).
+		WillReturnError(sql.ErrNoRows)
+	w = do(first, "GET", "/products/1", "")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
+
+func TestProductsAreReadFromTheDatabaseWhenRedisIsDown(t *testing.T) {
+	redis := miniredis.RunT(t)
+	cache := api.NewRedisCache(redis.Addr(), "")
+	t.Cleanup(func() { cache.Close() })
+	r, mock := newCachedRouter(t, cache)
+	redis.Close()
+
+	for range 2 {
+		mock.ExpectQuery("SELECT id, name, price, stock, version FROM products WHERE id = ?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(versionedProductColumns).AddRow(1, "Laptop", 999.99, 25, 1))
+		w := do(r, "GET", "/products/1", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "Laptop", decode[api.Product](t, w).Name)
+	}
+}
+
+func TestLRUCacheEvictsTheLeastRecentlyUsedEntries(t *testing.T) {
+	ctx := context.Background()
+	cache := api.NewLRUCache(2)
+	require.NoError(t, cache.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, cache.Set(ctx, "b", []byte("2"), 0))
+	_, ok, _ := cache.Get(ctx, "a")
+	require.True(t, ok)
+	require.NoError(t, cache.Set(ctx, "c", []byte("3"), 0))
+
+	_, ok, _ = cache.Get(ctx, "b")
+	assert.False(t, ok, "b was used least recently")
+	value, ok, _ := cache.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	require.NoError(t, cache.Set(ctx, "d", []byte("4"), 20*time.Millisecond))
+	_, ok, _ = cache.Get(ctx, "d")
+	assert.True(t, ok)
+	time.Sleep(40 * time.Millisecond)
+	_, ok, _ = cache.Get(ctx, "d")
+	assert.False(t, ok, "d has expired")
+}
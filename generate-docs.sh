@@ -1,11 +1,12 @@
 #!/bin/bash
 
-# Script to generate Swagger documentation for all services
+# Script to regenerate the embedded OpenAPI specification of all services
+# from their handler annotations (see tools/openapi-gen)
 # This script should be run from the root directory of the cluster-tester project
 
 set -e
 
-echo "🚀 Generating Swagger documentation for all services..."
+echo "🚀 Generating OpenAPI specifications for all services..."
 
 services=("coffee-shop" "pet-store" "restaurant" "college-admission" "electronics-store" "electronics-store-tracing")
 
@@ -13,29 +14,20 @@ for service in "${services[@]}"; do
     echo "📝 Processing $service..."
     
     if [ -d "$service" ]; then
-        cd "$service"
-        
-        # Check if swag is installed
-        if ! command -v swag &> /dev/null; then
-            echo "⚠️  swag command not found. Installing..."
-            go install github.com/swaggo/swag/cmd/swag@latest
-        fi
-        
-        # Generate Swagger docs
-        swag init
+        # Writes api/openapi_gen.go
+        (cd "$service" && go generate ./...)
         
         echo "✅ Generated documentation for $service"
-        cd ..
     else
         echo "❌ Directory $service not found"
     fi
 done
 
-echo "🎉 All Swagger documentation generated successfully!"
+echo "🎉 All OpenAPI specifications generated successfully!"
 echo ""
 echo "📖 To view the documentation for any service:"
 echo "   1. Start the service: cd <service-name> && go run main.go"
-echo "   2. Open browser: http://localhost:8080/swagger/"
+echo "   2. Open browser: http://localhost:8080/docs"
 echo ""
 echo "🔧 To register with Backstage.io:"
 echo "   Add this URL to your Backstage catalog:"
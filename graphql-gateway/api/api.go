@@ -0,0 +1,160 @@
+// Package api implements the GraphQL API of the graphql-gateway service,
+// which federates the records of the other services by calling their REST
+// APIs. NewRouter builds the complete router on top of the backend clients,
+// so tests and the service binary run the same handlers.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	serviceName    = "graphql-gateway"
+	serviceVersion = "1.0.0"
+
+	// maxRequestBytes caps the body of a GraphQL request.
+	maxRequestBytes = 1 << 20
+)
+
+// Config holds the router options.
+type Config struct {
+	// Backends are the services the schema is federated from.
+	Backends Backends
+
+	// Readiness is reported by /readyz and /health. A nil Readiness is
+	// replaced by one that is not yet warmed up.
+	Readiness *Readiness
+
+	// Logger receives one record per request. A nil Logger uses
+	// slog.Default.
+	Logger *slog.Logger
+
+	// TrustedProxies are the addresses or CIDR ranges whose X-Forwarded-For
+	// and X-Real-IP headers name the client in the request log. No proxy is
+	// trusted by default.
+	TrustedProxies []string
+}
+
+// ConfigFromEnv reads the router options from the environment: the backends
+// as described by BackendsFromEnv, LOG_LEVEL and LOG_FORMAT select the
+// logger, and TRUSTED_PROXIES is a comma-separated list of trusted proxies.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Backends: BackendsFromEnv(),
+		Logger:   LoggerFromEnv(),
+	}
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			cfg.TrustedProxies = append(cfg.TrustedProxies, proxy)
+		}
+	}
+	return cfg
+}
+
+// handler serves the GraphQL API.
+type handler struct {
+	schema   graphql.Schema
+	backends Backends
+	checks   []namedCheck
+}
+
+// graphQLRequest is the body of POST /graphql, and the query parameters of
+// GET /graphql.
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// NewRouter registers every route on a new engine. It panics if the schema is
+// invalid, which the package tests rule out, or if one of
+// Config.TrustedProxies is not an address or range.
+func NewRouter(cfg Config) *gin.Engine {
+	if cfg.Readiness == nil {
+		cfg.Readiness = &Readiness{}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	backends := cfg.Backends.withDefaults()
+	schema, err := newSchema(backends)
+	if err != nil {
+		panic(fmt.Sprintf("building GraphQL schema: %v", err))
+	}
+	h := &handler{
+		schema:   schema,
+		backends: backends,
+		checks:   readinessChecks(cfg.Readiness),
+	}
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		panic(fmt.Sprintf("setting trusted proxies: %v", err))
+	}
+	r.Use(requestLogger(cfg.Logger), gin.Recovery())
+
+	// Health check endpoints
+	r.GET("/livez", h.livenessCheck)
+	r.GET("/readyz", h.readinessCheck)
+	r.GET("/health", h.healthCheck)
+
+	// GraphQL endpoint. The gateway does not check credentials itself: it
+	// forwards them, and each backend applies its own scopes.
+	r.GET("/graphql", h.graphQL)
+	r.POST("/graphql", h.graphQL)
+
+	return r
+}
+
+// graphQL executes a query, read from the body of a POST or from the query,
+// operationName and variables parameters of a GET. Requests that cannot be
+// parsed or validated are answered with 400; errors of individual fields are
+// reported next to the data of the others.
+func (h *handler) graphQL(c *gin.Context) {
+	var req graphQLRequest
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if v := c.Query("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "variables must be a JSON object"})
+				return
+			}
+		}
+	} else {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestBytes)
+		if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "body must be a GraphQL request: " + err.Error()})
+			return
+		}
+	}
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		return
+	}
+
+	ctx := withForwardedHeaders(c.Request.Context(), c.Request)
+	ctx = context.WithValue(ctx, loadersKey{}, newLoaders(h.backends))
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        ctx,
+	})
+
+	code := http.StatusOK
+	if result.Data == nil && result.HasErrors() {
+		code = http.StatusBadRequest
+	}
+	c.JSON(code, result)
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNotFound is returned by a Backend when the record asked for does not
+// exist. Lookups by ID resolve it to null rather than to an error.
+var errNotFound = errors.New("not found")
+
+// errNotConfigured is returned for the fields of backends without a URL.
+var errNotConfigured = errors.New("is not configured")
+
+// BackendOptions tunes a Backend. Zero fields other than Retries take the
+// defaults of BackendsFromEnv.
+type BackendOptions struct {
+	// Timeout bounds each attempt, including reading the response.
+	Timeout time.Duration
+
+	// Retries is the number of attempts after the first one for requests
+	// that fail with a network error, 429 or 5xx.
+	Retries int
+
+	// Backoff is the wait before the first retry; it doubles for each one
+	// after that.
+	Backoff time.Duration
+}
+
+// Backend is a client of the REST API of one of the services.
+type Backend struct {
+	name    string
+	baseURL string
+	client  *http.Client
+	retries int
+	backoff time.Duration
+}
+
+// NewBackend returns a client of the service name at baseURL, which may be a
+// URL or a host:port such as ServiceStatus.Endpoint.
+func NewBackend(name, baseURL string, opts BackendOptions) *Backend {
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 100 * time.Millisecond
+	}
+	return &Backend{
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: opts.Timeout},
+		retries: max(opts.Retries, 0),
+		backoff: opts.Backoff,
+	}
+}
+
+// Backends are the services the schema is federated from. A nil Backend
+// fails the fields it serves.
+type Backends struct {
+	CoffeeShop       *Backend
+	PetStore         *Backend
+	Restaurant       *Backend
+	CollegeAdmission *Backend
+	ElectronicsStore *Backend
+}
+
+// withDefaults replaces the nil backends of bs by ones without a URL, which
+// fail every call with errNotConfigured under their name.
+func (bs Backends) withDefaults() Backends {
+	orUnset := func(b *Backend, name string) *Backend {
+		if b == nil {
+			return &Backend{name: name}
+		}
+		return b
+	}
+	bs.CoffeeShop = orUnset(bs.CoffeeShop, "coffee-shop")
+	bs.PetStore = orUnset(bs.PetStore, "pet-store")
+	bs.Restaurant = orUnset(bs.Restaurant, "restaurant")
+	bs.CollegeAdmission = orUnset(bs.CollegeAdmission, "college-admission")
+	bs.ElectronicsStore = orUnset(bs.ElectronicsStore, "electronics-store")
+	return bs
+}
+
+// BackendsFromEnv returns a client for each service whose <PREFIX>_URL is
+// set, which the operator sets from the ServiceStatus.Endpoint of the
+// enabled services: COFFEE_SHOP, PET_STORE, RESTAURANT, COLLEGE_ADMISSION
+// and ELECTRONICS_STORE. BACKEND_TIMEOUT (seconds, default 5) and
+// BACKEND_RETRIES (default 1) apply to all of them.
+func BackendsFromEnv() Backends {
+	retries := 1
+	if v, err := strconv.Atoi(os.Getenv("BACKEND_RETRIES")); err == nil && v >= 0 {
+		retries = v
+	}
+	timeout := envSeconds("BACKEND_TIMEOUT", 5*time.Second)
+	backend := func(name, prefix string) *Backend {
+		url := os.Getenv(prefix + "_URL")
+		if url == "" {
+			return nil
+		}
+		return NewBackend(name, url, BackendOptions{
+			Timeout: timeout,
+			Retries: retries,
+		})
+	}
+	return Backends{
+		CoffeeShop:       backend("coffee-shop", "COFFEE_SHOP"),
+		PetStore:         backend("pet-store", "PET_STORE"),
+		Restaurant:       backend("restaurant", "RESTAURANT"),
+		CollegeAdmission: backend("college-admission", "COLLEGE_ADMISSION"),
+		ElectronicsStore: backend("electronics-store", "ELECTRONICS_STORE"),
+	}
+}
+
+// forwardedHeaders are copied from the request to the gateway onto every
+// backend request it makes: the caller's credentials, so that each backend
+// applies its own scopes, and the request and trace IDs, so that the calls
+// can be followed across services.
+var forwardedHeaders = []string{"Authorization", "X-API-Key", requestIDHeader, "traceparent"}
+
+type forwardedKey struct{}
+
+// withForwardedHeaders returns ctx carrying the forwardedHeaders of req.
+func withForwardedHeaders(ctx context.Context, req *http.Request) context.Context {
+	h := http.Header{}
+	for _, name := range forwardedHeaders {
+		if v := req.Header.Get(name); v != "" {
+			h.Set(name, v)
+		}
+	}
+	return context.WithValue(ctx, forwardedKey{}, h)
+}
+
+// get decodes the JSON of GET path into out. Failed attempts are retried
+// with exponential backoff.
+func (b *Backend) get(ctx context.Context, path string, out any) error {
+	if b.baseURL == "" {
+		return fmt.Errorf("%s %w", b.name, errNotConfigured)
+	}
+	var err error
+	for attempt := 0; attempt <= b.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(b.backoff << (attempt - 1)):
+			}
+		}
+		var retry bool
+		retry, err = b.fetch(ctx, path, out)
+		if !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// list decodes a page of the collection at path into out.
+func (b *Backend) list(ctx context.Context, path string, limit, offset int, out any) error {
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(limit))
+	q.Set("offset", strconv.Itoa(offset))
+	return b.get(ctx, path+"?"+q.Encode(), out)
+}
+
+// fetch makes one attempt at GET path and reports whether a failure is worth
+// retrying.
+func (b *Backend) fetch(ctx context.Context, path string, out any) (retry bool, err error) {
+	url := b.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	if h, ok := ctx.Value(forwardedKey{}).(http.Header); ok {
+		for name, values := range h {
+			req.Header[name] = values
+		}
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return false, fmt.Errorf("GET %s: decoding response: %w", url, err)
+		}
+		return false, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return false, errNotFound
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return true, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return false, fmt.Errorf("GET %s: %s", url, resp.Status)
+}
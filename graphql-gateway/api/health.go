@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds how long a single readiness check may take.
+const readinessTimeout = 2 * time.Second
+
+// Readiness holds the lifecycle state reported by the readiness checks. The
+// zero value is not yet warmed up.
+type Readiness struct {
+	// warmedUp is set once the service has started.
+	warmedUp atomic.Bool
+
+	// draining is set when shutdown begins so that readiness fails and the
+	// pod is taken out of the Service endpoints before the listener closes.
+	draining atomic.Bool
+}
+
+// SetWarmedUp marks the service as started.
+func (r *Readiness) SetWarmedUp() {
+	r.warmedUp.Store(true)
+}
+
+// SetDraining marks the service as shutting down.
+func (r *Readiness) SetDraining() {
+	r.draining.Store(true)
+}
+
+// checkResult is the outcome of a single readiness check.
+type checkResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// healthStatus is the body of the health endpoints.
+type healthStatus struct {
+	Status  string        `json:"status"`
+	Service string        `json:"service"`
+	Version string        `json:"version"`
+	Checks  []checkResult `json:"checks,omitempty"`
+}
+
+// namedCheck is a check run by /readyz and /health.
+type namedCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// readinessChecks returns the checks for r, in the order they are reported.
+// The backends are not checked: a field whose backend is down fails on its
+// own, and the other fields are still served.
+func readinessChecks(r *Readiness) []namedCheck {
+	return []namedCheck{
+		{"warmup", func(context.Context) error {
+			if !r.warmedUp.Load() {
+				return errors.New("service is still warming up")
+			}
+			return nil
+		}},
+		{"draining", func(context.Context) error {
+			if r.draining.Load() {
+				return errors.New("service is shutting down")
+			}
+			return nil
+		}},
+	}
+}
+
+// runReadinessChecks runs every readiness check and reports whether all of
+// them passed.
+func (h *handler) runReadinessChecks(ctx context.Context) ([]checkResult, bool) {
+	results := make([]checkResult, 0, len(h.checks))
+	ready := true
+	for _, rc := range h.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+		err := rc.check(checkCtx)
+		cancel()
+
+		result := checkResult{Name: rc.name, Status: "ok"}
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			ready = false
+		}
+		results = append(results, result)
+	}
+	return results, ready
+}
+
+// livenessCheck only reports that the process is serving HTTP. It does not
+// look at dependencies, so an outage elsewhere never restarts the pod.
+func (h *handler) livenessCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, healthStatus{
+		Status:  "alive",
+		Service: serviceName,
+		Version: serviceVersion,
+	})
+}
+
+// readinessCheck reports whether the service should receive traffic.
+func (h *handler) readinessCheck(c *gin.Context) {
+	checks, ready := h.runReadinessChecks(c.Request.Context())
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not ready", http.StatusServiceUnavailable
+	}
+	c.JSON(code, healthStatus{
+		Status:  status,
+		Service: serviceName,
+		Version: serviceVersion,
+		Checks:  checks,
+	})
+}
+
+// healthCheck runs the readiness checks, reporting them with the
+// healthy/unhealthy status values of /health in the other services.
+func (h *handler) healthCheck(c *gin.Context) {
+	checks, ready := h.runReadinessChecks(c.Request.Context())
+	status, code := "healthy", http.StatusOK
+	if !ready {
+		status, code = "unhealthy", http.StatusServiceUnavailable
+	}
+	c.JSON(code, healthStatus{
+		Status:  status,
+		Service: serviceName,
+		Version: serviceVersion,
+		Checks:  checks,
+	})
+}
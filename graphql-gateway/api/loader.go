@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// maxConcurrentFetches bounds the backend requests a loader makes at once.
+const maxConcurrentFetches = 8
+
+// loader batches the lookups by ID of one GraphQL request. load only queues
+// the ID and returns a thunk; the executor resolves every field of a level
+// before calling the thunks, so the first thunk called fetches all the IDs
+// queued by that level at once, each distinct ID once. Results are kept for
+// the rest of the request, so later levels that refer to the same records do
+// not fetch them again.
+type loader[T any] struct {
+	fetch func(ctx context.Context, id int) (T, error)
+
+	mu      sync.Mutex
+	pending []int
+	results map[int]*outcome[T]
+}
+
+// outcome is the result of fetching one ID. done is closed once it is set.
+type outcome[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// newLoader returns a loader that fetches the records at path/{id} of b.
+func newLoader[T any](b *Backend, path string) *loader[T] {
+	return &loader[T]{
+		fetch: func(ctx context.Context, id int) (T, error) {
+			var v T
+			err := b.get(ctx, path+"/"+strconv.Itoa(id), &v)
+			return v, err
+		},
+		results: map[int]*outcome[T]{},
+	}
+}
+
+// load queues id and returns a thunk resolving to its record, or to null when
+// the backend has no record with that ID.
+func (l *loader[T]) load(ctx context.Context, id int) func() (interface{}, error) {
+	l.mu.Lock()
+	o, ok := l.results[id]
+	if !ok {
+		o = &outcome[T]{done: make(chan struct{})}
+		l.results[id] = o
+		l.pending = append(l.pending, id)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.dispatch(ctx)
+		<-o.done
+		if errors.Is(o.err, errNotFound) {
+			return nil, nil
+		}
+		if o.err != nil {
+			return nil, o.err
+		}
+		return o.value, nil
+	}
+}
+
+// prime records v as the record of id, as read from a list. It also answers
+// a lookup of id that is queued but not fetched yet, as the lists and lookups
+// of one level are resolved before any of them is fetched.
+func (l *loader[T]) prime(id int, v T) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	o, ok := l.results[id]
+	if !ok {
+		o = &outcome[T]{done: make(chan struct{}), value: v}
+		close(o.done)
+		l.results[id] = o
+		return
+	}
+	if i := slices.Index(l.pending, id); i >= 0 {
+		l.pending = slices.Delete(l.pending, i, i+1)
+		o.value = v
+		close(o.done)
+	}
+}
+
+// dispatch fetches the queued IDs concurrently and waits for them.
+func (l *loader[T]) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	batch := l.pending
+	l.pending = nil
+	outcomes := make([]*outcome[T], len(batch))
+	for i, id := range batch {
+		outcomes[i] = l.results[id]
+	}
+	l.mu.Unlock()
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentFetches)
+	for i, id := range batch {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(o *outcome[T], id int) {
+			defer func() { <-sem; wg.Done() }()
+			o.value, o.err = l.fetch(ctx, id)
+			close(o.done)
+		}(outcomes[i], id)
+	}
+	wg.Wait()
+}
+
+// loaders are the loaders of one GraphQL request, one per record type that
+// other records refer to or that can be looked up by ID.
+type loaders struct {
+	coffees      *loader[Coffee]
+	pets         *loader[Pet]
+	adopters     *loader[Adopter]
+	menuItems    *loader[MenuItem]
+	orders       *loader[Order]
+	applications *loader[Application]
+	products     *loader[Product]
+}
+
+func newLoaders(b Backends) *loaders {
+	return &loaders{
+		coffees:      newLoader[Coffee](b.CoffeeShop, "/coffees"),
+		pets:         newLoader[Pet](b.PetStore, "/pets"),
+		adopters:     newLoader[Adopter](b.PetStore, "/adopters"),
+		menuItems:    newLoader[MenuItem](b.Restaurant, "/menu"),
+		orders:       newLoader[Order](b.Restaurant, "/orders"),
+		applications: newLoader[Application](b.CollegeAdmission, "/applications"),
+		products:     newLoader[Product](b.ElectronicsStore, "/products"),
+	}
+}
+
+type loadersKey struct{}
+
+// loadersOf returns the loaders of the request that ctx belongs to.
+func loadersOf(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
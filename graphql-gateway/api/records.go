@@ -0,0 +1,86 @@
+package api
+
+import "time"
+
+// The records below are decoded from the REST APIs of the backends. The
+// GraphQL fields are resolved from the struct fields of the same name.
+
+// Coffee is a drink of the coffee-shop, as served by its /coffees.
+type Coffee struct {
+	ID    int     `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+// Pet is a pet of the pet-store, as served by its /pets. ReservedBy and
+// AdoptedBy are the IDs of adopters, or 0.
+type Pet struct {
+	ID            int        `json:"id"`
+	Name          string     `json:"name"`
+	Type          string     `json:"type"`
+	Age           int        `json:"age"`
+	Status        string     `json:"status"`
+	ReservedBy    int        `json:"reserved_by"`
+	ReservedUntil *time.Time `json:"reserved_until"`
+	AdoptedBy     int        `json:"adopted_by"`
+}
+
+// Adopter is a person who reserves or adopts pets, as served by the
+// pet-store's /adopters.
+type Adopter struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// MenuItem is a dish of the restaurant, as served by its /menu.
+type MenuItem struct {
+	ID    int     `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+// Order is an order placed at the restaurant, as served by its /orders.
+type Order struct {
+	ID      int           `json:"id"`
+	Items   []OrderItem   `json:"items"`
+	Coffees []OrderCoffee `json:"coffees"`
+	Total   float64       `json:"total"`
+}
+
+// OrderItem is a line of an order for a menu item.
+type OrderItem struct {
+	MenuItemID int     `json:"menu_item_id"`
+	Quantity   int     `json:"quantity"`
+	Name       string  `json:"name"`
+	UnitPrice  float64 `json:"unit_price"`
+}
+
+// OrderCoffee is a line of an order for a coffee of the coffee-shop.
+type OrderCoffee struct {
+	CoffeeID  int     `json:"coffee_id"`
+	Quantity  int     `json:"quantity"`
+	Name      string  `json:"name"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+// Application is an application to the college, as served by the
+// college-admission's /applications.
+type Application struct {
+	ID        int    `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Age       int    `json:"age"`
+	Course    string `json:"course"`
+	Status    string `json:"status"`
+}
+
+// Product is a product of the electronics-store, as served by its
+// /products.
+type Product struct {
+	ID    int     `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+	Stock int     `json:"stock"`
+}
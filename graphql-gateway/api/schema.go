@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// defaultPageSize and maxPageSize match the paging of the backends' list
+	// endpoints.
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// newSchema returns the schema federating the records of bs. Lookups by ID
+// and references between records go through the loaders of the request, so
+// that each level of a query makes at most one batch of backend calls per
+// record type.
+func newSchema(bs Backends) (graphql.Schema, error) {
+	coffeeType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Coffee",
+		Description: "A drink of the coffee-shop.",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"price": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		},
+	})
+
+	adopterType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Adopter",
+		Description: "A person who reserves or adopts pets at the pet-store.",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"email": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"phone": &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	petType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Pet",
+		Description: "A pet of the pet-store.",
+		Fields: graphql.Fields{
+			"id":            &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"type":          &graphql.Field{Type: graphql.String},
+			"age":           &graphql.Field{Type: graphql.Int},
+			"status":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"reservedUntil": &graphql.Field{Type: graphql.DateTime},
+			"reservedBy": &graphql.Field{
+				Type:        adopterType,
+				Description: "The adopter holding the reservation of the pet.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return reference(p.Context, loadersOf(p.Context).adopters, p.Source.(Pet).ReservedBy)
+				},
+			},
+			"adoptedBy": &graphql.Field{
+				Type:        adopterType,
+				Description: "The adopter who adopted the pet.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return reference(p.Context, loadersOf(p.Context).adopters, p.Source.(Pet).AdoptedBy)
+				},
+			},
+		},
+	})
+
+	menuItemType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "MenuItem",
+		Description: "A dish on the menu of the restaurant.",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"price": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		},
+	})
+
+	orderItemType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "OrderItem",
+		Description: "A line of an order for a menu item.",
+		Fields: graphql.Fields{
+			"quantity":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"unitPrice": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"menuItem": &graphql.Field{
+				Type:        menuItemType,
+				Description: "The menu item as it is now, or null if it has been removed.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return reference(p.Context, loadersOf(p.Context).menuItems, p.Source.(OrderItem).MenuItemID)
+				},
+			},
+		},
+	})
+
+	orderCoffeeType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "OrderCoffee",
+		Description: "A line of an order for a coffee of the coffee-shop.",
+		Fields: graphql.Fields{
+			"quantity":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"unitPrice": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"coffee": &graphql.Field{
+				Type:        coffeeType,
+				Description: "The coffee as it is now, or null if it has been removed.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return reference(p.Context, loadersOf(p.Context).coffees, p.Source.(OrderCoffee).CoffeeID)
+				},
+			},
+		},
+	})
+
+	orderType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Order",
+		Description: "An order placed at the restaurant.",
+		Fields: graphql.Fields{
+			"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"items":   &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(orderItemType)))},
+			"coffees": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(orderCoffeeType)))},
+			"total":   &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		},
+	})
+
+	applicationType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Application",
+		Description: "An application to the college.",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"firstName": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"lastName":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"age":       &graphql.Field{Type: graphql.Int},
+			"course":    &graphql.Field{Type: graphql.String},
+			"status":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	productType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Product",
+		Description: "A product of the electronics-store.",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"price": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"stock": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"coffee":  lookupField(coffeeType, func(l *loaders) *loader[Coffee] { return l.coffees }),
+			"coffees": listField(coffeeType, bs.CoffeeShop, "/coffees", func(l *loaders) *loader[Coffee] { return l.coffees }, func(c Coffee) int { return c.ID }),
+
+			"pet":     lookupField(petType, func(l *loaders) *loader[Pet] { return l.pets }),
+			"pets":    listField(petType, bs.PetStore, "/pets", func(l *loaders) *loader[Pet] { return l.pets }, func(p Pet) int { return p.ID }),
+			"adopter": lookupField(adopterType, func(l *loaders) *loader[Adopter] { return l.adopters }),
+
+			"menuItem": lookupField(menuItemType, func(l *loaders) *loader[MenuItem] { return l.menuItems }),
+			"menu":     listField(menuItemType, bs.Restaurant, "/menu", func(l *loaders) *loader[MenuItem] { return l.menuItems }, func(m MenuItem) int { return m.ID }),
+			"order":    lookupField(orderType, func(l *loaders) *loader[Order] { return l.orders }),
+			"orders":   listField(orderType, bs.Restaurant, "/orders", func(l *loaders) *loader[Order] { return l.orders }, func(o Order) int { return o.ID }),
+
+			"application":  lookupField(applicationType, func(l *loaders) *loader[Application] { return l.applications }),
+			"applications": listField(applicationType, bs.CollegeAdmission, "/applications", func(l *loaders) *loader[Application] { return l.applications }, func(a Application) int { return a.ID }),
+
+			"product":  lookupField(productType, func(l *loaders) *loader[Product] { return l.products }),
+			"products": listField(productType, bs.ElectronicsStore, "/products", func(l *loaders) *loader[Product] { return l.products }, func(p Product) int { return p.ID }),
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// lookupField returns a field resolving its id argument with the loader
+// picked by of, to null when there is no record with that ID.
+func lookupField[T any](typ *graphql.Object, of func(*loaders) *loader[T]) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return of(loadersOf(p.Context)).load(p.Context, p.Args["id"].(int)), nil
+		},
+	}
+}
+
+// listField returns a field resolving to a page of the collection at path of
+// b. The records read prime the loader picked by of, so that lookups of them
+// in the same query are not fetched again. The list itself is nullable, so
+// that a failing backend only nulls its own fields.
+func listField[T any](typ *graphql.Object, b *Backend, path string, of func(*loaders) *loader[T], id func(T) int) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewList(graphql.NewNonNull(typ)),
+		Args: graphql.FieldConfigArgument{
+			"limit": &graphql.ArgumentConfig{
+				Type:         graphql.Int,
+				DefaultValue: defaultPageSize,
+				Description:  fmt.Sprintf("The number of records to return, at most %d.", maxPageSize),
+			},
+			"offset": &graphql.ArgumentConfig{
+				Type:         graphql.Int,
+				DefaultValue: 0,
+				Description:  "The number of records to skip.",
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			limit, offset := p.Args["limit"].(int), p.Args["offset"].(int)
+			if limit < 1 || limit > maxPageSize {
+				return nil, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+			}
+			if offset < 0 {
+				return nil, fmt.Errorf("offset must not be negative")
+			}
+			var records []T
+			if err := b.list(p.Context, path, limit, offset, &records); err != nil {
+				return nil, err
+			}
+			l := of(loadersOf(p.Context))
+			for _, r := range records {
+				l.prime(id(r), r)
+			}
+			return records, nil
+		},
+	}
+}
+
+// reference resolves a reference to the record id of l, which is null when
+// id is 0.
+func reference[T any](ctx context.Context, l *loader[T], id int) (interface{}, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	return l.load(ctx, id), nil
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RunServer serves r on addr until SIGINT or SIGTERM. On shutdown it first
+// marks readiness as draining and waits SHUTDOWN_DRAIN_SECONDS so the
+// readiness probe can fail and endpoints are updated, then stops accepting
+// connections and waits for in-flight requests to finish.
+func RunServer(r *gin.Engine, addr string, readiness *Readiness) {
+	srv := &http.Server{Addr: addr, Handler: r}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+
+	readiness.SetDraining()
+	drainDelay := envSeconds("SHUTDOWN_DRAIN_SECONDS", 5*time.Second)
+	slog.Info("Shutdown requested, draining", "delay", drainDelay.String())
+	time.Sleep(drainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), envSeconds("SHUTDOWN_TIMEOUT_SECONDS", 15*time.Second))
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Graceful shutdown failed", "error", err)
+	}
+}
+
+// envSeconds reads a duration given in whole seconds from the environment,
+// falling back to def when the variable is unset or invalid.
+func envSeconds(key string, def time.Duration) time.Duration {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v < 0 {
+		return def
+	}
+	return time.Duration(v) * time.Second
+}
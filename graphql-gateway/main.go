@@ -0,0 +1,19 @@
+package main
+
+import (
+	"log/slog"
+
+	"graphql-gateway/api"
+)
+
+func main() {
+	readiness := &api.Readiness{}
+	cfg := api.ConfigFromEnv()
+	cfg.Readiness = readiness
+	slog.SetDefault(cfg.Logger)
+
+	r := api.NewRouter(cfg)
+
+	readiness.SetWarmedUp()
+	api.RunServer(r, ":8080", readiness)
+}
@@ -0,0 +1,357 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"graphql-gateway/api"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backend fakes the REST API of a service: it answers GET requests for the
+// paths in records with their JSON, 404 for other paths, and remembers the
+// requests it was sent.
+type backend struct {
+	records map[string]any
+	status  int
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (b *backend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	b.requests = append(b.requests, r)
+	b.mu.Unlock()
+
+	if b.status != 0 {
+		w.WriteHeader(b.status)
+		return
+	}
+	record, ok := b.records[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(record)
+}
+
+// paths returns the paths requested from b, with their query.
+func (b *backend) paths() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var paths []string
+	for _, r := range b.requests {
+		paths = append(paths, r.URL.RequestURI())
+	}
+	return paths
+}
+
+func serve(t *testing.T, b *backend) *api.Backend {
+	t.Helper()
+	srv := httptest.NewServer(b)
+	t.Cleanup(srv.Close)
+	return api.NewBackend("test", srv.URL, api.BackendOptions{})
+}
+
+// fakeBackends are fakes of every service, with a few records each.
+type fakeBackends struct {
+	coffeeShop, petStore, restaurant, collegeAdmission, electronicsStore *backend
+}
+
+func newFakeBackends() *fakeBackends {
+	return &fakeBackends{
+		coffeeShop: &backend{records: map[string]any{
+			"/coffees": []any{
+				map[string]any{"id": 1, "name": "Espresso", "price": 2.99},
+				map[string]any{"id": 2, "name": "Latte", "price": 3.99},
+			},
+			"/coffees/1": map[string]any{"id": 1, "name": "Espresso", "price": 2.99},
+			"/coffees/2": map[string]any{"id": 2, "name": "Latte", "price": 3.99},
+		}},
+		petStore: &backend{records: map[string]any{
+			"/pets": []any{
+				map[string]any{"id": 1, "name": "Max", "type": "Dog", "age": 3, "status": "adopted", "adopted_by": 7},
+				map[string]any{"id": 2, "name": "Bella", "type": "Cat", "age": 2, "status": "reserved", "reserved_by": 7, "reserved_until": "2026-01-02T15:04:05Z"},
+				map[string]any{"id": 3, "name": "Charlie", "type": "Dog", "age": 5, "status": "available"},
+			},
+			"/adopters/7": map[string]any{"id": 7, "name": "Sam Rivera", "email": "sam@example.com"},
+		}},
+		restaurant: &backend{records: map[string]any{
+			"/menu": []any{
+				map[string]any{"id": 1, "name": "Pizza", "price": 12.99},
+				map[string]any{"id": 2, "name": "Burger", "price": 9.99},
+			},
+			"/menu/1": map[string]any{"id": 1, "name": "Pizza", "price": 12.99},
+			"/menu/2": map[string]any{"id": 2, "name": "Burger", "price": 9.99},
+			"/orders": []any{
+				map[string]any{"id": 1, "total": 38.96,
+					"items":   []any{map[string]any{"menu_item_id": 1, "quantity": 2, "name": "Pizza", "unit_price": 12.99}, map[string]any{"menu_item_id": 2, "quantity": 1, "name": "Burger", "unit_price": 9.99}},
+					"coffees": []any{map[string]any{"coffee_id": 1, "quantity": 1, "name": "Espresso", "unit_price": 2.99}}},
+				map[string]any{"id": 2, "total": 15.98,
+					"items":   []any{map[string]any{"menu_item_id": 1, "quantity": 1, "name": "Pizza", "unit_price": 12.99}},
+					"coffees": []any{map[string]any{"coffee_id": 1, "quantity": 1, "name": "Espresso", "unit_price": 2.99}}},
+			},
+		}},
+		collegeAdmission: &backend{records: map[string]any{
+			"/applications": []any{
+				map[string]any{"id": 1, "first_name": "John", "last_name": "Doe", "age": 18, "course": "Computer Science", "status": "submitted"},
+			},
+		}},
+		electronicsStore: &backend{records: map[string]any{
+			"/products": []any{
+				map[string]any{"id": 1, "name": "Laptop", "price": 999.99, "stock": 25},
+			},
+			"/products/1": map[string]any{"id": 1, "name": "Laptop", "price": 999.99, "stock": 25},
+		}},
+	}
+}
+
+// newRouter returns the gateway router in front of f.
+func newRouter(t *testing.T, f *fakeBackends) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	return api.NewRouter(api.Config{Backends: api.Backends{
+		CoffeeShop:       serve(t, f.coffeeShop),
+		PetStore:         serve(t, f.petStore),
+		Restaurant:       serve(t, f.restaurant),
+		CollegeAdmission: serve(t, f.collegeAdmission),
+		ElectronicsStore: serve(t, f.electronicsStore),
+	}})
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path"`
+}
+
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []graphQLError             `json:"errors"`
+}
+
+func query(t *testing.T, r http.Handler, q string, header ...string) (int, graphQLResponse) {
+	t.Helper()
+	body, err := json.Marshal(map[string]string{"query": q})
+	require.NoError(t, err)
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp graphQLResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w.Code, resp
+}
+
+func TestQueryFederatesTheServices(t *testing.T) {
+	r := newRouter(t, newFakeBackends())
+
+	code, resp := query(t, r, `{
+		coffees { name price }
+		pets { name status }
+		menu { name }
+		applications { firstName lastName status }
+		products { name stock }
+	}`)
+
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `[{"name":"Espresso","price":2.99},{"name":"Latte","price":3.99}]`, string(resp.Data["coffees"]))
+	assert.JSONEq(t, `[{"name":"Max","status":"adopted"},{"name":"Bella","status":"reserved"},{"name":"Charlie","status":"available"}]`, string(resp.Data["pets"]))
+	assert.JSONEq(t, `[{"name":"Pizza"},{"name":"Burger"}]`, string(resp.Data["menu"]))
+	assert.JSONEq(t, `[{"firstName":"John","lastName":"Doe","status":"submitted"}]`, string(resp.Data["applications"]))
+	assert.JSONEq(t, `[{"name":"Laptop","stock":25}]`, string(resp.Data["products"]))
+}
+
+func TestListsArePaged(t *testing.T) {
+	f := newFakeBackends()
+	r := newRouter(t, f)
+
+	code, resp := query(t, r, `{ coffees(limit: 5, offset: 10) { id } }`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, []string{"/coffees?limit=5&offset=10"}, f.coffeeShop.paths())
+
+	_, resp = query(t, r, `{ coffees(limit: 5000) { id } }`)
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "limit must be between 1 and 1000")
+}
+
+func TestReferencesAreLoadedInBatches(t *testing.T) {
+	f := newFakeBackends()
+	r := newRouter(t, f)
+
+	code, resp := query(t, r, `{
+		orders {
+			id
+			items { quantity menuItem { name } }
+			coffees { coffee { name } }
+		}
+		pets { name reservedBy { name } adoptedBy { name } }
+	}`)
+
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `[
+		{"id":1,"items":[{"quantity":2,"menuItem":{"name":"Pizza"}},{"quantity":1,"menuItem":{"name":"Burger"}}],"coffees":[{"coffee":{"name":"Espresso"}}]},
+		{"id":2,"items":[{"quantity":1,"menuItem":{"name":"Pizza"}}],"coffees":[{"coffee":{"name":"Espresso"}}]}
+	]`, string(resp.Data["orders"]))
+	assert.JSONEq(t, `[
+		{"name":"Max","reservedBy":null,"adoptedBy":{"name":"Sam Rivera"}},
+		{"name":"Bella","reservedBy":{"name":"Sam Rivera"},"adoptedBy":null},
+		{"name":"Charlie","reservedBy":null,"adoptedBy":null}
+	]`, string(resp.Data["pets"]))
+
+	// Each record referred to is fetched once, however often it is referred
+	// to.
+	assert.ElementsMatch(t, []string{"/orders?limit=100&offset=0", "/menu/1", "/menu/2"}, f.restaurant.paths())
+	assert.Equal(t, []string{"/coffees/1"}, f.coffeeShop.paths())
+	assert.ElementsMatch(t, []string{"/pets?limit=100&offset=0", "/adopters/7"}, f.petStore.paths())
+}
+
+func TestListedRecordsAreNotFetchedAgain(t *testing.T) {
+	f := newFakeBackends()
+	r := newRouter(t, f)
+
+	code, resp := query(t, r, `{
+		menu { id }
+		orders { items { menuItem { name } } }
+		pizza: menuItem(id: 1) { name }
+	}`)
+
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"name":"Pizza"}`, string(resp.Data["pizza"]))
+	assert.ElementsMatch(t, []string{"/menu?limit=100&offset=0", "/orders?limit=100&offset=0"}, f.restaurant.paths())
+}
+
+func TestLookupsByID(t *testing.T) {
+	f := newFakeBackends()
+	r := newRouter(t, f)
+
+	code, resp := query(t, r, `{
+		a: product(id: 1) { name price }
+		b: product(id: 1) { stock }
+		missing: product(id: 99) { name }
+	}`)
+
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"name":"Laptop","price":999.99}`, string(resp.Data["a"]))
+	assert.JSONEq(t, `{"stock":25}`, string(resp.Data["b"]))
+	assert.JSONEq(t, `null`, string(resp.Data["missing"]))
+	assert.ElementsMatch(t, []string{"/products/1", "/products/99"}, f.electronicsStore.paths())
+}
+
+func TestBackendFailuresOnlyFailTheirFields(t *testing.T) {
+	f := newFakeBackends()
+	f.electronicsStore.status = http.StatusServiceUnavailable
+	srv := httptest.NewServer(f.electronicsStore)
+	t.Cleanup(srv.Close)
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.Config{Backends: api.Backends{
+		CoffeeShop:       serve(t, f.coffeeShop),
+		ElectronicsStore: api.NewBackend("electronics-store", srv.URL, api.BackendOptions{Retries: 1, Backoff: time.Millisecond}),
+	}})
+
+	code, resp := query(t, r, `{ coffees { name } products { name } pets { name } }`)
+
+	require.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `[{"name":"Espresso"},{"name":"Latte"}]`, string(resp.Data["coffees"]))
+	require.Len(t, resp.Errors, 2)
+	messages := map[string]string{}
+	for _, e := range resp.Errors {
+		messages[e.Path[0].(string)] = e.Message
+	}
+	assert.Contains(t, messages["products"], "503 Service Unavailable")
+	assert.Equal(t, "pet-store is not configured", messages["pets"])
+	// The failed request was retried once.
+	assert.Len(t, f.electronicsStore.paths(), 2)
+}
+
+func TestCredentialsAreForwarded(t *testing.T) {
+	f := newFakeBackends()
+	srv := httptest.NewServer(f.coffeeShop)
+	t.Cleanup(srv.Close)
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.Config{Backends: api.Backends{
+		CoffeeShop: api.NewBackend("coffee-shop", srv.URL, api.BackendOptions{}),
+	}})
+
+	query(t, r, `{ coffees { name } }`, "X-API-Key", "caller-key", "X-Request-ID", "req-1")
+	query(t, r, `{ coffees { name } }`, "Authorization", "Bearer token")
+	query(t, r, `{ coffees { name } }`)
+
+	require.Len(t, f.coffeeShop.requests, 3)
+	assert.Equal(t, "caller-key", f.coffeeShop.requests[0].Header.Get("X-API-Key"))
+	assert.Equal(t, "req-1", f.coffeeShop.requests[0].Header.Get("X-Request-ID"))
+	assert.Equal(t, "Bearer token", f.coffeeShop.requests[1].Header.Get("Authorization"))
+	assert.Empty(t, f.coffeeShop.requests[1].Header.Get("X-API-Key"))
+	// Anonymous callers stay anonymous, so that each backend rejects them
+	// as it would a direct call.
+	assert.Empty(t, f.coffeeShop.requests[2].Header.Get("X-API-Key"))
+	assert.Empty(t, f.coffeeShop.requests[2].Header.Get("Authorization"))
+}
+
+func TestQueriesOverGET(t *testing.T) {
+	r := newRouter(t, newFakeBackends())
+
+	q := url.Values{}
+	q.Set("query", `query Coffee($id: Int!) { coffee(id: $id) { name } }`)
+	q.Set("variables", `{"id": 2}`)
+	req := httptest.NewRequest("GET", "/graphql?"+q.Encode(), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"coffee":{"name":"Latte"}}}`, w.Body.String())
+}
+
+func TestInvalidQueriesAreRejected(t *testing.T) {
+	r := newRouter(t, newFakeBackends())
+
+	code, resp := query(t, r, `{ coffees { flavour } }`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, `Cannot query field "flavour"`)
+
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query":`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestReadinessFollowsLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	readiness := &api.Readiness{}
+	r := api.NewRouter(api.Config{Readiness: readiness})
+
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get("/livez"))
+	assert.Equal(t, http.StatusServiceUnavailable, get("/readyz"))
+	readiness.SetWarmedUp()
+	assert.Equal(t, http.StatusOK, get("/readyz"))
+	assert.Equal(t, http.StatusOK, get("/health"))
+	readiness.SetDraining()
+	assert.Equal(t, http.StatusServiceUnavailable, get("/readyz"))
+	assert.Equal(t, http.StatusOK, get("/livez"))
+}
@@ -0,0 +1,408 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PetStatus is the adoption status of a pet.
+type PetStatus string
+
+const (
+	PetAvailable PetStatus = "available"
+	PetReserved  PetStatus = "reserved"
+	PetAdopted   PetStatus = "adopted"
+)
+
+// ErrUnavailable is returned when a pet is reserved by another adopter or
+// already adopted.
+var ErrUnavailable = errors.New("pet is not available")
+
+// ErrNotReserved is returned when a pet that is not reserved is released.
+var ErrNotReserved = errors.New("pet is not reserved")
+
+// ErrUnknownAdopter is returned when a reservation or adoption names an
+// adopter that does not exist.
+var ErrUnknownAdopter = errors.New("unknown adopter")
+
+// Adopter is a person who reserves and adopts pets.
+type Adopter struct {
+	ID    int    `json:"id" example:"1"`
+	Name  string `json:"name" binding:"required" example:"Sam Rivera"`
+	Email string `json:"email" binding:"required" example:"sam@example.com"`
+	Phone string `json:"phone,omitempty" example:"+1 555 0100"`
+}
+
+// AdopterStore persists adopters. Get returns ErrNotFound for unknown IDs.
+type AdopterStore interface {
+	List(ctx context.Context) ([]Adopter, error)
+	Get(ctx context.Context, id int) (Adopter, int64, error)
+	Create(ctx context.Context, adopter Adopter) (Adopter, error)
+}
+
+// NewAdopterStore returns an AdopterStore that keeps adopters in memory,
+// starting with a copy of adopters.
+func NewAdopterStore(adopters []Adopter) AdopterStore {
+	return newMemoryStore(adopters, func(a *Adopter) *int { return &a.ID })
+}
+
+// AdoptionRequest names the adopter reserving or adopting a pet.
+type AdoptionRequest struct {
+	AdopterID int `json:"adopter_id" binding:"required" example:"1"`
+}
+
+// statusOf returns the status of pet as of now. Records imported without a
+// status are available, and so are pets whose reservation has expired but
+// not been swept yet.
+func statusOf(pet Pet, now time.Time) PetStatus {
+	if pet.Status == PetReserved && pet.ReservedUntil != nil && !now.Before(*pet.ReservedUntil) {
+		return PetAvailable
+	}
+	return cmp.Or(pet.Status, PetAvailable)
+}
+
+// asOf returns pet as of now, dropping a reservation that has expired but
+// not been swept yet.
+func asOf(pet Pet, now time.Time) Pet {
+	if pet.Status == PetReserved && statusOf(pet, now) == PetAvailable {
+		return available(pet)
+	}
+	pet.Status = statusOf(pet, now)
+	return pet
+}
+
+// available returns pet without its reservation.
+func available(pet Pet) Pet {
+	pet.Status = PetAvailable
+	pet.ReservedBy = 0
+	pet.ReservedUntil = nil
+	return pet
+}
+
+// changePet applies change to pet id and stores the result. The write is
+// conditional on the version change saw, and is retried on a newer version,
+// so that concurrent requests and sweepers on other replicas never
+// overwrite each other.
+func changePet(ctx context.Context, store Store, id int, change func(Pet) (Pet, error)) (Pet, int64, error) {
+	for attempt := 1; ; attempt++ {
+		pet, version, err := store.Get(ctx, id)
+		if err != nil {
+			return pet, 0, err
+		}
+		changed, err := change(pet)
+		if err != nil {
+			return pet, 0, err
+		}
+		updated, version, err := store.Update(ctx, id, changed, version)
+		if errors.Is(err, ErrVersionMismatch) && attempt < patchAttempts {
+			continue
+		}
+		return updated, version, err
+	}
+}
+
+// reservePet reserves a pet for an adopter.
+//
+// @Summary Reserve a pet
+// @Description Reserve an available pet for an adopter until the reservation expires. Reserving a pet again for the same adopter renews the reservation.
+// @Tags adoptions
+// @Accept json
+// @Param id path integer true "Pet ID"
+// @Param request body AdoptionRequest true "Adopter reserving the pet"
+// @Success 200 {object} Pet "Pet reserved"
+// @Header 200 {string} ETag "New version of the pet"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Pet not found"
+// @Failure 409 {object} map[string]string "Pet is reserved by another adopter or adopted"
+// @Failure 422 {object} map[string]string "Unknown adopter"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /pets/{id}/reserve [post]
+func (h *handler) reservePet(c *gin.Context) {
+	h.adopt(c, func(pet Pet, adopterID int, now time.Time) (Pet, error) {
+		if status := statusOf(pet, now); status == PetAdopted || status == PetReserved && pet.ReservedBy != adopterID {
+			return pet, fmt.Errorf("%w: pet %d is %s", ErrUnavailable, pet.ID, status)
+		}
+		until := now.Add(h.reservationTTL).UTC()
+		pet.Status, pet.ReservedBy, pet.ReservedUntil = PetReserved, adopterID, &until
+		return pet, nil
+	})
+}
+
+// adoptPet completes an adoption.
+//
+// @Summary Adopt a pet
+// @Description Adopt a pet that is available or reserved by the same adopter.
+// @Tags adoptions
+// @Accept json
+// @Param id path integer true "Pet ID"
+// @Param request body AdoptionRequest true "Adopter adopting the pet"
+// @Success 200 {object} Pet "Pet adopted"
+// @Header 200 {string} ETag "New version of the pet"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Pet not found"
+// @Failure 409 {object} map[string]string "Pet is reserved by another adopter or adopted"
+// @Failure 422 {object} map[string]string "Unknown adopter"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /pets/{id}/adopt [post]
+func (h *handler) adoptPet(c *gin.Context) {
+	h.adopt(c, func(pet Pet, adopterID int, now time.Time) (Pet, error) {
+		if status := statusOf(pet, now); status == PetAdopted || status == PetReserved && pet.ReservedBy != adopterID {
+			return pet, fmt.Errorf("%w: pet %d is %s", ErrUnavailable, pet.ID, status)
+		}
+		pet = available(pet)
+		pet.Status, pet.AdoptedBy = PetAdopted, adopterID
+		return pet, nil
+	})
+}
+
+// adopt answers the reserve and adopt endpoints, which apply change to the
+// pet on behalf of the adopter in the request.
+func (h *handler) adopt(c *gin.Context, change func(pet Pet, adopterID int, now time.Time) (Pet, error)) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var req AdoptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+	_, _, err := h.adopters.Get(ctx, req.AdopterID)
+	if errors.Is(err, ErrNotFound) {
+		err = fmt.Errorf("%w %d", ErrUnknownAdopter, req.AdopterID)
+	}
+	if err != nil {
+		petError(c, err)
+		return
+	}
+	updated, version, err := changePet(ctx, h.store, id, func(pet Pet) (Pet, error) {
+		return change(pet, req.AdopterID, time.Now())
+	})
+	if err != nil {
+		petError(c, err)
+		return
+	}
+	h.events.publish(EventUpdated, "pets", id, updated)
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// releasePet cancels a reservation.
+//
+// @Summary Release a pet
+// @Description Cancel the reservation of a pet, making it available again.
+// @Tags adoptions
+// @Param id path integer true "Pet ID"
+// @Success 200 {object} Pet "Pet released"
+// @Header 200 {string} ETag "New version of the pet"
+// @Failure 404 {object} map[string]string "Pet not found"
+// @Failure 409 {object} map[string]string "Pet is not reserved"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /pets/{id}/release [post]
+func (h *handler) releasePet(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	updated, version, err := changePet(c.Request.Context(), h.store, id, func(pet Pet) (Pet, error) {
+		if statusOf(pet, time.Now()) != PetReserved {
+			return pet, fmt.Errorf("%w: pet %d", ErrNotReserved, pet.ID)
+		}
+		return available(pet), nil
+	})
+	if err != nil {
+		petError(c, err)
+		return
+	}
+	h.events.publish(EventUpdated, "pets", id, updated)
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// getAdopters lists adopters.
+//
+// @Summary Get all adopters
+// @Description Returns a list of all adopters
+// @Tags adoptions
+// @Success 200 {array} Adopter "List of adopters"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /adopters [get]
+func (h *handler) getAdopters(c *gin.Context) {
+	adopters, err := h.adopters.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if adopters == nil {
+		adopters = []Adopter{}
+	}
+	c.JSON(http.StatusOK, adopters)
+}
+
+// getAdopterByID returns a single adopter.
+//
+// @Summary Get adopter by ID
+// @Description Returns a single adopter
+// @Tags adoptions
+// @Param id path integer true "Adopter ID"
+// @Success 200 {object} Adopter "Adopter details"
+// @Failure 404 {object} map[string]string "Adopter not found"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /adopters/{id} [get]
+func (h *handler) getAdopterByID(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	adopter, _, err := h.adopters.Get(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Adopter not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, adopter)
+}
+
+// createAdopter registers an adopter.
+//
+// @Summary Create a new adopter
+// @Description Register an adopter. An adopter without an id is assigned one; an id that is already taken is rejected.
+// @Tags adoptions
+// @Accept json
+// @Param adopter body Adopter true "Adopter to add"
+// @Success 201 {object} Adopter "Adopter created successfully"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 409 {object} map[string]string "Adopter already exists"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /adopters [post]
+func (h *handler) createAdopter(c *gin.Context) {
+	var adopter Adopter
+	if err := c.ShouldBindJSON(&adopter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	created, err := h.adopters.Create(c.Request.Context(), adopter)
+	if errors.Is(err, ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Adopter already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.events.publish(EventCreated, "adopters", created.ID, created)
+	c.JSON(http.StatusCreated, created)
+}
+
+// ReleaseExpired makes the pets whose reservation expired before now
+// available again and returns how many it released. Each release is
+// conditional on the version that was found expired, so a pet reserved
+// again in the meantime keeps its new reservation.
+func ReleaseExpired(ctx context.Context, store Store, now time.Time) (int, error) {
+	pets, err := store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	released := 0
+	for _, pet := range pets {
+		if pet.Status != PetReserved || statusOf(pet, now) != PetAvailable {
+			continue
+		}
+		_, _, err := changePet(ctx, store, pet.ID, func(pet Pet) (Pet, error) {
+			if pet.Status != PetReserved || statusOf(pet, now) != PetAvailable {
+				return pet, ErrNotReserved
+			}
+			return available(pet), nil
+		})
+		switch {
+		case err == nil:
+			released++
+		case errors.Is(err, ErrNotReserved), errors.Is(err, ErrNotFound):
+			// Released, adopted, renewed or deleted since the listing.
+		default:
+			return released, err
+		}
+	}
+	return released, nil
+}
+
+// StartSweeper calls ReleaseExpired on store every interval until the
+// returned stop is called. stop waits for a sweep in progress to finish, so
+// that a shutdown does not cut a release short.
+func StartSweeper(store Store, interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				n, err := ReleaseExpired(ctx, store, now)
+				if err != nil && ctx.Err() == nil {
+					slog.Error("Releasing expired reservations failed", "error", err)
+				}
+				if n > 0 {
+					slog.Info("Released expired reservations", "count", n)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// editablePets is the store PUT and PATCH write through. It keeps the
+// adoption fields of the stored pet, which only the adoption endpoints
+// change.
+type editablePets struct {
+	Store
+}
+
+func (s editablePets) Update(ctx context.Context, id int, pet Pet, ifVersion int64) (Pet, int64, error) {
+	for attempt := 1; ; attempt++ {
+		current, version, err := s.Get(ctx, id)
+		if err == nil && ifVersion != anyVersion && version != ifVersion {
+			err = ErrVersionMismatch
+		}
+		if err != nil {
+			return pet, 0, err
+		}
+		pet.Status, pet.ReservedBy, pet.ReservedUntil, pet.AdoptedBy = current.Status, current.ReservedBy, current.ReservedUntil, current.AdoptedBy
+		updated, version, err := s.Store.Update(ctx, id, pet, version)
+		if errors.Is(err, ErrVersionMismatch) && ifVersion == anyVersion && attempt < patchAttempts {
+			continue
+		}
+		return updated, version, err
+	}
+}
@@ -0,0 +1,293 @@
+// Package api implements the HTTP and gRPC APIs of the pet store service.
+// NewRouter builds the complete router on top of a Store, and NewServers a
+// gRPC server next to it, so tests and the service binary run the same
+// handlers.
+package api
+
+//go:generate go run -C ../../tools/openapi-gen . -dir ../../pet-store/api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+const (
+	serviceName    = "pet-store"
+	serviceVersion = "1.0.0"
+)
+
+// Config holds the router options that do not depend on the store.
+type Config struct {
+	// ValidateRequests rejects requests that do not match the OpenAPI spec.
+	ValidateRequests bool
+
+	// ValidateResponses logs responses that do not match the OpenAPI spec.
+	ValidateResponses bool
+
+	// Readiness is reported by /readyz and /health. A nil Readiness is
+	// replaced by one that is not yet warmed up.
+	Readiness *Readiness
+
+	// Logger receives one record per request. A nil Logger uses
+	// slog.Default.
+	Logger *slog.Logger
+
+	// Auth identifies the callers of the data and admin endpoints. Reads
+	// need the read scope and other methods the write scope. A nil Auth
+	// leaves the API open; health checks and docs are always open.
+	Auth Authenticator
+
+	// Limits rate limits the data and admin endpoints and caps request
+	// bodies. The zero value sets no limits.
+	Limits Limits
+
+	// Adopters keeps the adopters. A nil Adopters keeps them in memory.
+	Adopters AdopterStore
+
+	// ReservationTTL is how long a reservation holds a pet. Zero means 15
+	// minutes.
+	ReservationTTL time.Duration
+
+	// SweepInterval is how often the binary runs StartSweeper's sweep of
+	// expired reservations; the router does not use it.
+	SweepInterval time.Duration
+
+	// Events configures /events and the webhooks.
+	Events EventOptions
+
+	// Bus also carries every event to the other services. A nil Bus
+	// publishes them on /events and to webhooks only.
+	Bus Bus
+}
+
+// ConfigFromEnv reads the router options from the environment: requests are
+// validated unless OPENAPI_VALIDATE_REQUESTS=false, responses are checked
+// when OPENAPI_VALIDATE_RESPONSES=true, LOG_LEVEL and LOG_FORMAT select
+// the logger, the limits are read as described by LimitsFromEnv, and
+// reservations last RESERVATION_TTL_SECONDS (default 900) and are swept
+// every RESERVATION_SWEEP_SECONDS (default 30), and the change feed is
+// configured as described by EventOptionsFromEnv.
+func ConfigFromEnv() Config {
+	return Config{
+		ValidateRequests:  envBool("OPENAPI_VALIDATE_REQUESTS", true),
+		ValidateResponses: envBool("OPENAPI_VALIDATE_RESPONSES", false),
+		Logger:            LoggerFromEnv(),
+		Limits:            LimitsFromEnv(),
+		ReservationTTL:    envSeconds("RESERVATION_TTL_SECONDS", 15*time.Minute),
+		SweepInterval:     envSeconds("RESERVATION_SWEEP_SECONDS", 30*time.Second),
+		Events:            EventOptionsFromEnv(),
+	}
+}
+
+// handler serves the API from a store.
+type handler struct {
+	store          Store
+	adopters       AdopterStore
+	reservationTTL time.Duration
+	checks         []namedCheck
+	events         *events
+}
+
+// NewRouter registers every route on a new engine, serving data from store.
+// It panics if the embedded OpenAPI spec is invalid, which the package tests
+// rule out, or if one of Limits.TrustedProxies is not an address or range.
+//
+// @title Pet Store API
+// @version 1.0.0
+// @description This is a pet store service API
+// @contact.name API Support
+// @contact.url http://www.swagger.io/support
+// @contact.email support@swagger.io
+// @license.name Apache 2.0
+// @license.url http://www.apache.org/licenses/LICENSE-2.0.html
+// @host localhost:8080
+// @BasePath /
+// @schemes http
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.bearer BearerAuth
+// @bearerFormat JWT
+func NewRouter(store Store, cfg Config) *gin.Engine {
+	return newHandler(store, &cfg).router(cfg)
+}
+
+// NewServers returns the router and a gRPC server on top of the same
+// handler, so that both serve one store, and a change made through either is
+// published on the change feed of both.
+func NewServers(store Store, cfg Config) (*gin.Engine, *grpc.Server) {
+	h := newHandler(store, &cfg)
+	return h.router(cfg), newGRPCServer(h, cfg)
+}
+
+// newHandler fills in the defaults of cfg and returns a handler for store.
+func newHandler(store Store, cfg *Config) *handler {
+	if cfg.Readiness == nil {
+		cfg.Readiness = &Readiness{}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	if cfg.Adopters == nil {
+		cfg.Adopters = NewAdopterStore(nil)
+	}
+	if cfg.ReservationTTL <= 0 {
+		cfg.ReservationTTL = 15 * time.Minute
+	}
+	return &handler{
+		store:          store,
+		adopters:       cfg.Adopters,
+		reservationTTL: cfg.ReservationTTL,
+		checks:         readinessChecks(cfg.Readiness, store),
+		events:         newEvents(cfg.Events, cfg.Bus, cfg.Readiness.stopping()),
+	}
+}
+
+// router registers every route of h on a new engine.
+func (h *handler) router(cfg Config) *gin.Engine {
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Limits.TrustedProxies); err != nil {
+		panic(fmt.Sprintf("setting trusted proxies: %v", err))
+	}
+	r.Use(requestLogger(cfg.Logger), gin.Recovery(), limitBody(cfg.Limits.MaxBodyBytes))
+	// Credentials are checked before requests are validated, so that callers
+	// without them learn nothing about the API. Clients are rate limited by
+	// IP before that and by caller after it; probes and docs are not.
+	public := r.Group("/")
+	api := r.Group("/", rateLimit(cfg.Limits, clientIP), authorize(cfg.Auth), rateLimit(cfg.Limits, callerOf))
+	if cfg.ValidateRequests {
+		doc, err := loadOpenAPISpec()
+		if err != nil {
+			panic(fmt.Sprintf("loading OpenAPI spec: %v", err))
+		}
+		validator, err := validateOpenAPI(doc, cfg.ValidateResponses)
+		if err != nil {
+			panic(fmt.Sprintf("building OpenAPI validator: %v", err))
+		}
+		public.Use(validator)
+		api.Use(validator)
+	}
+
+	// Health check endpoints
+	public.GET("/health", h.healthCheck)
+	public.GET("/livez", h.livenessCheck)
+	public.GET("/readyz", h.readinessCheck)
+
+	// OpenAPI documentation endpoints
+	public.GET("/openapi.json", getOpenAPISpec)
+	public.GET("/docs", serveDocs)
+
+	api.GET("/pets", h.getPets)
+	api.GET("/pets/:id", h.getPetByID)
+	api.POST("/pets", h.createPet)
+	api.DELETE("/pets/:id", h.deletePet)
+	api.PUT("/pets/:id", h.updatePet)
+	api.PATCH("/pets/:id", h.patchPet)
+
+	// Adoptions
+	api.POST("/pets/:id/reserve", h.reservePet)
+	api.POST("/pets/:id/adopt", h.adoptPet)
+	api.POST("/pets/:id/release", h.releasePet)
+	api.GET("/adopters", h.getAdopters)
+	api.GET("/adopters/:id", h.getAdopterByID)
+	api.POST("/adopters", h.createAdopter)
+
+	// Change feed
+	api.GET("/events", h.streamEvents)
+	api.GET("/webhooks", h.getWebhooks)
+	api.GET("/webhooks/:id", h.getWebhookByID)
+	api.POST("/webhooks", h.createWebhook)
+	api.DELETE("/webhooks/:id", h.deleteWebhook)
+
+	// Bulk data seeding
+	api.GET("/admin/fixtures", h.exportPetFixtures)
+	api.POST("/admin/fixtures", h.importPetFixtures)
+	api.POST("/admin/fixtures/generate", h.generatePetFixtures)
+
+	return r
+}
+
+// getOpenAPISpec returns the OpenAPI specification as JSON
+//
+// @Summary OpenAPI specification
+// @Description Returns the OpenAPI 3.0 description of this API
+// @Tags docs
+// @Success 200 {object} object "OpenAPI document"
+// @Router /openapi.json [get]
+func getOpenAPISpec(c *gin.Context) {
+	c.Header("Content-Type", "application/json")
+	c.String(http.StatusOK, openAPISpec)
+}
+
+// serveDocs serves the Swagger UI documentation page
+//
+// @Summary API documentation
+// @Description Swagger UI for this API
+// @Tags docs
+// @Produce html
+// @Success 200 {string} string "Swagger UI page"
+// @Router /docs [get]
+func serveDocs(c *gin.Context) {
+	html := `<!DOCTYPE html>
+<html>
+<head>
+  <title>Pet Store API Documentation</title>
+  <link rel="stylesheet" type="text/css" href="https://unpkg.com/swagger-ui-dist@3.52.5/swagger-ui.css" />
+  <style>
+    html {
+      box-sizing: border-box;
+      overflow: -moz-scrollbars-vertical;
+      overflow-y: scroll;
+    }
+    *, *:before, *:after {
+      box-sizing: inherit;
+    }
+    body {
+      margin:0;
+      background: #fafafa;
+    }
+  </style>
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@3.52.5/swagger-ui-bundle.js"></script>
+  <script src="https://unpkg.com/swagger-ui-dist@3.52.5/swagger-ui-standalone-preset.js"></script>
+  <script>
+    window.onload = function() {
+      const ui = SwaggerUIBundle({
+        url: '/openapi.json',
+        dom_id: '#swagger-ui',
+        deepLinking: true,
+        presets: [
+          SwaggerUIBundle.presets.apis,
+          SwaggerUIStandalonePreset
+        ],
+        plugins: [
+          SwaggerUIBundle.plugins.DownloadUrl
+        ],
+        layout: "StandaloneLayout"
+      });
+    };
+  </script>
+</body>
+</html>`
+	c.Header("Content-Type", "text/html")
+	c.String(http.StatusOK, html)
+}
+
+// parseID reads the numeric id path parameter. The OpenAPI validator already
+// rejects other values unless it is disabled.
+func parseID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be an integer"})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,600 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eventResources are the resources whose changes are published, as named in
+// Event.Resource and in the resource filters of /events and webhooks.
+var eventResources = []string{"pets", "adopters"}
+
+// Event types.
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+)
+
+// Event is a change to a record, as streamed by /events and posted to
+// webhooks.
+type Event struct {
+	// ID increases by one with every event the replica publishes.
+	ID         int64     `json:"id" example:"42"`
+	Type       string    `json:"type" enums:"created,updated,deleted" example:"updated"`
+	Resource   string    `json:"resource" example:"pets"`
+	ResourceID int       `json:"resource_id" example:"1"`
+	Time       time.Time `json:"time"`
+	// Data is the record after the change. Deletions carry none.
+	Data any `json:"data,omitempty"`
+}
+
+// Webhook is a subscription that has events posted to a URL.
+type Webhook struct {
+	ID  int    `json:"id" readonly:"true" example:"1"`
+	URL string `json:"url" binding:"required,url" example:"https://hooks.example.com/pet-store"`
+	// Resources limits the subscription to the events of these resources.
+	// Empty means all of them.
+	Resources []string `json:"resources,omitempty" example:"pets"`
+	// Secret is the key of the signatures. It is generated when not given,
+	// and only returned by the request that creates the webhook.
+	Secret    string    `json:"secret,omitempty" example:"6f1ed002ab5595859014ebf0951522d9"`
+	CreatedAt time.Time `json:"created_at" readonly:"true"`
+	// Delivered and Failed count the events that were delivered and those
+	// given up on after the last attempt.
+	Delivered int    `json:"delivered" readonly:"true"`
+	Failed    int    `json:"failed" readonly:"true"`
+	LastError string `json:"last_error,omitempty" readonly:"true"`
+}
+
+// EventOptions configures the change feed. Zero fields take the defaults.
+type EventOptions struct {
+	// History is how many events are kept for clients that resume a stream
+	// with Last-Event-ID. Default 1000.
+	History int
+	// Heartbeat is how often an idle stream gets a comment, so that proxies
+	// do not close it. Default 15 seconds.
+	Heartbeat time.Duration
+	// WebhookAttempts is how many times a delivery is tried. Default 5.
+	WebhookAttempts int
+	// WebhookBackoff is the delay before the first retry, doubled for each
+	// further retry. Default 1 second.
+	WebhookBackoff time.Duration
+	// WebhookTimeout limits each delivery attempt. Default 10 seconds.
+	WebhookTimeout time.Duration
+}
+
+// EventOptionsFromEnv reads EVENTS_HISTORY, EVENTS_HEARTBEAT_SECONDS,
+// WEBHOOK_MAX_ATTEMPTS, WEBHOOK_RETRY_SECONDS and WEBHOOK_TIMEOUT_SECONDS.
+func EventOptionsFromEnv() EventOptions {
+	history, _ := strconv.Atoi(os.Getenv("EVENTS_HISTORY"))
+	attempts, _ := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS"))
+	return EventOptions{
+		History:         history,
+		Heartbeat:       envSeconds("EVENTS_HEARTBEAT_SECONDS", 0),
+		WebhookAttempts: attempts,
+		WebhookBackoff:  envSeconds("WEBHOOK_RETRY_SECONDS", 0),
+		WebhookTimeout:  envSeconds("WEBHOOK_TIMEOUT_SECONDS", 0),
+	}
+}
+
+func (o EventOptions) withDefaults() EventOptions {
+	if o.History <= 0 {
+		o.History = 1000
+	}
+	if o.Heartbeat <= 0 {
+		o.Heartbeat = 15 * time.Second
+	}
+	if o.WebhookAttempts <= 0 {
+		o.WebhookAttempts = 5
+	}
+	if o.WebhookBackoff <= 0 {
+		o.WebhookBackoff = time.Second
+	}
+	if o.WebhookTimeout <= 0 {
+		o.WebhookTimeout = 10 * time.Second
+	}
+	return o
+}
+
+// published is an event with its JSON encoding.
+type published struct {
+	Event
+	body []byte
+}
+
+// subscriber is an open event stream. Events that do not fit in its buffer
+// end the stream; the client resumes it with Last-Event-ID.
+type subscriber struct {
+	resource string
+	events   chan published
+}
+
+// subscriberBuffer is how many events a stream may fall behind by.
+const subscriberBuffer = 64
+
+// events publishes the changes of the records to streams, webhooks and the
+// bus. The history, the streams and the webhooks belong to the replica.
+type events struct {
+	opts     EventOptions
+	bus      Bus
+	stopping <-chan struct{}
+	client   *http.Client
+
+	mu          sync.Mutex
+	next        int64
+	history     []published
+	subscribers map[*subscriber]bool
+	webhooks    *memoryStore[Webhook]
+	deliveries  map[int]*delivery
+}
+
+func newEvents(opts EventOptions, bus Bus, stopping <-chan struct{}) *events {
+	return &events{
+		opts:        opts.withDefaults(),
+		bus:         bus,
+		stopping:    stopping,
+		client:      &http.Client{},
+		subscribers: make(map[*subscriber]bool),
+		webhooks:    newMemoryStore(nil, func(w *Webhook) *int { return &w.ID }),
+		deliveries:  make(map[int]*delivery),
+	}
+}
+
+// publish records a change and passes it on to the streams and webhooks
+// that want it, and to the bus.
+func (e *events) publish(typ, resource string, id int, data any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.next++
+	event := Event{ID: e.next, Type: typ, Resource: resource, ResourceID: id, Time: time.Now().UTC(), Data: data}
+	body, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Encoding event failed", "error", err)
+		return
+	}
+	p := published{event, body}
+	e.history = append(e.history, p)
+	if over := len(e.history) - e.opts.History; over > 0 {
+		e.history = slices.Delete(e.history, 0, over)
+	}
+
+	for sub := range e.subscribers {
+		if sub.resource != "" && sub.resource != resource {
+			continue
+		}
+		select {
+		case sub.events <- p:
+		default:
+			delete(e.subscribers, sub)
+			close(sub.events)
+		}
+	}
+	for _, d := range e.deliveries {
+		d.enqueue(p)
+	}
+	// Publishing under the lock keeps the bus in the order of the IDs.
+	if e.bus != nil {
+		if err := e.bus.Publish(EventSubject(serviceName, resource, typ), body); err != nil {
+			slog.Warn("Publishing event on the bus failed", "event", event.ID, "error", err)
+		}
+	}
+}
+
+// subscribe opens a stream of the events of resource, or of every resource
+// when it is empty. A stream that resumes after lastID starts with the
+// retained events after it; subscribe reports whether they are all of them,
+// which they are not when some have been dropped from the history or lastID
+// is from before the replica restarted.
+func (e *events) subscribe(resource string, lastID int64, resume bool) (*subscriber, []published, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	sub := &subscriber{resource: resource, events: make(chan published, subscriberBuffer)}
+	e.subscribers[sub] = true
+	if !resume {
+		return sub, nil, true
+	}
+
+	var backlog []published
+	complete := lastID <= e.next && (len(e.history) == 0 || e.history[0].ID <= lastID+1)
+	for _, p := range e.history {
+		if p.ID > lastID && (resource == "" || p.Resource == resource) {
+			backlog = append(backlog, p)
+		}
+	}
+	return sub, backlog, complete
+}
+
+func (e *events) unsubscribe(sub *subscriber) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.subscribers[sub] {
+		delete(e.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// streamEvents streams the changes to the records.
+//
+// @Summary Stream changes
+// @Description Streams the changes to the records as Server-Sent Events. Each event has the event ID as its id, the change type as its event name, and the Event as JSON data. Idle streams get a comment every 15 seconds.
+// @Description New streams start with the next change. A client that reconnects with the Last-Event-ID header, or the last_event_id parameter, first gets the retained events after that ID; last_event_id=0 replays all of them. If some are no longer retained, a reset event tells the client to reload the records.
+// @Tags events
+// @Produce text/event-stream
+// @Param resource query string false "Only stream the changes to this resource" Enums(pets, adopters)
+// @Param last_event_id query integer false "Resume after this event ID" minimum(0)
+// @Param Last-Event-ID header string false "Resume after this event ID, as sent by EventSource clients"
+// @Param follow query boolean false "Keep the stream open for new events; false ends it after the retained ones" default(true) example(false)
+// @Success 200 {string} string "Event stream"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /events [get]
+func (h *handler) streamEvents(c *gin.Context) {
+	last := c.DefaultQuery("last_event_id", c.GetHeader("Last-Event-ID"))
+	lastID, err := strconv.ParseInt(last, 10, 64)
+	follow := c.Query("follow") != "false"
+
+	sub, backlog, complete := h.events.subscribe(c.Query("resource"), lastID, err == nil && lastID >= 0)
+	defer h.events.unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	// Stops nginx based ingresses from buffering the stream.
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if !complete {
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, p := range backlog {
+		writeEvent(c.Writer, p)
+	}
+	c.Writer.Flush()
+	if !follow {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.events.opts.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-h.events.stopping:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": keepalive\n\n")
+		case p, ok := <-sub.events:
+			if !ok {
+				// Too slow to keep up; the client resumes from the last ID.
+				return
+			}
+			writeEvent(c.Writer, p)
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeEvent(w io.Writer, p published) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", p.ID, p.Type, p.body)
+}
+
+// delivery posts the events of one webhook in order, retrying failed
+// attempts with exponential backoff.
+type delivery struct {
+	events *events
+	hook   Webhook
+	queue  chan published
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// mu makes record's read and update of the counters one step, as
+	// publish records dropped events while run records deliveries.
+	mu sync.Mutex
+}
+
+// deliveryQueue is how many events a webhook may fall behind by before
+// further ones count as failed.
+const deliveryQueue = 1000
+
+func (d *delivery) enqueue(p published) {
+	if len(d.hook.Resources) > 0 && !slices.Contains(d.hook.Resources, p.Resource) {
+		return
+	}
+	select {
+	case d.queue <- p:
+	default:
+		d.record(fmt.Errorf("event %d dropped: %d events waiting", p.ID, deliveryQueue))
+	}
+}
+
+func (d *delivery) run(ctx context.Context) {
+	defer close(d.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case p := <-d.queue:
+			err := d.deliver(ctx, p)
+			if ctx.Err() != nil {
+				return
+			}
+			d.record(err)
+		}
+	}
+}
+
+// deliver posts p until an attempt succeeds, fails permanently or the
+// attempts run out.
+func (d *delivery) deliver(ctx context.Context, p published) error {
+	opts := d.events.opts
+	backoff := opts.WebhookBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = d.attempt(ctx, p)
+		if err == nil || !retry || attempt == opts.WebhookAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// attempt posts p once and reports whether a failure is worth retrying:
+// network errors, 408, 429 and 5xx are.
+func (d *delivery) attempt(ctx context.Context, p published) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.events.opts.WebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.hook.URL, bytes.NewReader(p.body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", serviceName+"/"+serviceVersion)
+	req.Header.Set("X-Webhook-ID", strconv.Itoa(d.hook.ID))
+	req.Header.Set("X-Event-ID", strconv.FormatInt(p.ID, 10))
+	req.Header.Set("X-Event-Type", p.Type)
+	req.Header.Set(SignatureHeader, signature(d.hook.Secret, timestamp, p.body))
+
+	resp, err := d.events.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return true, fmt.Errorf("event %d: %s", p.ID, resp.Status)
+	}
+	return false, fmt.Errorf("event %d: %s", p.ID, resp.Status)
+}
+
+// record counts the outcome of a delivery on the webhook.
+func (d *delivery) record(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ctx := context.Background()
+	hook, _, getErr := d.events.webhooks.Get(ctx, d.hook.ID)
+	if getErr != nil {
+		return
+	}
+	if err != nil {
+		slog.Warn("Webhook delivery failed", "webhook", hook.ID, "url", hook.URL, "error", err)
+		hook.Failed++
+		hook.LastError = err.Error()
+	} else {
+		hook.Delivered++
+	}
+	d.events.webhooks.Update(ctx, hook.ID, hook, anyVersion)
+}
+
+// SignatureHeader carries the signature of a webhook delivery as
+// "t=<unix time>,v1=<hex HMAC-SHA256 of the time, a dot and the body>", keyed
+// with the webhook's secret.
+const SignatureHeader = "X-Webhook-Signature"
+
+func signature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// ErrBadSignature is returned by VerifySignature for deliveries that were
+// not signed with the secret, or signed longer ago than the tolerance.
+var ErrBadSignature = errors.New("bad webhook signature")
+
+// VerifySignature checks the SignatureHeader of a webhook delivery against
+// its body. Receivers should reject deliveries signed more than tolerance
+// ago, so that recorded deliveries cannot be replayed.
+func VerifySignature(secret, header string, body []byte, tolerance time.Duration) error {
+	var timestamp int64
+	var sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp, _ = strconv.ParseInt(value, 10, 64)
+		case "v1":
+			sig = value
+		}
+	}
+	if timestamp == 0 || sig == "" {
+		return ErrBadSignature
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: signed %s ago", ErrBadSignature, age.Round(time.Second))
+	}
+	if !hmac.Equal([]byte(signature(secret, timestamp, body)), []byte(fmt.Sprintf("t=%d,v1=%s", timestamp, sig))) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// getWebhooks lists the webhooks.
+//
+// @Summary List webhooks
+// @Description Returns the webhook subscriptions of this replica, without their secrets
+// @Tags events
+// @Success 200 {array} Webhook "Webhooks"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /webhooks [get]
+func (h *handler) getWebhooks(c *gin.Context) {
+	hooks, err := h.events.webhooks.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if hooks == nil {
+		hooks = []Webhook{}
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// getWebhookByID returns a single webhook.
+//
+// @Summary Get a webhook
+// @Description Returns a webhook subscription and its delivery counts, without its secret
+// @Tags events
+// @Param id path integer true "Webhook ID"
+// @Success 200 {object} Webhook "Webhook details"
+// @Failure 404 {object} map[string]string "Webhook not found"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /webhooks/{id} [get]
+func (h *handler) getWebhookByID(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	hook, _, err := h.events.webhooks.Get(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	hook.Secret = ""
+	c.JSON(http.StatusOK, hook)
+}
+
+// createWebhook subscribes a webhook.
+//
+// @Summary Subscribe a webhook
+// @Description Posts every later change to the URL, signed in the X-Webhook-Signature header. Failed deliveries are retried with exponential backoff. The response holds the secret, generated unless given; it is not returned again.
+// @Tags events
+// @Accept json
+// @Param webhook body Webhook true "Webhook to subscribe"
+// @Success 201 {object} Webhook "Webhook subscribed"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /webhooks [post]
+func (h *handler) createWebhook(c *gin.Context) {
+	var hook Webhook
+	if err := c.ShouldBindJSON(&hook); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for _, resource := range hook.Resources {
+		if !slices.Contains(eventResources, resource) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown resource %q, want one of %s", resource, strings.Join(eventResources, ", "))})
+			return
+		}
+	}
+	if hook.Secret == "" {
+		b := make([]byte, 16)
+		rand.Read(b)
+		hook.Secret = hex.EncodeToString(b)
+	}
+	hook.ID, hook.Delivered, hook.Failed, hook.LastError = 0, 0, 0, ""
+	hook.CreatedAt = time.Now().UTC()
+
+	e := h.events
+	e.mu.Lock()
+	created, err := e.webhooks.Create(c.Request.Context(), hook)
+	if err == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		d := &delivery{events: e, hook: created, queue: make(chan published, deliveryQueue), cancel: cancel, done: make(chan struct{})}
+		e.deliveries[created.ID] = d
+		go d.run(ctx)
+	}
+	e.mu.Unlock()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// deleteWebhook unsubscribes a webhook.
+//
+// @Summary Unsubscribe a webhook
+// @Description Stops the deliveries to a webhook, including retries in progress
+// @Tags events
+// @Param id path integer true "Webhook ID"
+// @Success 200 {object} map[string]string "Webhook deleted"
+// @Failure 404 {object} map[string]string "Webhook not found"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /webhooks/{id} [delete]
+func (h *handler) deleteWebhook(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	e := h.events
+	e.mu.Lock()
+	err := e.webhooks.Delete(c.Request.Context(), id, anyVersion)
+	d := e.deliveries[id]
+	delete(e.deliveries, id)
+	e.mu.Unlock()
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if d != nil {
+		d.cancel()
+		<-d.done
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"pet-store/api/pb"
+)
+
+// registerGRPCServices registers the gRPC services of h on srv.
+func registerGRPCServices(srv *grpc.Server, h *handler) {
+	pb.RegisterPetServiceServer(srv, petService{h: h})
+}
+
+// petService serves the pets over gRPC, as the /pets endpoints do.
+type petService struct {
+	pb.UnimplementedPetServiceServer
+	h *handler
+}
+
+func (s petService) ListPets(ctx context.Context, req *pb.ListPetsRequest) (*pb.ListPetsResponse, error) {
+	pets, err := s.h.store.List(ctx)
+	if err != nil {
+		return nil, rpcError(err, "Pet")
+	}
+	page, err := rpcPage(pets, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ListPetsResponse{Total: int32(len(pets))}
+	now := time.Now()
+	for _, pet := range page {
+		resp.Pets = append(resp.Pets, petToPB(asOf(pet, now)))
+	}
+	return resp, nil
+}
+
+func (s petService) GetPet(ctx context.Context, req *pb.GetPetRequest) (*pb.Pet, error) {
+	pet, _, err := s.h.store.Get(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, rpcError(err, "Pet")
+	}
+	return petToPB(asOf(pet, time.Now())), nil
+}
+
+func (s petService) CreatePet(ctx context.Context, req *pb.CreatePetRequest) (*pb.Pet, error) {
+	pet, err := petFromPB(req.GetPet())
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.h.store.Create(ctx, available(pet))
+	if err != nil {
+		return nil, rpcError(err, "Pet")
+	}
+	s.h.events.publish(EventCreated, "pets", created.ID, created)
+	return petToPB(created), nil
+}
+
+func (s petService) UpdatePet(ctx context.Context, req *pb.UpdatePetRequest) (*pb.Pet, error) {
+	pet, err := petFromPB(req.GetPet())
+	if err != nil {
+		return nil, err
+	}
+	id := int(req.GetId())
+	updated, _, err := editablePets{s.h.store}.Update(ctx, id, pet, anyVersion)
+	if err != nil {
+		return nil, rpcError(err, "Pet")
+	}
+	s.h.events.publish(EventUpdated, "pets", id, updated)
+	return petToPB(updated), nil
+}
+
+func (s petService) DeletePet(ctx context.Context, req *pb.DeletePetRequest) (*pb.DeletePetResponse, error) {
+	id := int(req.GetId())
+	if err := s.h.store.Delete(ctx, id, anyVersion); err != nil {
+		return nil, rpcError(err, "Pet")
+	}
+	s.h.events.publish(EventDeleted, "pets", id, nil)
+	return &pb.DeletePetResponse{}, nil
+}
+
+func petToPB(p Pet) *pb.Pet {
+	out := &pb.Pet{
+		Id:         int64(p.ID),
+		Name:       p.Name,
+		Type:       p.Type,
+		Age:        int32(p.Age),
+		Status:     string(p.Status),
+		ReservedBy: int64(p.ReservedBy),
+		AdoptedBy:  int64(p.AdoptedBy),
+	}
+	if p.ReservedUntil != nil {
+		out.ReservedUntil = timestamppb.New(*p.ReservedUntil)
+	}
+	return out
+}
+
+// petFromPB converts and validates a pet sent over gRPC. The adoption fields
+// are output only, so they are dropped.
+func petFromPB(p *pb.Pet) (Pet, error) {
+	pet := Pet{ID: int(p.GetId()), Name: p.GetName(), Type: p.GetType(), Age: int(p.GetAge())}
+	if err := validateRPC(pet); err != nil {
+		return pet, err
+	}
+	if pet.Age < 0 {
+		return pet, status.Error(codes.InvalidArgument, "age must not be negative")
+	}
+	return pet, nil
+}
@@ -0,0 +1,460 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pet is an animal available for adoption.
+type Pet struct {
+	ID   int    `json:"id" example:"1"`
+	Name string `json:"name" binding:"required" example:"Max"`
+	Type string `json:"type" example:"Dog"`
+	Age  int    `json:"age" binding:"min=0" minimum:"0" example:"3"`
+
+	// Status and the fields after it are set by the adoption endpoints.
+	Status        PetStatus  `json:"status" readonly:"true" enums:"available,reserved,adopted" example:"available"`
+	ReservedBy    int        `json:"reserved_by,omitempty" readonly:"true" example:"1"`
+	ReservedUntil *time.Time `json:"reserved_until,omitempty" readonly:"true"`
+	AdoptedBy     int        `json:"adopted_by,omitempty" readonly:"true" example:"1"`
+}
+
+// PetPatch documents the JSON Merge Patch for a pet: the members it
+// has replace those of the pet, and null removes them.
+type PetPatch struct {
+	Name string `json:"name,omitempty" nullable:"true" example:"Max"`
+	Type string `json:"type,omitempty" nullable:"true" example:"Dog"`
+	Age  int    `json:"age,omitempty" nullable:"true" minimum:"0" example:"3"`
+}
+
+// DefaultPets returns the built-in pets the service starts with.
+func DefaultPets() []Pet {
+	return []Pet{
+		{ID: 1, Name: "Max", Type: "Dog", Age: 3, Status: PetAvailable},
+		{ID: 2, Name: "Bella", Type: "Cat", Age: 2, Status: PetAvailable},
+		{ID: 3, Name: "Charlie", Type: "Dog", Age: 4, Status: PetAvailable},
+		{ID: 4, Name: "Lucy", Type: "Cat", Age: 1, Status: PetAvailable},
+		{ID: 5, Name: "Buddy", Type: "Dog", Age: 5, Status: PetAvailable},
+		{ID: 6, Name: "Luna", Type: "Cat", Age: 3, Status: PetAvailable},
+		{ID: 7, Name: "Rocky", Type: "Dog", Age: 2, Status: PetAvailable},
+		{ID: 8, Name: "Molly", Type: "Cat", Age: 4, Status: PetAvailable},
+		{ID: 9, Name: "Duke", Type: "Dog", Age: 3, Status: PetAvailable},
+		{ID: 10, Name: "Daisy", Type: "Cat", Age: 2, Status: PetAvailable},
+		{ID: 11, Name: "Bear", Type: "Dog", Age: 1, Status: PetAvailable},
+		{ID: 12, Name: "Lola", Type: "Cat", Age: 3, Status: PetAvailable},
+		{ID: 13, Name: "Jack", Type: "Dog", Age: 5, Status: PetAvailable},
+		{ID: 14, Name: "Zoe", Type: "Cat", Age: 1, Status: PetAvailable},
+		{ID: 15, Name: "Toby", Type: "Dog", Age: 4, Status: PetAvailable},
+	}
+}
+
+// Store persists the pets served by the API. Get, Update and Delete
+// return ErrNotFound for unknown IDs.
+type Store interface {
+	// List returns every pet in insertion order.
+	List(ctx context.Context) ([]Pet, error)
+
+	// Get returns a pet with its version, which changes whenever the
+	// pet does.
+	Get(ctx context.Context, id int) (Pet, int64, error)
+
+	// Create stores a pet, assigning an ID when it has none, and returns
+	// it as stored. It returns ErrConflict when the ID is taken.
+	Create(ctx context.Context, pet Pet) (Pet, error)
+
+	// Update replaces the pet with the given ID and returns it as stored
+	// with its new version. Update and Delete return ErrVersionMismatch
+	// unless ifVersion is anyVersion or the current version.
+	Update(ctx context.Context, id int, pet Pet, ifVersion int64) (Pet, int64, error)
+	Delete(ctx context.Context, id int, ifVersion int64) error
+
+	// Count, Import and Export back the admin fixture endpoints, as
+	// described by fixtureSet.
+	Count(ctx context.Context) (int, error)
+	Import(ctx context.Context, pets []Pet, replace bool) (int, error)
+	Export(ctx context.Context) ([]Pet, error)
+}
+
+// NewMemoryStore returns a Store that keeps pets in memory, starting with
+// a copy of pets.
+func NewMemoryStore(pets []Pet) Store {
+	return newMemoryStore(pets, func(p *Pet) *int { return &p.ID })
+}
+
+// LoadSeedFixtures applies the start-up dataset configured through the
+// environment to store.
+func LoadSeedFixtures(ctx context.Context, store Store) error {
+	return loadSeedFixtures(ctx, petFixtures{store})
+}
+
+// petSortFields are the fields GET /pets can be sorted by.
+var petSortFields = map[string]comparator[Pet]{
+	"id":   func(a, b Pet) int { return cmp.Compare(a.ID, b.ID) },
+	"name": func(a, b Pet) int { return strings.Compare(a.Name, b.Name) },
+	"type": func(a, b Pet) int { return strings.Compare(a.Type, b.Type) },
+	"age":  func(a, b Pet) int { return cmp.Compare(a.Age, b.Age) },
+}
+
+// getPets lists pets matching the filters, one page at a time.
+//
+// @Summary Get all pets
+// @Description Returns a list of all available pets
+// @Tags pets
+// @Param name query string false "Case-insensitive substring of the name"
+// @Param type query string false "Exact pet type, case-insensitive"
+// @Param status query string false "Adoption status" Enums(available,reserved,adopted)
+// @Param minAge query integer false "Minimum age (inclusive)"
+// @Param maxAge query integer false "Maximum age (inclusive)"
+// @Param sort query string false "Field to sort by; prefix with '-' for descending order" Enums(age,id,name,type,-age,-id,-name,-type)
+// @Param limit query integer false "Maximum number of items to return" default(100) minimum(1) maximum(1000)
+// @Param offset query integer false "Number of items to skip" default(0) minimum(0)
+// @Success 200 {array} Pet "List of pets"
+// @Header 200 {integer} X-Total-Count "Total number of items matching the filters"
+// @Header 200 {string} Link "RFC 8288 links to the first, prev, next and last pages"
+// @Failure 400 {object} map[string]string "Invalid query parameter"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /pets [get]
+func (h *handler) getPets(c *gin.Context) {
+	q, err := parseListQuery(c, sortFieldNames(petSortFields))
+	minAge, minErr := queryInt(c, "minAge")
+	maxAge, maxErr := queryInt(c, "maxAge")
+	if err := errors.Join(err, minErr, maxErr); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.ToLower(c.Query("name"))
+	petType := c.Query("type")
+	status := PetStatus(c.Query("status"))
+	now := time.Now()
+
+	pets, err := h.store.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	matches := make([]Pet, 0, len(pets))
+	for _, pet := range pets {
+		pet = asOf(pet, now)
+		if name != "" && !strings.Contains(strings.ToLower(pet.Name), name) {
+			continue
+		}
+		if petType != "" && !strings.EqualFold(pet.Type, petType) {
+			continue
+		}
+		if status != "" && pet.Status != status {
+			continue
+		}
+		if minAge != nil && pet.Age < *minAge {
+			continue
+		}
+		if maxAge != nil && pet.Age > *maxAge {
+			continue
+		}
+		matches = append(matches, pet)
+	}
+	c.JSON(http.StatusOK, sortAndPage(c, matches, q, petSortFields))
+}
+
+// getPetByID returns a single pet.
+//
+// @Summary Get pet by ID
+// @Description Returns a single pet
+// @Tags pets
+// @Param id path integer true "Pet ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} Pet "Pet details"
+// @Success 304 "Cached copy is current"
+// @Header 200,304 {string} ETag "Version of the pet"
+// @Failure 404 {object} map[string]string "Pet not found"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /pets/{id} [get]
+func (h *handler) getPetByID(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	pet, version, err := h.store.Get(c.Request.Context(), id)
+	if err != nil {
+		petError(c, err)
+		return
+	}
+	if notModified(c, version) {
+		return
+	}
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, asOf(pet, time.Now()))
+}
+
+// createPet adds a pet. Only new pets are put up for adoption: a body whose
+// id is taken gets 409, so a reserved or adopted pet changes status through
+// the adoption endpoints alone.
+//
+// @Summary Create a new pet
+// @Description Add a new pet, which starts out available. A pet without an id is assigned one; an id that is already taken is rejected; replace a record with PUT.
+// @Tags pets
+// @Accept json
+// @Param pet body Pet true "Pet to add"
+// @Success 201 {object} Pet "Pet created successfully"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 409 {object} map[string]string "Pet already exists"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /pets [post]
+func (h *handler) createPet(c *gin.Context) {
+	var newPet Pet
+	if err := c.ShouldBindJSON(&newPet); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	created, err := h.store.Create(c.Request.Context(), available(newPet))
+	if err != nil {
+		petError(c, err)
+		return
+	}
+	h.events.publish(EventCreated, "pets", created.ID, created)
+	c.JSON(http.StatusCreated, created)
+}
+
+// deletePet removes a pet.
+//
+// @Summary Delete a pet
+// @Description Remove a pet. With If-Match, only the given version is removed.
+// @Tags pets
+// @Param id path integer true "Pet ID"
+// @Param If-Match header string false "ETag the pet must have"
+// @Success 200 {object} map[string]string "Pet deleted"
+// @Failure 404 {object} map[string]string "Pet not found"
+// @Failure 412 {object} map[string]string "Pet has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /pets/{id} [delete]
+func (h *handler) deletePet(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c, h.currentVersion(c, id))
+	if !ok {
+		return
+	}
+	if err := h.store.Delete(c.Request.Context(), id, ifVersion); err != nil {
+		petError(c, err)
+		return
+	}
+	h.events.publish(EventDeleted, "pets", id, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Pet deleted"})
+}
+
+// updatePet replaces a pet.
+//
+// @Summary Update a pet
+// @Description Replace an existing pet; the id in the path takes precedence over the body and the adoption status is kept. With If-Match, only the given version is replaced.
+// @Tags pets
+// @Accept json
+// @Param id path integer true "Pet ID"
+// @Param If-Match header string false "ETag the pet must have"
+// @Param pet body Pet true "Updated pet"
+// @Success 200 {object} Pet "Pet updated"
+// @Header 200 {string} ETag "New version of the pet"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 404 {object} map[string]string "Pet not found"
+// @Failure 412 {object} map[string]string "Pet has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /pets/{id} [put]
+func (h *handler) updatePet(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var updatedPet Pet
+	if err := c.ShouldBindJSON(&updatedPet); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c, h.currentVersion(c, id))
+	if !ok {
+		return
+	}
+	updated, version, err := editablePets{h.store}.Update(c.Request.Context(), id, updatedPet, ifVersion)
+	if err != nil {
+		petError(c, err)
+		return
+	}
+	h.events.publish(EventUpdated, "pets", id, updated)
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// patchPet changes some fields of a pet.
+//
+// @Summary Patch a pet
+// @Description Change some fields of a pet with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902). The patched pet must be valid; the id in the path and the adoption status take precedence. With If-Match, only the given version is patched.
+// @ID patchPet
+// @Tags pets
+// @Accept merge-patch,json-patch
+// @Param id path integer true "Pet ID"
+// @Param If-Match header string false "ETag the pet must have"
+// @Param patch body PetPatch true "Merge patch or JSON Patch to apply" accept(merge-patch)
+// @Param operations body []PatchOperation true "Operations to apply" accept(json-patch)
+// @Success 200 {object} Pet "Pet updated"
+// @Header 200 {string} ETag "New version of the pet"
+// @Failure 400 {object} map[string]string "Malformed patch"
+// @Failure 404 {object} map[string]string "Pet not found"
+// @Failure 409 {object} map[string]string "Patch does not apply to the pet"
+// @Failure 412 {object} map[string]string "Pet has changed"
+// @Failure 415 {object} map[string]string "Unsupported patch format"
+// @Failure 422 {object} map[string]string "Patched pet is invalid"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /pets/{id} [patch]
+func (h *handler) patchPet(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	updated, version, ok := patchRecord(c, editablePets{h.store}, id, "Pet", petError)
+	if !ok {
+		return
+	}
+	h.events.publish(EventUpdated, "pets", id, updated)
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// petError maps a store error to a response.
+func petError(c *gin.Context, err error) {
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pet not found"})
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		preconditionFailed(c)
+		return
+	}
+	if errors.Is(err, ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Pet already exists"})
+		return
+	}
+	if errors.Is(err, ErrUnavailable) || errors.Is(err, ErrNotReserved) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ErrUnknownAdopter) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// currentVersion returns a lookup of the version of pet id, for
+// ifMatchVersion.
+func (h *handler) currentVersion(c *gin.Context, id int) func() (int64, error) {
+	return func() (int64, error) {
+		_, version, err := h.store.Get(c.Request.Context(), id)
+		return version, err
+	}
+}
+
+// petFixtures exposes the store to the fixture endpoints.
+type petFixtures struct {
+	Store
+}
+
+// Import stores records without a status as available.
+func (f petFixtures) Import(ctx context.Context, items []Pet, replace bool) (int, error) {
+	for i := range items {
+		items[i].Status = cmp.Or(items[i].Status, PetAvailable)
+	}
+	return f.Store.Import(ctx, items, replace)
+}
+
+var (
+	petNames = []string{"Max", "Bella", "Charlie", "Lucy", "Buddy", "Luna", "Rocky", "Molly", "Duke", "Daisy"}
+	petTypes = []string{"Dog", "Cat", "Rabbit", "Bird", "Hamster"}
+)
+
+func (petFixtures) Generate(start, n int) []Pet {
+	items := make([]Pet, n)
+	for i := range items {
+		items[i] = Pet{
+			Name:   fmt.Sprintf("%s %d", petNames[rand.IntN(len(petNames))], start+i+1),
+			Type:   petTypes[rand.IntN(len(petTypes))],
+			Age:    rand.IntN(15) + 1,
+			Status: PetAvailable,
+		}
+	}
+	return items
+}
+
+// exportPetFixtures writes every pet as JSON or CSV.
+//
+// @Summary Export fixtures
+// @Description Export every record as JSON or CSV
+// @ID exportFixtures
+// @Tags admin
+// @Produce json,csv
+// @Param format query string false "Export format" Enums(json,csv) default(json)
+// @Success 200 {array} Pet "All records"
+// @Failure 400 {object} map[string]string "Unsupported format"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /admin/fixtures [get]
+func (h *handler) exportPetFixtures(c *gin.Context) {
+	exportFixtures(c, petFixtures{h.store})
+}
+
+// importPetFixtures bulk-loads pets from the request body.
+//
+// @Summary Import fixtures
+// @Description Bulk-load records from a JSON array or CSV file. Records without an id are assigned one; records with an existing id overwrite it. Records are validated as on create and the first invalid one is rejected with its index. The body may be up to 64 MiB whatever the service's body limit.
+// @ID importFixtures
+// @Tags admin
+// @Accept json,csv
+// @Param mode query string false "append keeps existing records; replace removes them first" Enums(append,replace) default(append)
+// @Param pets body []Pet true "Records to import; CSV files need a header row naming the JSON fields"
+// @Success 200 {object} fixtureResult "Records stored"
+// @Failure 400 {object} map[string]string "Invalid records or mode"
+// @Failure 415 {object} map[string]string "Unsupported content type"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /admin/fixtures [post]
+func (h *handler) importPetFixtures(c *gin.Context) {
+	importFixtures(c, petFixtures{h.store})
+}
+
+// generatePetFixtures stores synthetic pets.
+//
+// @Summary Generate fixtures
+// @Description Store count synthetic records
+// @ID generateFixtures
+// @Tags admin
+// @Param count query integer true "Number of records to generate" minimum(1) maximum(100000)
+// @Param mode query string false "append keeps existing records; replace removes them first" Enums(append,replace) default(append)
+// @Success 200 {object} fixtureResult "Records stored"
+// @Failure 400 {object} map[string]string "Invalid count or mode"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /admin/fixtures/generate [post]
+func (h *handler) generatePetFixtures(c *gin.Context) {
+	generateFixtures(c, petFixtures{h.store})
+}
@@ -1,93 +1,1001 @@
-package main
+package tests
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"pet-store/api"
+	"pet-store/api/pb"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 )
 
-type Pet struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
-	Type string `json:"type"`
-	Age  int    `json:"age"`
-}
-
-var testPets = []Pet{
-	{1, "Max", "Dog", 3},
-	{2, "Bella", "Cat", 2},
-	{3, "Charlie", "Dog", 4},
-	{4, "Lucy", "Cat", 1},
-	{5, "Buddy", "Dog", 5},
-	{6, "Luna", "Cat", 3},
-	{7, "Rocky", "Dog", 2},
-	{8, "Molly", "Cat", 4},
-	{9, "Duke", "Dog", 3},
-	{10, "Daisy", "Cat", 2},
-	{11, "Bear", "Dog", 1},
-	{12, "Lola", "Cat", 3},
-	{13, "Jack", "Dog", 5},
-	{14, "Zoe", "Cat", 1},
-	{15, "Toby", "Dog", 4},
+// newRouter returns the service router over a fresh in-memory store.
+func newRouter(t *testing.T) (*gin.Engine, api.Store) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store := api.NewMemoryStore(api.DefaultPets())
+	return api.NewRouter(store, api.Config{ValidateRequests: true}), store
 }
 
-func TestGetPets(t *testing.T) {
-	r := setupRouter()
-
+func do(r http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/pets", nil)
 	r.ServeHTTP(w, req)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &v), w.Body.String())
+	return v
+}
+
+func TestGetPets(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "GET", "/pets", "")
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "Max")
 	assert.Contains(t, w.Body.String(), "Bella")
 	assert.Contains(t, w.Body.String(), "Charlie")
+	assert.Equal(t, "15", w.Header().Get("X-Total-Count"))
 }
 
 func TestGetPet(t *testing.T) {
-	r := setupRouter()
+	r, _ := newRouter(t)
 
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/pets/1", nil)
-	r.ServeHTTP(w, req)
+	w := do(r, "GET", "/pets/1", "")
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Contains(t, w.Body.String(), "Max")
+	assert.Equal(t, api.Pet{ID: 1, Name: "Max", Type: "Dog", Age: 3, Status: api.PetAvailable}, decode[api.Pet](t, w))
 }
 
 func TestGetNonExistentPet(t *testing.T) {
-	r := setupRouter()
+	r, _ := newRouter(t)
 
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/pets/99", nil)
-	r.ServeHTTP(w, req)
+	w := do(r, "GET", "/pets/99", "")
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	assert.Contains(t, w.Body.String(), "Pet not found")
 }
 
-func setupRouter() *gin.Engine {
+func TestCreatePetAssignsID(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "POST", "/pets", `{"name":"Rex","type":"Dog","age":2}`)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	created := decode[api.Pet](t, w)
+	assert.Equal(t, api.Pet{ID: 16, Name: "Rex", Type: "Dog", Age: 2, Status: api.PetAvailable}, created)
+
+	stored, _, err := store.Get(context.Background(), 16)
+	require.NoError(t, err)
+	assert.Equal(t, created, stored)
+}
+
+func TestCreatePetWithTakenIDIsRejected(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "POST", "/pets", `{"id":1,"name":"Maximus","type":"Dog","age":4}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	pets, err := store.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, pets, 15)
+	assert.Equal(t, api.DefaultPets()[0], pets[0], "replacing a record is left to PUT")
+
+	w = do(r, "POST", "/pets", `{"id":100,"name":"Maximus","type":"Dog","age":4}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, 100, decode[api.Pet](t, w).ID)
+}
+
+func TestCreatePetRejectsInvalidBody(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "POST", "/pets", `{"type":"Dog"}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	n, err := store.Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 15, n)
+}
+
+func TestUpdatePet(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "PUT", "/pets/3", `{"id":42,"name":"Charlie","type":"Dog","age":5}`)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.Pet{ID: 3, Name: "Charlie", Type: "Dog", Age: 5, Status: api.PetAvailable}, decode[api.Pet](t, w))
+	stored, _, err := store.Get(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stored.Age)
+}
+
+func TestUpdateNonExistentPet(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "PUT", "/pets/99", `{"name":"Ghost","type":"Cat","age":1}`)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	_, _, err := store.Get(context.Background(), 99)
+	assert.ErrorIs(t, err, api.ErrNotFound)
+}
+
+func TestDeletePet(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "DELETE", "/pets/2", "")
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	_, _, err := store.Get(context.Background(), 2)
+	assert.ErrorIs(t, err, api.ErrNotFound)
+	assert.Equal(t, http.StatusNotFound, do(r, "GET", "/pets/2", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(r, "DELETE", "/pets/2", "").Code)
+}
+
+// failingStore is a Store whose reads fail.
+type failingStore struct {
+	api.Store
+}
+
+var errStorage = errors.New("storage unavailable")
+
+func (failingStore) List(context.Context) ([]api.Pet, error) {
+	return nil, errStorage
+}
+
+func (failingStore) Get(context.Context, int) (api.Pet, int64, error) {
+	return api.Pet{}, 0, errStorage
+}
+
+func TestStoreErrorsAreReported(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	r := gin.Default()
+	r := api.NewRouter(failingStore{}, api.Config{ValidateRequests: true})
 
-	r.GET("/pets", func(c *gin.Context) {
-		c.JSON(http.StatusOK, testPets)
+	for _, path := range []string{"/pets", "/pets/1"} {
+		w := do(r, "GET", path, "")
+		assert.Equal(t, http.StatusInternalServerError, w.Code, path)
+		assert.Contains(t, w.Body.String(), errStorage.Error(), path)
+	}
+}
+
+func TestReadinessFollowsLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	readiness := &api.Readiness{}
+	r := api.NewRouter(api.NewMemoryStore(nil), api.Config{Readiness: readiness})
+
+	assert.Equal(t, http.StatusServiceUnavailable, do(r, "GET", "/readyz", "").Code)
+	readiness.SetWarmedUp()
+	assert.Equal(t, http.StatusOK, do(r, "GET", "/readyz", "").Code)
+	readiness.SetDraining()
+	assert.Equal(t, http.StatusServiceUnavailable, do(r, "GET", "/readyz", "").Code)
+	assert.Equal(t, http.StatusOK, do(r, "GET", "/livez", "").Code)
+}
+
+func TestRequestsAreLogged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultPets()), api.Config{
+		Logger: api.NewLogger(&logs, "info", "json"),
 	})
 
-	r.GET("/pets/:id", func(c *gin.Context) {
-		id := c.Param("id")
-		for _, pet := range testPets {
-			if fmt.Sprintf("%d", pet.ID) == id {
-				c.JSON(http.StatusOK, pet)
-				return
+	req := httptest.NewRequest("GET", "/pets/99", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "req-42", w.Header().Get("X-Request-ID"))
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record), logs.String())
+	assert.Equal(t, "request", record["msg"])
+	assert.Equal(t, "pet-store", record["service"])
+	assert.Equal(t, "req-42", record["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.Equal(t, "/pets/:id", record["route"])
+	assert.Equal(t, float64(404), record["status"])
+	assert.Contains(t, record, "latency_ms")
+
+	// Requests without an X-Request-ID get a generated one.
+	w = do(r, "GET", "/pets", "")
+	assert.Regexp(t, "^[0-9a-f]{32}$", w.Header().Get("X-Request-ID"))
+}
+
+// withAuth returns the service router requiring the given credentials.
+func withAuth(t *testing.T, auth api.Authenticator) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	return api.NewRouter(api.NewMemoryStore(api.DefaultPets()), api.Config{ValidateRequests: true, Auth: auth})
+}
+
+func doWithHeader(r http.Handler, method, path, body, header, value string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set(header, value)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeysNeedTheMethodScope(t *testing.T) {
+	r := withAuth(t, api.APIKeys{
+		"reader-key": {Subject: "reader", Scopes: []string{api.ScopeRead}},
+		"writer-key": {Subject: "writer", Scopes: []string{api.ScopeWrite}},
+	})
+	body := `{"name":"Rex","type":"Dog","age":2}`
+
+	w := do(r, "GET", "/pets", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusUnauthorized, doWithHeader(r, "GET", "/pets", "", "X-API-Key", "wrong").Code)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/pets", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "POST", "/pets", body, "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "POST", "/admin/fixtures/generate", "", "X-API-Key", "reader-key").Code)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/pets/1", "", "X-API-Key", "writer-key").Code)
+	assert.Equal(t, http.StatusCreated, doWithHeader(r, "POST", "/pets", body, "X-API-Key", "writer-key").Code)
+
+	// Health checks and docs stay open.
+	for _, path := range []string{"/livez", "/openapi.json", "/docs"} {
+		assert.Equal(t, http.StatusOK, do(r, "GET", path, "").Code, path)
+	}
+}
+
+func TestAPIKeysFromEnv(t *testing.T) {
+	keys := filepath.Join(t.TempDir(), "api-keys")
+	require.NoError(t, os.WriteFile(keys, []byte("# ci keys\nreader-key read ci-reader\n\nwriter-key read write\n"), 0o600))
+	t.Setenv("AUTH_API_KEYS_FILE", keys)
+
+	auth, err := api.AuthFromEnv()
+	require.NoError(t, err)
+	r := withAuth(t, auth)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/pets", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "DELETE", "/pets/1", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/pets/1", "", "X-API-Key", "writer-key").Code)
+
+	t.Setenv("AUTH_API_KEYS_FILE", "")
+	auth, err = api.AuthFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, auth, "no configuration leaves the API open")
+}
+
+func TestBearerTokensAreVerifiedAgainstJWKS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"EC","crv":"P-256","kid":"test","use":"sig","x":%q,"y":%q}]}`,
+		base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))))
+	auth, err := api.NewJWTAuth([]byte(jwks), "https://issuer.test", "pet-store")
+	require.NoError(t, err)
+	r := withAuth(t, auth)
+
+	sign := func(claims map[string]any) string {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","kid":"test","typ":"JWT"}`))
+		payload, err := json.Marshal(claims)
+		require.NoError(t, err)
+		input := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(input))
+		sr, ss, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		require.NoError(t, err)
+		sig := append(sr.FillBytes(make([]byte, 32)), ss.FillBytes(make([]byte, 32))...)
+		return "Bearer " + input + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+	claims := func(scope, aud string, exp time.Duration) map[string]any {
+		return map[string]any{
+			"sub":   "client-1",
+			"iss":   "https://issuer.test",
+			"aud":   aud,
+			"exp":   time.Now().Add(exp).Unix(),
+			"scope": scope,
+		}
+	}
+
+	reader := sign(claims("read", "pet-store", time.Hour))
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/pets", "", "Authorization", reader).Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "DELETE", "/pets/1", "", "Authorization", reader).Code)
+
+	writer := sign(claims("read write", "pet-store", time.Hour))
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/pets/1", "", "Authorization", writer).Code)
+
+	for name, token := range map[string]string{
+		"expired":        sign(claims("read", "pet-store", -time.Hour)),
+		"other audience": sign(claims("read", "coffee-shop", time.Hour)),
+		"tampered":       reader[:len(reader)-4] + "AAAA",
+	} {
+		w := doWithHeader(r, "GET", "/pets", "", "Authorization", token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+	}
+}
+
+func TestRateLimitPerClientIPAndCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultPets()), api.Config{
+		Auth:   api.APIKeys{"shared-key": {Subject: "load-test", Scopes: []string{api.ScopeRead}}},
+		Limits: api.Limits{RequestsPerSecond: 1, Burst: 2},
+	})
+	from := func(ip, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = ip + ":40000"
+		req.Header.Set("X-API-Key", "shared-key")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/pets").Code)
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/pets/1").Code)
+	w := from("10.0.0.1", "/pets")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// Probes are never limited.
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/livez").Code)
+
+	// A new IP gets its own bucket, but the key it shares is spent.
+	assert.Equal(t, http.StatusTooManyRequests, from("10.0.0.2", "/pets").Code)
+}
+
+func TestForwardedForIsOnlyTrustedFromProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(proxies ...string) http.Handler {
+		return api.NewRouter(api.NewMemoryStore(api.DefaultPets()), api.Config{
+			Limits: api.Limits{RequestsPerSecond: 1, Burst: 1, TrustedProxies: proxies},
+		})
+	}
+	get := func(r http.Handler, peer, forwardedFor string) int {
+		req := httptest.NewRequest("GET", "/pets/1", nil)
+		req.RemoteAddr = peer + ":40000"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// By default a client cannot leave its bucket by naming another address.
+	r := newRouter()
+	assert.Equal(t, http.StatusOK, get(r, "203.0.113.7", "10.0.0.1"))
+	assert.Equal(t, http.StatusTooManyRequests, get(r, "203.0.113.7", "10.0.0.2"))
+
+	// Behind a trusted proxy, each forwarded client has its own bucket.
+	r = newRouter("192.0.2.0/24")
+	assert.Equal(t, http.StatusOK, get(r, "192.0.2.10", "10.0.0.1"))
+	assert.Equal(t, http.StatusOK, get(r, "192.0.2.10", "10.0.0.2"))
+	assert.Equal(t, http.StatusTooManyRequests, get(r, "192.0.2.10", "10.0.0.1"))
+}
+
+func TestLargeBodiesAreRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultPets()), api.Config{
+		ValidateRequests: true,
+		Limits:           api.Limits{MaxBodyBytes: 64},
+	})
+
+	w := do(r, "POST", "/pets", `{"name":"`+strings.Repeat("x", 64)+`","type":"Dog","age":2}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	req := httptest.NewRequest("POST", "/pets", io.MultiReader(strings.NewReader(`{"name":"`+strings.Repeat("x", 64)), strings.NewReader(`","type":"Dog","age":2}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, "bodies without a Content-Length are capped too")
+
+	assert.Equal(t, http.StatusCreated, do(r, "POST", "/pets", `{"name":"Rex","type":"Dog","age":2}`).Code)
+
+	w = do(r, "POST", "/admin/fixtures", `[{"name":"`+strings.Repeat("x", 64)+`","type":"Dog","age":2}]`)
+	assert.Equal(t, http.StatusOK, w.Code, "fixture imports are not held to the cap: %s", w.Body.String())
+}
+
+func TestFixtureImportsAreValidated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Seed files never reach the OpenAPI validator, so the import has to
+	// check the records itself.
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultPets()), api.Config{})
+
+	w := do(r, "POST", "/admin/fixtures", `[{"name":"Rex","type":"Dog","age":2},{"name":"","type":"Cat","age":1}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "record 1")
+
+	w = doWithHeader(r, "POST", "/admin/fixtures", "name,type,age\nRex,Dog,2\nTom,Cat,-1\n", "Content-Type", "text/csv")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "record 1")
+
+	stored := decode[[]api.Pet](t, do(r, "GET", "/admin/fixtures", ""))
+	assert.Len(t, stored, len(api.DefaultPets()), "nothing is stored from a rejected import")
+}
+
+func TestConditionalRequestsUseETags(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "GET", "/pets/3", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	tag := w.Header().Get("ETag")
+	require.NotEmpty(t, tag)
+
+	w = doWithHeader(r, "GET", "/pets/3", "", "If-None-Match", tag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, tag, w.Header().Get("ETag"))
+
+	w = doWithHeader(r, "PUT", "/pets/3", `{"name":"Charlie","type":"Dog","age":5}`, "If-Match", tag)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	updated := w.Header().Get("ETag")
+	assert.NotEqual(t, tag, updated)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/pets/3", "", "If-None-Match", tag).Code)
+
+	// A client still holding the old version loses the race.
+	w = doWithHeader(r, "PUT", "/pets/3", `{"name":"Charlie","type":"Dog","age":6}`, "If-Match", tag)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, http.StatusPreconditionFailed, doWithHeader(r, "DELETE", "/pets/3", "", "If-Match", tag).Code)
+	assert.Equal(t, http.StatusPreconditionFailed, doWithHeader(r, "DELETE", "/pets/3", "", "If-Match", "W/"+updated).Code)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/pets/3", "", "If-Match", tag+", "+updated).Code)
+	assert.Equal(t, http.StatusNotFound, doWithHeader(r, "DELETE", "/pets/3", "", "If-Match", updated).Code)
+}
+
+func TestPatchPet(t *testing.T) {
+	r, store := newRouter(t)
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		return doWithHeader(r, "PATCH", "/pets/1", body, "Content-Type", contentType)
+	}
+
+	w := patch("application/merge-patch+json", `{"age":4}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.Pet{ID: 1, Name: "Max", Type: "Dog", Age: 4, Status: api.PetAvailable}, decode[api.Pet](t, w))
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+
+	w = patch("application/json-patch+json", `[{"op":"test","path":"/name","value":"Max"},{"op":"replace","path":"/name","value":"Rex"}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	stored, _, err := store.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, api.Pet{ID: 1, Name: "Rex", Type: "Dog", Age: 4, Status: api.PetAvailable}, stored)
+
+	// The patched pet is validated, and nothing is stored when it fails.
+	assert.Equal(t, http.StatusUnprocessableEntity, patch("application/merge-patch+json", `{"name":null}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("application/merge-patch+json", `{"age":-1}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, patch("application/json-patch+json", `[{"op":"add","path":"/color","value":"brown"}]`).Code)
+	assert.Equal(t, http.StatusConflict, patch("application/json-patch+json", `[{"op":"test","path":"/name","value":"Max"}]`).Code)
+	assert.Equal(t, http.StatusConflict, patch("application/json-patch+json", `[{"op":"remove","path":"/color"}]`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("application/json-patch+json", `[{"op":"replace","path":"name","value":"Buddy"}]`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("application/json", `{"age":1}`).Code)
+	stored, _, err = store.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, api.Pet{ID: 1, Name: "Rex", Type: "Dog", Age: 4, Status: api.PetAvailable}, stored)
+
+	assert.Equal(t, http.StatusNotFound, doWithHeader(r, "PATCH", "/pets/99", `{"age":1}`, "Content-Type", "application/merge-patch+json").Code)
+}
+
+func TestPatchPetHonoursIfMatch(t *testing.T) {
+	r, _ := newRouter(t)
+	tag := do(r, "GET", "/pets/1", "").Header().Get("ETag")
+	require.Equal(t, http.StatusOK, do(r, "PUT", "/pets/1", `{"name":"Max","type":"Dog","age":5}`).Code)
+
+	req := httptest.NewRequest("PATCH", "/pets/1", strings.NewReader(`{"age":6}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", tag)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, 5, decode[api.Pet](t, do(r, "GET", "/pets/1", "")).Age)
+}
+
+// newAdoptionRouter returns the service router with two adopters and the
+// given reservation TTL.
+func newAdoptionRouter(t *testing.T, ttl time.Duration) (*gin.Engine, api.Store) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store := api.NewMemoryStore(api.DefaultPets())
+	adopters := api.NewAdopterStore([]api.Adopter{
+		{ID: 1, Name: "Sam Rivera", Email: "sam@example.com"},
+		{ID: 2, Name: "Alex Kim", Email: "alex@example.com"},
+	})
+	return api.NewRouter(store, api.Config{ValidateRequests: true, Adopters: adopters, ReservationTTL: ttl}), store
+}
+
+func TestAdoptionWorkflow(t *testing.T) {
+	r, _ := newAdoptionRouter(t, time.Hour)
+
+	w := do(r, "POST", "/pets/1/reserve", `{"adopter_id":1}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	reserved := decode[api.Pet](t, w)
+	assert.Equal(t, api.PetReserved, reserved.Status)
+	assert.Equal(t, 1, reserved.ReservedBy)
+	require.NotNil(t, reserved.ReservedUntil)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *reserved.ReservedUntil, time.Minute)
+
+	// Only the adopter holding the reservation can adopt.
+	assert.Equal(t, http.StatusConflict, do(r, "POST", "/pets/1/reserve", `{"adopter_id":2}`).Code)
+	assert.Equal(t, http.StatusConflict, do(r, "POST", "/pets/1/adopt", `{"adopter_id":2}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(r, "POST", "/pets/2/reserve", `{"adopter_id":9}`).Code)
+	assert.Equal(t, http.StatusNotFound, do(r, "POST", "/pets/99/reserve", `{"adopter_id":1}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(r, "POST", "/pets/1/adopt", `{}`).Code)
+
+	w = do(r, "POST", "/pets/1/adopt", `{"adopter_id":1}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.Pet{ID: 1, Name: "Max", Type: "Dog", Age: 3, Status: api.PetAdopted, AdoptedBy: 1}, decode[api.Pet](t, w))
+	assert.Equal(t, http.StatusConflict, do(r, "POST", "/pets/1/release", "").Code)
+	assert.Equal(t, http.StatusConflict, do(r, "POST", "/pets/1/reserve", `{"adopter_id":1}`).Code)
+
+	require.Equal(t, http.StatusOK, do(r, "POST", "/pets/2/reserve", `{"adopter_id":2}`).Code)
+	w = do(r, "POST", "/pets/2/release", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.Pet{ID: 2, Name: "Bella", Type: "Cat", Age: 2, Status: api.PetAvailable}, decode[api.Pet](t, w))
+	assert.Equal(t, http.StatusConflict, do(r, "POST", "/pets/2/release", "").Code)
+
+	// Creates cannot put an adopted or reserved pet back up for adoption.
+	require.Equal(t, http.StatusOK, do(r, "POST", "/pets/2/reserve", `{"adopter_id":2}`).Code)
+	assert.Equal(t, http.StatusConflict, do(r, "POST", "/pets", `{"id":1,"name":"Max","type":"Dog","age":3}`).Code)
+	assert.Equal(t, http.StatusConflict, do(r, "POST", "/pets", `{"id":2,"name":"Bella","type":"Cat","age":2}`).Code)
+	assert.Equal(t, http.StatusConflict, do(r, "POST", "/pets/2/reserve", `{"adopter_id":1}`).Code, "the reservation is kept")
+	require.Equal(t, http.StatusOK, do(r, "POST", "/pets/2/release", "").Code)
+
+	// Edits keep the adoption status.
+	w = do(r, "PUT", "/pets/1", `{"name":"Maximus","type":"Dog","age":4}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.PetAdopted, decode[api.Pet](t, w).Status)
+
+	w = do(r, "GET", "/pets?status=adopted", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, "14", do(r, "GET", "/pets?status=available", "").Header().Get("X-Total-Count"))
+}
+
+func TestAdopters(t *testing.T) {
+	r, _ := newAdoptionRouter(t, 0)
+
+	w := do(r, "POST", "/adopters", `{"name":"Jo Park","email":"jo@example.com"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, api.Adopter{ID: 3, Name: "Jo Park", Email: "jo@example.com"}, decode[api.Adopter](t, w))
+	assert.Len(t, decode[[]api.Adopter](t, do(r, "GET", "/adopters", "")), 3)
+	assert.Equal(t, http.StatusOK, do(r, "GET", "/adopters/3", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(r, "GET", "/adopters/9", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(r, "POST", "/adopters", `{"name":"Jo Park"}`).Code)
+}
+
+func TestExpiredReservationsAreReleased(t *testing.T) {
+	r, store := newAdoptionRouter(t, 50*time.Millisecond)
+	require.Equal(t, http.StatusOK, do(r, "POST", "/pets/1/reserve", `{"adopter_id":1}`).Code)
+	require.Equal(t, http.StatusOK, do(r, "POST", "/pets/2/reserve", `{"adopter_id":1}`).Code)
+	require.Equal(t, http.StatusOK, do(r, "POST", "/pets/2/adopt", `{"adopter_id":1}`).Code)
+
+	n, err := api.ReleaseExpired(context.Background(), store, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n, "reservations last for the TTL")
+
+	time.Sleep(60 * time.Millisecond)
+	// An expired reservation no longer holds the pet, even before it is
+	// swept.
+	assert.Equal(t, api.PetAvailable, decode[api.Pet](t, do(r, "GET", "/pets/1", "")).Status)
+
+	stop := api.StartSweeper(store, 10*time.Millisecond)
+	defer stop()
+	require.Eventually(t, func() bool {
+		pet, _, err := store.Get(context.Background(), 1)
+		return err == nil && pet.Status == api.PetAvailable && pet.ReservedUntil == nil
+	}, time.Second, 10*time.Millisecond)
+	adopted, _, err := store.Get(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, api.PetAdopted, adopted.Status)
+
+	require.Equal(t, http.StatusOK, do(r, "POST", "/pets/1/reserve", `{"adopter_id":2}`).Code)
+}
+
+func TestStoppingTheSweeperWaitsForIt(t *testing.T) {
+	stop := api.StartSweeper(api.NewMemoryStore(api.DefaultPets()), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stop did not return")
+	}
+}
+
+// newEventsServer serves the router over HTTP, so that event streams can be
+// read while other requests change the pets.
+func newEventsServer(t *testing.T, opts api.EventOptions) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultPets()), api.Config{ValidateRequests: true, Events: opts})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func send(t *testing.T, srv *httptest.Server, method, path, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// sseEvent is an event as read off a stream.
+type sseEvent struct {
+	id, name string
+	data     api.Event
+}
+
+// readEvents reads n events off a stream, skipping comments.
+func readEvents(t *testing.T, lines *bufio.Scanner, n int) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var ev sseEvent
+	for len(events) < n && lines.Scan() {
+		line := lines.Text()
+		switch {
+		case line == "":
+			if ev.name != "" {
+				events = append(events, ev)
 			}
+			ev = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev.data))
 		}
-		c.JSON(http.StatusNotFound, gin.H{"error": "Pet not found"})
+	}
+	require.Len(t, events, n, "stream ended early: %v", lines.Err())
+	return events
+}
+
+func TestEventStream(t *testing.T) {
+	srv := newEventsServer(t, api.EventOptions{Heartbeat: 10 * time.Millisecond})
+
+	resp := send(t, srv, "GET", "/events?resource=pets", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	lines := bufio.NewScanner(resp.Body)
+
+	require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/adopters", `{"name":"Sam Rivera","email":"sam@example.com"}`).StatusCode)
+	require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/pets", `{"name":"Rex","type":"Dog","age":2}`).StatusCode)
+	require.Equal(t, http.StatusOK, send(t, srv, "POST", "/pets/1/reserve", `{"adopter_id":1}`).StatusCode)
+	require.Equal(t, http.StatusOK, send(t, srv, "DELETE", "/pets/1", "").StatusCode)
+
+	// The adopter is filtered out, and heartbeats between events are skipped.
+	events := readEvents(t, lines, 3)
+	assert.Equal(t, []string{"created", "updated", "deleted"}, []string{events[0].name, events[1].name, events[2].name})
+	assert.Equal(t, []string{"2", "3", "4"}, []string{events[0].id, events[1].id, events[2].id})
+	assert.Equal(t, "pets", events[0].data.Resource)
+	assert.Equal(t, "Rex", events[0].data.Data.(map[string]any)["name"])
+	assert.Equal(t, 1, events[1].data.ResourceID)
+	assert.Equal(t, "reserved", events[1].data.Data.(map[string]any)["status"])
+	assert.Nil(t, events[2].data.Data)
+
+	// A client that reconnects gets the events it missed, then the stream
+	// ends when it does not follow.
+	req, err := http.NewRequest("GET", srv.URL+"/events?follow=false", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "2")
+	resp, err = srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resumed := readEvents(t, bufio.NewScanner(bytes.NewReader(body)), 2)
+	assert.Equal(t, []string{"3", "4"}, []string{resumed[0].id, resumed[1].id})
+}
+
+func TestEventStreamResetsWhenEventsWereDropped(t *testing.T) {
+	srv := newEventsServer(t, api.EventOptions{History: 2})
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/pets", `{"name":"Rex","type":"Dog","age":2}`).StatusCode)
+	}
+
+	body, err := io.ReadAll(send(t, srv, "GET", "/events?last_event_id=0&follow=false", "").Body)
+	require.NoError(t, err)
+	events := readEvents(t, bufio.NewScanner(bytes.NewReader(body)), 3)
+	assert.Equal(t, "reset", events[0].name, "the first event was dropped from the history")
+	assert.Equal(t, []string{"2", "3"}, []string{events[1].id, events[2].id})
+
+	// So are IDs from before a restart.
+	body, err = io.ReadAll(send(t, srv, "GET", "/events?last_event_id=99&follow=false", "").Body)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(body), "event: reset\n"), string(body))
+
+	body, err = io.ReadAll(send(t, srv, "GET", "/events?last_event_id=3&follow=false", "").Body)
+	require.NoError(t, err)
+	assert.Empty(t, string(body))
+}
+
+func TestWebhookDeliveriesAreSignedAndRetried(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+	var delivered []api.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		if err := api.VerifySignature("s3cret", r.Header.Get(api.SignatureHeader), body, time.Minute); err != nil {
+			t.Errorf("delivery not signed: %v", err)
+		}
+		if err := api.VerifySignature("other", r.Header.Get(api.SignatureHeader), body, time.Minute); err == nil {
+			t.Error("signature verified with the wrong secret")
+		}
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event api.Event
+		json.Unmarshal(body, &event)
+		if event.Resource == "adopters" {
+			// Not worth retrying.
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		assert.Equal(t, r.Header.Get("X-Event-ID"), strconv.FormatInt(event.ID, 10))
+		delivered = append(delivered, event)
+	}))
+	defer receiver.Close()
+
+	srv := newEventsServer(t, api.EventOptions{WebhookBackoff: time.Millisecond})
+	resp := send(t, srv, "POST", "/webhooks", fmt.Sprintf(`{"url":%q,"secret":"s3cret"}`, receiver.URL))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var hook api.Webhook
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&hook))
+	assert.Equal(t, "s3cret", hook.Secret)
+
+	require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/pets", `{"name":"Rex","type":"Dog","age":2}`).StatusCode)
+	require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/adopters", `{"name":"Sam Rivera","email":"sam@example.com"}`).StatusCode)
+
+	require.Eventually(t, func() bool {
+		var got api.Webhook
+		json.NewDecoder(send(t, srv, "GET", fmt.Sprintf("/webhooks/%d", hook.ID), "").Body).Decode(&got)
+		return got.Delivered == 1 && got.Failed == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, 3, attempts)
+	require.Len(t, delivered, 1)
+	assert.Equal(t, "pets", delivered[0].Resource)
+	mu.Unlock()
+
+	var listed []api.Webhook
+	require.NoError(t, json.NewDecoder(send(t, srv, "GET", "/webhooks", "").Body).Decode(&listed))
+	require.Len(t, listed, 1)
+	assert.Empty(t, listed[0].Secret, "the secret was returned after the webhook was created")
+	assert.Contains(t, listed[0].LastError, "410")
+
+	require.Equal(t, http.StatusOK, send(t, srv, "DELETE", fmt.Sprintf("/webhooks/%d", hook.ID), "").StatusCode)
+	assert.Equal(t, http.StatusNotFound, send(t, srv, "GET", fmt.Sprintf("/webhooks/%d", hook.ID), "").StatusCode)
+	assert.Equal(t, http.StatusBadRequest, send(t, srv, "POST", "/webhooks", `{"url":"https://hooks.example.com","resources":["cats"]}`).StatusCode)
+}
+
+func TestVerifySignatureRejectsOldDeliveries(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	old := time.Now().Add(-time.Hour).Unix()
+	fmt.Fprintf(mac, "%d.", old)
+	mac.Write(body)
+	header := fmt.Sprintf("t=%d,v1=%x", old, mac.Sum(nil))
+
+	assert.NoError(t, api.VerifySignature("s3cret", header, body, 2*time.Hour))
+	assert.ErrorIs(t, api.VerifySignature("s3cret", header, body, time.Minute), api.ErrBadSignature)
+	assert.ErrorIs(t, api.VerifySignature("s3cret", header, []byte(`{"id":2}`), 2*time.Hour), api.ErrBadSignature)
+	assert.ErrorIs(t, api.VerifySignature("s3cret", "", body, time.Minute), api.ErrBadSignature)
+}
+
+// newGRPCClient serves the gRPC server of NewServers over an in-memory
+// listener and returns a connection to it, with the router next to it.
+func newGRPCClient(t *testing.T, cfg api.Config) (*gin.Engine, *grpc.ClientConn) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r, srv := api.NewServers(api.NewMemoryStore(api.DefaultPets()), cfg)
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return r, conn
+}
+
+func TestGRPCPets(t *testing.T) {
+	r, conn := newGRPCClient(t, api.Config{Adopters: api.NewAdopterStore([]api.Adopter{{ID: 1, Name: "Sam Rivera", Email: "sam@example.com"}})})
+	client := pb.NewPetServiceClient(conn)
+	ctx := context.Background()
+
+	list, err := client.ListPets(ctx, &pb.ListPetsRequest{Limit: 5, Offset: 10})
+	require.NoError(t, err)
+	assert.EqualValues(t, 15, list.Total)
+	require.Len(t, list.Pets, 5)
+	assert.Equal(t, "Bear", list.Pets[0].Name)
+
+	pet, err := client.GetPet(ctx, &pb.GetPetRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Max", pet.Name)
+	assert.Equal(t, "available", pet.Status)
+
+	created, err := client.CreatePet(ctx, &pb.CreatePetRequest{Pet: &pb.Pet{Name: "Milo", Type: "Cat", Age: 2, Status: "adopted"}})
+	require.NoError(t, err)
+	assert.EqualValues(t, 16, created.Id)
+	assert.Equal(t, "available", created.Status)
+	// Both APIs serve the same store.
+	assert.Equal(t, "Milo", decode[api.Pet](t, do(r, "GET", "/pets/16", "")).Name)
+
+	// Updates keep the reservation made through the REST API.
+	require.Equal(t, http.StatusOK, do(r, "POST", "/pets/16/reserve", `{"adopter_id":1}`).Code)
+	updated, err := client.UpdatePet(ctx, &pb.UpdatePetRequest{Id: 16, Pet: &pb.Pet{Name: "Milo", Type: "Cat", Age: 3}})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, updated.Age)
+	assert.Equal(t, "reserved", updated.Status)
+	assert.EqualValues(t, 1, updated.ReservedBy)
+	assert.NotNil(t, updated.ReservedUntil)
+
+	_, err = client.DeletePet(ctx, &pb.DeletePetRequest{Id: 16})
+	require.NoError(t, err)
+	_, err = client.GetPet(ctx, &pb.GetPetRequest{Id: 16})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CreatePet(ctx, &pb.CreatePetRequest{Pet: &pb.Pet{Type: "Cat"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.CreatePet(ctx, &pb.CreatePetRequest{Pet: &pb.Pet{Name: "Milo", Age: -1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.ListPets(ctx, &pb.ListPetsRequest{Limit: 1001})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCHealthFollowsReadiness(t *testing.T) {
+	readiness := &api.Readiness{}
+	_, conn := newGRPCClient(t, api.Config{Readiness: readiness})
+	health := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("liveness"))
+
+	readiness.SetWarmedUp()
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("petstore.v1.PetService"))
+
+	readiness.SetDraining()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("liveness"))
+
+	_, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCReflectionListsTheServices(t *testing.T) {
+	_, conn := newGRPCClient(t, api.Config{})
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	var names []string
+	for _, s := range resp.GetListServicesResponse().Service {
+		names = append(names, s.Name)
+	}
+	assert.Contains(t, names, "petstore.v1.PetService")
+	assert.Contains(t, names, "grpc.health.v1.Health")
+}
+
+func TestGRPCCallsNeedTheMethodScope(t *testing.T) {
+	_, conn := newGRPCClient(t, api.Config{Auth: api.APIKeys{
+		"reader-key": {Subject: "reader", Scopes: []string{api.ScopeRead}},
+		"writer-key": {Subject: "writer", Scopes: []string{api.ScopeWrite}},
+	}})
+	client := pb.NewPetServiceClient(conn)
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+	}
+	create := &pb.CreatePetRequest{Pet: &pb.Pet{Name: "Milo", Type: "Cat", Age: 2}}
+
+	_, err := client.GetPet(context.Background(), &pb.GetPetRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.GetPet(withKey("wrong"), &pb.GetPetRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.GetPet(withKey("reader-key"), &pb.GetPetRequest{Id: 1})
+	assert.NoError(t, err)
+	_, err = client.CreatePet(withKey("reader-key"), create)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.CreatePet(withKey("writer-key"), create)
+	assert.NoError(t, err)
+
+	// Health checks stay open.
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "liveness"})
+	assert.NoError(t, err)
+}
+
+func TestGRPCChangesArePublished(t *testing.T) {
+	r, conn := newGRPCClient(t, api.Config{})
+	_, err := pb.NewPetServiceClient(conn).DeletePet(context.Background(), &pb.DeletePetRequest{Id: 2})
+	require.NoError(t, err)
+
+	w := do(r, "GET", "/events?last_event_id=0&follow=false", "")
+	events := readEvents(t, bufio.NewScanner(w.Body), 1)
+	assert.Equal(t, "deleted", events[0].name)
+	assert.Equal(t, 2, events[0].data.ResourceID)
+}
+
+func TestChangesArePublishedOnTheBus(t *testing.T) {
+	bus := api.NewLocalBus()
+	defer bus.Close()
+	received := make(chan api.Event, 1)
+	_, err := bus.Subscribe("pet-store.pets.*", func(subject string, data []byte) {
+		var event api.Event
+		assert.Equal(t, "pet-store.pets.deleted", subject)
+		assert.NoError(t, json.Unmarshal(data, &event))
+		received <- event
 	})
+	require.NoError(t, err)
+	_, conn := newGRPCClient(t, api.Config{Bus: bus})
+	_, err = pb.NewPetServiceClient(conn).DeletePet(context.Background(), &pb.DeletePetRequest{Id: 2})
+	require.NoError(t, err)
 
-	return r
+	select {
+	case event := <-received:
+		assert.Equal(t, "deleted", event.Type)
+		assert.Equal(t, 2, event.ResourceID)
+	case <-time.After(time.Second):
+		t.Fatal("no event on the bus")
+	}
 }
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// coffeeEvents is the subject pattern of the coffee-shop's coffee events.
+var coffeeEvents = EventSubject("coffee-shop", "coffees", ">")
+
+// CoffeePriceCache is a CoffeeCatalog that remembers the coffees looked up
+// in another one and keeps them current from the coffee-shop's events on a
+// bus, so that orders are priced without calling the coffee-shop each time
+// and follow its price changes as they happen. Entries also expire after a
+// TTL, as events published while the restaurant is disconnected from the
+// bus are lost.
+type CoffeePriceCache struct {
+	next CoffeeCatalog
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	seq     uint64
+	coffees map[int]cachedCoffee
+}
+
+// cachedCoffee is a coffee as last looked up or published. Deleted coffees
+// are kept until they expire, so that lookups of them fail without a call.
+type cachedCoffee struct {
+	coffee  Coffee
+	deleted bool
+	seq     uint64
+	expires time.Time
+}
+
+// NewCoffeePriceCache returns a cache of the coffees of next that keeps
+// them for ttl, 5 minutes if it is not positive. It follows no events until
+// Subscribe is called.
+func NewCoffeePriceCache(next CoffeeCatalog, ttl time.Duration) *CoffeePriceCache {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &CoffeePriceCache{next: next, ttl: ttl, now: time.Now, coffees: make(map[int]cachedCoffee)}
+}
+
+// CoffeePricesFromEnv returns a CoffeePriceCache of next that follows the
+// coffee events on bus, keeping coffees for COFFEE_PRICES_TTL_SECONDS
+// (default 300). Without a bus to keep it current, it returns next itself.
+func CoffeePricesFromEnv(next CoffeeCatalog, bus Bus) (CoffeeCatalog, error) {
+	if next == nil || bus == nil {
+		return next, nil
+	}
+	c := NewCoffeePriceCache(next, envSeconds("COFFEE_PRICES_TTL_SECONDS", 0))
+	if _, err := c.Subscribe(bus); err != nil {
+		return nil, fmt.Errorf("subscribing to %s: %w", coffeeEvents, err)
+	}
+	return c, nil
+}
+
+// Subscribe follows the coffee-shop's coffee events on bus until
+// unsubscribe is called.
+func (c *CoffeePriceCache) Subscribe(bus Bus) (unsubscribe func(), err error) {
+	return bus.Subscribe(coffeeEvents, c.apply)
+}
+
+// Coffee returns the cached coffee with the given ID, looking it up in the
+// next catalog when it is not cached or has expired.
+func (c *CoffeePriceCache) Coffee(ctx context.Context, id int) (Coffee, error) {
+	c.mu.Lock()
+	cached, ok := c.coffees[id]
+	seq := c.seq
+	c.mu.Unlock()
+	if ok && c.now().Before(cached.expires) {
+		if cached.deleted {
+			return Coffee{}, fmt.Errorf("%w %d", ErrUnknownCoffee, id)
+		}
+		return cached.coffee, nil
+	}
+
+	coffee, err := c.next.Coffee(ctx, id)
+	if err != nil {
+		return coffee, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// An event that arrived during the lookup is newer than its answer.
+	if current, ok := c.coffees[id]; !ok || current.seq <= seq {
+		c.coffees[id] = cachedCoffee{coffee: coffee, seq: seq, expires: c.now().Add(c.ttl)}
+	}
+	return coffee, nil
+}
+
+// apply updates the cache from a coffee event of the coffee-shop.
+func (c *CoffeePriceCache) apply(subject string, data []byte) {
+	var event struct {
+		Type       string  `json:"type"`
+		ResourceID int     `json:"resource_id"`
+		Data       *Coffee `json:"data"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		slog.Warn("Ignoring malformed coffee event", "subject", subject, "error", err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	entry := cachedCoffee{seq: c.seq, expires: c.now().Add(c.ttl)}
+	switch {
+	case event.Type == EventDeleted:
+		entry.deleted = true
+		entry.coffee.ID = event.ResourceID
+	case event.Data != nil:
+		entry.coffee = *event.Data
+	default:
+		return
+	}
+	old, ok := c.coffees[event.ResourceID]
+	c.coffees[event.ResourceID] = entry
+	if ok && !old.deleted && !entry.deleted && old.coffee.Price != entry.coffee.Price {
+		slog.Info("Coffee price changed", "coffee", event.ResourceID, "name", entry.coffee.Name, "from", old.coffee.Price, "to", entry.coffee.Price)
+	}
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Coffee is a drink on the coffee-shop menu, as served by its
+// GET /coffees/{id}.
+type Coffee struct {
+	ID    int     `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+// ErrUnknownCoffee is returned by a CoffeeCatalog when the coffee-shop has
+// no coffee with the requested ID.
+var ErrUnknownCoffee = errors.New("unknown coffee")
+
+// ErrCoffeeShopUnavailable is returned by a CoffeeCatalog when the
+// coffee-shop cannot be reached, keeps failing or is not configured.
+var ErrCoffeeShopUnavailable = errors.New("coffee-shop is unavailable")
+
+// CoffeeCatalog looks up the coffees an order refers to.
+type CoffeeCatalog interface {
+	Coffee(ctx context.Context, id int) (Coffee, error)
+}
+
+// CoffeeShopOptions tunes a CoffeeShopClient. Zero fields other than
+// Retries take the defaults of CoffeeShopFromEnv.
+type CoffeeShopOptions struct {
+	// Timeout bounds each attempt, including reading the response.
+	Timeout time.Duration
+
+	// Retries is the number of attempts after the first one for requests
+	// that fail with a network error, 429 or 5xx.
+	Retries int
+
+	// Backoff is the wait before the first retry; it doubles for each one
+	// after that.
+	Backoff time.Duration
+
+	// FailureThreshold is the number of consecutive failed lookups that
+	// opens the circuit. While it is open lookups fail at once, until
+	// Cooldown has passed and a single trial lookup is let through.
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	// APIKey is sent to the coffee-shop when it requires credentials.
+	APIKey string
+}
+
+// CoffeeShopClient is a CoffeeCatalog backed by the coffee-shop API.
+type CoffeeShopClient struct {
+	baseURL string
+	client  *http.Client
+	retries int
+	backoff time.Duration
+	breaker *circuitBreaker
+}
+
+// NewCoffeeShopClient returns a client of the coffee-shop at baseURL, which
+// may be a URL or a host:port such as ServiceStatus.Endpoint.
+func NewCoffeeShopClient(baseURL string, opts CoffeeShopOptions) *CoffeeShopClient {
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 100 * time.Millisecond
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 5
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 30 * time.Second
+	}
+	return &CoffeeShopClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  newClient(opts.Timeout, opts.APIKey),
+		retries: max(opts.Retries, 0),
+		backoff: opts.Backoff,
+		breaker: &circuitBreaker{threshold: opts.FailureThreshold, cooldown: opts.Cooldown, now: time.Now},
+	}
+}
+
+// CoffeeShopFromEnv returns a client of the coffee-shop at COFFEE_SHOP_URL,
+// which the operator sets from the coffee-shop's ServiceStatus.Endpoint, or
+// nil when it is unset. COFFEE_SHOP_TIMEOUT (seconds, default 2),
+// COFFEE_SHOP_RETRIES (default 2), COFFEE_SHOP_BREAKER_THRESHOLD (default
+// 5), COFFEE_SHOP_BREAKER_COOLDOWN (seconds, default 30) and
+// COFFEE_SHOP_API_KEY set the CoffeeShopOptions.
+func CoffeeShopFromEnv() CoffeeCatalog {
+	url := os.Getenv("COFFEE_SHOP_URL")
+	if url == "" {
+		return nil
+	}
+	retries := 2
+	if v, err := strconv.Atoi(os.Getenv("COFFEE_SHOP_RETRIES")); err == nil && v >= 0 {
+		retries = v
+	}
+	threshold, _ := strconv.Atoi(os.Getenv("COFFEE_SHOP_BREAKER_THRESHOLD"))
+	return NewCoffeeShopClient(url, CoffeeShopOptions{
+		Timeout:          envSeconds("COFFEE_SHOP_TIMEOUT", 2*time.Second),
+		Retries:          retries,
+		FailureThreshold: threshold,
+		Cooldown:         envSeconds("COFFEE_SHOP_BREAKER_COOLDOWN", 30*time.Second),
+		APIKey:           os.Getenv("COFFEE_SHOP_API_KEY"),
+	})
+}
+
+// Coffee returns the coffee with the given ID. Failed attempts are retried
+// with exponential backoff; lookups that still fail count towards opening
+// the circuit and return ErrCoffeeShopUnavailable. Lookups cut short by ctx
+// do not count, as the caller rather than the coffee-shop gave up.
+func (c *CoffeeShopClient) Coffee(ctx context.Context, id int) (Coffee, error) {
+	if !c.breaker.allow() {
+		return Coffee{}, fmt.Errorf("%w: circuit open", ErrCoffeeShopUnavailable)
+	}
+	var lastErr error
+retries:
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				break retries
+			case <-time.After(c.backoff << (attempt - 1)):
+			}
+		}
+		coffee, retry, err := c.fetch(ctx, id)
+		if !retry {
+			// The coffee-shop answered, even if not with a coffee.
+			c.breaker.record(true)
+			return coffee, err
+		}
+		lastErr = err
+	}
+	if err := ctx.Err(); err != nil {
+		c.breaker.release()
+		return Coffee{}, fmt.Errorf("%w: %v", ErrCoffeeShopUnavailable, err)
+	}
+	c.breaker.record(false)
+	return Coffee{}, fmt.Errorf("%w: %v", ErrCoffeeShopUnavailable, lastErr)
+}
+
+// fetch makes one attempt at GET /coffees/{id} and reports whether a
+// failure is worth retrying. It passes on the request and trace IDs of the
+// request ctx belongs to.
+func (c *CoffeeShopClient) fetch(ctx context.Context, id int) (coffee Coffee, retry bool, err error) {
+	url := c.baseURL + "/coffees/" + strconv.Itoa(id)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return coffee, false, err
+	}
+	for name, values := range forwardedHeaders(ctx) {
+		req.Header[name] = values
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return coffee, true, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		if err := json.NewDecoder(resp.Body).Decode(&coffee); err != nil {
+			return coffee, false, fmt.Errorf("GET %s: decoding response: %w", url, err)
+		}
+		return coffee, false, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return coffee, false, fmt.Errorf("%w %d", ErrUnknownCoffee, id)
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return coffee, true, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return coffee, false, fmt.Errorf("GET %s: %s", url, resp.Status)
+}
+
+// circuitBreaker stops calls to a dependency after threshold consecutive
+// failures. Once cooldown has passed it is half-open: one trial call is
+// let through, which closes it again on success and reopens it on failure.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+// allow reports whether a call may be made.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.trial || b.now().Before(b.openUntil) {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// release gives back a call allowed by allow that has no outcome, such as
+// one the caller cancelled, so that a trial call can be made again.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// record reports the outcome of a call allowed by allow.
+func (b *circuitBreaker) record(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if ok {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
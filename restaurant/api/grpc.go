@@ -0,0 +1,318 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// The gRPC API serves the records of the REST API from the same handler, so
+// both share the store, the change feed and the readiness checks. Its
+// services are defined in the pb package.
+
+// livenessService is the health service name that reports liveness rather
+// than readiness, for the liveness probe.
+const livenessService = "liveness"
+
+// healthWatchInterval is how often a health Watch reruns the checks.
+const healthWatchInterval = time.Second
+
+// newGRPCServer returns a gRPC server with the services of h, health checking
+// and reflection. Calls are logged, authenticated and rate limited like the
+// REST requests; health checks and reflection are open.
+func newGRPCServer(h *handler, cfg Config) *grpc.Server {
+	var opts []grpc.ServerOption
+	if cfg.Limits.MaxBodyBytes > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(int(min(cfg.Limits.MaxBodyBytes, math.MaxInt32))))
+	}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(
+			logRPC(cfg.Logger),
+			rateLimitRPC(cfg.Limits, peerIP),
+			authorizeRPC(cfg.Auth),
+			rateLimitRPC(cfg.Limits, callerOfRPC),
+		),
+		grpc.ChainStreamInterceptor(logStream(cfg.Logger)),
+	)
+	srv := grpc.NewServer(opts...)
+	registerGRPCServices(srv, h)
+	health := &healthServer{h: h, services: map[string]bool{"": true}}
+	for name := range srv.GetServiceInfo() {
+		health.services[name] = true
+	}
+	healthpb.RegisterHealthServer(srv, health)
+	reflection.Register(srv)
+	return srv
+}
+
+// isOpenRPC reports whether method is served without credentials.
+func isOpenRPC(method string) bool {
+	return strings.HasPrefix(method, "/grpc.health.v1.Health/")
+}
+
+// readRPC reports whether method only reads, and so needs ScopeRead.
+func readRPC(method string) bool {
+	name := method[strings.LastIndex(method, "/")+1:]
+	return strings.HasPrefix(name, "Get") || strings.HasPrefix(name, "List")
+}
+
+// logRPC logs one record per call, like requestLogger, with the request ID
+// from the x-request-id metadata or a new one, which is sent back as a header.
+func logRPC(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, reqLogger := withRPCLogger(ctx, logger)
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		code := status.Code(err)
+		level := slog.LevelInfo
+		if code == codes.Internal || code == codes.Unknown || code == codes.Unavailable {
+			level = slog.LevelError
+		}
+		reqLogger.LogAttrs(ctx, level, "rpc",
+			slog.String("method", info.FullMethod),
+			slog.String("code", code.String()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", strings.TrimPrefix(peerIP(ctx), "ip:")),
+		)
+		return resp, err
+	}
+}
+
+// logStream gives streaming calls, such as health watches and reflection,
+// a request logger without logging them.
+func logStream(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, _ := withRPCLogger(ss.Context(), logger)
+		return handler(srv, loggedStream{ss, ctx})
+	}
+}
+
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s loggedStream) Context() context.Context { return s.ctx }
+
+// withRPCLogger returns ctx with the logger of the call, which requestLog
+// finds, and that logger.
+func withRPCLogger(ctx context.Context, logger *slog.Logger) (context.Context, *slog.Logger) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := firstValue(md, strings.ToLower(requestIDHeader))
+	if !validRequestID.MatchString(id) {
+		id = newRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(requestIDHeader), id))
+	reqLogger := logger.With("request_id", id)
+	if m := traceParent.FindStringSubmatch(firstValue(md, "traceparent")); m != nil {
+		reqLogger = reqLogger.With("trace_id", m[1])
+	}
+	ctx = withForwardedHeaders(ctx, id, firstValue(md, "traceparent"))
+	return context.WithValue(ctx, loggerKey{}, reqLogger), reqLogger
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+type principalCtxKey struct{}
+
+// authorizeRPC is the gRPC counterpart of authorize. The credentials are
+// read from the x-api-key and authorization metadata.
+func authorizeRPC(auth Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if auth == nil || isOpenRPC(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		r := &http.Request{Header: make(http.Header)}
+		for key, values := range md {
+			for _, v := range values {
+				r.Header.Add(key, v)
+			}
+		}
+		p, err := auth.Authenticate(r)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		scope := ScopeWrite
+		if readRPC(info.FullMethod) {
+			scope = ScopeRead
+		}
+		if !p.allows(scope) {
+			requestLog(ctx).Warn("Request denied", "subject", p.Subject, "scope", scope)
+			return nil, status.Error(codes.PermissionDenied, "missing scope "+scope)
+		}
+		return handler(context.WithValue(ctx, principalCtxKey{}, p), req)
+	}
+}
+
+// rateLimitRPC is the gRPC counterpart of rateLimit. Calls that are over the
+// limit get RESOURCE_EXHAUSTED with a retry-after header.
+func rateLimitRPC(l Limits, clientOf func(context.Context) string) grpc.UnaryServerInterceptor {
+	if l.RequestsPerSecond <= 0 {
+		return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			return handler(ctx, req)
+		}
+	}
+	limiter := newRateLimiter(l)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		client := clientOf(ctx)
+		if client == "" || isOpenRPC(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		if ok, wait := limiter.allow(client); !ok {
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(wait.Seconds())))))
+			return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// peerIP names the client of a call by its IP address, like clientIP.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return "ip:" + host
+}
+
+// callerOfRPC names the client of a call by the caller authorizeRPC found,
+// like callerOf.
+func callerOfRPC(ctx context.Context) string {
+	if p, ok := ctx.Value(principalCtxKey{}).(Principal); ok {
+		return "caller:" + p.Subject
+	}
+	return ""
+}
+
+// validateRPC checks a record against its binding tags, as ShouldBindJSON
+// does for REST requests.
+func validateRPC(record any) error {
+	if err := binding.Validator.ValidateStruct(record); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
+
+// rpcError maps a store error to a gRPC status. what names the record in the
+// NOT_FOUND message, as in "Coffee not found".
+func rpcError(err error, what string) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, what+" not found")
+	case errors.Is(err, ErrVersionMismatch):
+		return status.Error(codes.Aborted, what+" has changed")
+	case errors.Is(err, ErrConflict):
+		return status.Error(codes.AlreadyExists, what+" already exists")
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// rpcPageBounds checks the limit and offset of a list call against the
+// bounds of the limit and offset query parameters. A limit of 0 selects the
+// default page size.
+func rpcPageBounds(limit, offset int32) (int, int, error) {
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+	if limit < 0 || limit > maxPageSize {
+		return 0, 0, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d", maxPageSize)
+	}
+	if offset < 0 {
+		return 0, 0, status.Error(codes.InvalidArgument, "offset must not be negative")
+	}
+	return int(limit), int(offset), nil
+}
+
+// rpcPage returns the page of items that limit and offset select.
+func rpcPage[T any](items []T, limit, offset int32) ([]T, error) {
+	l, o, err := rpcPageBounds(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	start := min(o, len(items))
+	return items[start:min(start+l, len(items))], nil
+}
+
+// healthServer answers health checks with the readiness checks, for the
+// server as a whole ("") and for each of its services, and with liveness
+// for the "liveness" service.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	h        *handler
+	services map[string]bool
+}
+
+func (s *healthServer) status(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	if service == livenessService {
+		return healthpb.HealthCheckResponse_SERVING, nil
+	}
+	if !s.services[service] {
+		return 0, status.Error(codes.NotFound, "unknown service")
+	}
+	if _, ready := s.h.runReadinessChecks(ctx); !ready {
+		return healthpb.HealthCheckResponse_NOT_SERVING, nil
+	}
+	return healthpb.HealthCheckResponse_SERVING, nil
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, err := s.status(ctx, req.GetService())
+	if err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch sends the status of the service whenever it changes. Unknown
+// services are reported as SERVICE_UNKNOWN, as the protocol asks.
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+	for {
+		st, err := s.status(ctx, req.GetService())
+		if err != nil {
+			st = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-s.h.events.stopping:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"restaurant/api/pb"
+)
+
+// registerGRPCServices registers the gRPC services of h on srv.
+func registerGRPCServices(srv *grpc.Server, h *handler) {
+	pb.RegisterMenuServiceServer(srv, menuService{h: h})
+}
+
+// menuService serves the menu over gRPC, as the /menu endpoints do.
+type menuService struct {
+	pb.UnimplementedMenuServiceServer
+	h *handler
+}
+
+func (s menuService) ListMenuItems(ctx context.Context, req *pb.ListMenuItemsRequest) (*pb.ListMenuItemsResponse, error) {
+	items, err := s.h.store.List(ctx)
+	if err != nil {
+		return nil, rpcError(err, "Item")
+	}
+	page, err := rpcPage(items, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ListMenuItemsResponse{Total: int32(len(items))}
+	for _, item := range page {
+		resp.Items = append(resp.Items, menuItemToPB(item))
+	}
+	return resp, nil
+}
+
+func (s menuService) GetMenuItem(ctx context.Context, req *pb.GetMenuItemRequest) (*pb.MenuItem, error) {
+	item, _, err := s.h.store.Get(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, rpcError(err, "Item")
+	}
+	return menuItemToPB(item), nil
+}
+
+func (s menuService) CreateMenuItem(ctx context.Context, req *pb.CreateMenuItemRequest) (*pb.MenuItem, error) {
+	item, err := menuItemFromPB(req.GetItem())
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.h.store.Create(ctx, item)
+	if err != nil {
+		return nil, rpcError(err, "Item")
+	}
+	s.h.events.publish(EventCreated, "menu", created.ID, created)
+	return menuItemToPB(created), nil
+}
+
+func (s menuService) UpdateMenuItem(ctx context.Context, req *pb.UpdateMenuItemRequest) (*pb.MenuItem, error) {
+	item, err := menuItemFromPB(req.GetItem())
+	if err != nil {
+		return nil, err
+	}
+	id := int(req.GetId())
+	updated, _, err := s.h.store.Update(ctx, id, item, anyVersion)
+	if err != nil {
+		return nil, rpcError(err, "Item")
+	}
+	s.h.events.publish(EventUpdated, "menu", id, updated)
+	return menuItemToPB(updated), nil
+}
+
+func (s menuService) DeleteMenuItem(ctx context.Context, req *pb.DeleteMenuItemRequest) (*pb.DeleteMenuItemResponse, error) {
+	id := int(req.GetId())
+	if err := s.h.store.Delete(ctx, id, anyVersion); err != nil {
+		return nil, rpcError(err, "Item")
+	}
+	s.h.events.publish(EventDeleted, "menu", id, nil)
+	return &pb.DeleteMenuItemResponse{}, nil
+}
+
+func menuItemToPB(i MenuItem) *pb.MenuItem {
+	return &pb.MenuItem{Id: int64(i.ID), Name: i.Name, Price: i.Price}
+}
+
+// menuItemFromPB converts and validates a menu item sent over gRPC.
+func menuItemFromPB(i *pb.MenuItem) (MenuItem, error) {
+	item := MenuItem{ID: int(i.GetId()), Name: i.GetName(), Price: i.GetPrice()}
+	if err := validateRPC(item); err != nil {
+		return item, err
+	}
+	if item.Price < 0 {
+		return item, status.Error(codes.InvalidArgument, "price must not be negative")
+	}
+	return item, nil
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestIDHeader carries the request ID. An incoming value is kept so that a
+// request can be followed across services; otherwise one is generated.
+const requestIDHeader = "X-Request-ID"
+
+var (
+	// validRequestID bounds what is accepted from clients, so that a request
+	// ID cannot inject arbitrary content into the logs.
+	validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+	// traceParent matches a W3C traceparent header and captures the trace ID.
+	traceParent = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}$`)
+)
+
+// NewLogger returns a logger writing format ("json" or "text") to w at level
+// ("debug", "info", "warn" or "error"). Empty or unknown values fall back to
+// JSON at info. Every record is tagged with the service name and, when set by
+// the operator, the pod it runs in.
+func NewLogger(w io.Writer, level, format string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var h slog.Handler = slog.NewJSONHandler(w, opts)
+	if strings.EqualFold(format, "text") {
+		h = slog.NewTextHandler(w, opts)
+	}
+
+	logger := slog.New(h).With("service", serviceName)
+	if pod := os.Getenv("POD_NAME"); pod != "" {
+		logger = logger.With("pod", pod, "namespace", os.Getenv("POD_NAMESPACE"))
+	}
+	return logger
+}
+
+// LoggerFromEnv returns the logger selected by LOG_LEVEL and LOG_FORMAT,
+// writing to stdout.
+func LoggerFromEnv() *slog.Logger {
+	return NewLogger(os.Stdout, os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
+}
+
+type loggerKey struct{}
+
+// requestLog returns the logger of the request that ctx belongs to, which
+// carries its request and trace IDs, or the default logger outside a request.
+func requestLog(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+type forwardedKey struct{}
+
+// withForwardedHeaders returns ctx carrying the request ID and, when the
+// caller sent a valid one, the traceparent of the request, which the calls
+// made to other services for it pass on so that they can be followed in the
+// logs.
+func withForwardedHeaders(ctx context.Context, requestID, traceparent string) context.Context {
+	h := http.Header{}
+	h.Set(requestIDHeader, requestID)
+	if traceParent.MatchString(traceparent) {
+		h.Set("traceparent", traceparent)
+	}
+	return context.WithValue(ctx, forwardedKey{}, h)
+}
+
+// forwardedHeaders returns the headers withForwardedHeaders stored in ctx, if
+// any.
+func forwardedHeaders(ctx context.Context) http.Header {
+	h, _ := ctx.Value(forwardedKey{}).(http.Header)
+	return h
+}
+
+// requestLogger logs one record per request with its ID, route, status and
+// latency, and echoes the request ID in the response. The trace ID is taken
+// from the traceparent header when the caller sends one.
+func requestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Header(requestIDHeader, id)
+
+		reqLogger := logger.With("request_id", id)
+		if m := traceParent.FindStringSubmatch(c.GetHeader("traceparent")); m != nil {
+			reqLogger = reqLogger.With("trace_id", m[1])
+		}
+		ctx := withForwardedHeaders(c.Request.Context(), id, c.GetHeader("traceparent"))
+		c.Request = c.Request.WithContext(context.WithValue(ctx, loggerKey{}, reqLogger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		reqLogger.LogAttrs(c.Request.Context(), level, "request",
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
+
+// newRequestID returns a random 128-bit ID in hex.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
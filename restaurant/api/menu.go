@@ -0,0 +1,419 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MenuItem is a dish on the menu.
+type MenuItem struct {
+	ID    int     `json:"id" example:"1"`
+	Name  string  `json:"name" binding:"required" example:"Pizza"`
+	Price float64 `json:"price" binding:"min=0" minimum:"0" example:"12.99"`
+}
+
+// MenuItemPatch documents the JSON Merge Patch for a menu item: the members
+// it has replace those of the menu item, and null removes them.
+type MenuItemPatch struct {
+	Name  string  `json:"name,omitempty" nullable:"true" example:"Pizza"`
+	Price float64 `json:"price,omitempty" nullable:"true" minimum:"0" example:"12.99"`
+}
+
+// DefaultMenuItems returns the built-in menu the service starts with.
+func DefaultMenuItems() []MenuItem {
+	return []MenuItem{
+		{1, "Pizza", 12.99},
+		{2, "Burger", 9.99},
+		{3, "Pasta", 11.99},
+		{4, "Salad", 7.99},
+		{5, "Steak", 19.99},
+		{6, "Sushi", 14.99},
+		{7, "Ramen", 10.99},
+		{8, "Tacos", 8.99},
+		{9, "Sandwich", 6.99},
+		{10, "Fries", 3.99},
+		{11, "Ice Cream", 4.99},
+		{12, "Soup", 5.99},
+		{13, "MenuItem", 2.99},
+		{14, "Tea", 2.49},
+		{15, "Smoothie", 4.49},
+	}
+}
+
+// Store persists the menu items served by the API. Get, Update and Delete
+// return ErrNotFound for unknown IDs.
+type Store interface {
+	// List returns every menu item in insertion order.
+	List(ctx context.Context) ([]MenuItem, error)
+
+	// Get returns a menu item with its version, which changes whenever the
+	// menu item does.
+	Get(ctx context.Context, id int) (MenuItem, int64, error)
+
+	// Create stores a menu item, assigning an ID when it has none, and
+	// returns it as stored. It returns ErrConflict when the ID is taken.
+	Create(ctx context.Context, menuItem MenuItem) (MenuItem, error)
+
+	// Update replaces the menu item with the given ID and returns it as stored
+	// with its new version. Update and Delete return ErrVersionMismatch
+	// unless ifVersion is anyVersion or the current version.
+	Update(ctx context.Context, id int, menuItem MenuItem, ifVersion int64) (MenuItem, int64, error)
+	Delete(ctx context.Context, id int, ifVersion int64) error
+
+	// Count, Import and Export back the admin fixture endpoints, as
+	// described by fixtureSet.
+	Count(ctx context.Context) (int, error)
+	Import(ctx context.Context, menuItems []MenuItem, replace bool) (int, error)
+	Export(ctx context.Context) ([]MenuItem, error)
+}
+
+// NewMemoryStore returns a Store that keeps menu items in memory, starting
+// with a copy of menuItems.
+func NewMemoryStore(menuItems []MenuItem) Store {
+	return newMemoryStore(menuItems, func(m *MenuItem) *int { return &m.ID })
+}
+
+// LoadSeedFixtures applies the start-up dataset configured through the
+// environment to store.
+func LoadSeedFixtures(ctx context.Context, store Store) error {
+	return loadSeedFixtures(ctx, menuFixtures{store})
+}
+
+// getMenuItems godoc
+// @Summary Get all menu items
+// @Description Get list of all available menu items
+// @Tags menu
+// @Param name query string false "Case-insensitive substring of the name"
+// @Param minPrice query number false "Minimum price (inclusive)"
+// @Param maxPrice query number false "Maximum price (inclusive)"
+// @Param sort query string false "Field to sort by; prefix with '-' for descending order" Enums(id,name,price,-id,-name,-price)
+// @Param limit query integer false "Maximum number of items to return" default(100) minimum(1) maximum(1000)
+// @Param offset query integer false "Number of items to skip" default(0) minimum(0)
+// @Success 200 {array} MenuItem "List of menu items"
+// @Header 200 {integer} X-Total-Count "Total number of items matching the filters"
+// @Header 200 {string} Link "RFC 8288 links to the first, prev, next and last pages"
+// @Failure 400 {object} map[string]string "Invalid query parameter"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /menu [get]
+func (h *handler) getMenuItems(c *gin.Context) {
+	q, err := parseListQuery(c, sortFieldNames(menuItemSortFields))
+	minPrice, minErr := queryFloat(c, "minPrice")
+	maxPrice, maxErr := queryFloat(c, "maxPrice")
+	if err := errors.Join(err, minErr, maxErr); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.ToLower(c.Query("name"))
+
+	menuItems, err := h.store.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	matches := make([]MenuItem, 0, len(menuItems))
+	for _, item := range menuItems {
+		if name != "" && !strings.Contains(strings.ToLower(item.Name), name) {
+			continue
+		}
+		if minPrice != nil && item.Price < *minPrice {
+			continue
+		}
+		if maxPrice != nil && item.Price > *maxPrice {
+			continue
+		}
+		matches = append(matches, item)
+	}
+	c.JSON(http.StatusOK, sortAndPage(c, matches, q, menuItemSortFields))
+}
+
+// menuItemSortFields are the fields GET /menu can be sorted by.
+var menuItemSortFields = map[string]comparator[MenuItem]{
+	"id":    func(a, b MenuItem) int { return cmp.Compare(a.ID, b.ID) },
+	"name":  func(a, b MenuItem) int { return strings.Compare(a.Name, b.Name) },
+	"price": func(a, b MenuItem) int { return cmp.Compare(a.Price, b.Price) },
+}
+
+// getMenuItemByID godoc
+// @Summary Get menu item by ID
+// @Description Get a specific menu item by its ID
+// @Tags menu
+// @Accept json
+// @Produce json
+// @Param id path int true "Menu Item ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} MenuItem
+// @Success 304 "Cached copy is current"
+// @Header 200,304 {string} ETag "Version of the menu item"
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /menu/{id} [get]
+func (h *handler) getMenuItemByID(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	menuItem, version, err := h.store.Get(c.Request.Context(), id)
+	if err != nil {
+		menuItemError(c, err)
+		return
+	}
+	if notModified(c, version) {
+		return
+	}
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, menuItem)
+}
+
+// createMenuItem godoc
+// @Summary Create a new menu item
+// @Description Add a new item to the menu. A menu item without an id is assigned one; an id that is already taken is rejected; replace a record with PUT.
+// @Tags menu
+// @Accept json
+// @Produce json
+// @Param menuItem body MenuItem true "Menu Item object"
+// @Success 201 {object} MenuItem
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string "Item already exists"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /menu [post]
+func (h *handler) createMenuItem(c *gin.Context) {
+	var newItem MenuItem
+	if err := c.ShouldBindJSON(&newItem); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	created, err := h.store.Create(c.Request.Context(), newItem)
+	if err != nil {
+		menuItemError(c, err)
+		return
+	}
+	h.events.publish(EventCreated, "menu", created.ID, created)
+	c.JSON(http.StatusCreated, created)
+}
+
+// deleteMenuItem godoc
+// @Summary Delete a menu item
+// @Description Remove an item from the menu. With If-Match, only the given version is removed.
+// @Tags menu
+// @Accept json
+// @Produce json
+// @Param id path int true "Menu Item ID"
+// @Param If-Match header string false "ETag the menu item must have"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string "Menu item has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /menu/{id} [delete]
+func (h *handler) deleteMenuItem(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c, h.currentVersion(c, id))
+	if !ok {
+		return
+	}
+	if err := h.store.Delete(c.Request.Context(), id, ifVersion); err != nil {
+		menuItemError(c, err)
+		return
+	}
+	h.events.publish(EventDeleted, "menu", id, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Item deleted"})
+}
+
+// updateMenuItem godoc
+// @Summary Update a menu item
+// @Description Update an existing menu item; the id in the path takes precedence over the body. With If-Match, only the given version is replaced.
+// @Tags menu
+// @Accept json
+// @Produce json
+// @Param id path int true "Menu Item ID"
+// @Param If-Match header string false "ETag the menu item must have"
+// @Param menuItem body MenuItem true "Menu Item object"
+// @Success 200 {object} MenuItem
+// @Header 200 {string} ETag "New version of the menu item"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string "Menu item has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /menu/{id} [put]
+func (h *handler) updateMenuItem(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var updatedItem MenuItem
+	if err := c.ShouldBindJSON(&updatedItem); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c, h.currentVersion(c, id))
+	if !ok {
+		return
+	}
+	updated, version, err := h.store.Update(c.Request.Context(), id, updatedItem, ifVersion)
+	if err != nil {
+		menuItemError(c, err)
+		return
+	}
+	h.events.publish(EventUpdated, "menu", id, updated)
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// patchMenuItem godoc
+// @Summary Patch a menu item
+// @Description Change some fields of a menu item with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902). The patched menu item must be valid; the id in the path takes precedence. With If-Match, only the given version is patched.
+// @ID patchMenuItem
+// @Tags menu
+// @Accept merge-patch,json-patch
+// @Produce json
+// @Param id path int true "Menu Item ID"
+// @Param If-Match header string false "ETag the menu item must have"
+// @Param patch body MenuItemPatch true "Merge patch or JSON Patch to apply" accept(merge-patch)
+// @Param operations body []PatchOperation true "Operations to apply" accept(json-patch)
+// @Success 200 {object} MenuItem "Menu item updated"
+// @Header 200 {string} ETag "New version of the menu item"
+// @Failure 400 {object} map[string]string "Malformed patch"
+// @Failure 404 {object} map[string]string "Menu item not found"
+// @Failure 409 {object} map[string]string "Patch does not apply to the menu item"
+// @Failure 412 {object} map[string]string "Menu item has changed"
+// @Failure 415 {object} map[string]string "Unsupported patch format"
+// @Failure 422 {object} map[string]string "Patched menu item is invalid"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /menu/{id} [patch]
+func (h *handler) patchMenuItem(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	updated, version, ok := patchRecord(c, h.store, id, "MenuItem", menuItemError)
+	if !ok {
+		return
+	}
+	h.events.publish(EventUpdated, "menu", id, updated)
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// menuItemError maps a store error to a response.
+func menuItemError(c *gin.Context, err error) {
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		preconditionFailed(c)
+		return
+	}
+	if errors.Is(err, ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Item already exists"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// currentVersion returns a lookup of the version of menu item id, for
+// ifMatchVersion.
+func (h *handler) currentVersion(c *gin.Context, id int) func() (int64, error) {
+	return func() (int64, error) {
+		_, version, err := h.store.Get(c.Request.Context(), id)
+		return version, err
+	}
+}
+
+// menuFixtures exposes the store to the fixture endpoints.
+type menuFixtures struct {
+	Store
+}
+
+var (
+	dishStyles = []string{"Grilled", "Spicy", "Smoked", "Crispy", "Roasted", "Garlic", "Lemon", "Truffle"}
+	dishNames  = []string{"Chicken", "Salmon", "Burger", "Pasta", "Risotto", "Tacos", "Curry", "Salad"}
+)
+
+func (menuFixtures) Generate(start, n int) []MenuItem {
+	items := make([]MenuItem, n)
+	for i := range items {
+		items[i] = MenuItem{
+			Name:  fmt.Sprintf("%s %s %d", dishStyles[rand.IntN(len(dishStyles))], dishNames[rand.IntN(len(dishNames))], start+i+1),
+			Price: float64(499+rand.IntN(2500)) / 100,
+		}
+	}
+	return items
+}
+
+// exportMenuFixtures writes every menu item as JSON or CSV.
+//
+// @Summary Export fixtures
+// @Description Export every record as JSON or CSV
+// @ID exportFixtures
+// @Tags admin
+// @Produce json,csv
+// @Param format query string false "Export format" Enums(json,csv) default(json)
+// @Success 200 {array} MenuItem "All records"
+// @Failure 400 {object} map[string]string "Unsupported format"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /admin/fixtures [get]
+func (h *handler) exportMenuFixtures(c *gin.Context) {
+	exportFixtures(c, menuFixtures{h.store})
+}
+
+// importMenuFixtures bulk-loads menu items from the request body.
+//
+// @Summary Import fixtures
+// @Description Bulk-load records from a JSON array or CSV file. Records without an id are assigned one; records with an existing id overwrite it. Records are validated as on create and the first invalid one is rejected with its index. The body may be up to 64 MiB whatever the service's body limit.
+// @ID importFixtures
+// @Tags admin
+// @Accept json,csv
+// @Param mode query string false "append keeps existing records; replace removes them first" Enums(append,replace) default(append)
+// @Param menuItems body []MenuItem true "Records to import; CSV files need a header row naming the JSON fields"
+// @Success 200 {object} fixtureResult "Records stored"
+// @Failure 400 {object} map[string]string "Invalid records or mode"
+// @Failure 415 {object} map[string]string "Unsupported content type"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /admin/fixtures [post]
+func (h *handler) importMenuFixtures(c *gin.Context) {
+	importFixtures(c, menuFixtures{h.store})
+}
+
+// generateMenuFixtures stores synthetic menu items.
+//
+// @Summary Generate fixtures
+// @Description Store count synthetic records
+// @ID generateFixtures
+// @Tags admin
+// @Param count query integer true "Number of records to generate" minimum(1) maximum(100000)
+// @Param mode query string false "append keeps existing records; replace removes them first" Enums(append,replace) default(append)
+// @Success 200 {object} fixtureResult "Records stored"
+// @Failure 400 {object} map[string]string "Invalid count or mode"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /admin/fixtures/generate [post]
+func (h *handler) generateMenuFixtures(c *gin.Context) {
+	generateFixtures(c, menuFixtures{h.store})
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openAPITestRequests exercise every documented response shape that can be
+// reached without external dependencies.
+var openAPITestRequests = []struct {
+	method, path, body string
+	status             int
+}{
+	{"GET", "/livez", "", http.StatusOK},
+	{"GET", "/health", "", http.StatusServiceUnavailable},
+	{"GET", "/openapi.json", "", http.StatusOK},
+	{"GET", "/docs", "", http.StatusOK},
+	{"GET", "/menu?maxPrice=10&sort=-price&limit=5", "", http.StatusOK},
+	{"GET", "/menu/1", "", http.StatusOK},
+	{"GET", "/menu/999", "", http.StatusNotFound},
+	{"POST", "/menu", `{"id":99,"name":"Risotto","price":13.5}`, http.StatusCreated},
+	{"PUT", "/menu/99", `{"id":99,"name":"Risotto","price":14}`, http.StatusOK},
+	{"DELETE", "/menu/99", "", http.StatusOK},
+	{"GET", "/events?follow=false", "", http.StatusOK},
+	{"GET", "/events?last_event_id=0&resource=orders&follow=false", "", http.StatusOK},
+	{"POST", "/webhooks", `{"url":"http://127.0.0.1:1/hooks","resources":["menu","orders"]}`, http.StatusCreated},
+	{"GET", "/webhooks", "", http.StatusOK},
+	{"GET", "/webhooks/1", "", http.StatusOK},
+	{"GET", "/webhooks/99", "", http.StatusNotFound},
+	{"DELETE", "/webhooks/1", "", http.StatusOK},
+	{"GET", "/admin/fixtures", "", http.StatusOK},
+}
+
+// openAPIInvalidRequests are rejected by the validator before reaching the
+// handlers.
+var openAPIInvalidRequests = []struct {
+	method, path, body string
+}{
+	{"GET", "/menu?limit=0", ""},
+	{"GET", "/menu?sort=category", ""},
+	{"GET", "/menu/abc", ""},
+	{"POST", "/menu", `{"price":3.19}`},
+	{"GET", "/events?resource=coffees", ""},
+	{"GET", "/events?last_event_id=-1", ""},
+	{"POST", "/webhooks", `{"resources":["menu"]}`},
+	{"POST", "/admin/fixtures/generate", ""},
+}
+
+// newTestRouter returns a router over a fresh store holding the default
+// menu, with request validation enabled.
+func newTestRouter(validateResponses bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return NewRouter(NewMemoryStore(DefaultMenuItems()), Config{
+		ValidateRequests:  true,
+		ValidateResponses: validateResponses,
+	})
+}
+
+var ginPathParam = regexp.MustCompile(`:(\w+)`)
+
+// openAPIPath converts a gin route path to OpenAPI path template syntax.
+func openAPIPath(path string) string {
+	return ginPathParam.ReplaceAllString(path, "{$1}")
+}
+
+func TestRoutesMatchOpenAPISpec(t *testing.T) {
+	doc, err := loadOpenAPISpec()
+	require.NoError(t, err)
+
+	var routes []string
+	for _, route := range newTestRouter(false).Routes() {
+		routes = append(routes, route.Method+" "+openAPIPath(route.Path))
+	}
+	var documented []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+	slices.Sort(routes)
+	slices.Sort(documented)
+
+	assert.Equal(t, documented, routes, "routes and OpenAPI operations differ; annotate the handler and run go generate")
+}
+
+func TestResponsesMatchOpenAPISpec(t *testing.T) {
+	r := newTestRouter(true)
+
+	for _, tc := range openAPITestRequests {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			saved := responseMismatch
+			defer func() { responseMismatch = saved }()
+			responseMismatch = func(c *gin.Context, err error) {
+				t.Errorf("response does not match the OpenAPI spec: %v", err)
+			}
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestInvalidRequestsAreRejected(t *testing.T) {
+	r := newTestRouter(false)
+
+	for _, tc := range openAPIInvalidRequests {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), `"error"`)
+		})
+	}
+}
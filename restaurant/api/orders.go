@@ -0,0 +1,266 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Order is a customer's order of dishes from the menu and drinks from the
+// coffee-shop. Names and prices are copied when the order is placed, so
+// later menu changes do not alter it.
+type Order struct {
+	ID      int           `json:"id" readonly:"true" example:"1"`
+	Items   []OrderItem   `json:"items" binding:"dive"`
+	Coffees []OrderCoffee `json:"coffees" binding:"dive"`
+	Total   float64       `json:"total" readonly:"true" example:"28.97"`
+}
+
+// OrderItem is a line of an order for a menu item.
+type OrderItem struct {
+	MenuItemID int     `json:"menu_item_id" binding:"required" example:"1"`
+	Quantity   int     `json:"quantity" binding:"required,min=1" minimum:"1" example:"2"`
+	Name       string  `json:"name" readonly:"true" example:"Pizza"`
+	UnitPrice  float64 `json:"unit_price" readonly:"true" example:"12.99"`
+}
+
+// OrderCoffee is a line of an order for a coffee served by the coffee-shop.
+type OrderCoffee struct {
+	CoffeeID  int     `json:"coffee_id" binding:"required" example:"1"`
+	Quantity  int     `json:"quantity" binding:"required,min=1" minimum:"1" example:"1"`
+	Name      string  `json:"name" readonly:"true" example:"Espresso"`
+	UnitPrice float64 `json:"unit_price" readonly:"true" example:"2.99"`
+}
+
+// OrderStore persists orders. Get and Delete return ErrNotFound for unknown
+// IDs; Delete returns ErrVersionMismatch unless ifVersion is anyVersion or
+// the current version.
+type OrderStore interface {
+	List(ctx context.Context) ([]Order, error)
+	Get(ctx context.Context, id int) (Order, int64, error)
+	Create(ctx context.Context, order Order) (Order, error)
+	Delete(ctx context.Context, id int, ifVersion int64) error
+}
+
+// NewOrderStore returns an OrderStore that keeps orders in memory, starting
+// with a copy of orders.
+func NewOrderStore(orders []Order) OrderStore {
+	return newMemoryStore(orders, func(o *Order) *int { return &o.ID })
+}
+
+// getOrders godoc
+// @Summary Get all orders
+// @Description Get the list of placed orders
+// @Tags orders
+// @Param sort query string false "Field to sort by; prefix with '-' for descending order" Enums(id,total,-id,-total)
+// @Param limit query integer false "Maximum number of items to return" default(100) minimum(1) maximum(1000)
+// @Param offset query integer false "Number of items to skip" default(0) minimum(0)
+// @Success 200 {array} Order "List of orders"
+// @Header 200 {integer} X-Total-Count "Total number of orders"
+// @Header 200 {string} Link "RFC 8288 links to the first, prev, next and last pages"
+// @Failure 400 {object} map[string]string "Invalid query parameter"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /orders [get]
+func (h *handler) getOrders(c *gin.Context) {
+	q, err := parseListQuery(c, sortFieldNames(orderSortFields))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	orders, err := h.orders.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if orders == nil {
+		orders = []Order{}
+	}
+	c.JSON(http.StatusOK, sortAndPage(c, orders, q, orderSortFields))
+}
+
+// orderSortFields are the fields GET /orders can be sorted by.
+var orderSortFields = map[string]comparator[Order]{
+	"id":    func(a, b Order) int { return cmp.Compare(a.ID, b.ID) },
+	"total": func(a, b Order) int { return cmp.Compare(a.Total, b.Total) },
+}
+
+// getOrderByID godoc
+// @Summary Get order by ID
+// @Description Get a specific order by its ID
+// @Tags orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} Order
+// @Success 304 "Cached copy is current"
+// @Header 200,304 {string} ETag "Version of the order"
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[read]
+// @Security BearerAuth[read]
+// @Router /orders/{id} [get]
+func (h *handler) getOrderByID(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	order, version, err := h.orders.Get(c.Request.Context(), id)
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	if notModified(c, version) {
+		return
+	}
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, order)
+}
+
+// createOrder godoc
+// @Summary Place an order
+// @Description Place an order for menu items and coffee-shop coffees. The server assigns the id and prices every line from the menu and the coffee-shop; an order needs at least one line.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param order body Order true "Order to place"
+// @Success 201 {object} Order
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string "Unknown menu item or coffee"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Failure 502 {object} map[string]string "Coffee-shop answered with an error"
+// @Failure 503 {object} map[string]string "Coffee-shop is unavailable or not configured"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /orders [post]
+func (h *handler) createOrder(c *gin.Context) {
+	var order Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(order.Items) == 0 && len(order.Coffees) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "an order needs at least one item or coffee"})
+		return
+	}
+	ctx := c.Request.Context()
+	order.ID = 0
+	order.Total = 0
+	// Both lists are always present in responses.
+	if order.Items == nil {
+		order.Items = []OrderItem{}
+	}
+	if order.Coffees == nil {
+		order.Coffees = []OrderCoffee{}
+	}
+	for i := range order.Items {
+		line := &order.Items[i]
+		menuItem, _, err := h.store.Get(ctx, line.MenuItemID)
+		if errors.Is(err, ErrNotFound) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("menu item %d not found", line.MenuItemID)})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		line.Name, line.UnitPrice = menuItem.Name, menuItem.Price
+		order.Total += float64(line.Quantity) * line.UnitPrice
+	}
+	for i := range order.Coffees {
+		line := &order.Coffees[i]
+		coffee, err := h.coffee(ctx, line.CoffeeID)
+		if err != nil {
+			coffeeError(c, err)
+			return
+		}
+		line.Name, line.UnitPrice = coffee.Name, coffee.Price
+		order.Total += float64(line.Quantity) * line.UnitPrice
+	}
+	order.Total = math.Round(order.Total*100) / 100
+
+	created, err := h.orders.Create(ctx, order)
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	h.events.publish(EventCreated, "orders", created.ID, created)
+	c.JSON(http.StatusCreated, created)
+}
+
+// coffee looks up a coffee in the coffee-shop, if one is configured.
+func (h *handler) coffee(ctx context.Context, id int) (Coffee, error) {
+	if h.coffees == nil {
+		return Coffee{}, fmt.Errorf("%w: COFFEE_SHOP_URL is not set", ErrCoffeeShopUnavailable)
+	}
+	coffee, err := h.coffees.Coffee(ctx, id)
+	if err != nil {
+		requestLog(ctx).Warn("coffee lookup failed", "coffee_id", id, "error", err)
+	}
+	return coffee, err
+}
+
+// coffeeError maps a CoffeeCatalog error to a response.
+func coffeeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownCoffee):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCoffeeShopUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	}
+}
+
+// deleteOrder godoc
+// @Summary Cancel an order
+// @Description Remove an order. With If-Match, only the given version is removed.
+// @Tags orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param If-Match header string false "ETag the order must have"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string "Order has changed"
+// @Failure 500 {object} map[string]string "Storage error"
+// @Security ApiKeyAuth[write]
+// @Security BearerAuth[write]
+// @Router /orders/{id} [delete]
+func (h *handler) deleteOrder(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c, func() (int64, error) {
+		_, version, err := h.orders.Get(c.Request.Context(), id)
+		return version, err
+	})
+	if !ok {
+		return
+	}
+	if err := h.orders.Delete(c.Request.Context(), id, ifVersion); err != nil {
+		orderError(c, err)
+		return
+	}
+	h.events.publish(EventDeleted, "orders", id, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Order deleted"})
+}
+
+// orderError maps an order store error to a response.
+func orderError(c *gin.Context, err error) {
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		preconditionFailed(c)
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
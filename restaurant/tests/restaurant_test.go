@@ -1,92 +1,1132 @@
-package main
+package tests
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"restaurant/api"
+	"restaurant/api/pb"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 )
 
-type MenuItem struct {
-	ID    int     `json:"id"`
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
-}
-
-var testMenuItems = []MenuItem{
-	{1, "Pizza", 12.99},
-	{2, "Burger", 9.99},
-	{3, "Pasta", 11.99},
-	{4, "Salad", 7.99},
-	{5, "Steak", 19.99},
-	{6, "Sushi", 14.99},
-	{7, "Ramen", 10.99},
-	{8, "Tacos", 8.99},
-	{9, "Sandwich", 6.99},
-	{10, "Fries", 3.99},
-	{11, "Ice Cream", 4.99},
-	{12, "Soup", 5.99},
-	{13, "Coffee", 2.99},
-	{14, "Tea", 2.49},
-	{15, "Smoothie", 4.49},
+// newRouter returns the service router over a fresh in-memory store.
+func newRouter(t *testing.T) (*gin.Engine, api.Store) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store := api.NewMemoryStore(api.DefaultMenuItems())
+	return api.NewRouter(store, api.Config{ValidateRequests: true}), store
 }
 
-func TestGetMenu(t *testing.T) {
-	r := setupRouter()
-
+func do(r http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/menu", nil)
 	r.ServeHTTP(w, req)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &v), w.Body.String())
+	return v
+}
+
+func TestGetMenu(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "GET", "/menu", "")
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "Pizza")
 	assert.Contains(t, w.Body.String(), "Burger")
 	assert.Contains(t, w.Body.String(), "Pasta")
+	assert.Equal(t, "15", w.Header().Get("X-Total-Count"))
 }
 
 func TestGetMenuItem(t *testing.T) {
-	r := setupRouter()
+	r, _ := newRouter(t)
 
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/menu/1", nil)
-	r.ServeHTTP(w, req)
+	w := do(r, "GET", "/menu/1", "")
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Contains(t, w.Body.String(), "Pizza")
+	assert.Equal(t, api.MenuItem{ID: 1, Name: "Pizza", Price: 12.99}, decode[api.MenuItem](t, w))
 }
 
 func TestGetNonExistentMenuItem(t *testing.T) {
-	r := setupRouter()
+	r, _ := newRouter(t)
 
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/menu/99", nil)
-	r.ServeHTTP(w, req)
+	w := do(r, "GET", "/menu/99", "")
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	assert.Contains(t, w.Body.String(), "Item not found")
 }
 
-func setupRouter() *gin.Engine {
+func TestCreateMenuItemAssignsID(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "POST", "/menu", `{"name":"Lasagna","price":13.49}`)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	created := decode[api.MenuItem](t, w)
+	assert.Equal(t, api.MenuItem{ID: 16, Name: "Lasagna", Price: 13.49}, created)
+
+	stored, _, err := store.Get(context.Background(), 16)
+	require.NoError(t, err)
+	assert.Equal(t, created, stored)
+}
+
+func TestCreateMenuItemWithTakenIDIsRejected(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "POST", "/menu", `{"id":1,"name":"Margherita Pizza","price":13.99}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	items, err := store.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, items, 15)
+	assert.Equal(t, api.DefaultMenuItems()[0], items[0], "replacing a record is left to PUT")
+
+	w = do(r, "POST", "/menu", `{"id":100,"name":"Margherita Pizza","price":13.99}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, 100, decode[api.MenuItem](t, w).ID)
+}
+
+func TestCreateMenuItemRejectsInvalidBody(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "POST", "/menu", `{"price":13.49}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	n, err := store.Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 15, n)
+}
+
+func TestUpdateMenuItem(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "PUT", "/menu/3", `{"id":42,"name":"Pasta","price":12.49}`)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.MenuItem{ID: 3, Name: "Pasta", Price: 12.49}, decode[api.MenuItem](t, w))
+	stored, _, err := store.Get(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, 12.49, stored.Price)
+}
+
+func TestUpdateNonExistentMenuItem(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "PUT", "/menu/99", `{"name":"Ghost","price":1}`)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	_, _, err := store.Get(context.Background(), 99)
+	assert.ErrorIs(t, err, api.ErrNotFound)
+}
+
+func TestDeleteMenuItem(t *testing.T) {
+	r, store := newRouter(t)
+
+	w := do(r, "DELETE", "/menu/2", "")
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	_, _, err := store.Get(context.Background(), 2)
+	assert.ErrorIs(t, err, api.ErrNotFound)
+	assert.Equal(t, http.StatusNotFound, do(r, "GET", "/menu/2", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(r, "DELETE", "/menu/2", "").Code)
+}
+
+// failingStore is a Store whose reads fail.
+type failingStore struct {
+	api.Store
+}
+
+var errStorage = errors.New("storage unavailable")
+
+func (failingStore) List(context.Context) ([]api.MenuItem, error) {
+	return nil, errStorage
+}
+
+func (failingStore) Get(context.Context, int) (api.MenuItem, int64, error) {
+	return api.MenuItem{}, 0, errStorage
+}
+
+func TestStoreErrorsAreReported(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(failingStore{}, api.Config{ValidateRequests: true})
+
+	for _, path := range []string{"/menu", "/menu/1"} {
+		w := do(r, "GET", path, "")
+		assert.Equal(t, http.StatusInternalServerError, w.Code, path)
+		assert.Contains(t, w.Body.String(), errStorage.Error(), path)
+	}
+}
+
+func TestReadinessFollowsLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	readiness := &api.Readiness{}
+	r := api.NewRouter(api.NewMemoryStore(nil), api.Config{Readiness: readiness})
+
+	assert.Equal(t, http.StatusServiceUnavailable, do(r, "GET", "/readyz", "").Code)
+	readiness.SetWarmedUp()
+	assert.Equal(t, http.StatusOK, do(r, "GET", "/readyz", "").Code)
+	readiness.SetDraining()
+	assert.Equal(t, http.StatusServiceUnavailable, do(r, "GET", "/readyz", "").Code)
+	assert.Equal(t, http.StatusOK, do(r, "GET", "/livez", "").Code)
+}
+
+func TestRequestsAreLogged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultMenuItems()), api.Config{
+		Logger: api.NewLogger(&logs, "info", "json"),
+	})
+
+	req := httptest.NewRequest("GET", "/menu/99", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "req-42", w.Header().Get("X-Request-ID"))
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record), logs.String())
+	assert.Equal(t, "request", record["msg"])
+	assert.Equal(t, "restaurant", record["service"])
+	assert.Equal(t, "req-42", record["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.Equal(t, "/menu/:id", record["route"])
+	assert.Equal(t, float64(404), record["status"])
+	assert.Contains(t, record, "latency_ms")
+
+	// Requests without an X-Request-ID get a generated one.
+	w = do(r, "GET", "/menu", "")
+	assert.Regexp(t, "^[0-9a-f]{32}$", w.Header().Get("X-Request-ID"))
+}
+
+// withAuth returns the service router requiring the given credentials.
+func withAuth(t *testing.T, auth api.Authenticator) *gin.Engine {
+	t.Helper()
 	gin.SetMode(gin.TestMode)
-	r := gin.Default()
+	return api.NewRouter(api.NewMemoryStore(api.DefaultMenuItems()), api.Config{ValidateRequests: true, Auth: auth})
+}
+
+func doWithHeader(r http.Handler, method, path, body, header, value string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set(header, value)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeysNeedTheMethodScope(t *testing.T) {
+	r := withAuth(t, api.APIKeys{
+		"reader-key": {Subject: "reader", Scopes: []string{api.ScopeRead}},
+		"writer-key": {Subject: "writer", Scopes: []string{api.ScopeWrite}},
+	})
+	body := `{"name":"Lasagna","price":13.49}`
+
+	w := do(r, "GET", "/menu", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusUnauthorized, doWithHeader(r, "GET", "/menu", "", "X-API-Key", "wrong").Code)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/menu", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "POST", "/menu", body, "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "POST", "/admin/fixtures/generate", "", "X-API-Key", "reader-key").Code)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/menu/1", "", "X-API-Key", "writer-key").Code)
+	assert.Equal(t, http.StatusCreated, doWithHeader(r, "POST", "/menu", body, "X-API-Key", "writer-key").Code)
+
+	// Health checks and docs stay open.
+	for _, path := range []string{"/livez", "/openapi.json", "/docs"} {
+		assert.Equal(t, http.StatusOK, do(r, "GET", path, "").Code, path)
+	}
+}
+
+func TestAPIKeysFromEnv(t *testing.T) {
+	keys := filepath.Join(t.TempDir(), "api-keys")
+	require.NoError(t, os.WriteFile(keys, []byte("# ci keys\nreader-key read ci-reader\n\nwriter-key read write\n"), 0o600))
+	t.Setenv("AUTH_API_KEYS_FILE", keys)
 
-	r.GET("/menu", func(c *gin.Context) {
-		c.JSON(http.StatusOK, testMenuItems)
+	auth, err := api.AuthFromEnv()
+	require.NoError(t, err)
+	r := withAuth(t, auth)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/menu", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "DELETE", "/menu/1", "", "X-API-Key", "reader-key").Code)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/menu/1", "", "X-API-Key", "writer-key").Code)
+
+	t.Setenv("AUTH_API_KEYS_FILE", "")
+	auth, err = api.AuthFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, auth, "no configuration leaves the API open")
+}
+
+func TestBearerTokensAreVerifiedAgainstJWKS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"EC","crv":"P-256","kid":"test","use":"sig","x":%q,"y":%q}]}`,
+		base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))))
+	auth, err := api.NewJWTAuth([]byte(jwks), "https://issuer.test", "restaurant")
+	require.NoError(t, err)
+	r := withAuth(t, auth)
+
+	sign := func(claims map[string]any) string {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","kid":"test","typ":"JWT"}`))
+		payload, err := json.Marshal(claims)
+		require.NoError(t, err)
+		input := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(input))
+		sr, ss, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		require.NoError(t, err)
+		sig := append(sr.FillBytes(make([]byte, 32)), ss.FillBytes(make([]byte, 32))...)
+		return "Bearer " + input + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+	claims := func(scope, aud string, exp time.Duration) map[string]any {
+		return map[string]any{
+			"sub":   "client-1",
+			"iss":   "https://issuer.test",
+			"aud":   aud,
+			"exp":   time.Now().Add(exp).Unix(),
+			"scope": scope,
+		}
+	}
+
+	reader := sign(claims("read", "restaurant", time.Hour))
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/menu", "", "Authorization", reader).Code)
+	assert.Equal(t, http.StatusForbidden, doWithHeader(r, "DELETE", "/menu/1", "", "Authorization", reader).Code)
+
+	writer := sign(claims("read write", "restaurant", time.Hour))
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/menu/1", "", "Authorization", writer).Code)
+
+	for name, token := range map[string]string{
+		"expired":        sign(claims("read", "restaurant", -time.Hour)),
+		"other audience": sign(claims("read", "coffee-shop", time.Hour)),
+		"tampered":       reader[:len(reader)-4] + "AAAA",
+	} {
+		w := doWithHeader(r, "GET", "/menu", "", "Authorization", token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+	}
+}
+
+func TestRateLimitPerClientIPAndCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultMenuItems()), api.Config{
+		Auth:   api.APIKeys{"shared-key": {Subject: "load-test", Scopes: []string{api.ScopeRead}}},
+		Limits: api.Limits{RequestsPerSecond: 1, Burst: 2},
 	})
+	from := func(ip, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = ip + ":40000"
+		req.Header.Set("X-API-Key", "shared-key")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/menu").Code)
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/menu/1").Code)
+	w := from("10.0.0.1", "/menu")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// Probes are never limited.
+	assert.Equal(t, http.StatusOK, from("10.0.0.1", "/livez").Code)
+
+	// A new IP gets its own bucket, but the key it shares is spent.
+	assert.Equal(t, http.StatusTooManyRequests, from("10.0.0.2", "/menu").Code)
+}
+
+func TestForwardedForIsOnlyTrustedFromProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(proxies ...string) http.Handler {
+		return api.NewRouter(api.NewMemoryStore(api.DefaultMenuItems()), api.Config{
+			Limits: api.Limits{RequestsPerSecond: 1, Burst: 1, TrustedProxies: proxies},
+		})
+	}
+	get := func(r http.Handler, peer, forwardedFor string) int {
+		req := httptest.NewRequest("GET", "/menu/1", nil)
+		req.RemoteAddr = peer + ":40000"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// By default a client cannot leave its bucket by naming another address.
+	r := newRouter()
+	assert.Equal(t, http.StatusOK, get(r, "203.0.113.7", "10.0.0.1"))
+	assert.Equal(t, http.StatusTooManyRequests, get(r, "203.0.113.7", "10.0.0.2"))
+
+	// Behind a trusted proxy, each forwarded client has its own bucket.
+	r = newRouter("192.0.2.0/24")
+	assert.Equal(t, http.StatusOK, get(r, "192.0.2.10", "10.0.0.1"))
+	assert.Equal(t, http.StatusOK, get(r, "192.0.2.10", "10.0.0.2"))
+	assert.Equal(t, http.StatusTooManyRequests, get(r, "192.0.2.10", "10.0.0.1"))
+}
+
+func TestLargeBodiesAreRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultMenuItems()), api.Config{
+		ValidateRequests: true,
+		Limits:           api.Limits{MaxBodyBytes: 64},
+	})
+
+	w := do(r, "POST", "/menu", `{"name":"`+strings.Repeat("x", 64)+`","price":13.49}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	req := httptest.NewRequest("POST", "/menu", io.MultiReader(strings.NewReader(`{"name":"`+strings.Repeat("x", 64)), strings.NewReader(`","price":13.49}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, "bodies without a Content-Length are capped too")
+
+	assert.Equal(t, http.StatusCreated, do(r, "POST", "/menu", `{"name":"Lasagna","price":13.49}`).Code)
+
+	w = do(r, "POST", "/admin/fixtures", `[{"name":"`+strings.Repeat("x", 64)+`","price":13.49}]`)
+	assert.Equal(t, http.StatusOK, w.Code, "fixture imports are not held to the cap: %s", w.Body.String())
+}
+
+func TestFixtureImportsAreValidated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Seed files never reach the OpenAPI validator, so the import has to
+	// check the records itself.
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultMenuItems()), api.Config{})
+
+	w := do(r, "POST", "/admin/fixtures", `[{"name":"Lasagna","price":13.49},{"name":"","price":9.5}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "record 1")
+
+	w = doWithHeader(r, "POST", "/admin/fixtures", "name,price\nLasagna,13.49\nRisotto,-2\n", "Content-Type", "text/csv")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "record 1")
+
+	stored := decode[[]api.MenuItem](t, do(r, "GET", "/admin/fixtures", ""))
+	assert.Len(t, stored, len(api.DefaultMenuItems()), "nothing is stored from a rejected import")
+}
+
+func TestConditionalRequestsUseETags(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, "GET", "/menu/3", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	tag := w.Header().Get("ETag")
+	require.NotEmpty(t, tag)
+
+	w = doWithHeader(r, "GET", "/menu/3", "", "If-None-Match", tag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, tag, w.Header().Get("ETag"))
+
+	w = doWithHeader(r, "PUT", "/menu/3", `{"name":"Pasta","price":12.49}`, "If-Match", tag)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	updated := w.Header().Get("ETag")
+	assert.NotEqual(t, tag, updated)
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "GET", "/menu/3", "", "If-None-Match", tag).Code)
+
+	// A client still holding the old version loses the race.
+	w = doWithHeader(r, "PUT", "/menu/3", `{"name":"Pasta","price":13.99}`, "If-Match", tag)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, http.StatusPreconditionFailed, doWithHeader(r, "DELETE", "/menu/3", "", "If-Match", tag).Code)
+	assert.Equal(t, http.StatusPreconditionFailed, doWithHeader(r, "DELETE", "/menu/3", "", "If-Match", "W/"+updated).Code)
+
+	assert.Equal(t, http.StatusOK, doWithHeader(r, "DELETE", "/menu/3", "", "If-Match", tag+", "+updated).Code)
+	assert.Equal(t, http.StatusNotFound, doWithHeader(r, "DELETE", "/menu/3", "", "If-Match", updated).Code)
+}
+
+func TestPatchMenuItem(t *testing.T) {
+	r, store := newRouter(t)
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		return doWithHeader(r, "PATCH", "/menu/1", body, "Content-Type", contentType)
+	}
+
+	w := patch("application/merge-patch+json", `{"price":13.49}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, api.MenuItem{ID: 1, Name: "Pizza", Price: 13.49}, decode[api.MenuItem](t, w))
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+
+	w = patch("application/json-patch+json", `[{"op":"test","path":"/name","value":"Pizza"},{"op":"replace","path":"/name","value":"Calzone"}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	stored, _, err := store.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, api.MenuItem{ID: 1, Name: "Calzone", Price: 13.49}, stored)
+
+	// The patched menu item is validated, and nothing is stored when it fails.
+	assert.Equal(t, http.StatusUnprocessableEntity, patch("application/merge-patch+json", `{"name":null}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("application/merge-patch+json", `{"price":-1}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, patch("application/json-patch+json", `[{"op":"add","path":"/cuisine","value":"Italian"}]`).Code)
+	assert.Equal(t, http.StatusConflict, patch("application/json-patch+json", `[{"op":"test","path":"/name","value":"Pizza"}]`).Code)
+	assert.Equal(t, http.StatusConflict, patch("application/json-patch+json", `[{"op":"remove","path":"/cuisine"}]`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("application/json-patch+json", `[{"op":"replace","path":"name","value":"Lasagna"}]`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("application/json", `{"price":1}`).Code)
+	stored, _, err = store.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, api.MenuItem{ID: 1, Name: "Calzone", Price: 13.49}, stored)
 
-	r.GET("/menu/:id", func(c *gin.Context) {
-		id := c.Param("id")
-		for _, item := range testMenuItems {
-			if fmt.Sprintf("%d", item.ID) == id {
-				c.JSON(http.StatusOK, item)
-				return
+	assert.Equal(t, http.StatusNotFound, doWithHeader(r, "PATCH", "/menu/99", `{"price":1}`, "Content-Type", "application/merge-patch+json").Code)
+}
+
+func TestPatchMenuItemHonoursIfMatch(t *testing.T) {
+	r, _ := newRouter(t)
+	tag := do(r, "GET", "/menu/1", "").Header().Get("ETag")
+	require.Equal(t, http.StatusOK, do(r, "PUT", "/menu/1", `{"name":"Pizza","price":13.99}`).Code)
+
+	req := httptest.NewRequest("PATCH", "/menu/1", strings.NewReader(`{"price":11.49}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", tag)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, 13.99, decode[api.MenuItem](t, do(r, "GET", "/menu/1", "")).Price)
+}
+
+// coffeeShop serves GET /coffees/{id} like the coffee-shop service, after
+// answering the first failures requests with 503. It counts the requests it
+// receives.
+type coffeeShop struct {
+	failures int
+	calls    atomic.Int32
+}
+
+func (s *coffeeShop) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if int(s.calls.Add(1)) <= s.failures {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.URL.Path != "/coffees/1" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"id":1,"name":"Espresso","price":2.99}`))
+}
+
+// newOrderRouter returns the service router using the coffee-shop at url.
+func newOrderRouter(t *testing.T, url string, opts api.CoffeeShopOptions) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	opts.Backoff = time.Millisecond
+	return api.NewRouter(api.NewMemoryStore(api.DefaultMenuItems()), api.Config{
+		ValidateRequests: true,
+		CoffeeShop:       api.NewCoffeeShopClient(url, opts),
+	})
+}
+
+func TestCreateOrderPricesMenuItemsAndCoffees(t *testing.T) {
+	shop := httptest.NewServer(&coffeeShop{})
+	defer shop.Close()
+	r := newOrderRouter(t, shop.URL, api.CoffeeShopOptions{})
+
+	w := do(r, "POST", "/orders", `{"items":[{"menu_item_id":1,"quantity":2}],"coffees":[{"coffee_id":1,"quantity":1}]}`)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	want := api.Order{
+		ID:      1,
+		Items:   []api.OrderItem{{MenuItemID: 1, Quantity: 2, Name: "Pizza", UnitPrice: 12.99}},
+		Coffees: []api.OrderCoffee{{CoffeeID: 1, Quantity: 1, Name: "Espresso", UnitPrice: 2.99}},
+		Total:   28.97,
+	}
+	assert.Equal(t, want, decode[api.Order](t, w))
+	assert.Equal(t, want, decode[api.Order](t, do(r, "GET", "/orders/1", "")))
+
+	require.Equal(t, http.StatusOK, do(r, "DELETE", "/orders/1", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(r, "GET", "/orders/1", "").Code)
+}
+
+func TestCreateOrderRejectsUnknownReferences(t *testing.T) {
+	shop := httptest.NewServer(&coffeeShop{})
+	defer shop.Close()
+	r := newOrderRouter(t, shop.URL, api.CoffeeShopOptions{})
+
+	assert.Equal(t, http.StatusBadRequest, do(r, "POST", "/orders", `{"items":[],"coffees":[]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(r, "POST", "/orders", `{"items":[{"menu_item_id":1,"quantity":0}]}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(r, "POST", "/orders", `{"items":[{"menu_item_id":99,"quantity":1}]}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(r, "POST", "/orders", `{"coffees":[{"coffee_id":99,"quantity":1}]}`).Code)
+	assert.Equal(t, "0", do(r, "GET", "/orders", "").Header().Get("X-Total-Count"))
+}
+
+func TestCreateOrderWithoutCoffeeShop(t *testing.T) {
+	r, _ := newRouter(t)
+
+	assert.Equal(t, http.StatusServiceUnavailable, do(r, "POST", "/orders", `{"coffees":[{"coffee_id":1,"quantity":1}]}`).Code)
+	assert.Equal(t, http.StatusCreated, do(r, "POST", "/orders", `{"items":[{"menu_item_id":1,"quantity":1}]}`).Code)
+}
+
+func TestCoffeeShopFailuresAreRetried(t *testing.T) {
+	shop := &coffeeShop{failures: 2}
+	srv := httptest.NewServer(shop)
+	defer srv.Close()
+	r := newOrderRouter(t, srv.URL, api.CoffeeShopOptions{Retries: 2})
+
+	w := do(r, "POST", "/orders", `{"coffees":[{"coffee_id":1,"quantity":1}]}`)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, int32(3), shop.calls.Load())
+}
+
+func TestCoffeeShopAttemptsTimeOut(t *testing.T) {
+	calls := atomic.Int32{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+	r := newOrderRouter(t, srv.URL, api.CoffeeShopOptions{Timeout: 20 * time.Millisecond, Retries: 1})
+
+	start := time.Now()
+	w := do(r, "POST", "/orders", `{"coffees":[{"coffee_id":1,"quantity":1}]}`)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestCoffeeShopCircuitOpensAfterFailures(t *testing.T) {
+	shop := &coffeeShop{failures: 1000}
+	srv := httptest.NewServer(shop)
+	defer srv.Close()
+	r := newOrderRouter(t, srv.URL, api.CoffeeShopOptions{FailureThreshold: 2, Cooldown: 50 * time.Millisecond})
+	order := `{"coffees":[{"coffee_id":1,"quantity":1}]}`
+
+	// Two lookups fail after their retries, which opens the circuit.
+	assert.Equal(t, http.StatusServiceUnavailable, do(r, "POST", "/orders", order).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, do(r, "POST", "/orders", order).Code)
+	assert.Equal(t, int32(2), shop.calls.Load())
+
+	w := do(r, "POST", "/orders", order)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "circuit open")
+	assert.Equal(t, int32(2), shop.calls.Load())
+
+	// After the cooldown a trial lookup is let through and closes the
+	// circuit when it succeeds.
+	shop.failures = 0
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, http.StatusCreated, do(r, "POST", "/orders", order).Code)
+	assert.Equal(t, http.StatusCreated, do(r, "POST", "/orders", order).Code)
+}
+
+func TestCancelledLookupsDoNotOpenTheCircuit(t *testing.T) {
+	var hang atomic.Bool
+	hang.Store(true)
+	shop := &coffeeShop{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hang.Load() {
+			<-r.Context().Done()
+			return
+		}
+		shop.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	client := api.NewCoffeeShopClient(srv.URL, api.CoffeeShopOptions{Retries: 1, Backoff: time.Millisecond, FailureThreshold: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.Coffee(ctx, 1)
+	require.ErrorIs(t, err, api.ErrCoffeeShopUnavailable)
+
+	// The caller gave up, not the coffee-shop, so the circuit stays closed.
+	hang.Store(false)
+	coffee, err := client.Coffee(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Espresso", coffee.Name)
+}
+
+func TestCoffeeShopCallsCarryTheRequestIDs(t *testing.T) {
+	var mu sync.Mutex
+	var headers []http.Header
+	shop := &coffeeShop{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Clone())
+		mu.Unlock()
+		shop.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	r := newOrderRouter(t, srv.URL, api.CoffeeShopOptions{})
+	order := `{"coffees":[{"coffee_id":1,"quantity":1}]}`
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(order))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "req-42")
+	req.Header.Set("traceparent", traceparent)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// A request without an ID passes on the one generated for it.
+	w = do(r, "POST", "/orders", order)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, headers, 2)
+	assert.Equal(t, "req-42", headers[0].Get("X-Request-ID"))
+	assert.Equal(t, traceparent, headers[0].Get("traceparent"))
+	assert.Equal(t, w.Header().Get("X-Request-ID"), headers[1].Get("X-Request-ID"))
+	assert.Empty(t, headers[1].Get("traceparent"))
+}
+
+// newEventsServer serves the router over HTTP, so that event streams can be
+// read while other requests change the menu items.
+func newEventsServer(t *testing.T, opts api.EventOptions) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultMenuItems()), api.Config{ValidateRequests: true, Events: opts})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func send(t *testing.T, srv *httptest.Server, method, path, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// sseEvent is an event as read off a stream.
+type sseEvent struct {
+	id, name string
+	data     api.Event
+}
+
+// readEvents reads n events off a stream, skipping comments.
+func readEvents(t *testing.T, lines *bufio.Scanner, n int) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var ev sseEvent
+	for len(events) < n && lines.Scan() {
+		line := lines.Text()
+		switch {
+		case line == "":
+			if ev.name != "" {
+				events = append(events, ev)
 			}
+			ev = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev.data))
 		}
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	}
+	require.Len(t, events, n, "stream ended early: %v", lines.Err())
+	return events
+}
+
+func TestEventStream(t *testing.T) {
+	srv := newEventsServer(t, api.EventOptions{Heartbeat: 10 * time.Millisecond})
+
+	resp := send(t, srv, "GET", "/events?resource=menu", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	lines := bufio.NewScanner(resp.Body)
+
+	require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/menu", `{"name":"Risotto","price":13.5}`).StatusCode)
+	require.Equal(t, http.StatusOK, send(t, srv, "PUT", "/menu/1", `{"name":"Margherita","price":11}`).StatusCode)
+	require.Equal(t, http.StatusOK, send(t, srv, "DELETE", "/menu/2", "").StatusCode)
+
+	// Heartbeats between events are skipped.
+	events := readEvents(t, lines, 3)
+	assert.Equal(t, []string{"created", "updated", "deleted"}, []string{events[0].name, events[1].name, events[2].name})
+	assert.Equal(t, []string{"1", "2", "3"}, []string{events[0].id, events[1].id, events[2].id})
+	assert.Equal(t, "menu", events[0].data.Resource)
+	assert.Equal(t, "Risotto", events[0].data.Data.(map[string]any)["name"])
+	assert.Equal(t, 1, events[1].data.ResourceID)
+	assert.Equal(t, 11.0, events[1].data.Data.(map[string]any)["price"])
+	assert.Nil(t, events[2].data.Data)
+
+	// A client that reconnects gets the events it missed, then the stream
+	// ends when it does not follow.
+	req, err := http.NewRequest("GET", srv.URL+"/events?follow=false", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err = srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resumed := readEvents(t, bufio.NewScanner(bytes.NewReader(body)), 2)
+	assert.Equal(t, []string{"2", "3"}, []string{resumed[0].id, resumed[1].id})
+}
+
+func TestEventStreamResetsWhenEventsWereDropped(t *testing.T) {
+	srv := newEventsServer(t, api.EventOptions{History: 2})
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/menu", `{"name":"Risotto","price":13.5}`).StatusCode)
+	}
+
+	body, err := io.ReadAll(send(t, srv, "GET", "/events?last_event_id=0&follow=false", "").Body)
+	require.NoError(t, err)
+	events := readEvents(t, bufio.NewScanner(bytes.NewReader(body)), 3)
+	assert.Equal(t, "reset", events[0].name, "the first event was dropped from the history")
+	assert.Equal(t, []string{"2", "3"}, []string{events[1].id, events[2].id})
+
+	// So are IDs from before a restart.
+	body, err = io.ReadAll(send(t, srv, "GET", "/events?last_event_id=99&follow=false", "").Body)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(body), "event: reset\n"), string(body))
+
+	body, err = io.ReadAll(send(t, srv, "GET", "/events?last_event_id=3&follow=false", "").Body)
+	require.NoError(t, err)
+	assert.Empty(t, string(body))
+}
+
+func TestWebhookDeliveriesAreSignedAndRetried(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+	var delivered []api.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		if err := api.VerifySignature("s3cret", r.Header.Get(api.SignatureHeader), body, time.Minute); err != nil {
+			t.Errorf("delivery not signed: %v", err)
+		}
+		if err := api.VerifySignature("other", r.Header.Get(api.SignatureHeader), body, time.Minute); err == nil {
+			t.Error("signature verified with the wrong secret")
+		}
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event api.Event
+		json.Unmarshal(body, &event)
+		if event.Type == api.EventDeleted {
+			// Not worth retrying.
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		assert.Equal(t, r.Header.Get("X-Event-ID"), strconv.FormatInt(event.ID, 10))
+		delivered = append(delivered, event)
+	}))
+	defer receiver.Close()
+
+	srv := newEventsServer(t, api.EventOptions{WebhookBackoff: time.Millisecond})
+	resp := send(t, srv, "POST", "/webhooks", fmt.Sprintf(`{"url":%q,"secret":"s3cret","resources":["menu"]}`, receiver.URL))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var hook api.Webhook
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&hook))
+	assert.Equal(t, "s3cret", hook.Secret)
+
+	require.Equal(t, http.StatusCreated, send(t, srv, "POST", "/menu", `{"name":"Risotto","price":13.5}`).StatusCode)
+	require.Equal(t, http.StatusOK, send(t, srv, "DELETE", "/menu/2", "").StatusCode)
+
+	require.Eventually(t, func() bool {
+		var got api.Webhook
+		json.NewDecoder(send(t, srv, "GET", fmt.Sprintf("/webhooks/%d", hook.ID), "").Body).Decode(&got)
+		return got.Delivered == 1 && got.Failed == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, 3, attempts)
+	require.Len(t, delivered, 1)
+	assert.Equal(t, api.EventCreated, delivered[0].Type)
+	mu.Unlock()
+
+	var listed []api.Webhook
+	require.NoError(t, json.NewDecoder(send(t, srv, "GET", "/webhooks", "").Body).Decode(&listed))
+	require.Len(t, listed, 1)
+	assert.Empty(t, listed[0].Secret, "the secret was returned after the webhook was created")
+	assert.Contains(t, listed[0].LastError, "410")
+
+	require.Equal(t, http.StatusOK, send(t, srv, "DELETE", fmt.Sprintf("/webhooks/%d", hook.ID), "").StatusCode)
+	assert.Equal(t, http.StatusNotFound, send(t, srv, "GET", fmt.Sprintf("/webhooks/%d", hook.ID), "").StatusCode)
+	assert.Equal(t, http.StatusBadRequest, send(t, srv, "POST", "/webhooks", `{"url":"https://hooks.example.com","resources":["cats"]}`).StatusCode)
+}
+
+func TestVerifySignatureRejectsOldDeliveries(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	old := time.Now().Add(-time.Hour).Unix()
+	fmt.Fprintf(mac, "%d.", old)
+	mac.Write(body)
+	header := fmt.Sprintf("t=%d,v1=%x", old, mac.Sum(nil))
+
+	assert.NoError(t, api.VerifySignature("s3cret", header, body, 2*time.Hour))
+	assert.ErrorIs(t, api.VerifySignature("s3cret", header, body, time.Minute), api.ErrBadSignature)
+	assert.ErrorIs(t, api.VerifySignature("s3cret", header, []byte(`{"id":2}`), 2*time.Hour), api.ErrBadSignature)
+	assert.ErrorIs(t, api.VerifySignature("s3cret", "", body, time.Minute), api.ErrBadSignature)
+}
+
+// newGRPCClient serves the gRPC server of NewServers over an in-memory
+// listener and returns a connection to it, with the router next to it.
+func newGRPCClient(t *testing.T, cfg api.Config) (*gin.Engine, *grpc.ClientConn) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r, srv := api.NewServers(api.NewMemoryStore(api.DefaultMenuItems()), cfg)
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return r, conn
+}
+
+func TestGRPCMenu(t *testing.T) {
+	r, conn := newGRPCClient(t, api.Config{})
+	client := pb.NewMenuServiceClient(conn)
+	ctx := context.Background()
+
+	list, err := client.ListMenuItems(ctx, &pb.ListMenuItemsRequest{Limit: 5, Offset: 10})
+	require.NoError(t, err)
+	assert.EqualValues(t, 15, list.Total)
+	require.Len(t, list.Items, 5)
+	assert.Equal(t, "Ice Cream", list.Items[0].Name)
+
+	item, err := client.GetMenuItem(ctx, &pb.GetMenuItemRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Pizza", item.Name)
+
+	created, err := client.CreateMenuItem(ctx, &pb.CreateMenuItemRequest{Item: &pb.MenuItem{Name: "Gnocchi", Price: 3.19}})
+	require.NoError(t, err)
+	assert.EqualValues(t, 16, created.Id)
+	// Both APIs serve the same store.
+	assert.Equal(t, "Gnocchi", decode[api.MenuItem](t, do(r, "GET", "/menu/16", "")).Name)
+
+	updated, err := client.UpdateMenuItem(ctx, &pb.UpdateMenuItemRequest{Id: 16, Item: &pb.MenuItem{Name: "Gnocchi", Price: 3.29}})
+	require.NoError(t, err)
+	assert.Equal(t, 3.29, updated.Price)
+
+	_, err = client.DeleteMenuItem(ctx, &pb.DeleteMenuItemRequest{Id: 16})
+	require.NoError(t, err)
+	_, err = client.GetMenuItem(ctx, &pb.GetMenuItemRequest{Id: 16})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CreateMenuItem(ctx, &pb.CreateMenuItemRequest{Item: &pb.MenuItem{Price: 3.19}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.CreateMenuItem(ctx, &pb.CreateMenuItemRequest{Item: &pb.MenuItem{Name: "Gnocchi", Price: -1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.ListMenuItems(ctx, &pb.ListMenuItemsRequest{Limit: 1001})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCHealthFollowsReadiness(t *testing.T) {
+	readiness := &api.Readiness{}
+	_, conn := newGRPCClient(t, api.Config{Readiness: readiness})
+	health := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("liveness"))
+
+	readiness.SetWarmedUp()
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("restaurant.v1.MenuService"))
+
+	readiness.SetDraining()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("liveness"))
+
+	_, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCReflectionListsTheServices(t *testing.T) {
+	_, conn := newGRPCClient(t, api.Config{})
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	var names []string
+	for _, s := range resp.GetListServicesResponse().Service {
+		names = append(names, s.Name)
+	}
+	assert.Contains(t, names, "restaurant.v1.MenuService")
+	assert.Contains(t, names, "grpc.health.v1.Health")
+}
+
+func TestGRPCCallsNeedTheMethodScope(t *testing.T) {
+	_, conn := newGRPCClient(t, api.Config{Auth: api.APIKeys{
+		"reader-key": {Subject: "reader", Scopes: []string{api.ScopeRead}},
+		"writer-key": {Subject: "writer", Scopes: []string{api.ScopeWrite}},
+	}})
+	client := pb.NewMenuServiceClient(conn)
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+	}
+	create := &pb.CreateMenuItemRequest{Item: &pb.MenuItem{Name: "Gnocchi", Price: 3.19}}
+
+	_, err := client.GetMenuItem(context.Background(), &pb.GetMenuItemRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.GetMenuItem(withKey("wrong"), &pb.GetMenuItemRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.GetMenuItem(withKey("reader-key"), &pb.GetMenuItemRequest{Id: 1})
+	assert.NoError(t, err)
+	_, err = client.CreateMenuItem(withKey("reader-key"), create)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.CreateMenuItem(withKey("writer-key"), create)
+	assert.NoError(t, err)
+
+	// Health checks stay open.
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "liveness"})
+	assert.NoError(t, err)
+}
+
+func TestGRPCChangesArePublished(t *testing.T) {
+	r, conn := newGRPCClient(t, api.Config{})
+	_, err := pb.NewMenuServiceClient(conn).DeleteMenuItem(context.Background(), &pb.DeleteMenuItemRequest{Id: 2})
+	require.NoError(t, err)
+
+	w := do(r, "GET", "/events?last_event_id=0&follow=false", "")
+	events := readEvents(t, bufio.NewScanner(w.Body), 1)
+	assert.Equal(t, "deleted", events[0].name)
+	assert.Equal(t, 2, events[0].data.ResourceID)
+}
+
+func TestChangesArePublishedOnTheBus(t *testing.T) {
+	bus := api.NewLocalBus()
+	defer bus.Close()
+	received := make(chan api.Event, 1)
+	_, err := bus.Subscribe("restaurant.menu.*", func(subject string, data []byte) {
+		var event api.Event
+		assert.Equal(t, "restaurant.menu.deleted", subject)
+		assert.NoError(t, json.Unmarshal(data, &event))
+		received <- event
 	})
+	require.NoError(t, err)
+	_, conn := newGRPCClient(t, api.Config{Bus: bus})
+	_, err = pb.NewMenuServiceClient(conn).DeleteMenuItem(context.Background(), &pb.DeleteMenuItemRequest{Id: 2})
+	require.NoError(t, err)
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "deleted", event.Type)
+		assert.Equal(t, 2, event.ResourceID)
+	case <-time.After(time.Second):
+		t.Fatal("no event on the bus")
+	}
+}
+
+func TestCoffeePricesFollowTheCoffeeShopEvents(t *testing.T) {
+	shop := &coffeeShop{}
+	srv := httptest.NewServer(shop)
+	defer srv.Close()
+	bus := api.NewLocalBus()
+	defer bus.Close()
+	prices, err := api.CoffeePricesFromEnv(api.NewCoffeeShopClient(srv.URL, api.CoffeeShopOptions{}), bus)
+	require.NoError(t, err)
+	gin.SetMode(gin.TestMode)
+	r := api.NewRouter(api.NewMemoryStore(api.DefaultMenuItems()), api.Config{ValidateRequests: true, CoffeeShop: prices})
+	order := `{"coffees":[{"coffee_id":1,"quantity":2}]}`
+
+	// The coffee is looked up once, then served from the cache.
+	for i := 0; i < 2; i++ {
+		w := do(r, "POST", "/orders", order)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Equal(t, 5.98, decode[api.Order](t, w).Total)
+	}
+	assert.Equal(t, int32(1), shop.calls.Load())
+
+	// Price changes published by the coffee-shop apply to the next orders.
+	require.NoError(t, bus.Publish("coffee-shop.coffees.updated", []byte(`{"id":4,"type":"updated","resource":"coffees","resource_id":1,"data":{"id":1,"name":"Espresso","price":3.49}}`)))
+	require.Eventually(t, func() bool {
+		coffee, err := prices.Coffee(context.Background(), 1)
+		return err == nil && coffee.Price == 3.49
+	}, time.Second, 5*time.Millisecond)
+	w := do(r, "POST", "/orders", order)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, 6.98, decode[api.Order](t, w).Total)
+
+	// So do deletions, and events of other resources are ignored.
+	require.NoError(t, bus.Publish("coffee-shop.coffees.deleted", []byte(`{"id":5,"type":"deleted","resource":"coffees","resource_id":1}`)))
+	require.NoError(t, bus.Publish("restaurant.menu.updated", []byte(`{"id":6,"type":"updated","resource":"menu","resource_id":1,"data":{"id":1,"name":"Pizza","price":0}}`)))
+	require.Eventually(t, func() bool {
+		_, err := prices.Coffee(context.Background(), 1)
+		return errors.Is(err, api.ErrUnknownCoffee)
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(r, "POST", "/orders", order).Code)
+	assert.Equal(t, int32(1), shop.calls.Load())
+}
+
+func TestCoffeePricesExpire(t *testing.T) {
+	shop := &coffeeShop{}
+	srv := httptest.NewServer(shop)
+	defer srv.Close()
+	prices := api.NewCoffeePriceCache(api.NewCoffeeShopClient(srv.URL, api.CoffeeShopOptions{}), 20*time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		_, err := prices.Coffee(context.Background(), 1)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), shop.calls.Load())
+	time.Sleep(30 * time.Millisecond)
+	_, err := prices.Coffee(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), shop.calls.Load())
 
-	return r
+	// Without a bus there is nothing to keep a cache current.
+	catalog := api.NewCoffeeShopClient(srv.URL, api.CoffeeShopOptions{})
+	same, err := api.CoffeePricesFromEnv(catalog, nil)
+	require.NoError(t, err)
+	assert.Same(t, catalog, same)
 }
@@ -0,0 +1,3 @@
+module openapi-gen
+
+go 1.23
@@ -0,0 +1,92 @@
+// Command openapi-gen builds the OpenAPI 3.0 specification of a service from
+// the swag-style annotations on its handlers and the Go types they reference,
+// and writes it to openapi_gen.go in the same package as the openAPISpec
+// constant.
+//
+// Each service runs it through go generate:
+//
+//	//go:generate go run -C ../../tools/openapi-gen . -dir ../../coffee-shop/api
+//
+// General API information is read from the comment group that contains
+// @title (usually the doc comment of the router constructor):
+//
+//	@title, @version, @description, @contact.name, @contact.url,
+//	@contact.email, @license.name, @license.url, @host, @BasePath, @schemes
+//
+// Security schemes are declared in the same comment group. Each is followed
+// by the lines that describe it:
+//
+//	@securityDefinitions.apikey  Name, then @in header|query and @name X-API-Key
+//	@securityDefinitions.bearer  Name, then optionally @bearerFormat JWT
+//
+// Operations are read from the doc comment of any function or variable that
+// contains @Router:
+//
+//	@Summary  text
+//	@Description  text (repeatable, lines are joined)
+//	@ID  operationId (defaults to the function or variable name)
+//	@Tags  tag1,tag2
+//	@Accept  json,csv (request body media types, default json)
+//	@Produce  json,csv,html (response media types, default json)
+//	@Param  name in type required "description" [attributes]
+//	@Success, @Failure  code {object|array|string|integer|number|boolean} Type "description"
+//	@Header  code[,code] {type} Name "description"
+//	@Security  Name[scope1,scope2] (repeatable, any one scheme suffices)
+//	@Router  /path/{param} [method] (repeatable)
+//
+// in is query, path, header or body. Parameter attributes are Enums(a,b),
+// default(v), example(v), minimum(n), maximum(n), minLength(n), maxLength(n)
+// and format(f). A body parameter with accept(type) applies to that media
+// type only, so an operation may declare one body per media type; the
+// description and required flag of the first one are used.
+//
+// Operations with parameters or a body that do not declare a 400 response get
+// one, since the services reject requests that do not match the spec.
+// Likewise, operations with a body get 413, and operations with @Security
+// get 401, 403 and 429, since the services rate limit those routes.
+//
+// Struct types become component schemas, named after the type with the first
+// letter upper-cased. Fields are named by their json tag and take the doc
+// comment as description. The struct tags example, enums, minimum, maximum,
+// minLength, maxLength, format, readonly:"true" and nullable:"true" are
+// honoured, and a binding or validate tag containing "required" marks the
+// field required.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "service directory to read")
+	out := flag.String("out", "openapi_gen.go", "output file, relative to -dir")
+	check := flag.Bool("check", false, "fail if the output file is out of date instead of writing it")
+	flag.Parse()
+
+	spec, pkg, err := generate(*dir)
+	if err != nil {
+		log.Fatalf("openapi-gen: %s: %v", *dir, err)
+	}
+	src, err := render(spec, pkg)
+	if err != nil {
+		log.Fatalf("openapi-gen: %v", err)
+	}
+
+	path := filepath.Join(*dir, *out)
+	if *check {
+		current, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(current, src) {
+			fmt.Fprintf(os.Stderr, "openapi-gen: %s is out of date, run go generate\n", path)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := os.WriteFile(path, src, 0o644); err != nil {
+		log.Fatalf("openapi-gen: %v", err)
+	}
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// annotation is a single "@Name value" comment line.
+type annotation struct {
+	name  string
+	value string
+}
+
+// annotated is a comment group with annotations and the declaration it
+// documents.
+type annotated struct {
+	decl        string
+	annotations []annotation
+}
+
+// generate reads the Go files of the service package in dir and builds its
+// spec. It also returns the package name.
+func generate(dir string) (*document, string, error) {
+	fset := token.NewFileSet()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var files []*ast.File
+	var pkg string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, dir+"/"+name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, "", err
+		}
+		pkg = f.Name.Name
+		files = append(files, f)
+	}
+
+	b := &schemaBuilder{types: make(map[string]*ast.TypeSpec), components: make(map[string]*schema)}
+	var general []annotation
+	var ops []annotated
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						if ts.Doc == nil {
+							ts.Doc = d.Doc
+						}
+						b.types[ts.Name.Name] = ts
+					}
+				}
+				if d.Tok == token.VAR && len(d.Specs) == 1 {
+					if vs := d.Specs[0].(*ast.ValueSpec); len(vs.Names) == 1 {
+						ops = appendAnnotated(ops, vs.Names[0].Name, d.Doc)
+					}
+				}
+			case *ast.FuncDecl:
+				ops = appendAnnotated(ops, d.Name.Name, d.Doc)
+			}
+		}
+		for _, group := range f.Comments {
+			if a := parseAnnotations(group); hasAnnotation(a, "@title") {
+				general = a
+			}
+		}
+	}
+	if general == nil {
+		return nil, "", fmt.Errorf("no general API annotations (@title) found")
+	}
+
+	doc := &document{
+		OpenAPI: "3.0.3",
+		Paths:   make(map[string]map[string]*operation),
+	}
+	if err := parseGeneral(doc, general); err != nil {
+		return nil, "", err
+	}
+
+	for _, a := range ops {
+		if err := addOperation(doc, b, a); err != nil {
+			return nil, "", fmt.Errorf("%s: %w", a.decl, err)
+		}
+	}
+	doc.Components.Schemas = b.components
+	return doc, pkg, nil
+}
+
+func appendAnnotated(ops []annotated, decl string, doc *ast.CommentGroup) []annotated {
+	if a := parseAnnotations(doc); hasAnnotation(a, "@Router") {
+		ops = append(ops, annotated{decl: decl, annotations: a})
+	}
+	return ops
+}
+
+func parseAnnotations(group *ast.CommentGroup) []annotation {
+	if group == nil {
+		return nil
+	}
+	var annotations []annotation
+	for _, line := range strings.Split(group.Text(), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "@") {
+			continue
+		}
+		name, value, _ := strings.Cut(line, " ")
+		annotations = append(annotations, annotation{name: name, value: strings.TrimSpace(value)})
+	}
+	return annotations
+}
+
+func hasAnnotation(annotations []annotation, name string) bool {
+	for _, a := range annotations {
+		if a.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func parseGeneral(doc *document, annotations []annotation) error {
+	var host, basePath string
+	var schemes []string
+	// scheme is the security scheme the @in, @name and @bearerFormat lines
+	// that follow a @securityDefinitions line belong to.
+	var scheme *securityScheme
+	for _, a := range annotations {
+		switch a.name {
+		case "@securityDefinitions.apikey", "@securityDefinitions.bearer":
+			if a.value == "" {
+				return fmt.Errorf("%s needs a scheme name", a.name)
+			}
+			scheme = &securityScheme{Type: "apiKey"}
+			if a.name == "@securityDefinitions.bearer" {
+				scheme = &securityScheme{Type: "http", Scheme: "bearer"}
+			}
+			if doc.Components.SecuritySchemes == nil {
+				doc.Components.SecuritySchemes = make(map[string]*securityScheme)
+			}
+			doc.Components.SecuritySchemes[a.value] = scheme
+			continue
+		case "@in", "@name", "@bearerFormat":
+			if scheme == nil {
+				return fmt.Errorf("%s outside a @securityDefinitions block", a.name)
+			}
+			switch a.name {
+			case "@in":
+				scheme.In = a.value
+			case "@name":
+				scheme.Name = a.value
+			default:
+				scheme.BearerFormat = a.value
+			}
+			continue
+		}
+		scheme = nil
+
+		switch a.name {
+		case "@title":
+			doc.Info.Title = a.value
+		case "@version":
+			doc.Info.Version = a.value
+		case "@description":
+			doc.Info.Description = joinLines(doc.Info.Description, a.value)
+		case "@contact.name", "@contact.url", "@contact.email":
+			if doc.Info.Contact == nil {
+				doc.Info.Contact = &contact{}
+			}
+			switch a.name {
+			case "@contact.name":
+				doc.Info.Contact.Name = a.value
+			case "@contact.url":
+				doc.Info.Contact.URL = a.value
+			default:
+				doc.Info.Contact.Email = a.value
+			}
+		case "@license.name", "@license.url":
+			if doc.Info.License == nil {
+				doc.Info.License = &license{}
+			}
+			if a.name == "@license.name" {
+				doc.Info.License.Name = a.value
+			} else {
+				doc.Info.License.URL = a.value
+			}
+		case "@host":
+			host = a.value
+		case "@BasePath":
+			basePath = strings.TrimSuffix(a.value, "/")
+		case "@schemes":
+			schemes = strings.Fields(a.value)
+		}
+	}
+	if host != "" {
+		if len(schemes) == 0 {
+			schemes = []string{"http"}
+		}
+		for _, scheme := range schemes {
+			doc.Servers = append(doc.Servers, server{URL: scheme + "://" + host + basePath})
+		}
+	}
+	return nil
+}
+
+func joinLines(current, line string) string {
+	if current == "" {
+		return line
+	}
+	return current + "\n" + line
+}
+
+var (
+	routerPattern   = regexp.MustCompile(`^(\S+)\s+\[(\w+)\]$`)
+	securityPattern = regexp.MustCompile(`^(\w+)(?:\[([\w, ]*)\])?$`)
+	responsePattern = regexp.MustCompile(`^(\w+)(?:\s+\{(\w+)\}\s*([^\s"]*))?\s*(?:"(.*)")?$`)
+	headerPattern   = regexp.MustCompile(`^([\w,]+)\s+\{(\w+)\}\s+(\S+)\s*(?:"(.*)")?$`)
+	paramPattern    = regexp.MustCompile(`^(\S+)\s+(\w+)\s+(\S+)\s+(true|false)\s+"([^"]*)"\s*(.*)$`)
+	attrPattern     = regexp.MustCompile(`(\w+)\(([^)]*)\)`)
+)
+
+func addOperation(doc *document, b *schemaBuilder, a annotated) error {
+	op := &operation{OperationID: a.decl, Responses: make(map[string]*response)}
+	accept := []string{"application/json"}
+	produce := []string{"application/json"}
+	type route struct{ path, method string }
+	var routes []route
+
+	// Media types must be known before parameters and responses are built.
+	for _, ann := range a.annotations {
+		switch ann.name {
+		case "@Accept", "@Produce":
+			var types []string
+			for _, t := range strings.Split(ann.value, ",") {
+				types = append(types, mediaTypeOf(strings.TrimSpace(t)))
+			}
+			if ann.name == "@Accept" {
+				accept = types
+			} else {
+				produce = types
+			}
+		}
+	}
+
+	var headers []annotation
+	for _, ann := range a.annotations {
+		switch ann.name {
+		case "@Summary":
+			op.Summary = ann.value
+		case "@Description":
+			op.Description = joinLines(op.Description, ann.value)
+		case "@ID":
+			op.OperationID = ann.value
+		case "@Tags":
+			for _, tag := range strings.Split(ann.value, ",") {
+				op.Tags = append(op.Tags, strings.TrimSpace(tag))
+			}
+		case "@Param":
+			if err := addParam(op, b, ann.value, accept); err != nil {
+				return fmt.Errorf("@Param %s: %w", ann.value, err)
+			}
+		case "@Success", "@Failure":
+			if err := addResponse(op, b, ann.value, produce); err != nil {
+				return fmt.Errorf("%s %s: %w", ann.name, ann.value, err)
+			}
+		case "@Header":
+			headers = append(headers, ann)
+		case "@Security":
+			m := securityPattern.FindStringSubmatch(ann.value)
+			if m == nil {
+				return fmt.Errorf("invalid @Security %q", ann.value)
+			}
+			if _, ok := doc.Components.SecuritySchemes[m[1]]; !ok {
+				return fmt.Errorf("@Security %s: undefined security scheme", m[1])
+			}
+			scopes := []string{}
+			for _, scope := range strings.Split(m[2], ",") {
+				if scope = strings.TrimSpace(scope); scope != "" {
+					scopes = append(scopes, scope)
+				}
+			}
+			op.Security = append(op.Security, securityRequirement{m[1]: scopes})
+		case "@Router":
+			m := routerPattern.FindStringSubmatch(ann.value)
+			if m == nil {
+				return fmt.Errorf("invalid @Router %q", ann.value)
+			}
+			routes = append(routes, route{path: m[1], method: strings.ToLower(m[2])})
+		case "@Accept", "@Produce":
+		default:
+			return fmt.Errorf("unknown annotation %s", ann.name)
+		}
+	}
+
+	for _, ann := range headers {
+		m := headerPattern.FindStringSubmatch(ann.value)
+		if m == nil {
+			return fmt.Errorf("invalid @Header %q", ann.value)
+		}
+		for _, code := range strings.Split(m[1], ",") {
+			resp, ok := op.Responses[code]
+			if !ok {
+				return fmt.Errorf("@Header for undeclared response %s", code)
+			}
+			if resp.Headers == nil {
+				resp.Headers = make(map[string]header)
+			}
+			s := primitive(m[2])
+			if s == nil {
+				return fmt.Errorf("unknown header type %q", m[2])
+			}
+			resp.Headers[m[3]] = header{Description: m[4], Schema: s}
+		}
+	}
+
+	if len(op.Responses) == 0 {
+		return fmt.Errorf("no @Success or @Failure responses")
+	}
+	// The services validate requests against the spec, so any operation
+	// with inputs can answer 400, and they cap request bodies.
+	errorContent := contentFor([]string{"application/json"}, &schema{Type: "object", AdditionalProperties: &schema{Type: "string"}})
+	defaultResponse(op, "400", len(op.Parameters) > 0 || op.RequestBody != nil, &response{
+		Description: "Request does not match the specification",
+		Content:     errorContent,
+	})
+	defaultResponse(op, "413", op.RequestBody != nil, &response{
+		Description: "Request body is too large",
+		Content:     errorContent,
+	})
+	// Operations that require credentials can answer 401 and 403, and are
+	// rate limited.
+	defaultResponse(op, "401", len(op.Security) > 0, &response{Description: "Missing or invalid credentials", Content: errorContent})
+	defaultResponse(op, "403", len(op.Security) > 0, &response{Description: "Credentials lack the required scope", Content: errorContent})
+	defaultResponse(op, "429", len(op.Security) > 0, &response{
+		Description: "Rate limit exceeded",
+		Headers: map[string]header{
+			"Retry-After": {Description: "Seconds until the client may retry", Schema: &schema{Type: "integer"}},
+		},
+		Content: errorContent,
+	})
+	for _, r := range routes {
+		if doc.Paths[r.path] == nil {
+			doc.Paths[r.path] = make(map[string]*operation)
+		}
+		if _, dup := doc.Paths[r.path][r.method]; dup {
+			return fmt.Errorf("duplicate operation %s %s", strings.ToUpper(r.method), r.path)
+		}
+		doc.Paths[r.path][r.method] = op
+	}
+	return nil
+}
+
+// defaultResponse adds r to op as the response for code when cond holds and
+// the annotations do not document that code.
+func defaultResponse(op *operation, code string, cond bool, r *response) {
+	if _, ok := op.Responses[code]; !ok && cond {
+		op.Responses[code] = r
+	}
+}
+
+func addParam(op *operation, b *schemaBuilder, value string, accept []string) error {
+	m := paramPattern.FindStringSubmatch(value)
+	if m == nil {
+		return fmt.Errorf("expected: name in type required \"description\" [attributes]")
+	}
+	name, in, typ, required, description := m[1], m[2], m[3], m[4] == "true", m[5]
+
+	s, err := b.parseType(typ)
+	if err != nil {
+		return err
+	}
+	attrs := make(map[string]string)
+	for _, attr := range attrPattern.FindAllStringSubmatch(m[6], -1) {
+		key := attr[1]
+		if key == "Enums" {
+			key = "enums"
+		}
+		attrs[key] = attr[2]
+	}
+	// accept(type) limits a body parameter to one media type, so that an
+	// operation can take a different schema for each.
+	if t, ok := attrs["accept"]; ok {
+		if in != "body" {
+			return fmt.Errorf("accept() applies to body parameters only")
+		}
+		accept = []string{mediaTypeOf(t)}
+		delete(attrs, "accept")
+	}
+	if err := applyAttributes(s, attrs); err != nil {
+		return err
+	}
+
+	switch in {
+	case "body":
+		if op.RequestBody == nil {
+			op.RequestBody = &requestBody{Description: description, Required: required, Content: contentFor(accept, s)}
+			return nil
+		}
+		for t, content := range contentFor(accept, s) {
+			if _, dup := op.RequestBody.Content[t]; dup {
+				return fmt.Errorf("more than one body parameter for %s", t)
+			}
+			op.RequestBody.Content[t] = content
+		}
+	case "query", "path", "header":
+		op.Parameters = append(op.Parameters, &parameter{
+			Name:        name,
+			In:          in,
+			Description: description,
+			Required:    required || in == "path",
+			Schema:      s,
+		})
+	default:
+		return fmt.Errorf("unsupported parameter location %q", in)
+	}
+	return nil
+}
+
+func addResponse(op *operation, b *schemaBuilder, value string, produce []string) error {
+	m := responsePattern.FindStringSubmatch(value)
+	if m == nil {
+		return fmt.Errorf("expected: code {kind} Type \"description\"")
+	}
+	code, kind, typ, description := m[1], m[2], m[3], m[4]
+
+	var s *schema
+	switch kind {
+	case "":
+		// No body, as for 204 responses.
+	case "object", "array":
+		var err error
+		if s, err = b.parseType(typ); err != nil {
+			return err
+		}
+		if kind == "array" {
+			s = &schema{Type: "array", Items: s}
+		}
+	default:
+		if s = primitive(kind); s == nil {
+			return fmt.Errorf("unknown response kind %q", kind)
+		}
+	}
+
+	if description == "" {
+		var status int
+		fmt.Sscan(code, &status)
+		description = http.StatusText(status)
+	}
+	resp := &response{Description: description}
+	if s != nil {
+		resp.Content = contentFor(produce, s)
+	}
+	op.Responses[code] = resp
+	return nil
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// schemaBuilder converts Go types of the service package to schemas and
+// collects the component schemas they reference.
+type schemaBuilder struct {
+	types      map[string]*ast.TypeSpec
+	components map[string]*schema
+}
+
+// parseType converts a Go type expression written in an annotation.
+func (b *schemaBuilder) parseType(expr string) (*schema, error) {
+	e, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid type %q: %w", expr, err)
+	}
+	return b.schemaOf(e)
+}
+
+func (b *schemaBuilder) schemaOf(expr ast.Expr) (*schema, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if s := primitive(t.Name); s != nil {
+			return s, nil
+		}
+		return b.named(t.Name)
+	case *ast.StarExpr:
+		return b.schemaOf(t.X)
+	case *ast.ParenExpr:
+		return b.schemaOf(t.X)
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return &schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := b.schemaOf(t.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{Type: "array", Items: items}, nil
+	case *ast.MapType:
+		values, err := b.schemaOf(t.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{Type: "object", AdditionalProperties: values}, nil
+	case *ast.InterfaceType:
+		return &schema{}, nil
+	case *ast.SelectorExpr:
+		switch fmt.Sprintf("%s.%s", t.X, t.Sel.Name) {
+		case "time.Time":
+			return &schema{Type: "string", Format: "date-time"}, nil
+		case "time.Duration":
+			return &schema{Type: "integer", Format: "int64"}, nil
+		case "gin.H":
+			return &schema{Type: "object", AdditionalProperties: &schema{}}, nil
+		}
+		return &schema{}, nil
+	case *ast.StructType:
+		return b.structSchema(t)
+	}
+	return nil, fmt.Errorf("unsupported type %T", expr)
+}
+
+// primitive returns the schema of a predeclared type, or nil.
+func primitive(name string) *schema {
+	switch name {
+	case "string":
+		return &schema{Type: "string"}
+	case "bool", "boolean":
+		return &schema{Type: "boolean"}
+	case "int", "integer", "int8", "int16", "uint", "uint8", "uint16":
+		return &schema{Type: "integer"}
+	case "int32", "uint32":
+		return &schema{Type: "integer", Format: "int32"}
+	case "int64", "uint64":
+		return &schema{Type: "integer", Format: "int64"}
+	case "float32":
+		return &schema{Type: "number", Format: "float"}
+	case "float64", "number":
+		return &schema{Type: "number", Format: "double"}
+	case "any", "object":
+		return &schema{}
+	}
+	return nil
+}
+
+// named returns a reference to the component schema of a package-level type,
+// building the component on first use. Named non-struct types are inlined.
+func (b *schemaBuilder) named(name string) (*schema, error) {
+	spec, ok := b.types[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", name)
+	}
+	if _, ok := spec.Type.(*ast.StructType); !ok {
+		return b.schemaOf(spec.Type)
+	}
+
+	component := componentName(name)
+	ref := &schema{Ref: "#/components/schemas/" + component}
+	if _, done := b.components[component]; done {
+		return ref, nil
+	}
+	b.components[component] = nil // breaks cycles
+	s, err := b.schemaOf(spec.Type)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if spec.Doc != nil {
+		s.Description = docText(spec.Doc.Text(), name)
+	}
+	b.components[component] = s
+	return ref, nil
+}
+
+// componentName exports a type name, so that checkResult is published as
+// CheckResult.
+func componentName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+// docText turns a Go doc comment into a description, dropping the leading
+// identifier of "Name is ..." style comments.
+func docText(text, name string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if rest, ok := strings.CutPrefix(text, name+" "); ok {
+		text = rest
+		for _, verb := range []string{"is ", "are "} {
+			text = strings.TrimPrefix(text, verb)
+		}
+		text = strings.ToUpper(text[:1]) + text[1:]
+	}
+	return strings.TrimSuffix(text, ".")
+}
+
+func (b *schemaBuilder) structSchema(t *ast.StructType) (*schema, error) {
+	s := &schema{Type: "object", Properties: make(map[string]*schema)}
+	for _, field := range t.Fields.List {
+		tag := reflect.StructTag("")
+		if field.Tag != nil {
+			unquoted, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return nil, err
+			}
+			tag = reflect.StructTag(unquoted)
+		}
+		jsonName, jsonOpts, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+
+		// Embedded structs without a json name contribute their fields.
+		if len(field.Names) == 0 && jsonName == "" {
+			embedded, err := b.schemaOf(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			if embedded.Ref != "" {
+				embedded = b.components[strings.TrimPrefix(embedded.Ref, "#/components/schemas/")]
+			}
+			for name, prop := range embedded.Properties {
+				s.Properties[name] = prop
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			name := jsonName
+			if name == "" {
+				name = ident.Name
+			}
+			prop, err := b.schemaOf(field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", ident.Name, err)
+			}
+			if prop.Ref == "" {
+				if err := applyFieldTags(prop, tag); err != nil {
+					return nil, fmt.Errorf("field %s: %w", ident.Name, err)
+				}
+				if doc := fieldDoc(field); doc != "" {
+					prop.Description = docText(doc, ident.Name)
+				}
+			}
+			s.Properties[name] = prop
+
+			required := tag.Get("binding") + "," + tag.Get("validate")
+			if containsOption(required, "required") && !containsOption(jsonOpts, "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+	}
+	return s, nil
+}
+
+func fieldDoc(field *ast.Field) string {
+	if field.Doc != nil {
+		return field.Doc.Text()
+	}
+	if field.Comment != nil {
+		return field.Comment.Text()
+	}
+	return ""
+}
+
+func containsOption(list, option string) bool {
+	for _, o := range strings.Split(list, ",") {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// applyFieldTags applies the swag struct tags to a property schema.
+func applyFieldTags(s *schema, tag reflect.StructTag) error {
+	attrs := map[string]string{}
+	for _, key := range []string{"example", "enums", "default", "minimum", "maximum", "minLength", "maxLength", "format"} {
+		if v, ok := tag.Lookup(key); ok {
+			attrs[key] = v
+		}
+	}
+	if tag.Get("readonly") == "true" {
+		s.ReadOnly = true
+	}
+	if tag.Get("nullable") == "true" {
+		s.Nullable = true
+	}
+	return applyAttributes(s, attrs)
+}
+
+// applyAttributes sets validation keywords and examples on s. Values are
+// converted to the schema's type.
+func applyAttributes(s *schema, attrs map[string]string) error {
+	target := s
+	if s.Type == "array" && s.Items != nil {
+		target = s.Items
+	}
+	for key, raw := range attrs {
+		var err error
+		switch key {
+		case "example":
+			if s.Type == "array" {
+				var values []any
+				for _, v := range strings.Split(raw, ",") {
+					value, err := convertValue(target.Type, v)
+					if err != nil {
+						return err
+					}
+					values = append(values, value)
+				}
+				s.Example = values
+			} else {
+				s.Example, err = convertValue(s.Type, raw)
+			}
+		case "default":
+			s.Default, err = convertValue(s.Type, raw)
+		case "enums":
+			for _, v := range strings.Split(raw, ",") {
+				value, err := convertValue(target.Type, strings.TrimSpace(v))
+				if err != nil {
+					return err
+				}
+				target.Enum = append(target.Enum, value)
+			}
+		case "minimum", "maximum":
+			var f float64
+			if f, err = strconv.ParseFloat(raw, 64); err == nil {
+				if key == "minimum" {
+					target.Minimum = &f
+				} else {
+					target.Maximum = &f
+				}
+			}
+		case "minLength", "maxLength":
+			var n int
+			if n, err = strconv.Atoi(raw); err == nil {
+				if key == "minLength" {
+					target.MinLength = &n
+				} else {
+					target.MaxLength = &n
+				}
+			}
+		case "format":
+			target.Format = raw
+		default:
+			return fmt.Errorf("unknown attribute %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("%s(%s): %w", key, raw, err)
+		}
+	}
+	return nil
+}
+
+func convertValue(typ, raw string) (any, error) {
+	switch typ {
+	case "integer":
+		return strconv.ParseInt(raw, 10, 64)
+	case "number":
+		return strconv.ParseFloat(raw, 64)
+	case "boolean":
+		return strconv.ParseBool(raw)
+	}
+	return raw, nil
+}
@@ -0,0 +1,133 @@
+// Command proto-gen compiles the .proto files of a service and writes the Go
+// code that protoc-gen-go and protoc-gen-go-grpc generate for them next to
+// them. It parses the files itself, so protoc does not need to be installed;
+// the plugins are run with go run at the versions in this module's go.mod.
+//
+// Each service runs it through go generate in its pb package:
+//
+//	//go:generate go run -C ../../../tools/proto-gen . -dir ../../coffee-shop/api/pb
+//
+// The files are generated with paths=source_relative, so a file's
+// go_package option must name the directory it is in.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// plugins are the code generators run on the files, as go run packages.
+var plugins = []string{
+	"google.golang.org/protobuf/cmd/protoc-gen-go",
+	"google.golang.org/grpc/cmd/protoc-gen-go-grpc",
+}
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the .proto files, where the Go files are written")
+	flag.Parse()
+
+	names, err := filepath.Glob(filepath.Join(*dir, "*.proto"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(names) == 0 {
+		log.Fatalf("no .proto files in %s", *dir)
+	}
+	for i, name := range names {
+		names[i] = filepath.Base(name)
+	}
+	slices.Sort(names)
+
+	req, err := compile(*dir, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, plugin := range plugins {
+		if err := generate(*dir, plugin, req); err != nil {
+			log.Fatalf("%s: %v", plugin, err)
+		}
+	}
+}
+
+// compile parses names, relative to dir, and returns the request protoc
+// would send a plugin to generate them.
+func compile(dir string, names []string) (*pluginpb.CodeGeneratorRequest, error) {
+	compiler := protocompile.Compiler{
+		Resolver:       protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{dir}}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	files, err := compiler.Compile(context.Background(), names...)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: names,
+		Parameter:      proto.String("paths=source_relative"),
+	}
+	// Plugins expect every file after the files it imports.
+	seen := map[string]bool{}
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(fd))
+	}
+	for _, fd := range files {
+		add(fd)
+	}
+	for _, f := range req.ProtoFile {
+		if slices.Contains(names, f.GetName()) {
+			req.SourceFileDescriptors = append(req.SourceFileDescriptors, proto.Clone(f).(*descriptorpb.FileDescriptorProto))
+		}
+	}
+	return req, nil
+}
+
+// generate runs plugin on req and writes the files it returns to dir.
+func generate(dir, plugin string, req *pluginpb.CodeGeneratorRequest) error {
+	in, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	cmd := exec.Command("go", "run", plugin)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(in), &out, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	var resp pluginpb.CodeGeneratorResponse
+	if err := proto.Unmarshal(out.Bytes(), &resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s", resp.GetError())
+	}
+	for _, f := range resp.File {
+		if err := os.WriteFile(filepath.Join(dir, f.GetName()), []byte(f.GetContent()), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build tools
+
+package main
+
+// The code generator plugins that main runs with go run.
+import (
+	_ "google.golang.org/grpc/cmd/protoc-gen-go-grpc"
+	_ "google.golang.org/protobuf/cmd/protoc-gen-go"
+)
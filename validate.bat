@@ -45,19 +45,6 @@ if %errorlevel% equ 0 (
     call :print_error "Docker not found"
 )
 
-REM Check swag installation
-swag --version >nul 2>&1
-if %errorlevel% equ 0 (
-    call :print_success "Swag tool available"
-) else (
-    call :print_info "Installing swag tool..."
-    go install github.com/swaggo/swag/cmd/swag@latest
-    if %errorlevel% equ 0 (
-        call :print_success "Swag tool installation"
-    ) else (
-        call :print_error "Swag tool installation failed"
-    )
-)
 
 echo.
 call :print_info "Validating services..."
@@ -91,11 +78,11 @@ for %%s in (%SERVICES%) do (
         call :print_error "%%s: catalog-info.yaml missing"
     )
     
-    REM Check if docs directory exists
-    if exist "docs" (
-        call :print_success "%%s: docs directory exists"
+    REM Check if the generated OpenAPI spec exists
+    if exist "api\openapi_gen.go" (
+        call :print_success "%%s: api\openapi_gen.go exists"
     ) else (
-        call :print_error "%%s: docs directory missing"
+        call :print_error "%%s: api\openapi_gen.go missing"
     )
     
     REM Clean dependencies
@@ -107,13 +94,13 @@ for %%s in (%SERVICES%) do (
         call :print_error "%%s: Go module cleanup failed"
     )
     
-    REM Generate Swagger docs
-    call :print_info "%%s: Generating Swagger documentation..."
-    swag init --parseDependency --parseInternal
+    REM Check the OpenAPI spec matches the handler annotations
+    call :print_info "%%s: Checking OpenAPI specification..."
+    go run -C ..\tools\openapi-gen . -dir ..\..\%%s\api -check
     if %errorlevel% equ 0 (
-        call :print_success "%%s: Swagger doc generation"
+        call :print_success "%%s: OpenAPI spec up to date"
     ) else (
-        call :print_error "%%s: Swagger doc generation failed"
+        call :print_error "%%s: OpenAPI spec out of date, run go generate"
     )
     
     REM Build service
@@ -126,15 +113,13 @@ for %%s in (%SERVICES%) do (
         call :print_error "%%s: Build failed"
     )
     
-    REM Run tests if they exist
-    if exist "tests" (
-        call :print_info "%%s: Running tests..."
-        go test ./tests/... -v
-        if %errorlevel% equ 0 (
-            call :print_success "%%s: Tests passed"
-        ) else (
-            call :print_error "%%s: Tests failed"
-        )
+    REM Run the handler and API tests
+    call :print_info "%%s: Running tests..."
+    go test ./... -v
+    if %errorlevel% equ 0 (
+        call :print_success "%%s: Tests passed"
+    ) else (
+        call :print_error "%%s: Tests failed"
     )
     
     cd ..
@@ -237,7 +222,7 @@ echo.
 echo 🎉 All validations completed successfully!
 echo.
 echo Next steps:
-echo 1. Review generated Swagger documentation at http://localhost:PORT/swagger/index.html
+echo 1. Review generated OpenAPI documentation at http://localhost:PORT/docs
 echo 2. Import services into Backstage using the catalog-info.yaml files
 echo 3. Deploy to Kubernetes using the cluster-operator
 echo 4. Run individual services for testing and development
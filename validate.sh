@@ -47,15 +47,6 @@ else
     print_status 1 "Docker not found"
 fi
 
-# Check swag installation
-if command -v swag &> /dev/null; then
-    print_status 0 "Swag tool available"
-else
-    print_info "Installing swag tool..."
-    go install github.com/swaggo/swag/cmd/swag@latest
-    print_status $? "Swag tool installation"
-fi
-
 echo ""
 print_info "Validating services..."
 
@@ -88,11 +79,11 @@ for service in "${SERVICES[@]}"; do
         print_status 1 "$service: catalog-info.yaml missing"
     fi
     
-    # Check if docs directory exists
-    if [ -d "docs" ]; then
-        print_status 0 "$service: docs directory exists"
+    # Check if the generated OpenAPI spec exists
+    if [ -f "api/openapi_gen.go" ]; then
+        print_status 0 "$service: api/openapi_gen.go exists"
     else
-        print_status 1 "$service: docs directory missing"
+        print_status 1 "$service: api/openapi_gen.go missing"
     fi
     
     # Clean dependencies
@@ -100,22 +91,20 @@ for service in "${SERVICES[@]}"; do
     go mod tidy
     print_status $? "$service: Go module cleanup"
     
-    # Generate Swagger docs
-    print_info "$service: Generating Swagger documentation..."
-    swag init --parseDependency --parseInternal
-    print_status $? "$service: Swagger doc generation"
+    # Check the OpenAPI spec matches the handler annotations
+    print_info "$service: Checking OpenAPI specification..."
+    go run -C ../tools/openapi-gen . -dir "../../$service/api" -check
+    print_status $? "$service: OpenAPI spec up to date"
     
     # Build service
     print_info "$service: Building service..."
     go build -o "bin/$service" main.go
     print_status $? "$service: Build successful"
     
-    # Run tests if they exist
-    if [ -d "tests" ]; then
-        print_info "$service: Running tests..."
-        go test ./tests/... -v
-        print_status $? "$service: Tests passed"
-    fi
+    # Run the handler and API tests
+    print_info "$service: Running tests..."
+    go test ./... -v
+    print_status $? "$service: Tests passed"
     
     cd ..
 done
@@ -208,7 +197,7 @@ echo ""
 echo -e "${GREEN}🎉 All validations completed successfully!${NC}"
 echo ""
 echo "Next steps:"
-echo "1. Review generated Swagger documentation at http://localhost:PORT/swagger/index.html"
+echo "1. Review generated OpenAPI documentation at http://localhost:PORT/docs"
 echo "2. Import services into Backstage using the catalog-info.yaml files"
 echo "3. Deploy to Kubernetes using the cluster-operator"
 echo "4. Run individual services for testing and development"